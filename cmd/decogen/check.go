@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/komandakycto/decogen/internal/config"
+	"github.com/komandakycto/decogen/internal/generator"
+	"github.com/komandakycto/decogen/internal/parser"
+)
+
+// runCheck implements "decogen check [patterns]"
+// It reports the decorators of annotated interfaces that are missing or were generated from another
+// version of the interface, according to the source hash in their header
+func runCheck(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	jobs, err := discover(patterns)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	var checked int
+	for _, j := range jobs {
+		cfg := &config.Config{}
+		for _, name := range j.directive.Decorators {
+			cfg.Decorators = append(cfg.Decorators, struct {
+				Name   string                 `json:"name"`
+				Config map[string]interface{} `json:"config"`
+			}{Name: name})
+		}
+		decoratorTypes, err := cfg.GetDecoratorTypes()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", j.source, j.directive.Interface, err))
+			continue
+		}
+
+		interfaceModel, err := parser.ParseInterface(j.source, j.directive.Interface)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", j.source, j.directive.Interface, err))
+			continue
+		}
+
+		for _, dt := range decoratorTypes {
+			if err := generator.CheckSourceHash(j.output(dt), interfaceModel); err != nil {
+				errs = append(errs, err)
+			}
+			checked++
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	log.Printf("%d generated files are up to date", checked)
+	return nil
+}
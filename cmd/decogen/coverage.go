@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/komandakycto/decogen/internal/generator"
+)
+
+// coverageRule requires the interfaces matching a pattern to have decorators generated
+type coverageRule struct {
+	pattern    *regexp.Regexp
+	decorators []generator.DecoratorType
+}
+
+// runCoverage implements "decogen coverage [-json] [-require pattern=decorators] [patterns]"
+// It reports the decorators generated for every interface of the matched packages, and fails when
+// an interface misses a decorator required for it
+func runCoverage(args []string) error {
+	flags := flag.NewFlagSet("coverage", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	match := flags.String("match", "", "Only report interfaces whose name matches this regular expression")
+	var requires listFlag
+	flags.Var(&requires, "require", "Decorators required for the interfaces matching a regular expression, as pattern=decorators, e.g. 'Storage$=observability,retry'; repeat for several rules")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	gen, err := generator.NewGenerator()
+	if err != nil {
+		return fmt.Errorf("failed to create generator: %w", err)
+	}
+	rules, err := parseCoverageRules(requires, gen.DecoratorTypes())
+	if err != nil {
+		return err
+	}
+	var filter *regexp.Regexp
+	if *match != "" {
+		if filter, err = regexp.Compile(*match); err != nil {
+			return fmt.Errorf("invalid -match: %w", err)
+		}
+	}
+
+	files, err := goFiles(patterns)
+	if err != nil {
+		return err
+	}
+	var dirs []string
+	for _, file := range files {
+		dirs = append(dirs, filepath.Dir(file))
+	}
+	slices.Sort(dirs)
+
+	coverage := []generator.Coverage{}
+	for _, dir := range slices.Compact(dirs) {
+		found, err := gen.Coverage(dir)
+		if err != nil {
+			return err
+		}
+		for _, c := range found {
+			if filter == nil || filter.MatchString(c.Interface) {
+				coverage = append(coverage, c)
+			}
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(coverage); err != nil {
+			return err
+		}
+	} else if err := printCoverage(os.Stdout, coverage); err != nil {
+		return err
+	}
+
+	return checkCoverage(coverage, rules)
+}
+
+// parseCoverageRules parses -require flags
+func parseCoverageRules(requires []string, known []generator.DecoratorType) ([]coverageRule, error) {
+	var rules []coverageRule
+	for _, spec := range requires {
+		pattern, names, ok := strings.Cut(spec, "=")
+		if !ok || names == "" {
+			return nil, fmt.Errorf("-require %s: want pattern=decorators", spec)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("-require %s: %w", spec, err)
+		}
+
+		rule := coverageRule{pattern: re}
+		for _, name := range strings.Split(names, ",") {
+			dt := generator.DecoratorType(strings.ToLower(strings.TrimSpace(name)))
+			if !slices.Contains(known, dt) {
+				return nil, fmt.Errorf("-require %s: unknown decorator %s", spec, name)
+			}
+			rule.decorators = append(rule.decorators, dt)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// checkCoverage returns an error listing the decorators missing from interfaces according to the rules
+func checkCoverage(coverage []generator.Coverage, rules []coverageRule) error {
+	var errs []error
+	for _, c := range coverage {
+		var missing []string
+		for _, rule := range rules {
+			if !rule.pattern.MatchString(c.Interface) {
+				continue
+			}
+			for _, dt := range rule.decorators {
+				if !c.Has(dt) && !slices.Contains(missing, string(dt)) {
+					missing = append(missing, string(dt))
+				}
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("%s: %s misses %s", c.Source, c.Interface, strings.Join(missing, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// printCoverage writes a table of the decorators generated for each interface
+func printCoverage(w io.Writer, coverage []generator.Coverage) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "INTERFACE\tDECORATORS\tSOURCE")
+
+	var undecorated int
+	for _, c := range coverage {
+		decorators := "-"
+		if len(c.Decorators) > 0 {
+			names := make([]string, len(c.Decorators))
+			for i, dt := range c.Decorators {
+				names[i] = string(dt)
+			}
+			decorators = strings.Join(names, ",")
+		} else {
+			undecorated++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Interface, decorators, c.Source)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d interfaces, %d undecorated\n", len(coverage), undecorated)
+	return err
+}
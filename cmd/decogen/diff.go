@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/komandakycto/decogen/internal/model"
+	"github.com/komandakycto/decogen/internal/parser"
+)
+
+// runDiffInterface implements "decogen diff-interface old.go new.go -interface X"
+// It reports the methods added, removed and changed between two versions of an interface
+func runDiffInterface(args []string) error {
+	flags := flag.NewFlagSet("diff-interface", flag.ExitOnError)
+	interfaceName := flags.String("interface", "", "Name of the interface to compare")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+
+	// Flags may follow the file arguments
+	var files []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		files = append(files, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if len(files) != 2 {
+		return fmt.Errorf("want the old and the new source file, got %d files", len(files))
+	}
+	if *interfaceName == "" {
+		return fmt.Errorf("interface name is required")
+	}
+
+	from, err := parser.ParseInterface(files[0], *interfaceName)
+	if err != nil {
+		return fmt.Errorf("failed to parse old version: %w", err)
+	}
+	to, err := parser.ParseInterface(files[1], *interfaceName)
+	if err != nil {
+		return fmt.Errorf("failed to parse new version: %w", err)
+	}
+
+	diff := model.Diff(from, to)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+	printDiff(os.Stdout, diff)
+	return nil
+}
+
+// printDiff writes a human readable report of an interface diff
+func printDiff(w io.Writer, diff *model.InterfaceDiff) {
+	if diff.Empty() {
+		fmt.Fprintf(w, "%s: no changes\n", diff.Interface)
+		return
+	}
+
+	compatibility := "compatible for callers"
+	if diff.Breaking {
+		compatibility = "breaking for callers"
+	}
+	fmt.Fprintf(w, "%s: %d added, %d removed, %d changed (%s)\n",
+		diff.Interface, len(diff.Added), len(diff.Removed), len(diff.Changed), compatibility)
+
+	for _, m := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", m.New)
+	}
+	for _, m := range diff.Removed {
+		fmt.Fprintf(w, "- %s\n", m.Old)
+	}
+	for _, m := range diff.Changed {
+		fmt.Fprintf(w, "~ %s\n  -> %s\n", m.Old, m.New)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/config"
+	"github.com/komandakycto/decogen/internal/generator"
+)
+
+// listFlag collects the values of a flag that may be repeated
+type listFlag []string
+
+// String implements flag.Value
+func (l *listFlag) String() string {
+	return strings.Join(*l, " ")
+}
+
+// Set implements flag.Value
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// applySettings applies -set flags, "decorator.option=value", to the decorator settings of cfg
+// With stackOnly, settings must target decorators of the configured stack, as adding a decorator
+// to the stack only to configure it would also generate it
+func applySettings(cfg *config.Config, settings []string, stackOnly bool) error {
+	for _, setting := range settings {
+		if stackOnly {
+			name, _, _ := strings.Cut(setting, ".")
+			if !hasDecorator(cfg, name) {
+				return fmt.Errorf("-set %s: %s is not among the decorators", setting, name)
+			}
+		}
+		if err := cfg.Set(setting); err != nil {
+			return fmt.Errorf("-set: %w", err)
+		}
+	}
+	return nil
+}
+
+// hasDecorator reports whether the configured stack has the named decorator
+func hasDecorator(cfg *config.Config, name string) bool {
+	for _, dec := range cfg.Decorators {
+		if strings.EqualFold(dec.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyImplementations applies -implementations flags, "Interface=*Impl,*Other", to cfg
+func applyImplementations(cfg *config.Config, implementations []string) error {
+	for _, spec := range implementations {
+		iface, impls, ok := strings.Cut(spec, "=")
+		if !ok || iface == "" || impls == "" {
+			return fmt.Errorf("-implementations %s: want Interface=*Impl,*Other", spec)
+		}
+		if cfg.Implementations == nil {
+			cfg.Implementations = make(map[string][]string)
+		}
+		cfg.Implementations[iface] = strings.Split(impls, ",")
+	}
+	return nil
+}
+
+// flagSet reports whether the named flag was passed on the command line rather than left to its default
+func flagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// joinTypes joins decorator types for messages, e.g. "observability,retry"
+func joinTypes(types []generator.DecoratorType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/internal/config"
+)
+
+func TestApplySettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		stack      string
+		settings   []string
+		stackOnly  bool
+		decorators string
+		err        string
+	}{
+		{
+			name:       "json and string values",
+			settings:   []string{"retry.max_attempts=5", "retry.backoff=exp(50ms,5s)"},
+			decorators: `[{"name":"retry","config":{"maxAttempts":5,"backoff":"exp(50ms,5s)"}}]`,
+		},
+		{
+			name:       "nested keys",
+			settings:   []string{"cache.ttl.Get=30s"},
+			decorators: `[{"name":"cache","config":{"ttl":{"Get":"30s"}}}]`,
+		},
+		{
+			name:       "decorator of the stack",
+			stack:      `[{"name":"retry"}]`,
+			settings:   []string{"RETRY.max_attempts=5"},
+			stackOnly:  true,
+			decorators: `[{"name":"retry","config":{"maxAttempts":5}}]`,
+		},
+		{
+			name:      "decorator outside the stack",
+			stack:     `[{"name":"retry"}]`,
+			settings:  []string{"cache.ttl=30s"},
+			stackOnly: true,
+			err:       "-set cache.ttl=30s: cache is not among the decorators",
+		},
+		{
+			name:     "not a map",
+			settings: []string{"cache.ttl=30s", "cache.ttl.Get=1m"},
+			err:      "ttl is not a map",
+		},
+		{
+			name:     "missing value",
+			settings: []string{"retry.max_attempts"},
+			err:      "want decorator.option=value",
+		},
+		{
+			name:     "empty key",
+			settings: []string{".max_attempts=5"},
+			err:      "want decorator.option=value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config.Config
+			if tt.stack != "" {
+				require.NoError(t, json.Unmarshal([]byte(`{"decorators": `+tt.stack+`}`), &cfg))
+			}
+
+			err := applySettings(&cfg, tt.settings, tt.stackOnly)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			decorators, err := json.Marshal(cfg.Decorators)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.decorators, string(decorators))
+		})
+	}
+}
+
+func TestApplyImplementations(t *testing.T) {
+	tests := []struct {
+		name            string
+		implementations []string
+		want            map[string][]string
+		err             string
+	}{
+		{
+			name:            "several interfaces",
+			implementations: []string{"UserStorage=*PostgresStore,*MemoryStore", "Cache=*RedisCache"},
+			want: map[string][]string{
+				"UserStorage": {"*PostgresStore", "*MemoryStore"},
+				"Cache":       {"*RedisCache"},
+			},
+		},
+		{
+			name:            "later flags win",
+			implementations: []string{"UserStorage=*PostgresStore", "UserStorage=*MemoryStore"},
+			want:            map[string][]string{"UserStorage": {"*MemoryStore"}},
+		},
+		{
+			name:            "missing equals sign",
+			implementations: []string{"UserStorage"},
+			err:             "want Interface=*Impl,*Other",
+		},
+		{
+			name:            "empty interface",
+			implementations: []string{"=*PostgresStore"},
+			err:             "want Interface=*Impl,*Other",
+		},
+		{
+			name:            "empty implementations",
+			implementations: []string{"UserStorage="},
+			err:             "want Interface=*Impl,*Other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config.Config
+			err := applyImplementations(&cfg, tt.implementations)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, cfg.Implementations)
+		})
+	}
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/komandakycto/decogen/internal/config"
+	"github.com/komandakycto/decogen/internal/generator"
+	"github.com/komandakycto/decogen/internal/model"
+	"github.com/komandakycto/decogen/internal/parser"
+)
+
+// defaultsFile is the name of the configuration file holding bulk generation defaults
+const defaultsFile = "decogen.json"
+
+// job generates the decorators requested by one directive
+type job struct {
+	source    string
+	directive parser.Directive
+}
+
+// output returns the path of the file generated for a decorator of the job
+func (j job) output(dt generator.DecoratorType) string {
+	return filepath.Join(filepath.Dir(j.source), fmt.Sprintf("%s_%s.go", snakeCase(j.directive.Interface), dt))
+}
+
+// runGenerate implements "decogen generate [flags] [patterns]"
+// It finds every interface annotated with a //decogen:decorate directive and generates its decorators
+// next to it, skipping interfaces whose generated files are newer than their inputs
+func runGenerate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	configFile := flags.String("config", "", "Path to a configuration file with decorator defaults (default: decogen.json at the module root)")
+	parallel := flags.Int("parallel", runtime.GOMAXPROCS(0), "Number of interfaces generated concurrently")
+	force := flags.Bool("force", false, "Regenerate even if the generated files are up to date")
+	verify := flags.Bool("verify", false, "Type-check every package with regenerated files")
+	local := flags.String("local", "", "Comma-separated import path prefixes grouped after third party imports (default: local from the configuration file)")
+	wrapErrors := flags.String("wrap-errors", "", "How generated decorators wrap returned errors: none, method, or a function as import/path.Func (default: wrapErrors from the configuration file)")
+	callMeta := flags.Bool("callmeta", false, "Record the interface, method and decorator stack of each call in its context (default: callmeta from the configuration file)")
+	methodNames := flags.String("method-names", "", "Also generate constants naming the methods of each interface, used by the decorators (const,typed) (default: methodNames from the configuration file)")
+	assertSource := flags.Bool("assert-source", false, "Panic at init when an interface changed since its decorators were generated, where the source is available (default: assertSource from the configuration file)")
+	style := flags.String("style", "", "Output style of the decorators: struct, or functional for functions returning the interface (default: style from the configuration file)")
+	raceTest := flags.Bool("race-test", false, "Also generate a test calling the decorators of each interface from several goroutines")
+	ctxTest := flags.Bool("with-ctx-tests", false, "Also generate a test cancelling the context of each method mid-call, checking the decorators of each interface return promptly with the error of the context")
+	doc := flags.String("doc", "", "Also generate documentation of the decorators of each interface with wiring examples (go,markdown) (default: doc from the configuration file)")
+	di := flags.String("di", "", "Dependency injection framework to emit providers for (wire,fx) (default: di from the configuration file)")
+	var settings, implementations listFlag
+	flags.Var(&settings, "set", "Decorator default as decorator.option=value, e.g. retry.wrap_errors=method; repeat for several settings")
+	flags.Var(&implementations, "implementations", "Implementations of an interface asserted in a guard file as Interface=*Impl,*Other; repeat for several interfaces")
+	minimalDeps := flags.Bool("minimal-deps", false, "Fail unless the generated code depends only on the standard library and decogen runtimes without third party dependencies (default: minimalDeps from the configuration file)")
+	profile := flags.String("profile", "", "Built-in profile whose settings complete the configuration file (grpc-client,http-client,repository,queue-consumer); directives still list the decorators")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	defaults, defaultsPath, err := loadDefaults(*configFile)
+	if err != nil {
+		return err
+	}
+	if *profile != "" {
+		if err := defaults.ApplyProfile(*profile); err != nil {
+			return err
+		}
+	}
+	if *local != "" {
+		defaults.Local = *local
+	}
+	if *wrapErrors != "" {
+		defaults.WrapErrors = *wrapErrors
+	}
+	if *callMeta {
+		defaults.CallMeta = true
+	}
+	if *methodNames != "" {
+		defaults.MethodNames = *methodNames
+	}
+	if *assertSource {
+		defaults.AssertSource = true
+	}
+	if *style != "" {
+		defaults.Style = *style
+	}
+	if *doc != "" {
+		defaults.Doc = *doc
+	}
+	if *minimalDeps {
+		defaults.MinimalDeps = true
+	}
+	if *di != "" {
+		defaults.DI = *di
+	}
+	if err := applySettings(defaults, settings, false); err != nil {
+		return err
+	}
+	if err := applyImplementations(defaults, implementations); err != nil {
+		return err
+	}
+
+	jobs, err := discover(patterns)
+	if err != nil {
+		return err
+	}
+	log.Printf("Found %d annotated interfaces", len(jobs))
+
+	gen, err := generator.NewGenerator()
+	if err != nil {
+		return fmt.Errorf("failed to create generator: %w", err)
+	}
+
+	if *parallel < 1 {
+		*parallel = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		errs    []error
+		origins = make(map[string]map[string]generator.Origin) // by package directory
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, *parallel)
+	)
+	for _, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(j job) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			generated, err := generateJob(gen, j, defaults, defaultsPath, *force, *raceTest, *ctxTest)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", j.source, j.directive.Interface, err))
+			}
+			for path, origin := range generated {
+				dir := filepath.Dir(path)
+				if origins[dir] == nil {
+					origins[dir] = make(map[string]generator.Origin)
+				}
+				origins[dir][path] = origin
+			}
+		}(j)
+	}
+	wg.Wait()
+
+	if *verify {
+		dirs := make([]string, 0, len(origins))
+		for dir := range origins {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+
+		for _, dir := range dirs {
+			if err := generator.Verify(dir, origins[dir]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadDefaults loads the configuration holding decorator defaults
+// An explicit path must exist; the default file at the module root is optional
+func loadDefaults(path string) (*config.Config, string, error) {
+	if path != "" {
+		cfg, err := config.LoadFromFile(path)
+		return cfg, path, err
+	}
+
+	root, err := moduleRoot()
+	if err != nil {
+		return nil, "", err
+	}
+
+	path = filepath.Join(root, defaultsFile)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return &config.Config{}, "", nil
+	}
+
+	cfg, err := config.LoadFromFile(path)
+	return cfg, path, err
+}
+
+// moduleRoot returns the directory of the go.mod enclosing the working directory
+func moduleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("go.mod not found")
+		}
+		dir = parent
+	}
+}
+
+// discover finds the annotated interfaces in the directories matched by the patterns
+// A pattern is a directory, optionally followed by "/..." to include its subdirectories
+func discover(patterns []string) ([]job, error) {
+	files, err := goFiles(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []job
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		directives, err := parser.FindDirectives(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range directives {
+			jobs = append(jobs, job{source: file, directive: d})
+		}
+	}
+
+	return jobs, nil
+}
+
+// goFiles returns the Go files in the directories matched by the patterns, sorted and without duplicates
+// Directories named vendor or testdata, or starting with a dot or an underscore, are skipped
+func goFiles(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		dir, recursive := strings.CutSuffix(pattern, "...")
+		dir = filepath.Clean(dir)
+
+		if !recursive {
+			matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+			continue
+		}
+
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				name := d.Name()
+				if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".go") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+		}
+	}
+
+	sort.Strings(files)
+	return slices.Compact(files), nil
+}
+
+// generateJob generates every decorator of a directive into its own file next to the source
+// It returns the origin of each file it wrote
+// With raceTest, a concurrency test of all the decorators is written next to them as well, and with ctxTest
+// a cancellation test
+func generateJob(gen *generator.Generator, j job, defaults *config.Config, defaultsPath string, force, raceTest, ctxTest bool) (map[string]generator.Origin, error) {
+	cfg := &config.Config{
+		DI:           defaults.DI,
+		Local:        defaults.Local,
+		WrapErrors:   defaults.WrapErrors,
+		CallMeta:     defaults.CallMeta,
+		MethodNames:  defaults.MethodNames,
+		AssertSource: defaults.AssertSource,
+		Style:        defaults.Style,
+		Doc:          defaults.Doc,
+		MinimalDeps:  defaults.MinimalDeps,
+	}
+	implementations := defaults.Implementations[j.directive.Interface]
+	cfg.Interface.Name = j.directive.Interface
+	cfg.Interface.Source = j.source
+	for _, name := range j.directive.Decorators {
+		cfg.Decorators = append(cfg.Decorators, struct {
+			Name   string                 `json:"name"`
+			Config map[string]interface{} `json:"config"`
+		}{
+			Name:   name,
+			Config: defaults.DecoratorDefaults(name),
+		})
+	}
+
+	decoratorTypes, err := cfg.GetDecoratorTypes()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOrder(j.directive.Interface, decoratorTypes); err != nil {
+		return nil, err
+	}
+	options, err := cfg.GetDecoratorOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]string, len(decoratorTypes))
+	for i, dt := range decoratorTypes {
+		outputs[i] = j.output(dt)
+	}
+	var variants []string
+	for i, dt := range decoratorTypes {
+		if tag, _ := options[dt].BuildTag(); tag != nil {
+			variants = append(variants, generator.VariantPath(outputs[i]))
+		}
+	}
+	outputs = append(outputs, variants...)
+
+	var namesPath string
+	if cfg.MethodNames != "" {
+		namesPath = filepath.Join(filepath.Dir(j.source), snakeCase(j.directive.Interface)+"_methods.go")
+		outputs = append(outputs, namesPath)
+	}
+
+	var docPath string
+	if cfg.Doc != "" {
+		docPath = generator.DocPath(filepath.Join(filepath.Dir(j.source), snakeCase(j.directive.Interface)+".go"), cfg.Doc)
+		outputs = append(outputs, docPath)
+	}
+
+	var guardPath string
+	if len(implementations) > 0 {
+		guardPath = filepath.Join(filepath.Dir(j.source), snakeCase(j.directive.Interface)+"_guard.go")
+		outputs = append(outputs, guardPath)
+	}
+
+	var testPath string
+	if raceTest {
+		testPath = filepath.Join(filepath.Dir(j.source), snakeCase(j.directive.Interface)+"_race_test.go")
+		outputs = append(outputs, testPath)
+	}
+
+	var ctxTestPath string
+	if ctxTest {
+		ctxTestPath = filepath.Join(filepath.Dir(j.source), snakeCase(j.directive.Interface)+"_ctx_test.go")
+		outputs = append(outputs, ctxTestPath)
+	}
+
+	inputs := []string{j.source}
+	if defaultsPath != "" {
+		inputs = append(inputs, defaultsPath)
+	}
+	if !force && upToDate(inputs, outputs) {
+		return nil, nil
+	}
+
+	interfaceModel, err := parser.ParseInterface(j.source, j.directive.Interface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse interface: %w", err)
+	}
+	logKeyWarnings(interfaceModel, decoratorTypes, options)
+
+	generated := make(map[string]generator.Origin, len(decoratorTypes))
+	for i, dt := range decoratorTypes {
+		err := gen.Generate(interfaceModel, []generator.DecoratorType{dt}, interfaceModel.PackageName, outputs[i], options)
+		if err != nil {
+			return generated, fmt.Errorf("failed to generate %s decorator: %w", dt, err)
+		}
+		generated[outputs[i]] = generator.Origin{Decorator: dt, Interface: j.directive.Interface, Source: j.source}
+		log.Printf("Generated %s", outputs[i])
+
+		if tag, _ := options[dt].BuildTag(); tag != nil {
+			variant := generator.VariantPath(outputs[i])
+			generated[variant] = generator.Origin{Decorator: dt, Interface: j.directive.Interface, Source: j.source}
+			log.Printf("Generated %s", variant)
+		}
+	}
+
+	if namesPath != "" {
+		if err := gen.GenerateMethodNames(interfaceModel, interfaceModel.PackageName, namesPath, cfg.MethodNames); err != nil {
+			return generated, fmt.Errorf("failed to generate method names: %w", err)
+		}
+		log.Printf("Generated %s", namesPath)
+	}
+
+	if docPath != "" {
+		if err := gen.GenerateDoc(interfaceModel, decoratorTypes, interfaceModel.PackageName, docPath, options, cfg.Doc); err != nil {
+			return generated, fmt.Errorf("failed to generate documentation: %w", err)
+		}
+		log.Printf("Generated %s", docPath)
+	}
+
+	if guardPath != "" {
+		if err := gen.GenerateGuard(interfaceModel, guardPath, implementations); err != nil {
+			return generated, fmt.Errorf("failed to generate implementation guard: %w", err)
+		}
+		log.Printf("Generated %s", guardPath)
+	}
+
+	if testPath != "" {
+		if err := gen.GenerateRaceTest(interfaceModel, decoratorTypes, interfaceModel.PackageName, testPath, options); err != nil {
+			return generated, fmt.Errorf("failed to generate race test: %w", err)
+		}
+		log.Printf("Generated %s", testPath)
+	}
+
+	if ctxTestPath != "" {
+		if err := gen.GenerateCtxTest(interfaceModel, decoratorTypes, interfaceModel.PackageName, ctxTestPath, options); err != nil {
+			return generated, fmt.Errorf("failed to generate context test: %w", err)
+		}
+		log.Printf("Generated %s", ctxTestPath)
+	}
+
+	return generated, nil
+}
+
+// checkOrder logs ordering warnings of a decorator stack and returns its ordering errors
+func checkOrder(iface string, decoratorTypes []generator.DecoratorType) error {
+	issues := generator.CheckOrder(decoratorTypes)
+	for _, issue := range issues {
+		if issue.Severity == generator.SeverityWarning {
+			log.Printf("Warning: %s decorators: %s", iface, issue.Message)
+		}
+	}
+	return generator.OrderError(issues)
+}
+
+// logKeyWarnings logs the methods whose generated keys may not be stable
+func logKeyWarnings(interfaceModel *model.Interface, decoratorTypes []generator.DecoratorType, options map[generator.DecoratorType]generator.Options) {
+	for _, dt := range decoratorTypes {
+		for _, warning := range generator.KeyWarnings(dt, interfaceModel, options[dt]) {
+			log.Printf("Warning: %s", warning)
+		}
+	}
+}
+
+// upToDate reports whether every output exists and is newer than every input
+func upToDate(inputs, outputs []string) bool {
+	var newestInput int64
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return false
+		}
+		if t := info.ModTime().UnixNano(); t > newestInput {
+			newestInput = t
+		}
+	}
+
+	for _, output := range outputs {
+		info, err := os.Stat(output)
+		if err != nil || info.ModTime().UnixNano() < newestInput {
+			return false
+		}
+	}
+
+	return true
+}
+
+// snakeCase converts an identifier such as UserHTTPStorage to user_http_storage
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if i > 0 && (prevLower || nextLower) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
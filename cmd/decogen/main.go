@@ -3,6 +3,8 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/komandakycto/decogen/internal/config"
@@ -11,16 +13,104 @@ import (
 )
 
 func main() {
+	// Bulk mode regenerates every annotated interface of the module
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		if err := runGenerate(os.Args[2:]); err != nil {
+			log.Fatalf("Failed to generate: %v", err)
+		}
+		return
+	}
+
+	// Check mode reports generated files that are stale
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		if err := runCheck(os.Args[2:]); err != nil {
+			log.Fatalf("Check failed: %v", err)
+		}
+		return
+	}
+
+	// Coverage mode reports the decorators generated for every interface
+	if len(os.Args) > 1 && os.Args[1] == "coverage" {
+		if err := runCoverage(os.Args[2:]); err != nil {
+			log.Fatalf("Coverage check failed: %v", err)
+		}
+		return
+	}
+
+	// Template commands help template authors
+	if len(os.Args) > 1 && os.Args[1] == "template" {
+		if err := runTemplate(os.Args[2:]); err != nil {
+			log.Fatalf("Template check failed: %v", err)
+		}
+		return
+	}
+
+	// Diff mode compares two versions of an interface
+	if len(os.Args) > 1 && os.Args[1] == "diff-interface" {
+		if err := runDiffInterface(os.Args[2:]); err != nil {
+			log.Fatalf("Failed to compare interfaces: %v", err)
+		}
+		return
+	}
+
+	// Policy commands help reviewing retry policies
+	if len(os.Args) > 1 && os.Args[1] == "policy" {
+		if err := runPolicy(os.Args[2:]); err != nil {
+			log.Fatalf("Policy command failed: %v", err)
+		}
+		return
+	}
+
+	// Regen mode generates the files of a package again with the arguments recorded in their header
+	if len(os.Args) > 1 && os.Args[1] == "regen" {
+		if err := runRegen(os.Args[2:]); err != nil {
+			log.Fatalf("Regeneration failed: %v", err)
+		}
+		return
+	}
+
+	// Profiles mode lists the built-in decorator stacks
+	if len(os.Args) > 1 && os.Args[1] == "profiles" {
+		if err := runProfiles(os.Stdout); err != nil {
+			log.Fatalf("Failed to list profiles: %v", err)
+		}
+		return
+	}
+
 	// Parse command-line flags
 	interfaceName := flag.String("interface", "", "Name of the interface to generate decorators for")
 	sourceFile := flag.String("source", "", "Source file containing the interface")
-	decorators := flag.String("decorators", "retry", "Comma-separated list of decorators to generate (retry,cache,metrics)")
-	outputFile := flag.String("output", "", "Output file for generated code")
+	decorators := flag.String("decorators", "retry", "Comma-separated list of decorators to generate, outermost first (retry,cache,dedupe,lastgood,async,observability,contextcheck,returns,fake)")
+	outputFile := flag.String("output", "", "Output file for generated code; with several decorators, each is written next to it suffixed with its name, e.g. storage_retry.go for storage.go")
 	packageName := flag.String("package", "decorators", "Package name for generated code")
 	configFile := flag.String("config", "", "Path to configuration file")
+	verify := flag.Bool("verify", false, "Type-check the generated code and report errors against the template that produced them")
+	di := flag.String("di", "", "Dependency injection framework to emit providers for (wire,fx)")
+	local := flag.String("local", "", "Comma-separated import path prefixes grouped after third party imports, like goimports -local")
+	wrapErrors := flag.String("wrap-errors", "", "How generated decorators wrap returned errors: none, method, or a function as import/path.Func")
+	callMeta := flag.Bool("callmeta", false, "Record the interface, method and decorator stack of each call in its context")
+	methodNames := flag.String("method-names", "", "Also generate constants naming the interface methods, used by the decorators (const,typed)")
+	methods := flag.String("methods", "", "Comma-separated methods to decorate; the decorators embed the interface for the others")
+	assertSource := flag.Bool("assert-source", false, "Panic at init when the interface changed since the decorators were generated, where the source is available")
+	style := flag.String("style", "", "Output style of the decorators: struct, or functional for functions returning the interface")
+	raceTest := flag.Bool("race-test", false, "Also generate a test calling the decorator from several goroutines, to run with -race")
+	ctxTest := flag.Bool("with-ctx-tests", false, "Also generate a test cancelling the context of each method mid-call, checking the decorator returns promptly with the error of the context")
+	implementations := flag.String("implementations", "", "Comma-separated implementations of the interface in its package, such as *PostgresStore, asserted in a guard file next to the interface")
+	doc := flag.String("doc", "", "Also generate documentation of the decorator stack with wiring examples (go,markdown)")
+	var settings listFlag
+	flag.Var(&settings, "set", "Decorator setting as decorator.option=value, e.g. retry.policies.Get=reads or cache.ttl=5m; repeat for several settings")
+	minimalDeps := flag.Bool("minimal-deps", false, "Fail unless the generated code depends only on the standard library and decogen runtimes without third party dependencies")
+	workDir := flag.String("C", "", "Change to this directory before generating; recorded by the generated files for decogen regen")
+	profile := flag.String("profile", "", "Built-in decorator stack with default settings (grpc-client,http-client,repository,queue-consumer), used unless -decorators is set; see decogen profiles")
 
 	flag.Parse()
 
+	if *workDir != "" {
+		if err := os.Chdir(*workDir); err != nil {
+			log.Fatalf("Failed to change directory: %v", err)
+		}
+	}
+
 	var cfg *config.Config
 	var err error
 
@@ -48,6 +138,51 @@ func main() {
 			log.Fatalf("Failed to create configuration: %v", err)
 		}
 	}
+	if *profile != "" {
+		// The profile selects the stack unless decorators were chosen explicitly
+		if *configFile == "" && !flagSet(flag.CommandLine, "decorators") {
+			cfg.Decorators = nil
+		}
+		if err := cfg.ApplyProfile(*profile); err != nil {
+			log.Fatalf("Failed to apply profile: %v", err)
+		}
+	}
+	if *di != "" {
+		cfg.DI = *di
+	}
+	if *local != "" {
+		cfg.Local = *local
+	}
+	if *wrapErrors != "" {
+		cfg.WrapErrors = *wrapErrors
+	}
+	if *callMeta {
+		cfg.CallMeta = true
+	}
+	if *methodNames != "" {
+		cfg.MethodNames = *methodNames
+	}
+	if *assertSource {
+		cfg.AssertSource = true
+	}
+	if *style != "" {
+		cfg.Style = *style
+	}
+	if *doc != "" {
+		cfg.Doc = *doc
+	}
+	if *minimalDeps {
+		cfg.MinimalDeps = true
+	}
+	if *implementations != "" {
+		if cfg.Implementations == nil {
+			cfg.Implementations = make(map[string][]string)
+		}
+		cfg.Implementations[cfg.Interface.Name] = strings.Split(*implementations, ",")
+	}
+	if err := applySettings(cfg, settings, true); err != nil {
+		log.Fatalf("Failed to apply settings: %v", err)
+	}
 
 	// Parse the interface
 	log.Printf("Parsing interface %s from %s", cfg.Interface.Name, cfg.Interface.Source)
@@ -63,6 +198,29 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to get decorator types: %v", err)
 	}
+	if err := checkOrder(cfg.Interface.Name, decoratorTypes); err != nil {
+		log.Fatalf("Failed to validate decorators: %v", err)
+	}
+
+	decoratorOptions, err := cfg.GetDecoratorOptions()
+	if err != nil {
+		log.Fatalf("Failed to get decorator options: %v", err)
+	}
+	if *methods != "" {
+		for _, opts := range decoratorOptions {
+			opts["methods"] = strings.Split(*methods, ",")
+		}
+	}
+	logKeyWarnings(interfaceModel, decoratorTypes, decoratorOptions)
+
+	// Record the invocation in the generated files, so that decogen regen can repeat it
+	recorded, err := recordArgs(flag.CommandLine, cfg.Output)
+	if err != nil {
+		log.Fatalf("Failed to record arguments: %v", err)
+	}
+	for _, opts := range decoratorOptions {
+		opts["args"] = recorded
+	}
 
 	// Create generator
 	gen, err := generator.NewGenerator()
@@ -70,12 +228,64 @@ func main() {
 		log.Fatalf("Failed to create generator: %v", err)
 	}
 
-	// Generate code
-	log.Printf("Generating %s decorators for %s", strings.Join(*decorators, ","), cfg.Interface.Name)
-	err = gen.Generate(interfaceModel, decoratorTypes, cfg.Package, cfg.Output)
-	if err != nil {
-		log.Fatalf("Failed to generate code: %v", err)
+	// Generate code, one file per decorator
+	log.Printf("Generating %s decorators for %s", joinTypes(decoratorTypes), cfg.Interface.Name)
+	outputs := generator.StackOutputs(cfg.Output, decoratorTypes)
+	for i, dt := range decoratorTypes {
+		err = gen.Generate(interfaceModel, []generator.DecoratorType{dt}, cfg.Package, outputs[i], decoratorOptions)
+		if err != nil {
+			log.Fatalf("Failed to generate %s decorator: %v", dt, err)
+		}
+	}
+
+	if cfg.MethodNames != "" {
+		namesPath := strings.TrimSuffix(cfg.Output, ".go") + "_methods.go"
+		if err := gen.GenerateMethodNames(interfaceModel, cfg.Package, namesPath, cfg.MethodNames); err != nil {
+			log.Fatalf("Failed to generate method names: %v", err)
+		}
+	}
+
+	if *raceTest && len(decoratorTypes) > 0 {
+		testPath := strings.TrimSuffix(cfg.Output, ".go") + "_race_test.go"
+		if err := gen.GenerateRaceTest(interfaceModel, decoratorTypes, cfg.Package, testPath, decoratorOptions); err != nil {
+			log.Fatalf("Failed to generate race test: %v", err)
+		}
+	}
+
+	if *ctxTest && len(decoratorTypes) > 0 {
+		testPath := strings.TrimSuffix(cfg.Output, ".go") + "_ctx_test.go"
+		if err := gen.GenerateCtxTest(interfaceModel, decoratorTypes, cfg.Package, testPath, decoratorOptions); err != nil {
+			log.Fatalf("Failed to generate context test: %v", err)
+		}
+	}
+
+	if cfg.Doc != "" {
+		docPath := generator.DocPath(cfg.Output, cfg.Doc)
+		if err := gen.GenerateDoc(interfaceModel, decoratorTypes, cfg.Package, docPath, decoratorOptions, cfg.Doc); err != nil {
+			log.Fatalf("Failed to generate documentation: %v", err)
+		}
+	}
+
+	if impls := cfg.Implementations[cfg.Interface.Name]; len(impls) > 0 {
+		guardPath := filepath.Join(filepath.Dir(cfg.Interface.Source), snakeCase(cfg.Interface.Name)+"_guard.go")
+		if err := gen.GenerateGuard(interfaceModel, guardPath, impls); err != nil {
+			log.Fatalf("Failed to generate implementation guard: %v", err)
+		}
+	}
+
+	if *verify && len(decoratorTypes) > 0 {
+		origins := make(map[string]generator.Origin, len(outputs))
+		for i, dt := range decoratorTypes {
+			origin := generator.Origin{Decorator: dt, Interface: cfg.Interface.Name, Source: cfg.Interface.Source}
+			origins[outputs[i]] = origin
+			if tag, _ := decoratorOptions[dt].BuildTag(); tag != nil {
+				origins[generator.VariantPath(outputs[i])] = origin
+			}
+		}
+		if err := generator.Verify(filepath.Dir(cfg.Output), origins); err != nil {
+			log.Fatalf("Failed to verify generated code: %v", err)
+		}
 	}
 
-	log.Printf("Successfully generated code to %s", cfg.Output)
+	log.Printf("Successfully generated code to %s", strings.Join(outputs, ", "))
 }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+)
+
+// explainSamples is the number of schedules simulated to report the range of a jittered schedule
+const explainSamples = 1000
+
+// runPolicy implements "decogen policy <command>"
+func runPolicy(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing policy command (explain)")
+	}
+
+	switch args[0] {
+	case "explain":
+		return runPolicyExplain(args[1:])
+	default:
+		return fmt.Errorf("unknown policy command: %s", args[0])
+	}
+}
+
+// runPolicyExplain implements "decogen policy explain -file policies.json -policy reads"
+// It prints the delay schedule of a policy of a retry.PolicyDocument
+func runPolicyExplain(args []string) error {
+	flags := flag.NewFlagSet("policy explain", flag.ExitOnError)
+	file := flags.String("file", "", "JSON policy document, as loaded by retry.PolicyRegistry")
+	name := flags.String("policy", retry.DefaultPolicy, "Name of the policy to explain")
+	failures := flags.Int("failures", 0, "Number of failed attempts to simulate; 0 fails every attempt")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return fmt.Errorf("policy document is required")
+	}
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("failed to read policy document: %w", err)
+	}
+
+	// Error classes only select which errors are retried, so any name is accepted
+	var doc retry.PolicyDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to decode policy document: %w", err)
+	}
+	classes := make(map[string]func(error) bool)
+	for _, spec := range doc.Policies {
+		for _, class := range spec.Recoverable {
+			classes[class] = func(error) bool { return true }
+		}
+		for _, eb := range spec.ErrorBackoffs {
+			classes[eb.Class] = func(error) bool { return true }
+		}
+	}
+
+	registry := retry.NewPolicyRegistry(retry.RegistryConfig{Classes: classes})
+	if err := registry.Load(data); err != nil {
+		return err
+	}
+
+	resolved := *name
+	if _, ok := registry.Policies()[resolved]; !ok {
+		resolved = retry.DefaultPolicy
+		if _, ok := registry.Policies()[resolved]; !ok {
+			return fmt.Errorf("policy %q not found and no %q policy to fall back to", *name, retry.DefaultPolicy)
+		}
+	}
+
+	config := registry.Policy(resolved)
+	if *failures <= 0 {
+		*failures = int(config.MaxAttempts)
+	}
+
+	// The spec is parsed again for every sample, so stateful strategies start afresh
+	samples := make([][]time.Duration, explainSamples)
+	for i := range samples {
+		if err := registry.Load(data); err != nil {
+			return err
+		}
+		samples[i] = retry.Simulate(registry.Policy(resolved), *failures)
+	}
+
+	printSchedule(os.Stdout, *name, resolved, config, samples)
+	return nil
+}
+
+// printSchedule writes a human readable report of simulated retry schedules
+// The first sample is listed delay by delay, the others only widen the reported ranges
+func printSchedule(w io.Writer, name, resolved string, config retry.Config, samples [][]time.Duration) {
+	if name != resolved {
+		fmt.Fprintf(w, "Policy %s is not defined, falling back to %s\n", name, resolved)
+	}
+
+	schedule := samples[0]
+	retries := len(schedule)
+	if config.DelayFirstAttempt && retries > 0 {
+		retries--
+	}
+	fmt.Fprintf(w, "Policy %s: up to %d attempts, %d retries simulated\n", resolved, config.MaxAttempts, retries)
+
+	for i, delay := range schedule {
+		attempt := i + 2
+		if config.DelayFirstAttempt {
+			attempt = i + 1
+		}
+		fmt.Fprintf(w, "  before attempt %d: wait %s\n", attempt, delay.Round(time.Millisecond))
+	}
+
+	totals := make([]time.Duration, len(samples))
+	for i, sample := range samples {
+		for _, delay := range sample {
+			totals[i] += delay
+		}
+	}
+	lowest, highest := slices.Min(totals), slices.Max(totals)
+	if lowest == highest {
+		fmt.Fprintf(w, "Total wait: %s\n", highest)
+	} else {
+		fmt.Fprintf(w, "Total wait: %s, between %s and %s over %d samples with jitter\n",
+			totals[0].Round(time.Millisecond), lowest.Round(time.Millisecond), highest.Round(time.Millisecond), len(samples))
+	}
+	if config.MaxElapsedTime > 0 {
+		fmt.Fprintf(w, "Elapsed time budget: %s\n", config.MaxElapsedTime)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/komandakycto/decogen/internal/config"
+)
+
+// runProfiles implements "decogen profiles", listing the built-in profiles selectable with -profile
+// with their decorator stacks, outermost first
+func runProfiles(w io.Writer) error {
+	profiles, err := config.Profiles()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROFILE\tDECORATORS\tDESCRIPTION")
+	for _, profile := range profiles {
+		names := make([]string, len(profile.Decorators))
+		for i, dec := range profile.Decorators {
+			names[i] = dec.Name
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", profile.Name, strings.Join(names, ","), profile.Description)
+	}
+	return tw.Flush()
+}
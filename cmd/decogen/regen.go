@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/generator"
+	"github.com/komandakycto/decogen/pkg/sourcehash"
+)
+
+// regenFile is a generated file holding the arguments it was generated with
+type regenFile struct {
+	path string
+	hash string
+}
+
+// runRegen implements "decogen regen [-dry-run] [dirs]", typically run from a directive such as
+// //go:generate go tool decogen regen
+// It finds the decogen-generated files of each directory, default the current one, and runs decogen again
+// with the arguments recorded in their header, once per distinct invocation, from the directory of the file
+func runRegen(args []string) error {
+	flags := flag.NewFlagSet("regen", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "Print the decogen invocations instead of running them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	dirs := flags.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate decogen: %w", err)
+	}
+
+	var errs []error
+	var regenerated int
+	for _, dir := range dirs {
+		invocations, order, err := findInvocations(dir)
+		if err != nil {
+			return err
+		}
+		if len(order) == 0 {
+			log.Printf("%s: no files generated with recorded arguments", dir)
+			continue
+		}
+
+		for _, recorded := range order {
+			files := invocations[recorded]
+			if *dryRun {
+				fmt.Printf("cd %s && decogen %s\n", dir, recorded)
+				continue
+			}
+			if err := regenerate(self, dir, recorded, files); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", files[0].path, err))
+				continue
+			}
+			regenerated++
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if !*dryRun {
+		log.Printf("regenerated %d decogen invocations", regenerated)
+	}
+	return nil
+}
+
+// findInvocations returns the generated files of dir by recorded arguments, with the arguments in file order
+func findInvocations(dir string) (map[string][]regenFile, []string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	invocations := make(map[string][]regenFile)
+	var order []string
+	for _, path := range paths {
+		code, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		recorded, ok := generator.ArgsFromHeader(code)
+		if !ok {
+			continue
+		}
+		hash, _ := sourcehash.FromHeader(code)
+		if _, ok := invocations[recorded]; !ok {
+			order = append(order, recorded)
+		}
+		invocations[recorded] = append(invocations[recorded], regenFile{path: path, hash: hash})
+	}
+	return invocations, order, nil
+}
+
+// regenerate runs decogen with recorded arguments from dir and reports the files whose interface changed
+func regenerate(self, dir, recorded string, files []regenFile) error {
+	args, err := splitArgs(recorded)
+	if err != nil {
+		return fmt.Errorf("invalid recorded arguments: %w", err)
+	}
+
+	cmd := exec.Command(self, args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("decogen %s: %w", recorded, err)
+	}
+
+	for _, file := range files {
+		code, err := os.ReadFile(file.path)
+		if err != nil {
+			// The invocation no longer generates the file, e.g. after a change of its output
+			continue
+		}
+		if hash, _ := sourcehash.FromHeader(code); hash != file.hash {
+			log.Printf("%s: regenerated for the changed interface", file.path)
+		}
+	}
+	return nil
+}
+
+// pathFlags are the flags holding paths, recorded with forward slashes so that regen runs them on any platform
+var pathFlags = map[string]bool{"source": true, "output": true, "config": true}
+
+// recordArgs returns the flags set on the command line, to be recorded in the header of the files generated
+// to output; -C is set to the working directory relative to the directory of output, where regen runs
+func recordArgs(flags *flag.FlagSet, output string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	outputDir, err := filepath.Abs(filepath.Dir(output))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(outputDir, wd)
+	if err != nil {
+		// No relative path leads to another Windows volume, the files then only regenerate on this machine
+		rel = wd
+	}
+
+	var args []string
+	if rel != "." {
+		args = append(args, "-C="+filepath.ToSlash(rel))
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch value := f.Value.(type) {
+		case *listFlag:
+			for _, v := range *value {
+				args = append(args, "-"+f.Name+"="+v)
+			}
+		default:
+			switch {
+			case f.Name == "C":
+			case pathFlags[f.Name]:
+				args = append(args, "-"+f.Name+"="+filepath.ToSlash(f.Value.String()))
+			default:
+				args = append(args, "-"+f.Name+"="+f.Value.String())
+			}
+		}
+	})
+	return quoteArgs(args), nil
+}
+
+// quoteArgs joins arguments with spaces, quoting those that would not split back as a single argument
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// splitArgs splits arguments joined by quoteArgs
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] == '"' {
+			prefix, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("unterminated quoted argument %s", s)
+			}
+			arg, err := strconv.Unquote(prefix)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			s = s[len(prefix):]
+			continue
+		}
+		arg, rest, _ := strings.Cut(s, " ")
+		args = append(args, arg)
+		s = rest
+	}
+	return args, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/komandakycto/decogen/internal/generator"
+)
+
+// runTemplate implements "decogen template <command>"
+func runTemplate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing template command (lint)")
+	}
+
+	switch args[0] {
+	case "lint":
+		return runTemplateLint(args[1:])
+	default:
+		return fmt.Errorf("unknown template command: %s", args[0])
+	}
+}
+
+// runTemplateLint implements "decogen template lint"
+// It renders every template against a synthetic interface covering the method shapes templates must handle
+func runTemplateLint(args []string) error {
+	flags := flag.NewFlagSet("template lint", flag.ExitOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	gen, err := generator.NewGenerator()
+	if err != nil {
+		return fmt.Errorf("failed to create generator: %w", err)
+	}
+
+	if err := gen.Lint(); err != nil {
+		return err
+	}
+
+	log.Printf("Templates rendered %s without errors", generator.LintInterface().Name)
+	return nil
+}
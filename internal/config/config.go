@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/komandakycto/decogen/internal/generator"
@@ -17,7 +19,7 @@ type Config struct {
 		Source string `json:"source"`
 	} `json:"interface"`
 
-	// Decorators to generate
+	// Decorators to generate, listed outermost first
 	Decorators []struct {
 		Name   string                 `json:"name"`
 		Config map[string]interface{} `json:"config"`
@@ -29,9 +31,54 @@ type Config struct {
 
 	// Additional imports
 	Imports []string `json:"imports"`
+
+	// DI selects a dependency injection framework ("wire" or "fx") to emit providers for
+	// Decorators may override it with a "di" option
+	DI string `json:"di"`
+
+	// Local is a comma-separated list of import path prefixes grouped after third party imports
+	// It mirrors goimports -local; decorators may override it with a "local" option
+	Local string `json:"local"`
+
+	// WrapErrors selects how generated decorators wrap returned errors: "none", "method"
+	// or a function as "import/path.Func"; decorators may override it with a "wrapErrors" option
+	// The cache decorator returns the errors of the underlying implementation unchanged
+	WrapErrors string `json:"wrapErrors"`
+
+	// CallMeta makes generated decorators record the interface, method and decorator stack of each call
+	// in the context with the callmeta package; decorators may override it with a "callmeta" option
+	CallMeta bool `json:"callmeta"`
+
+	// MethodNames generates constants naming the interface methods, "const" or "typed", next to the decorators,
+	// which then refer to methods through them
+	MethodNames string `json:"methodNames"`
+
+	// AssertSource makes generated decorators check at init that their interface did not change since generation
+	// Decorators may override it with an "assertSource" option
+	AssertSource bool `json:"assertSource"`
+
+	// Style selects the output style of generated decorators: "struct" exports the decorator structs,
+	// "functional" emits functions such as UserStorageWithRetry(next, config) returning the interface
+	// Decorators may override it with a "style" option
+	Style string `json:"style"`
+
+	// Doc generates documentation of the decorator stack next to the decorators, "go" for the package comment
+	// of a Go file or "markdown" for a markdown fragment, with the real type and constructor names
+	Doc string `json:"doc"`
+
+	// MinimalDeps restricts generated code to the standard library and the decogen runtimes without third party
+	// dependencies, for strict dependency allowlists; decorators that cannot comply, such as observability with
+	// OpenTelemetry or any with dependency injection providers, fail to generate
+	// Decorators may override it with a "minimalDeps" option
+	MinimalDeps bool `json:"minimalDeps"`
+
+	// Implementations lists hand-written implementations by interface name, as type names of the interface
+	// package such as "*PostgresStore"; a guard file next to the interface asserts that they satisfy it
+	Implementations map[string][]string `json:"implementations"`
 }
 
 // LoadFromFile loads configuration from a JSON file
+// Relative source and output paths are resolved against the directory of the file, not the working directory
 func LoadFromFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -43,9 +90,21 @@ func LoadFromFile(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	dir := filepath.Dir(path)
+	config.Interface.Source = resolvePath(dir, config.Interface.Source)
+	config.Output = resolvePath(dir, config.Output)
+
 	return &config, nil
 }
 
+// resolvePath returns path relative to dir, leaving empty and absolute paths unchanged
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
 // GetDecoratorTypes converts string decorator names to DecoratorType values
 func (c *Config) GetDecoratorTypes() ([]generator.DecoratorType, error) {
 	var types []generator.DecoratorType
@@ -57,7 +116,21 @@ func (c *Config) GetDecoratorTypes() ([]generator.DecoratorType, error) {
 		case "cache":
 			types = append(types, generator.CacheDecorator)
 		case "metrics":
-			types = append(types, generator.MetricsDecorator)
+			return nil, fmt.Errorf("decorator type metrics has no template, use observability to report calls to metrics")
+		case "dedupe":
+			types = append(types, generator.DedupeDecorator)
+		case "lastgood":
+			types = append(types, generator.LastGoodDecorator)
+		case "async":
+			types = append(types, generator.AsyncDecorator)
+		case "observability":
+			types = append(types, generator.ObservabilityDecorator)
+		case "contextcheck":
+			types = append(types, generator.ContextCheckDecorator)
+		case "returns":
+			types = append(types, generator.ReturnsDecorator)
+		case "fake":
+			types = append(types, generator.FakeDecorator)
 		default:
 			return nil, fmt.Errorf("unknown decorator type: %s", dec.Name)
 		}
@@ -66,6 +139,125 @@ func (c *Config) GetDecoratorTypes() ([]generator.DecoratorType, error) {
 	return types, nil
 }
 
+// GetDecoratorOptions returns the settings of each configured decorator
+func (c *Config) GetDecoratorOptions() (map[generator.DecoratorType]generator.Options, error) {
+	types, err := c.GetDecoratorTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	options := make(map[generator.DecoratorType]generator.Options, len(types))
+	for i, dt := range types {
+		opts := generator.Options{}
+		if c.DI != "" {
+			opts["di"] = c.DI
+		}
+		if c.Local != "" {
+			opts["local"] = c.Local
+		}
+		if c.WrapErrors != "" {
+			opts["wrapErrors"] = c.WrapErrors
+		}
+		if c.CallMeta {
+			opts["callmeta"] = true
+		}
+		if c.MethodNames != "" {
+			opts["methodNames"] = c.MethodNames
+		}
+		if c.AssertSource {
+			opts["assertSource"] = true
+		}
+		if c.Style != "" {
+			opts["style"] = c.Style
+		}
+		if c.MinimalDeps {
+			opts["minimalDeps"] = true
+		}
+		for k, v := range c.Decorators[i].Config {
+			opts[k] = v
+		}
+		options[dt] = opts
+	}
+
+	return options, nil
+}
+
+// DecoratorDefaults returns the settings configured for the named decorator, or nil
+func (c *Config) DecoratorDefaults(name string) map[string]interface{} {
+	for _, dec := range c.Decorators {
+		if strings.EqualFold(dec.Name, name) {
+			return dec.Config
+		}
+	}
+	return nil
+}
+
+// Set applies a decorator setting given as "decorator.option=value", e.g. "retry.wrapErrors=method"
+// Further dotted keys set entries of map options, as in "cache.keys.Get=user:{{.id}}", and snake_case options
+// such as "max_attempts" are converted to the camelCase of the configuration file. Values are parsed as JSON
+// when valid, so numbers, booleans, lists and objects keep their type, and kept as strings otherwise.
+// A decorator missing from the configuration is added with the setting.
+func (c *Config) Set(setting string) error {
+	path, raw, ok := strings.Cut(setting, "=")
+	if !ok {
+		return fmt.Errorf("invalid setting %q: want decorator.option=value", setting)
+	}
+	keys := strings.Split(strings.TrimSpace(path), ".")
+	if len(keys) < 2 || slices.Contains(keys, "") {
+		return fmt.Errorf("invalid setting %q: want decorator.option=value", setting)
+	}
+	keys[1] = camelCase(keys[1])
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+
+	i := -1
+	for j, dec := range c.Decorators {
+		if strings.EqualFold(dec.Name, keys[0]) {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		c.Decorators = append(c.Decorators, struct {
+			Name   string                 `json:"name"`
+			Config map[string]interface{} `json:"config"`
+		}{Name: keys[0]})
+		i = len(c.Decorators) - 1
+	}
+	if c.Decorators[i].Config == nil {
+		c.Decorators[i].Config = make(map[string]interface{})
+	}
+
+	options := c.Decorators[i].Config
+	for _, key := range keys[1 : len(keys)-1] {
+		nested, ok := options[key].(map[string]interface{})
+		if !ok {
+			if _, exists := options[key]; exists {
+				return fmt.Errorf("invalid setting %q: %s is not a map", setting, key)
+			}
+			nested = make(map[string]interface{})
+			options[key] = nested
+		}
+		options = nested
+	}
+	options[keys[len(keys)-1]] = value
+	return nil
+}
+
+// camelCase converts a snake_case option name such as max_attempts to maxAttempts
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
 // FromFlags creates a configuration from command-line flags
 func FromFlags(
 	interfaceName string,
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/internal/generator"
+)
+
+func TestLoadFromFileResolvesPaths(t *testing.T) {
+	dir := t.TempDir()
+	configDir := filepath.Join(dir, "storage")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "decogen.json"), []byte(`{
+	"interface": {"name": "UserStorage", "source": "storage.go"},
+	"decorators": [{"name": "retry"}],
+	"output": "decorators/storage_retry.go",
+	"package": "decorators"
+}`), 0644))
+
+	// Paths are relative to the configuration file wherever decogen runs
+	t.Chdir(dir)
+	config, err := LoadFromFile(filepath.Join("storage", "decogen.json"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("storage", "storage.go"), config.Interface.Source)
+	require.Equal(t, filepath.Join("storage", "decorators", "storage_retry.go"), config.Output)
+
+	t.Chdir(t.TempDir())
+	config, err = LoadFromFile(filepath.Join(configDir, "decogen.json"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(configDir, "storage.go"), config.Interface.Source)
+	require.Equal(t, filepath.Join(configDir, "decorators", "storage_retry.go"), config.Output)
+}
+
+func TestGetDecoratorTypes(t *testing.T) {
+	decorators := func(names string) *Config {
+		var config Config
+		require.NoError(t, json.Unmarshal([]byte(`{"decorators": [`+names+`]}`), &config))
+		return &config
+	}
+
+	types, err := decorators(`{"name": "observability"}, {"name": "Retry"}`).GetDecoratorTypes()
+	require.NoError(t, err)
+	require.Equal(t, []generator.DecoratorType{generator.ObservabilityDecorator, generator.RetryDecorator}, types)
+
+	// Metrics has no template of its own, observability reports calls to metrics instead
+	_, err = decorators(`{"name": "retry"}, {"name": "metrics"}`).GetDecoratorTypes()
+	require.ErrorContains(t, err, "use observability")
+
+	_, err = decorators(`{"name": "timing"}`).GetDecoratorTypes()
+	require.ErrorContains(t, err, "unknown decorator type: timing")
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   []string
+		decorators string
+		err        string
+	}{
+		{
+			name:       "json value",
+			settings:   []string{"retry.maxAttempts=5", "cache.enabled=true", "dedupe.methods=[\"Get\"]"},
+			decorators: `[{"name":"retry","config":{"maxAttempts":5}},{"name":"cache","config":{"enabled":true}},{"name":"dedupe","config":{"methods":["Get"]}}]`,
+		},
+		{
+			name:       "string value",
+			settings:   []string{"retry.backoff=exp(50ms,5s)", "retry.policy="},
+			decorators: `[{"name":"retry","config":{"backoff":"exp(50ms,5s)","policy":""}}]`,
+		},
+		{
+			name:       "snake case option",
+			settings:   []string{"retry.max_attempts=3", "retry.retry_on_timeout=false"},
+			decorators: `[{"name":"retry","config":{"maxAttempts":3,"retryOnTimeout":false}}]`,
+		},
+		{
+			name:       "existing decorator",
+			settings:   []string{"Retry.maxAttempts=2", "retry.maxAttempts=4"},
+			decorators: `[{"name":"Retry","config":{"maxAttempts":4}}]`,
+		},
+		{
+			name:       "nested keys",
+			settings:   []string{"retry.policies.reads.max_attempts=5", "retry.policies.reads.backoff=const(1s)", "retry.policies.writes.max_attempts=1"},
+			decorators: `[{"name":"retry","config":{"policies":{"reads":{"max_attempts":5,"backoff":"const(1s)"},"writes":{"max_attempts":1}}}}]`,
+		},
+		{
+			name:     "not a map",
+			settings: []string{"retry.policies=reads", "retry.policies.reads.max_attempts=5"},
+			err:      "policies is not a map",
+		},
+		{
+			name:     "missing value",
+			settings: []string{"retry.maxAttempts"},
+			err:      "want decorator.option=value",
+		},
+		{
+			name:     "missing option",
+			settings: []string{"retry=5"},
+			err:      "want decorator.option=value",
+		},
+		{
+			name:     "empty key",
+			settings: []string{"retry..maxAttempts=5"},
+			err:      "want decorator.option=value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config Config
+			var err error
+			for _, setting := range tt.settings {
+				if err = config.Set(setting); err != nil {
+					break
+				}
+			}
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			decorators, err := json.Marshal(config.Decorators)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.decorators, string(decorators))
+		})
+	}
+}
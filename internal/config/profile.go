@@ -0,0 +1,109 @@
+package config
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed profiles/*.json
+var profilesFS embed.FS
+
+// Profile is a built-in configuration selecting an opinionated decorator stack with default settings
+// for a common kind of dependency, such as "repository" or "grpc-client"
+type Profile struct {
+	// Name selects the profile, as in -profile repository
+	Name string `json:"-"`
+
+	// Description tells what the profile is meant for
+	Description string `json:"description"`
+
+	Config
+}
+
+// Profiles returns the built-in profiles, sorted by name
+func Profiles() ([]Profile, error) {
+	files, err := fs.Glob(profilesFS, "profiles/*.json")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	profiles := make([]Profile, 0, len(files))
+	for _, file := range files {
+		data, err := profilesFS.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var profile Profile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			return nil, fmt.Errorf("failed to parse profile %s: %w", file, err)
+		}
+		profile.Name = strings.TrimSuffix(path.Base(file), ".json")
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// LoadProfile returns the built-in profile with the given name
+func LoadProfile(name string) (*Profile, error) {
+	profiles, err := Profiles()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(profiles))
+	for i, profile := range profiles {
+		if profile.Name == name {
+			return &profile, nil
+		}
+		names[i] = profile.Name
+	}
+	return nil, fmt.Errorf("unknown profile %s: want one of %s", name, strings.Join(names, ", "))
+}
+
+// ApplyProfile fills the settings missing from c with those of the named built-in profile
+// Without decorators, c takes the decorator stack of the profile; the profile settings of a decorator are
+// defaults its own settings override
+func (c *Config) ApplyProfile(name string) error {
+	profile, err := LoadProfile(name)
+	if err != nil {
+		return err
+	}
+
+	if len(c.Decorators) == 0 {
+		c.Decorators = profile.Decorators
+	} else {
+		for i, dec := range c.Decorators {
+			defaults := profile.DecoratorDefaults(dec.Name)
+			if len(defaults) == 0 {
+				continue
+			}
+			settings := maps.Clone(defaults)
+			maps.Copy(settings, dec.Config)
+			c.Decorators[i].Config = settings
+		}
+	}
+
+	for _, setting := range []struct{ value, fallback *string }{
+		{&c.DI, &profile.DI},
+		{&c.Local, &profile.Local},
+		{&c.WrapErrors, &profile.WrapErrors},
+		{&c.MethodNames, &profile.MethodNames},
+		{&c.Style, &profile.Style},
+		{&c.Doc, &profile.Doc},
+	} {
+		if *setting.value == "" {
+			*setting.value = *setting.fallback
+		}
+	}
+	c.CallMeta = c.CallMeta || profile.CallMeta
+	c.AssertSource = c.AssertSource || profile.AssertSource
+	c.MinimalDeps = c.MinimalDeps || profile.MinimalDeps
+	return nil
+}
@@ -0,0 +1,342 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/komandakycto/decogen/internal/model"
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+// annotationParams lists the method annotation parameters understood by each decorator
+var annotationParams = map[DecoratorType][]string{
+	RetryDecorator:         {"policy", "max_attempts", "backoff", "max_elapsed", "idempotent", "panics"},
+	CacheDecorator:         {"ttl", "negative_ttl", "error_ttl", "key", "codec", "invalidates"},
+	ObservabilityDecorator: {"log_successes", "trace_ratio", "redact"},
+}
+
+// backoffShorthands expands positional backoff specifications of annotations such as "exp(50ms,5s)"
+// The first element is the backoff.Parse name, the rest are the parameters in order
+var backoffShorthands = map[string][]string{
+	"exp":   {"exponential", "min", "max", "factor", "jitter"},
+	"const": {"constant", "delay"},
+}
+
+// annotation returns the annotation of a method for a decorator, rejecting parameters the decorator does not know
+func annotation(dt DecoratorType, m *model.Method) (map[string]string, error) {
+	params := m.Annotation(string(dt))
+
+	var unknown []string
+	for key := range params {
+		known := false
+		for _, name := range annotationParams[dt] {
+			if key == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%s annotation of %s: unknown parameters %s, want %s",
+			dt, m.Name, strings.Join(unknown, ", "), strings.Join(annotationParams[dt], ", "))
+	}
+
+	return params, nil
+}
+
+// retryPolicy returns the expression of the policy name of a method
+// The "policy" annotation wins over the "policies" option; other methods use retry.DefaultPolicy
+func retryPolicy(options Options, m *model.Method) (string, error) {
+	if policy, ok := m.Annotation(string(RetryDecorator))["policy"]; ok {
+		return strconv.Quote(policy), nil
+	}
+	if value, ok := options["policies"]; ok {
+		policies, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("policies option must map method names to policy names, got %T", value)
+		}
+		if policy, ok := policies[m.Name].(string); ok {
+			return strconv.Quote(policy), nil
+		}
+	}
+	return "retry.DefaultPolicy", nil
+}
+
+// retryIdempotent reports whether a method is in the generated set of idempotent methods
+// Methods are idempotent unless their retry annotation sets idempotent=false
+func retryIdempotent(m *model.Method) (bool, error) {
+	value, ok := m.Annotation(string(RetryDecorator))["idempotent"]
+	if !ok {
+		return true, nil
+	}
+	idempotent, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("retry annotation of %s: idempotent must be true or false, got %q", m.Name, value)
+	}
+	return idempotent, nil
+}
+
+// retryPanics reports whether the panics of a method are retried with retry.DoRecover
+// It applies to methods without an error result whose retry annotation sets panics=true
+func retryPanics(m *model.Method) (bool, error) {
+	value, ok := m.Annotation(string(RetryDecorator))["panics"]
+	if !ok {
+		return false, nil
+	}
+	panics, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("retry annotation of %s: panics must be true or false, got %q", m.Name, value)
+	}
+	if panics && m.HasErrorReturn() {
+		return false, fmt.Errorf("retry annotation of %s: panics applies to methods without an error result", m.Name)
+	}
+	return panics, nil
+}
+
+// retrySetup is the retry config of a generated method
+type retrySetup struct {
+	// Config is the expression of the config passed to retry.Do
+	Config string
+
+	// Statements declare the config when the method annotation overrides some of its fields
+	Statements string
+}
+
+// retryConfig returns the retry config of a method, applying the fields set by its retry annotation
+// to the config of its policy, then the idempotent set of the decorator
+func retryConfig(options Options, iface string, m *model.Method, receiver string) (retrySetup, error) {
+	name, err := retryPolicy(options, m)
+	if err != nil {
+		return retrySetup{}, err
+	}
+	policy := fmt.Sprintf("%s.policies.Policy(%s)", receiver, name)
+
+	method, err := methodName(options, iface, m)
+	if err != nil {
+		return retrySetup{}, err
+	}
+	idempotent := func(config string) string {
+		return fmt.Sprintf("%s.idempotent.Config(%s, %s)", receiver, method, config)
+	}
+
+	params, err := annotation(RetryDecorator, m)
+	if err != nil {
+		return retrySetup{}, err
+	}
+
+	var overrides []string
+	if value, ok := params["max_attempts"]; ok {
+		attempts, err := strconv.ParseUint(value, 10, 0)
+		if err != nil || attempts == 0 {
+			return retrySetup{}, fmt.Errorf("retry annotation of %s: max_attempts must be a positive integer, got %q", m.Name, value)
+		}
+		overrides = append(overrides, fmt.Sprintf("MaxAttempts = %d", attempts))
+	}
+	if value, ok := params["max_elapsed"]; ok {
+		d, err := annotationDuration(value)
+		if err != nil {
+			return retrySetup{}, fmt.Errorf("retry annotation of %s: max_elapsed: %w", m.Name, err)
+		}
+		overrides = append(overrides, "MaxElapsedTime = "+durationLiteral(d))
+	}
+	if _, ok := params["backoff"]; ok {
+		overrides = append(overrides, fmt.Sprintf("Backoff = %s%sRetryBackoff", iface, m.Name))
+	}
+
+	if len(overrides) == 0 {
+		return retrySetup{Config: idempotent(policy)}, nil
+	}
+
+	config := m.Receiver("config")
+	statements := []string{fmt.Sprintf("%s := %s", config, policy)}
+	for _, o := range overrides {
+		statements = append(statements, config+"."+o)
+	}
+	return retrySetup{Config: idempotent(config), Statements: strings.Join(statements, "\n")}, nil
+}
+
+// retryBackoff returns the backoff.Parse specification of the backoff annotated for a method, or an empty string
+func retryBackoff(m *model.Method) (string, error) {
+	spec, ok := m.Annotation(string(RetryDecorator))["backoff"]
+	if !ok {
+		return "", nil
+	}
+
+	b, err := backoff.Parse(expandBackoff(spec))
+	if err != nil {
+		return "", fmt.Errorf("retry annotation of %s: %w", m.Name, err)
+	}
+	return fmt.Sprint(b), nil
+}
+
+// expandBackoff turns a positional shorthand such as "exp(50ms,5s)" into a backoff.Parse specification
+// Other specifications are returned unchanged
+func expandBackoff(spec string) string {
+	open := strings.Index(spec, "(")
+	if open < 0 || !strings.HasSuffix(spec, ")") {
+		return spec
+	}
+	shorthand, ok := backoffShorthands[strings.TrimSpace(spec[:open])]
+	if !ok {
+		return spec
+	}
+
+	args := strings.Split(spec[open+1:len(spec)-1], ",")
+	if len(args) > len(shorthand)-1 {
+		return spec
+	}
+	params := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			params = append(params, shorthand[i+1]+"="+arg)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", shorthand[0], strings.Join(params, ","))
+}
+
+// cacheTTL returns the expression of the time to live annotated for a method, or 0 for the default of the cache
+func cacheTTL(m *model.Method) (string, error) {
+	params, err := annotation(CacheDecorator, m)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := params["ttl"]
+	if !ok {
+		return "0", nil
+	}
+	d, err := annotationDuration(value)
+	if err != nil {
+		return "", fmt.Errorf("cache annotation of %s: ttl: %w", m.Name, err)
+	}
+	return durationLiteral(d), nil
+}
+
+// cacheLoaderConfig returns the fields of the cache.LoaderConfig of a method, or an empty string
+// The negative_ttl and error_ttl annotations cache the errors selected by the IsNotFound and CacheError of caches
+func cacheLoaderConfig(m *model.Method, caches string) (string, error) {
+	params, err := annotation(CacheDecorator, m)
+	if err != nil {
+		return "", err
+	}
+
+	var fields []string
+	for _, ttl := range []struct{ param, classifier, field string }{
+		{"negative_ttl", "IsNotFound", "NegativeTTL"},
+		{"error_ttl", "CacheError", "ErrorTTL"},
+	} {
+		value, ok := params[ttl.param]
+		if !ok {
+			continue
+		}
+		d, err := annotationDuration(value)
+		if err != nil {
+			return "", fmt.Errorf("cache annotation of %s: %s: %w", m.Name, ttl.param, err)
+		}
+		fields = append(fields,
+			fmt.Sprintf("%s: %s.%s", ttl.classifier, caches, ttl.classifier),
+			fmt.Sprintf("%s: %s", ttl.field, durationLiteral(d)))
+	}
+	return strings.Join(fields, ", "), nil
+}
+
+// observabilitySampling returns the fields of the observe.Sampling annotated for a method, or an empty string
+func observabilitySampling(m *model.Method) (string, error) {
+	params, err := annotation(ObservabilityDecorator, m)
+	if err != nil {
+		return "", err
+	}
+
+	var fields []string
+	if value, ok := params["log_successes"]; ok {
+		n, err := strconv.ParseUint(value, 10, 0)
+		if err != nil || n == 0 {
+			return "", fmt.Errorf("observability annotation of %s: log_successes must be a positive integer, got %q", m.Name, value)
+		}
+		fields = append(fields, fmt.Sprintf("LogSuccesses: %d", n))
+	}
+	if value, ok := params["trace_ratio"]; ok {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return "", fmt.Errorf("observability annotation of %s: trace_ratio must be between 0 and 1, got %q", m.Name, value)
+		}
+		fields = append(fields, fmt.Sprintf("TraceRatio: observe.Ratio(%s)", strconv.FormatFloat(ratio, 'g', -1, 64)))
+	}
+	return strings.Join(fields, ", "), nil
+}
+
+// observabilityArgs returns the arguments of a method passed to observe.Observer.Start,
+// with the parameters listed by the redact parameter of its annotation replaced by redact.Placeholder
+func observabilityArgs(options Options, m *model.Method) (string, error) {
+	params, err := annotation(ObservabilityDecorator, m)
+	if err != nil {
+		return "", err
+	}
+
+	known := make(map[string]bool, len(m.Parameters))
+	for _, p := range m.Parameters {
+		known[p.Name] = true
+	}
+	redacted := make(map[string]bool)
+	if value, ok := params["redact"]; ok {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if !known[name] {
+				return "", fmt.Errorf("observability annotation of %s: redact: unknown parameter %q", m.Name, name)
+			}
+			redacted[name] = true
+		}
+	}
+
+	var args []string
+	for _, p := range keyParams(options, m) {
+		if redacted[p.Name] {
+			args = append(args, "redact.Placeholder")
+		} else {
+			args = append(args, p.Name)
+		}
+	}
+	return strings.Join(args, ", "), nil
+}
+
+// annotationDuration parses a positive duration
+func annotationDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive, got %s", value)
+	}
+	return d, nil
+}
+
+// durationLiteral formats a duration as a Go expression in the largest unit dividing it, e.g. 30 * time.Second
+func durationLiteral(d time.Duration) string {
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+	for _, u := range units {
+		if d%u.unit == 0 {
+			if d == u.unit {
+				return u.name
+			}
+			return fmt.Sprintf("%d * %s", d/u.unit, u.name)
+		}
+	}
+	return fmt.Sprintf("%d * time.Nanosecond", d)
+}
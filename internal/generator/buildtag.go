@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/build/constraint"
+	"go/format"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// noopTemplate renders the no-op variant of a decorator generated with the "buildTag" option
+const noopTemplate = "templates/noop.go.tmpl"
+
+// noopData is the data of the no-op variant template
+type noopData struct {
+	*TemplateData
+
+	// Decorator is the type of the real decorator
+	Decorator DecoratorType
+
+	// Title is the decorator name as used in exported identifiers, e.g. Observability
+	Title string
+
+	// Constraint is the build constraint of the real decorator, e.g. otel
+	Constraint string
+}
+
+// BuildTag returns the build constraint set with the "buildTag" option, or nil
+// A decorator with a build constraint is only built where it holds; a no-op variant with the same
+// constructors is generated next to it for the other builds, so they do not depend on its runtime
+func (o Options) BuildTag() (constraint.Expr, error) {
+	tag, _ := o["buildTag"].(string)
+	if tag = strings.TrimSpace(tag); tag == "" {
+		return nil, nil
+	}
+
+	expr, err := constraint.Parse("//go:build " + tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid buildTag %q: %w", tag, err)
+	}
+	return expr, nil
+}
+
+// VariantPath returns the path of the no-op variant generated next to a decorator with the "buildTag" option
+func VariantPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, ".go") + "_noop.go"
+}
+
+// addBuildConstraint inserts a //go:build line before the package clause of generated code
+// The header comments stay first so the source hash is still found in the first lines
+func addBuildConstraint(code []byte, expr constraint.Expr) []byte {
+	line := []byte("//go:build " + expr.String() + "\n\n")
+	if bytes.HasPrefix(code, []byte("package ")) {
+		return append(line, code...)
+	}
+
+	i := bytes.Index(code, []byte("\npackage "))
+	if i < 0 {
+		return code
+	}
+	return bytes.Join([][]byte{code[:i+1], line, code[i+1:]}, nil)
+}
+
+// generateVariant writes the no-op variant of a decorator next to its output when it has a build constraint
+// Otherwise a variant left by an earlier generation is removed, as it would clash with the decorator
+func (g *Generator) generateVariant(
+	dt DecoratorType,
+	interfaceModel *model.Interface,
+	outputPackage string,
+	outputPath string,
+	options Options,
+) error {
+	expr, err := options.BuildTag()
+	if err != nil {
+		return err
+	}
+	variantPath := VariantPath(outputPath)
+	if expr == nil {
+		if err := os.Remove(variantPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove no-op variant: %w", err)
+		}
+		return nil
+	}
+
+	code, err := g.renderVariant(dt, expr, interfaceModel, outputPackage, options)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(variantPath, code); err != nil {
+		return fmt.Errorf("failed to write no-op variant: %w", err)
+	}
+	return nil
+}
+
+// renderVariant executes the no-op variant template for a decorator built where expr holds
+func (g *Generator) renderVariant(
+	dt DecoratorType,
+	expr constraint.Expr,
+	interfaceModel *model.Interface,
+	outputPackage string,
+	options Options,
+) ([]byte, error) {
+	if dt == FakeDecorator {
+		return nil, errors.New("the fake decorator has no no-op variant, leave out its buildTag option")
+	}
+
+	di, err := options.DI()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &noopData{
+		TemplateData: templateData(interfaceModel, outputPackage, options, di),
+		Decorator:    dt,
+		Title:        decoratorTitle(dt),
+		Constraint:   expr.String(),
+	}
+	if err := selectMethods(data.TemplateData, interfaceModel, options); err != nil {
+		return nil, err
+	}
+	if err := setStyle(data.TemplateData, dt, interfaceModel, options); err != nil {
+		return nil, err
+	}
+
+	importSpecs, err := resolveImports(g.noop, data, interfaceModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve imports: %w", err)
+	}
+	data.ImportSpecs = groupImports(importSpecs, options.Local())
+
+	var buf bytes.Buffer
+	if err := execute(g.noop, &buf, data); err != nil {
+		return nil, newTemplateError(dt, g.noop, interfaceModel, data, data.TemplateData, err)
+	}
+
+	code := buf.Bytes()
+	if pruned, err := pruneImports(code); err == nil {
+		code = pruned
+	}
+	code = addBuildConstraint(code, &constraint.NotExpr{X: expr})
+
+	formattedCode, err := format.Source(code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format no-op variant: %w", err)
+	}
+	return formattedCode, nil
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// Codecs of the "codec" and "codecs" options and of the codec parameter of cache annotations
+const (
+	// CodecJSON encodes values with cache.JSONCodec
+	CodecJSON = "json"
+	// CodecGob encodes values with cache.GobCodec
+	CodecGob = "gob"
+	// CodecProto encodes protocol buffer messages with protocodec.Codec
+	CodecProto = "proto"
+)
+
+// cacheCodecName returns the codec configured for a method, or an empty string when none is
+// The codec annotation parameter wins over the "codecs" option mapping method names to codecs,
+// which wins over the "codec" option of the whole interface
+func cacheCodecName(options Options, m *model.Method) (string, error) {
+	if codec, ok := m.Annotation(string(CacheDecorator))["codec"]; ok {
+		return codec, nil
+	}
+	if value, ok := options["codecs"]; ok {
+		codecs, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("codecs option must map method names to codecs, got %T", value)
+		}
+		if codec, ok := codecs[m.Name].(string); ok {
+			return codec, nil
+		}
+	}
+	if value, ok := options["codec"]; ok {
+		codec, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("codec option must be a string, got %T", value)
+		}
+		return codec, nil
+	}
+	return "", nil
+}
+
+// cacheCodec returns the expression of the codec encoding the cached values of a method, of type valueType
+// Methods without a configured codec use JSON like cache.NewRemote
+func cacheCodec(options Options, m *model.Method, valueType string) (string, error) {
+	name, err := cacheCodecName(options, m)
+	if err != nil {
+		return "", err
+	}
+
+	switch name {
+	case "", CodecJSON:
+		return fmt.Sprintf("cache.JSONCodec[%s]{}", valueType), nil
+	case CodecGob:
+		return fmt.Sprintf("cache.GobCodec[%s]{}", valueType), nil
+	case CodecProto:
+		if len(m.Results) > 2 {
+			return "", fmt.Errorf("codec of %s: %s cannot encode the %d values it returns together", m.Name, name, len(m.Results)-1)
+		}
+		return fmt.Sprintf("protocodec.Codec[%s]{}", valueType), nil
+	default:
+		return "", fmt.Errorf("codec of %s: unknown codec %q, want %s, %s or %s", m.Name, name, CodecJSON, CodecGob, CodecProto)
+	}
+}
+
+// cacheRemote reports whether a codec is configured for one of the methods,
+// in which case the cache decorator comes with a constructor of remote caches
+func cacheRemote(options Options, methods []*model.Method) (bool, error) {
+	for _, m := range methods {
+		name, err := cacheCodecName(options, m)
+		if err != nil {
+			return false, err
+		}
+		if name != "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// cacheKey returns the Go expression building the cache key of a method
+//
+// The "keys" option maps method names to key templates such as "user:{{.id}}",
+// where each action names a parameter or a field of one; the "key" parameter of
+// a cache annotation takes precedence. Methods without a key template use an
+// automatic composite key of the method name and its arguments, leaving out
+// contexts, callbacks, channels and the types of the "skipTypes" option.
+func cacheKey(options Options, m *model.Method) (string, error) {
+	text, ok := m.Annotation(string(CacheDecorator))["key"]
+	if !ok {
+		keys, _ := options["keys"].(map[string]interface{})
+		text, ok = keys[m.Name].(string)
+	}
+	if !ok {
+		return automaticCacheKey(options, m), nil
+	}
+
+	return templateCacheKey(m, text)
+}
+
+// automaticCacheKey builds the key from the method name and the arguments kept by the skipTypes rules
+func automaticCacheKey(options Options, m *model.Method) string {
+	args := []string{strconv.Quote(m.Name)}
+	for _, p := range keyParams(options, m) {
+		args = append(args, p.Name)
+	}
+
+	return fmt.Sprintf("cache.Key(%s)", strings.Join(args, ", "))
+}
+
+// templateCacheKey compiles a key template to a string expression
+func templateCacheKey(m *model.Method, text string) (string, error) {
+	trees, err := parse.Parse("key", text, "{{", "}}")
+	if err != nil {
+		return "", fmt.Errorf("invalid cache key template for %s: %w", m.Name, err)
+	}
+
+	params := make(map[string]bool, len(m.Parameters))
+	for _, p := range m.Parameters {
+		params[p.Name] = true
+	}
+
+	var format strings.Builder
+	var args []string
+	for _, node := range trees["key"].Root.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			format.WriteString(strings.ReplaceAll(string(n.Text), "%", "%%"))
+		case *parse.ActionNode:
+			field, ok := keyField(n)
+			if !ok {
+				return "", fmt.Errorf("cache key template for %s: unsupported action %s, only parameters such as {{.id}} are allowed", m.Name, n)
+			}
+			if !params[field[0]] {
+				return "", fmt.Errorf("cache key template for %s: %s is not a parameter", m.Name, field[0])
+			}
+			format.WriteString("%v")
+			args = append(args, strings.Join(field, "."))
+		default:
+			return "", fmt.Errorf("cache key template for %s: unsupported template node %s", m.Name, n)
+		}
+	}
+
+	if len(args) == 0 {
+		return strconv.Quote(format.String()), nil
+	}
+	return fmt.Sprintf("fmt.Sprintf(%s, %s)", strconv.Quote(format.String()), strings.Join(args, ", ")), nil
+}
+
+// keyField returns the identifiers of an action made of a single field reference such as {{.user.ID}}
+func keyField(n *parse.ActionNode) ([]string, bool) {
+	if len(n.Pipe.Decl) != 0 || len(n.Pipe.Cmds) != 1 || len(n.Pipe.Cmds[0].Args) != 1 {
+		return nil, false
+	}
+
+	field, ok := n.Pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	if !ok {
+		return nil, false
+	}
+	return field.Ident, true
+}
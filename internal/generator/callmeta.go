@@ -0,0 +1,27 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// callMeta returns the statement recording the call in the context of a method with callmeta.With, or an empty string
+// It is enabled by the "callmeta" option; methods without a context parameter are left unchanged
+// Methods named by a //decogen:name annotation are recorded under that name with callmeta.WithName
+func callMeta(options Options, decorator DecoratorType, iface string, m *model.Method) (string, error) {
+	enabled, _ := options["callmeta"].(bool)
+	ctx := m.FormatContextParam()
+	if !enabled || ctx == "" {
+		return "", nil
+	}
+
+	name, err := methodName(options, iface, m)
+	if err != nil {
+		return "", err
+	}
+	if m.Alias != "" {
+		return fmt.Sprintf("%s = callmeta.WithName(%s, %q, %s, %q, %q)", ctx, ctx, iface, name, m.Alias, decorator), nil
+	}
+	return fmt.Sprintf("%s = callmeta.With(%s, %q, %s, %q)", ctx, ctx, iface, name, decorator), nil
+}
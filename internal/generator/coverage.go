@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// generatedHeader starts the files generated by decogen
+const generatedHeader = "// Code generated by decogen."
+
+// Coverage lists the decorators generated for an interface
+type Coverage struct {
+	// Interface is the name of the interface
+	Interface string `json:"interface"`
+
+	// Source is the file declaring the interface
+	Source string `json:"source"`
+
+	// Decorators are the decorators generated for the interface in its package, sorted by name
+	Decorators []DecoratorType `json:"decorators"`
+}
+
+// Has reports whether the decorator was generated for the interface
+func (c Coverage) Has(dt DecoratorType) bool {
+	for _, d := range c.Decorators {
+		if d == dt {
+			return true
+		}
+	}
+	return false
+}
+
+// DecoratorTypes returns the decorator types with a template, sorted by name
+func (g *Generator) DecoratorTypes() []DecoratorType {
+	types := make([]DecoratorType, 0, len(g.templates))
+	for dt := range g.templates {
+		types = append(types, dt)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// DecoratorTypeNames returns the names the type generated by a decorator for an interface has in either style,
+// e.g. UserStorageWithRetry and retryUserStorage
+func DecoratorTypeNames(dt DecoratorType, iface string) []string {
+	if dt == FakeDecorator {
+		return []string{"Fake" + iface}
+	}
+	return []string{iface + "With" + decoratorTitle(dt), string(dt) + iface}
+}
+
+// Coverage reports the decorators generated for each interface declared in the package in dir
+// Decorators are found by the types declared in the files generated by decogen, so decorators generated
+// into another package are not reported
+func (g *Generator) Coverage(dir string) ([]Coverage, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	generated := make(map[string]bool)
+	var coverage []Coverage
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := goparser.ParseFile(fset, file, nil, goparser.ParseComments|goparser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		isGenerated := len(f.Comments) > 0 && f.Comments[0].Pos() < f.Package &&
+			strings.HasPrefix(f.Comments[0].List[0].Text, generatedHeader)
+
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if isGenerated {
+					generated[typeSpec.Name.Name] = true
+				} else if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+					coverage = append(coverage, Coverage{Interface: typeSpec.Name.Name, Source: file})
+				}
+			}
+		}
+	}
+
+	for i := range coverage {
+		for _, dt := range g.DecoratorTypes() {
+			for _, name := range DecoratorTypeNames(dt, coverage[i].Interface) {
+				if generated[name] {
+					coverage[i].Decorators = append(coverage[i].Decorators, dt)
+					break
+				}
+			}
+		}
+	}
+	return coverage, nil
+}
@@ -0,0 +1,23 @@
+package generator
+
+import (
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// cancelTemplate is the name of the template block building a decorator for the cancellation test
+// Templates define it when their race block does not fit the test; rendering nothing leaves the decorator out,
+// e.g. when it detaches calls from the context of the caller by design
+const cancelTemplate = "cancel"
+
+// GenerateCtxTest generates a test cancelling the context of every method taking one mid-call
+// The test fails when a generated decorator does not return promptly with the error of the context
+// It skips itself when no decorator applies
+func (g *Generator) GenerateCtxTest(
+	interfaceModel *model.Interface,
+	decoratorTypes []DecoratorType,
+	outputPackage string,
+	outputPath string,
+	options map[DecoratorType]Options,
+) error {
+	return g.generateDecoratorTest("context test", g.ctxTest, cancelTemplate, interfaceModel, decoratorTypes, outputPackage, outputPath, options)
+}
@@ -0,0 +1,195 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// Formats of the generated documentation
+const (
+	// DocGo writes the documentation as the package comment of a Go file
+	DocGo = "go"
+	// DocMarkdown writes the documentation as a markdown fragment
+	DocMarkdown = "markdown"
+)
+
+// Names of the template blocks documenting a decorator
+// The doc block renders sentences, the docExample block renders Go statements declaring "decorated" from "underlying"
+const (
+	docTemplate        = "doc"
+	docExampleTemplate = "docExample"
+)
+
+// docDecorator is a decorator of the documented stack
+type docDecorator struct {
+	// Type is the decorator type
+	Type DecoratorType
+
+	// Name is the name of the decorator struct, e.g. UserStorageWithRetry
+	Name string
+
+	// Doc are the lines describing the decorator and its configuration
+	Doc []string
+
+	// Example are the lines of Go code wiring the decorator
+	Example []string
+}
+
+// docData is the data of the documentation templates
+type docData struct {
+	*TemplateData
+
+	// Stack are the decorator types that wrap each other, outermost first
+	Stack []DecoratorType
+
+	// Decorators are the documented decorators, in the order they were requested
+	Decorators []docDecorator
+}
+
+// DocPath returns the path of the documentation generated for the decorators written to outputPath
+func DocPath(outputPath, docFormat string) string {
+	base := strings.TrimSuffix(outputPath, ".go")
+	if docFormat == DocMarkdown {
+		return base + ".md"
+	}
+	return base + "_doc.go"
+}
+
+// GenerateDoc generates the documentation of a decorator stack, listed outermost first, in the given format
+// Each decorator is described by the doc blocks of its template, rendered with its options,
+// so the documentation names the real types and constructors
+func (g *Generator) GenerateDoc(
+	interfaceModel *model.Interface,
+	decoratorTypes []DecoratorType,
+	outputPackage string,
+	outputPath string,
+	options map[DecoratorType]Options,
+	docFormat string,
+) error {
+	var tmpl *template.Template
+	switch docFormat {
+	case DocGo:
+		tmpl = g.docGo
+	case DocMarkdown:
+		tmpl = g.docMarkdown
+	default:
+		return fmt.Errorf("unknown documentation format %q: want %q or %q", docFormat, DocGo, DocMarkdown)
+	}
+
+	data := &docData{TemplateData: templateData(interfaceModel, outputPackage, nil, "")}
+	for _, dt := range decoratorTypes {
+		decorator, err := g.docDecorator(dt, interfaceModel, outputPackage, options[dt])
+		if err != nil {
+			return err
+		}
+		data.Decorators = append(data.Decorators, decorator)
+		if dt != FakeDecorator {
+			data.Stack = append(data.Stack, dt)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := execute(tmpl, &buf, data); err != nil {
+		return newTemplateError("documentation", tmpl, interfaceModel, data, data.TemplateData, err)
+	}
+
+	code := buf.Bytes()
+	if docFormat == DocGo {
+		formatted, err := format.Source(code)
+		if err != nil {
+			return fmt.Errorf("failed to format generated documentation: %w", err)
+		}
+		code = formatted
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := writeFile(outputPath, code); err != nil {
+		return fmt.Errorf("failed to write generated documentation: %w", err)
+	}
+
+	return nil
+}
+
+// docDecorator renders the doc blocks of a decorator template
+func (g *Generator) docDecorator(dt DecoratorType, interfaceModel *model.Interface, outputPackage string, options Options) (docDecorator, error) {
+	tmpl, ok := g.templates[dt]
+	if !ok {
+		return docDecorator{}, fmt.Errorf("unknown decorator type: %s", dt)
+	}
+
+	di, err := options.DI()
+	if err != nil {
+		return docDecorator{}, err
+	}
+	data := templateData(interfaceModel, outputPackage, options, di)
+	if err := selectMethods(data, interfaceModel, options); err != nil {
+		return docDecorator{}, err
+	}
+	if err := setStyle(data, dt, interfaceModel, options); err != nil {
+		return docDecorator{}, err
+	}
+
+	decorator := docDecorator{Type: dt, Name: data.Type}
+	for _, block := range []string{docTemplate, docExampleTemplate} {
+		t := tmpl.Lookup(block)
+		if t == nil {
+			return docDecorator{}, fmt.Errorf("%s template has no %s block", dt, block)
+		}
+		var buf bytes.Buffer
+		if err := execute(t, &buf, data); err != nil {
+			return docDecorator{}, newTemplateError(dt, tmpl, interfaceModel, data, data, err)
+		}
+
+		if block == docTemplate {
+			decorator.Doc = docLines(buf.String())
+			continue
+		}
+		example, err := formatStatements(buf.String())
+		if err != nil {
+			return docDecorator{}, fmt.Errorf("invalid %s example: %w", dt, err)
+		}
+		decorator.Example = docLines(example)
+	}
+
+	return decorator, nil
+}
+
+// formatStatements formats Go statements as the body of a function
+func formatStatements(code string) (string, error) {
+	src := "package p\n\nfunc _() {\n" + code + "\n}\n"
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return "", err
+	}
+
+	body := string(formatted)
+	body = body[strings.Index(body, "{\n")+2 : strings.LastIndex(body, "}")]
+
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "\t")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// docLines splits rendered documentation into lines without surrounding blank lines
+func docLines(text string) []string {
+	text = strings.Trim(text, "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return lines
+}
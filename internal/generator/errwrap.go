@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// Error wrapping styles of the "wrapErrors" option
+// Any other value names a function as "import/path.Func", called as Func(err, "Interface.Method")
+// A method named by a //decogen:name annotation is wrapped with that name instead of "Interface.Method"
+const (
+	// WrapNone returns errors unchanged
+	WrapNone = "none"
+	// WrapMethod prefixes errors with the interface and method name using %w
+	WrapMethod = "method"
+)
+
+// wrapFunc returns the import and the call prefix of a custom error wrapping function, if one is configured
+func (o Options) wrapFunc() (Import, string, bool) {
+	style, _ := o["wrapErrors"].(string)
+	if style == "" || style == WrapNone || style == WrapMethod {
+		return Import{}, "", false
+	}
+
+	dot := strings.LastIndex(style, ".")
+	if dot <= 0 || dot == len(style)-1 {
+		return Import{}, "", false
+	}
+
+	imp := Import{Path: style[:dot]}
+	return imp, packageName(imp.Path) + "." + style[dot+1:], true
+}
+
+// wrapError returns the statement wrapping errVar before it is returned from a method, or an empty string
+// Errors are wrapped with the name of the method from Method.Op
+func wrapError(options Options, iface string, m *model.Method, errVar string) (string, error) {
+	op := m.Op(iface)
+
+	var expr string
+	style, _ := options["wrapErrors"].(string)
+	switch style {
+	case "", WrapNone:
+		return "", nil
+	case WrapMethod:
+		expr = fmt.Sprintf("fmt.Errorf(%q, %s)", op+": %w", errVar)
+	default:
+		_, call, ok := options.wrapFunc()
+		if !ok {
+			return "", fmt.Errorf("invalid wrapErrors option %q: want %q, %q or a function such as \"example.com/errs.Wrap\"", style, WrapNone, WrapMethod)
+		}
+		expr = fmt.Sprintf("%s(%s, %q)", call, errVar, op)
+	}
+
+	return fmt.Sprintf("if %s != nil {\n\t%s = %s\n}", errVar, errVar, expr), nil
+}
@@ -0,0 +1,31 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// Fakes are test doubles generated from the same interface model as the decorators
+// They wrap nothing, so they are named Fake<Interface> whatever the style and have no providers
+
+// setFake sets template data of a fake, which implements every method of the interface
+func setFake(data *TemplateData, interfaceModel *model.Interface) error {
+	if data.Partial {
+		return fmt.Errorf("the %s type implements every method of %s, the methods option is not supported", FakeDecorator, interfaceModel.Name)
+	}
+	data.Type = "Fake" + interfaceModel.Name
+	data.DI = ""
+	return nil
+}
+
+// funcType returns the type of a function with the signature of a method, e.g. func(ctx context.Context) error
+func funcType(m *model.Method) string {
+	return "func" + strings.TrimPrefix(m.FormatMethodSignature(), m.Name)
+}
+
+// callArgs returns the parenthesized arguments of a call passing on the method parameters, e.g. (ctx, ids...)
+func callArgs(m *model.Method) string {
+	return strings.TrimPrefix(m.FormatMethodCall(), m.Name)
+}
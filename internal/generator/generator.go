@@ -1,16 +1,29 @@
 package generator
 
 import (
+	"bytes"
+	"embed"
+	"errors"
 	"fmt"
 	"go/format"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/komandakycto/decogen/internal/model"
 )
 
+// templatesFS holds the decorator templates so the generator works from any directory
+//
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
 // DecoratorType represents the type of decorator to generate
 type DecoratorType string
 
@@ -21,26 +34,231 @@ const (
 	CacheDecorator DecoratorType = "cache"
 	// MetricsDecorator generates a metrics decorator
 	MetricsDecorator DecoratorType = "metrics"
+	// DedupeDecorator generates a decorator suppressing duplicate calls
+	DedupeDecorator DecoratorType = "dedupe"
+	// LastGoodDecorator generates a decorator serving the last good result of failed calls
+	LastGoodDecorator DecoratorType = "lastgood"
+	// AsyncDecorator generates a decorator running calls that only return an error on a worker pool
+	AsyncDecorator DecoratorType = "async"
+	// ObservabilityDecorator generates a single decorator reporting calls to metrics, tracing and logging
+	ObservabilityDecorator DecoratorType = "observability"
+	// ContextCheckDecorator generates a development-mode decorator asserting the context of calls carries required values
+	ContextCheckDecorator DecoratorType = "contextcheck"
+	// ReturnsDecorator generates a decorator wrapping returned values of other decoratable types, such as transactions
+	ReturnsDecorator DecoratorType = "returns"
+	// FakeDecorator generates a configurable fake implementation of the interface for tests
+	FakeDecorator DecoratorType = "fake"
+)
+
+// Options holds the settings of a decorator from the configuration file
+// They are available to templates as .Options
+type Options map[string]interface{}
+
+// Dependency injection frameworks generated decorators can be registered with
+const (
+	// DIWire emits a google/wire provider set
+	DIWire = "wire"
+	// DIFx emits an uber/fx decorate option
+	DIFx = "fx"
 )
 
+// DI returns the dependency injection framework selected with the "di" option, if any
+func (o Options) DI() (string, error) {
+	di, _ := o["di"].(string)
+	switch di {
+	case "", DIWire, DIFx:
+		return di, nil
+	default:
+		return "", fmt.Errorf("unknown dependency injection framework: %s", di)
+	}
+}
+
+// templateFuncs are the functions available to templates besides the model methods
+var templateFuncs = template.FuncMap{
+	"cacheCodec":       cacheCodec,
+	"cacheInvalidated": cacheInvalidated,
+	"cacheInvalidates": cacheInvalidations,
+	"cacheKey":         cacheKey,
+	"cacheLoader":      cacheLoaderConfig,
+	"callArgs":         callArgs,
+	"callMeta":         callMeta,
+	"cacheRemote":      cacheRemote,
+	"cacheTTL":         cacheTTL,
+	"funcType":         funcType,
+	"hasMethod":        hasMethod,
+	"keyArgs":          keyArgs,
+	"methodName":       methodName,
+	"observeArgs":      observabilityArgs,
+	"observeSampling":  observabilitySampling,
+	"retryBackoff":     retryBackoff,
+	"retryConfig":      retryConfig,
+	"retryIdempotent":  retryIdempotent,
+	"retryPanics":      retryPanics,
+	"retryPolicy":      retryPolicy,
+	"retrySkipsStream": retrySkipsStream,
+	"returnWrappers":   returnWrappers,
+	"warmCall":         warmCall,
+	"warmParams":       warmParams,
+	"wrapError":        wrapError,
+	"wrappedResults":   wrappedResults,
+}
+
+// parseTemplate loads an embedded template
+// Referencing a key missing from the template data fails instead of rendering "<no value>"
+func parseTemplate(path string) (*template.Template, error) {
+	return template.New(filepath.Base(path)).Option("missingkey=error").Funcs(templateFuncs).ParseFS(templatesFS, path)
+}
+
+// Local returns the import path prefixes set with the "local" option
+// Imports matching them are grouped after third party imports
+func (o Options) Local() []string {
+	local, _ := o["local"].(string)
+
+	var prefixes []string
+	for _, prefix := range strings.Split(local, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
 // Generator handles code generation for decorators
 type Generator struct {
-	templates map[DecoratorType]*template.Template
+	templates   map[DecoratorType]*template.Template
+	raceTest    *template.Template
+	ctxTest     *template.Template
+	methodNames *template.Template
+	docGo       *template.Template
+	docMarkdown *template.Template
+	guard       *template.Template
+	noop        *template.Template
 }
 
+// loadedTemplates parses the embedded templates once per process
+// Parsed templates are safe for concurrent execution, so every Generator shares them
+var loadedTemplates = sync.OnceValues(loadTemplates)
+
 // NewGenerator creates a new generator with loaded templates
 func NewGenerator() (*Generator, error) {
+	loaded, err := loadedTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	g := *loaded
+	return &g, nil
+}
+
+// loadTemplates parses the embedded templates
+func loadTemplates() (*Generator, error) {
 	g := &Generator{
 		templates: make(map[DecoratorType]*template.Template),
 	}
 
 	// Load retry template
-	retryTemplate, err := template.ParseFiles("internal/generator/templates/retry.go.tmpl")
+	retryTemplate, err := parseTemplate("templates/retry.go.tmpl")
 	if err != nil {
 		return nil, fmt.Errorf("failed to load retry template: %w", err)
 	}
 	g.templates[RetryDecorator] = retryTemplate
 
+	// Load dedupe template
+	dedupeTemplate, err := parseTemplate("templates/dedupe.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dedupe template: %w", err)
+	}
+	g.templates[DedupeDecorator] = dedupeTemplate
+
+	// Load cache template
+	cacheTemplate, err := parseTemplate("templates/cache.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cache template: %w", err)
+	}
+	g.templates[CacheDecorator] = cacheTemplate
+
+	// Load last-known-good template
+	lastGoodTemplate, err := parseTemplate("templates/lastgood.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last-known-good template: %w", err)
+	}
+	g.templates[LastGoodDecorator] = lastGoodTemplate
+
+	// Load async template
+	asyncTemplate, err := parseTemplate("templates/async.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load async template: %w", err)
+	}
+	g.templates[AsyncDecorator] = asyncTemplate
+
+	// Load observability template
+	observabilityTemplate, err := parseTemplate("templates/observability.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load observability template: %w", err)
+	}
+	g.templates[ObservabilityDecorator] = observabilityTemplate
+
+	// Load context check template
+	contextCheckTemplate, err := parseTemplate("templates/contextcheck.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load context check template: %w", err)
+	}
+	g.templates[ContextCheckDecorator] = contextCheckTemplate
+
+	// Load returns template
+	returnsTemplate, err := parseTemplate("templates/returns.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load returns template: %w", err)
+	}
+	g.templates[ReturnsDecorator] = returnsTemplate
+
+	// Load fake template
+	fakeTemplate, err := parseTemplate("templates/fake.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fake template: %w", err)
+	}
+	g.templates[FakeDecorator] = fakeTemplate
+
+	// Load the concurrency test template
+	g.raceTest, err = parseTemplate("templates/racetest.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load race test template: %w", err)
+	}
+
+	// Load the cancellation test template
+	g.ctxTest, err = parseTemplate("templates/ctxtest.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load context test template: %w", err)
+	}
+
+	// Load the method names template
+	g.methodNames, err = parseTemplate("templates/methods.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load method names template: %w", err)
+	}
+
+	// Load the documentation templates
+	g.docGo, err = parseTemplate("templates/doc.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load documentation template: %w", err)
+	}
+	g.docMarkdown, err = parseTemplate("templates/doc.md.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load markdown documentation template: %w", err)
+	}
+
+	// Load the implementation guard template
+	g.guard, err = parseTemplate("templates/guard.go.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load guard template: %w", err)
+	}
+
+	// Load the no-op variant template
+	g.noop, err = parseTemplate(noopTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load no-op variant template: %w", err)
+	}
+
 	// Load other templates as needed
 	// ...
 
@@ -48,12 +266,19 @@ func NewGenerator() (*Generator, error) {
 }
 
 // Generate generates code for the specified interface and decorators
+// Each decorator needs a file of its own, so a stack of several decorators is generated with one call
+// per decorator, to the paths returned by StackOutputs
 func (g *Generator) Generate(
 	interfaceModel *model.Interface,
 	decoratorTypes []DecoratorType,
 	outputPackage string,
 	outputPath string,
+	options map[DecoratorType]Options,
 ) error {
+	if len(decoratorTypes) > 1 {
+		return fmt.Errorf("%d decorators would overwrite each other in %s, generate each to its own file", len(decoratorTypes), outputPath)
+	}
+
 	// Ensure output directory exists
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
@@ -66,39 +291,192 @@ func (g *Generator) Generate(
 			return fmt.Errorf("unknown decorator type: %s", dt)
 		}
 
-		// Prepare template data
-		data := map[string]interface{}{
-			"PackageName": outputPackage,
-			"Name":        interfaceModel.Name,
-			"Methods":     interfaceModel.Methods,
-			"Imports":     interfaceModel.Imports,
-			"Comments":    interfaceModel.Comments,
-		}
-
-		// Create a buffer for the generated code
-		var buf strings.Builder
-
-		// Execute the template
-		if err := tmpl.Execute(&buf, data); err != nil {
-			return fmt.Errorf("failed to execute template: %w", err)
-		}
-
-		// Format the generated code
-		formattedCode, err := format.Source([]byte(buf.String()))
+		// Render the decorator
+		code, err := g.render(dt, tmpl, interfaceModel, outputPackage, options[dt])
 		if err != nil {
-			// If formatting fails, still write the unformatted code
-			// so we can diagnose the issue
-			if err := os.WriteFile(outputPath, []byte(buf.String()), 0644); err != nil {
+			var formatErr *formatError
+			if !errors.As(err, &formatErr) {
+				return err
+			}
+			// If formatting fails, write the unformatted code next to the output
+			// so we can diagnose the issue without breaking the build
+			if err := writeFile(outputPath+unformattedSuffix, formatErr.code); err != nil {
 				return fmt.Errorf("failed to write unformatted code: %w", err)
 			}
-			return fmt.Errorf("failed to format generated code: %w", err)
+			return fmt.Errorf("%w (unformatted code written to %s)", err, outputPath+unformattedSuffix)
 		}
 
 		// Write the formatted code to the output file
-		if err := os.WriteFile(outputPath, formattedCode, 0644); err != nil {
+		if err := writeFile(outputPath, code); err != nil {
 			return fmt.Errorf("failed to write generated code: %w", err)
 		}
+
+		// Drop code kept from an earlier failed run
+		if err := os.Remove(outputPath + unformattedSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove unformatted code: %w", err)
+		}
+
+		// Builds excluded by the build constraint of the decorator get its no-op variant
+		if err := g.generateVariant(dt, interfaceModel, outputPackage, outputPath, options[dt]); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// StackOutputs returns the file of each decorator of a stack generated to output
+// A single decorator is written to output; several are written next to it, suffixed with their name,
+// e.g. storage_retry.go and storage_cache.go for storage.go
+func StackOutputs(output string, decoratorTypes []DecoratorType) []string {
+	if len(decoratorTypes) == 1 {
+		return []string{output}
+	}
+
+	base := strings.TrimSuffix(output, ".go")
+	outputs := make([]string, len(decoratorTypes))
+	for i, dt := range decoratorTypes {
+		outputs[i] = fmt.Sprintf("%s_%s.go", base, dt)
+	}
+	return outputs
+}
+
+// formatError is returned by render when the generated code is not valid Go
+type formatError struct {
+	code []byte
+	err  error
+}
+
+// Error returns the error message
+func (e *formatError) Error() string {
+	return fmt.Sprintf("failed to format generated code: %v", e.err)
+}
+
+// Unwrap returns the gofmt error
+func (e *formatError) Unwrap() error {
+	return e.err
+}
+
+// render executes the template of a decorator and returns the formatted code
+func (g *Generator) render(
+	dt DecoratorType,
+	tmpl *template.Template,
+	interfaceModel *model.Interface,
+	outputPackage string,
+	options Options,
+) ([]byte, error) {
+	di, err := options.DI()
+	if err != nil {
+		return nil, err
+	}
+	buildTag, err := options.BuildTag()
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare template data
+	data := templateData(interfaceModel, outputPackage, options, di)
+	if err := selectMethods(data, interfaceModel, options); err != nil {
+		return nil, err
+	}
+	if err := setStyle(data, dt, interfaceModel, options); err != nil {
+		return nil, err
+	}
+
+	// Resolve the imports of the generated file
+	importSpecs, err := resolveImports(tmpl, data, interfaceModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve imports: %w", err)
+	}
+	if imp, _, ok := options.wrapFunc(); ok {
+		importSpecs = mergeImports(importSpecs, []Import{imp})
+	}
+	data.ImportSpecs = groupImports(importSpecs, options.Local())
+
+	// Execute the template into a pooled buffer
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := execute(tmpl, buf, data); err != nil {
+		return nil, newTemplateError(dt, tmpl, interfaceModel, data, data, err)
+	}
+
+	// Drop imports only needed by branches that were not rendered
+	code := buf.Bytes()
+	if pruned, err := pruneImports(code); err == nil {
+		code = pruned
+	}
+	if args := options.Args(); args != "" {
+		code = addArgsHeader(code, args)
+	}
+	if buildTag != nil {
+		code = addBuildConstraint(code, buildTag)
+	}
+
+	// Format the generated code
+	formattedCode, err := format.Source(code)
+	if err != nil {
+		return nil, &formatError{code: bytes.Clone(buf.Bytes()), err: err}
+	}
+
+	if options.MinimalDeps() {
+		application := slices.Collect(maps.Values(interfaceModel.Imports))
+		if imp, _, ok := options.wrapFunc(); ok {
+			application = append(application, imp.Path)
+		}
+		if err := checkMinimalImports(dt, formattedCode, application); err != nil {
+			return nil, err
+		}
+	}
+
+	return formattedCode, nil
+}
+
+// Render writes the formatted code of a single decorator to w
+// Unlike Generate, nothing is written when the template fails or the code is not valid Go
+func (g *Generator) Render(
+	w io.Writer,
+	interfaceModel *model.Interface,
+	decoratorType DecoratorType,
+	outputPackage string,
+	options Options,
+) error {
+	tmpl, ok := g.templates[decoratorType]
+	if !ok {
+		return fmt.Errorf("unknown decorator type: %s", decoratorType)
+	}
+
+	code, err := g.render(decoratorType, tmpl, interfaceModel, outputPackage, options)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(code); err != nil {
+		return fmt.Errorf("failed to write generated code: %w", err)
+	}
+	return nil
+}
+
+// maxPooledBuffer is the capacity above which buffers are not returned to the pool
+// so that one huge interface does not pin its memory for the rest of the run
+const maxPooledBuffer = 1 << 20
+
+// bufferPool holds the buffers templates are executed into
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns a buffer to the pool
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,1171 @@
+package generator_test
+
+import (
+	"bytes"
+	"fmt"
+	goparser "go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/internal/config"
+	"github.com/komandakycto/decogen/internal/generator"
+	"github.com/komandakycto/decogen/internal/model"
+	"github.com/komandakycto/decogen/internal/parser"
+	"github.com/komandakycto/decogen/pkg/decogentest"
+	"github.com/komandakycto/decogen/pkg/sourcehash"
+)
+
+func TestTemplates(t *testing.T) {
+	tests := []decogentest.Case{
+		{
+			Decorators: []string{"retry"},
+			Golden:     "testdata/storage_retry.golden",
+		},
+		{
+			Decorators: []string{"retry"},
+			Options: map[string]map[string]interface{}{
+				"retry": {"policies": map[string]interface{}{"Get": "reads", "Search": "reads", "Save": "writes"}},
+			},
+			Golden: "testdata/storage_retry_policies.golden",
+		},
+		{
+			Decorators: []string{"retry"},
+			DI:         "fx",
+			Golden:     "testdata/storage_retry_fx.golden",
+		},
+		{
+			Decorators: []string{"dedupe"},
+			DI:         "wire",
+			Golden:     "testdata/storage_dedupe_wire.golden",
+		},
+		{
+			Decorators: []string{"contextcheck"},
+			Golden:     "testdata/storage_contextcheck.golden",
+		},
+		{
+			Decorators: []string{"retry", "cache"},
+			Options: map[string]map[string]interface{}{
+				"retry": {"minimalDeps": true},
+				"cache": {"minimalDeps": true},
+			},
+			Golden: "testdata/storage_minimal.golden",
+		},
+		{
+			Decorators: []string{"observability"},
+			Options: map[string]map[string]interface{}{
+				"observability": {"buildTag": "otel"},
+			},
+			Golden: "testdata/storage_observability_otel.golden",
+		},
+		{
+			Decorators: []string{"observability"},
+			Options: map[string]map[string]interface{}{
+				"observability": {"buildTag": "otel && !tiny", "style": generator.StyleFunctional},
+			},
+			Golden: "testdata/storage_observability_otel_functional.golden",
+		},
+		{
+			Decorators: []string{"cache"},
+			Options: map[string]map[string]interface{}{
+				"cache": {"keys": map[string]interface{}{"Get": "user:{{.id}}"}},
+			},
+			Golden: "testdata/storage_cache.golden",
+		},
+		{
+			Decorators: []string{"retry"},
+			Options: map[string]map[string]interface{}{
+				"retry": {"local": "github.com/komandakycto"},
+			},
+			DI:     "wire",
+			Golden: "testdata/storage_retry_wire_local.golden",
+		},
+		{
+			Decorators: []string{"retry"},
+			Options: map[string]map[string]interface{}{
+				"retry": {"wrapErrors": generator.WrapMethod},
+			},
+			Golden: "testdata/storage_retry_wrap.golden",
+		},
+		{
+			Decorators: []string{"dedupe"},
+			Options: map[string]map[string]interface{}{
+				"dedupe": {"wrapErrors": "example.com/errs.Wrap"},
+			},
+			Golden: "testdata/storage_dedupe_wrap.golden",
+		},
+		{
+			Decorators: []string{"retry"},
+			Options: map[string]map[string]interface{}{
+				"retry": {"callmeta": true},
+			},
+			Golden: "testdata/storage_retry_callmeta.golden",
+		},
+		{
+			Decorators: []string{"dedupe"},
+			Options: map[string]map[string]interface{}{
+				"dedupe": {"callmeta": true, "methodNames": generator.MethodNamesTyped},
+			},
+			Golden: "testdata/storage_dedupe_method_names.golden",
+		},
+		{
+			Decorators: []string{"retry"},
+			Options: map[string]map[string]interface{}{
+				"retry": {"methods": []interface{}{"Get", "Search"}},
+			},
+			Golden: "testdata/storage_retry_partial.golden",
+		},
+		{
+			Decorators: []string{"cache"},
+			Options: map[string]map[string]interface{}{
+				"cache": {"methods": []interface{}{"Get"}},
+			},
+			Golden: "testdata/storage_cache_partial.golden",
+		},
+		{
+			Decorators: []string{"cache"},
+			Options: map[string]map[string]interface{}{
+				"cache": {"codec": generator.CodecGob, "codecs": map[string]interface{}{"Search": generator.CodecJSON}},
+			},
+			Golden: "testdata/storage_cache_codecs.golden",
+		},
+		{
+			Decorators: []string{"retry"},
+			Options: map[string]map[string]interface{}{
+				"retry": {"style": generator.StyleFunctional},
+			},
+			Golden: "testdata/storage_retry_functional.golden",
+		},
+		{
+			Decorators: []string{"dedupe"},
+			Options: map[string]map[string]interface{}{
+				"dedupe": {"style": generator.StyleFunctional},
+			},
+			Golden: "testdata/storage_dedupe_functional.golden",
+		},
+		{
+			Decorators: []string{"cache"},
+			Options: map[string]map[string]interface{}{
+				"cache": {"style": generator.StyleFunctional, "methods": []interface{}{"Get"}},
+			},
+			Golden: "testdata/storage_cache_functional_partial.golden",
+		},
+		{
+			Decorators: []string{"lastgood"},
+			Options: map[string]map[string]interface{}{
+				"lastgood": {"callmeta": true},
+			},
+			Golden: "testdata/storage_lastgood.golden",
+		},
+		{
+			Decorators: []string{"async"},
+			Options: map[string]map[string]interface{}{
+				"async": {"callmeta": true, "wrapErrors": "method"},
+			},
+			Golden: "testdata/storage_async.golden",
+		},
+		{
+			Decorators: []string{"observability"},
+			Options: map[string]map[string]interface{}{
+				"observability": {"callmeta": true},
+			},
+			Golden: "testdata/storage_observability.golden",
+		},
+		{
+			Decorators: []string{"fake"},
+			Golden:     "testdata/storage_fake.golden",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Golden, func(t *testing.T) {
+			tc.Source = "testdata/storage.go"
+			tc.Interface = "UserStorage"
+			decogentest.Run(t, tc)
+		})
+	}
+}
+
+func TestImports(t *testing.T) {
+	t.Run("interface imports", func(t *testing.T) {
+		decogentest.Run(t, decogentest.Case{
+			Source:     "testdata/clock.go",
+			Interface:  "Clock",
+			Decorators: []string{"retry"},
+			Golden:     "testdata/clock_retry.golden",
+		})
+	})
+
+	t.Run("unused template imports", func(t *testing.T) {
+		decogentest.Run(t, decogentest.Case{
+			Source:     "testdata/clock.go",
+			Interface:  "Names",
+			Decorators: []string{"retry"},
+			Golden:     "testdata/names_retry.golden",
+		})
+	})
+}
+
+func TestResultShapes(t *testing.T) {
+	for _, dt := range []string{"retry", "dedupe", "cache", "lastgood", "async", "observability", "contextcheck", "fake"} {
+		t.Run(dt, func(t *testing.T) {
+			decogentest.Run(t, decogentest.Case{
+				Source:     "testdata/shapes.go",
+				Interface:  "Shapes",
+				Decorators: []string{dt},
+				Golden:     "testdata/shapes_" + dt + ".golden",
+			})
+		})
+	}
+}
+
+func TestStreams(t *testing.T) {
+	for _, streams := range []string{generator.StreamsSkip, generator.StreamsOpen} {
+		t.Run(streams, func(t *testing.T) {
+			decogentest.Run(t, decogentest.Case{
+				Source:     "testdata/streams.go",
+				Interface:  "Events",
+				Decorators: []string{"retry"},
+				Options:    map[string]map[string]interface{}{"retry": {"streams": streams}},
+				Golden:     "testdata/streams_retry_" + streams + ".golden",
+			})
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	for _, dt := range []string{"retry", "cache", "observability"} {
+		t.Run(dt, func(t *testing.T) {
+			decogentest.Run(t, decogentest.Case{
+				Source:     "testdata/annotated.go",
+				Interface:  "Profiles",
+				Decorators: []string{dt},
+				Golden:     "testdata/annotated_" + dt + ".golden",
+			})
+		})
+	}
+
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	invalid := []struct {
+		decorator   generator.DecoratorType
+		annotations map[string]map[string]string
+		message     string
+	}{
+		{generator.RetryDecorator, map[string]map[string]string{"retry": {"attempts": "5"}}, "unknown parameters attempts"},
+		{generator.RetryDecorator, map[string]map[string]string{"retry": {"max_attempts": "0"}}, "max_attempts must be a positive integer"},
+		{generator.RetryDecorator, map[string]map[string]string{"retry": {"idempotent": "sometimes"}}, "idempotent must be true or false"},
+		{generator.RetryDecorator, map[string]map[string]string{"retry": {"panics": "true"}}, "panics applies to methods without an error result"},
+		{generator.RetryDecorator, map[string]map[string]string{"retry": {"backoff": "exp(fast)"}}, "invalid exponential backoff"},
+		{generator.CacheDecorator, map[string]map[string]string{"cache": {"ttl": "-1s"}}, "duration must be positive"},
+		{generator.CacheDecorator, map[string]map[string]string{"cache": {"negative_ttl": "soon"}}, "negative_ttl: time: invalid duration"},
+		{generator.CacheDecorator, map[string]map[string]string{"cache": {"codec": "msgpack"}}, `unknown codec "msgpack"`},
+		{generator.ObservabilityDecorator, map[string]map[string]string{"observability": {"log_successes": "0"}}, "log_successes must be a positive integer"},
+		{generator.ObservabilityDecorator, map[string]map[string]string{"observability": {"trace_ratio": "2"}}, "trace_ratio must be between 0 and 1"},
+		{generator.ObservabilityDecorator, map[string]map[string]string{"observability": {"redact": "password"}}, `redact: unknown parameter "password"`},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.message, func(t *testing.T) {
+			iface := generator.LintInterface()
+			iface.Methods[0].Annotations = tc.annotations
+
+			err := gen.Render(io.Discard, iface, tc.decorator, "lint", nil)
+			var templateErr *generator.TemplateError
+			require.ErrorAs(t, err, &templateErr)
+			require.Equal(t, "Get", templateErr.Method)
+			require.ErrorContains(t, err, tc.message)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping go build in short mode")
+	}
+
+	// The check runs in a standalone module without dependencies
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/verify\n\ngo 1.24\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "storage.go"), []byte(`package storage
+
+type Storage interface {
+	Get(id string) (string, error)
+}
+`), 0644))
+
+	generated := filepath.Join(dir, "storage_retry.go")
+	origins := map[string]generator.Origin{
+		generated: {Decorator: generator.RetryDecorator, Interface: "Storage", Source: "storage.go"},
+	}
+
+	t.Run("valid code", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(generated, []byte(`package storage
+
+type StorageWithRetry struct {
+	underlying Storage
+}
+
+func (r *StorageWithRetry) Get(id string) (string, error) {
+	return r.underlying.Get(id)
+}
+`), 0644))
+
+		require.NoError(t, generator.Verify(dir, origins))
+	})
+
+	t.Run("diagnostics are mapped to their origin", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(generated, []byte(`package storage
+
+type StorageWithRetry struct {
+	underlying Storage
+}
+
+func (r *StorageWithRetry) Get(id string) (string, error) {
+	return r.underlying.Get(undefinedID)
+}
+`), 0644))
+
+		err := generator.Verify(dir, origins)
+		var verifyErr *generator.VerifyError
+		require.ErrorAs(t, err, &verifyErr)
+		require.Len(t, verifyErr.Diagnostics, 1)
+
+		d := verifyErr.Diagnostics[0]
+		require.Equal(t, 8, d.Line)
+		require.Equal(t, "Get", d.Method)
+		require.Equal(t, generator.RetryDecorator, d.Origin.Decorator)
+		require.Contains(t, d.Message, "undefinedID")
+		require.Contains(t, err.Error(), "retry template for Storage.Get declared in storage.go")
+	})
+
+	t.Run("errors of other files are not reported", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(generated, []byte(`package storage
+
+type StorageWithRetry struct {
+	underlying Storage
+}
+
+func (r *StorageWithRetry) Get(id string) (string, error) {
+	return r.underlying.Get(id)
+}
+`), 0644))
+		handwritten := filepath.Join(dir, "broken.go")
+		require.NoError(t, os.WriteFile(handwritten, []byte("package storage\n\nvar broken int = \"broken\"\n"), 0644))
+		defer os.Remove(handwritten)
+
+		require.NoError(t, generator.Verify(dir, origins))
+	})
+}
+
+func TestCheckOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		decorators []generator.DecoratorType
+		severities []generator.Severity
+	}{
+		{
+			name:       "valid stack",
+			decorators: []generator.DecoratorType{generator.MetricsDecorator, generator.DedupeDecorator, generator.RetryDecorator, generator.CacheDecorator},
+		},
+		{
+			name:       "retry outside dedupe",
+			decorators: []generator.DecoratorType{generator.RetryDecorator, generator.DedupeDecorator},
+			severities: []generator.Severity{generator.SeverityError},
+		},
+		{
+			name:       "retry outside lastgood",
+			decorators: []generator.DecoratorType{generator.RetryDecorator, generator.LastGoodDecorator},
+			severities: []generator.Severity{generator.SeverityWarning},
+		},
+		{
+			name:       "retry outside async",
+			decorators: []generator.DecoratorType{generator.RetryDecorator, generator.AsyncDecorator},
+			severities: []generator.Severity{generator.SeverityWarning},
+		},
+		{
+			name:       "observability inside retry",
+			decorators: []generator.DecoratorType{generator.RetryDecorator, generator.ObservabilityDecorator},
+			severities: []generator.Severity{generator.SeverityWarning},
+		},
+		{
+			name:       "metrics and cache misplaced",
+			decorators: []generator.DecoratorType{generator.CacheDecorator, generator.MetricsDecorator},
+			severities: []generator.Severity{generator.SeverityWarning, generator.SeverityWarning},
+		},
+		{
+			name:       "fake alongside the stack",
+			decorators: []generator.DecoratorType{generator.MetricsDecorator, generator.CacheDecorator, generator.FakeDecorator},
+		},
+		{
+			name:       "duplicate decorator",
+			decorators: []generator.DecoratorType{generator.RetryDecorator, generator.RetryDecorator},
+			severities: []generator.Severity{generator.SeverityError},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := generator.CheckOrder(tt.decorators)
+
+			var severities []generator.Severity
+			for _, issue := range issues {
+				severities = append(severities, issue.Severity)
+			}
+			require.Equal(t, tt.severities, severities)
+
+			hasError := false
+			for _, s := range tt.severities {
+				hasError = hasError || s == generator.SeverityError
+			}
+			if hasError {
+				require.Error(t, generator.OrderError(issues))
+			} else {
+				require.NoError(t, generator.OrderError(issues))
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+	src, err := os.ReadFile("testdata/storage.go")
+	require.NoError(t, err)
+	profiles, err := config.Profiles()
+	require.NoError(t, err)
+	require.NotEmpty(t, profiles)
+
+	for _, profile := range profiles {
+		t.Run(profile.Name, func(t *testing.T) {
+			require.NotEmpty(t, profile.Description)
+
+			cfg := &config.Config{}
+			require.NoError(t, cfg.ApplyProfile(profile.Name))
+			types, err := cfg.GetDecoratorTypes()
+			require.NoError(t, err)
+			require.NotEmpty(t, types)
+			require.Empty(t, generator.CheckOrder(types))
+			options, err := cfg.GetDecoratorOptions()
+			require.NoError(t, err)
+
+			// Every decorator of the stack is generated to a file of its own
+			dir := t.TempDir()
+			source := filepath.Join(dir, "storage.go")
+			require.NoError(t, os.WriteFile(source, src, 0644))
+			iface, err := parser.ParseInterface(source, "UserStorage")
+			require.NoError(t, err)
+			outputs := generator.StackOutputs(filepath.Join(dir, "user_storage.go"), types)
+			for i, dt := range types {
+				require.NoError(t, gen.Generate(iface, []generator.DecoratorType{dt}, iface.PackageName, outputs[i], options))
+			}
+			coverage, err := gen.Coverage(dir)
+			require.NoError(t, err)
+			require.Len(t, coverage, 1)
+			require.ElementsMatch(t, types, coverage[0].Decorators)
+		})
+	}
+
+	_, err = config.LoadProfile("unknown")
+	require.ErrorContains(t, err, "repository")
+}
+
+func TestStackOutputs(t *testing.T) {
+	single := []generator.DecoratorType{generator.RetryDecorator}
+	require.Equal(t, []string{"storage.go"}, generator.StackOutputs("storage.go", single))
+
+	stack := []generator.DecoratorType{generator.ObservabilityDecorator, generator.RetryDecorator}
+	require.Equal(t, []string{"storage_observability.go", "storage_retry.go"}, generator.StackOutputs("storage.go", stack))
+
+	// Several decorators in one file would overwrite each other
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+	output := filepath.Join(t.TempDir(), "storage.go")
+	require.ErrorContains(t, gen.Generate(iface, stack, "storage", output, nil), "overwrite")
+	require.NoFileExists(t, output)
+}
+
+func TestLint(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	require.NoError(t, gen.Lint())
+}
+
+func TestTemplateError(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface := generator.LintInterface()
+	output := filepath.Join(t.TempDir(), "linted_retry.go")
+	options := map[generator.DecoratorType]generator.Options{
+		generator.RetryDecorator: {"policies": "reads"},
+	}
+
+	err = gen.Generate(iface, []generator.DecoratorType{generator.RetryDecorator}, "lint", output, options)
+	var templateErr *generator.TemplateError
+	require.ErrorAs(t, err, &templateErr)
+	require.Equal(t, generator.RetryDecorator, templateErr.Decorator)
+	require.Equal(t, "retry.go.tmpl", templateErr.Template)
+	require.Positive(t, templateErr.Line)
+	require.Equal(t, iface.Name, templateErr.Interface)
+	require.Equal(t, iface.Methods[0].Name, templateErr.Method)
+	require.Contains(t, err.Error(), "Get(ctx context.Context, id string) (*Item, error)")
+}
+
+func TestTemplateDataContract(t *testing.T) {
+	// Fields of a TemplateDataVersion may be added but never renamed or removed
+	v1 := []string{
+		"Version", "PackageName", "Name", "Type", "Methods", "Partial", "Functional", "Imports",
+		"ImportSpecs", "Comments", "Source", "SourceHash", "Options", "DI",
+	}
+
+	fields := reflect.VisibleFields(reflect.TypeOf(generator.TemplateData{}))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	require.Subset(t, names, v1)
+	require.Equal(t, 1, generator.TemplateDataVersion)
+}
+
+func TestCacheKeys(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface := generator.LintInterface()
+	generate := func(keys map[string]interface{}) (string, error) {
+		output := filepath.Join(t.TempDir(), "linted_cache.go")
+		options := map[generator.DecoratorType]generator.Options{
+			generator.CacheDecorator: {"keys": keys},
+		}
+		if err := gen.Generate(iface, []generator.DecoratorType{generator.CacheDecorator}, "lint", output, options); err != nil {
+			return "", err
+		}
+		code, err := os.ReadFile(output)
+		return string(code), err
+	}
+
+	t.Run("automatic keys skip the context", func(t *testing.T) {
+		code, err := generate(nil)
+		require.NoError(t, err)
+		require.Contains(t, code, `cache.Key("Get", id)`)
+	})
+
+	t.Run("key templates", func(t *testing.T) {
+		code, err := generate(map[string]interface{}{
+			"Get":  "item:{{.id}}",
+			"List": "static",
+		})
+		require.NoError(t, err)
+		require.Contains(t, code, `fmt.Sprintf("item:%v", id)`)
+	})
+
+	t.Run("unknown parameter", func(t *testing.T) {
+		_, err := generate(map[string]interface{}{"Get": "item:{{.key}}"})
+		var templateErr *generator.TemplateError
+		require.ErrorAs(t, err, &templateErr)
+		require.Equal(t, "Get", templateErr.Method)
+		require.ErrorContains(t, err, "key is not a parameter")
+	})
+
+	t.Run("unsupported action", func(t *testing.T) {
+		_, err := generate(map[string]interface{}{"Get": `{{"item"}}`})
+		require.ErrorContains(t, err, "unsupported action")
+	})
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	// Wait is the only method of the lint interface without cached results
+	render := func(invalidates string) (string, error) {
+		var buf strings.Builder
+		options := generator.Options{
+			"keys":        map[string]interface{}{"Get": "item:{{.id}}"},
+			"invalidates": map[string]interface{}{"Wait": invalidates},
+		}
+		err := gen.Render(&buf, generator.LintInterface(), generator.CacheDecorator, "lint", options)
+		return buf.String(), err
+	}
+
+	t.Run("invalidated keys match the cached keys", func(t *testing.T) {
+		code, err := render(`Get("item"); List(0, 10)`)
+		require.NoError(t, err)
+		require.Contains(t, code, `c.loaders.Get.Invalidate(ctx, c.cacheKeyGet("item"))`)
+		require.Contains(t, code, `c.loaders.List.Invalidate(ctx, c.cacheKeyList(0, 10))`)
+		require.Contains(t, code, `return fmt.Sprintf("item:%v", id)`)
+	})
+
+	invalid := map[string]string{
+		"Wait(d)":     "Wait is not a cached method",
+		"Get(d, d)":   "Get takes 1 arguments besides the context, got 2",
+		"Get(id)":     "id is not a parameter",
+		"Get(f(d))":   "unsupported argument",
+		"Get(d) junk": "invalid call",
+	}
+	for invalidates, message := range invalid {
+		t.Run(message, func(t *testing.T) {
+			_, err := render(invalidates)
+			var templateErr *generator.TemplateError
+			require.ErrorAs(t, err, &templateErr)
+			require.Equal(t, "Wait", templateErr.Method)
+			require.ErrorContains(t, err, message)
+		})
+	}
+}
+
+func TestWrapErrors(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface := generator.LintInterface()
+	for _, style := range []string{"", generator.WrapNone, generator.WrapMethod, "example.com/errs.Wrap"} {
+		t.Run("valid "+style, func(t *testing.T) {
+			for _, dt := range []generator.DecoratorType{generator.RetryDecorator, generator.DedupeDecorator} {
+				require.NoError(t, gen.Render(io.Discard, iface, dt, "lint", generator.Options{"wrapErrors": style}))
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		err := gen.Render(io.Discard, iface, generator.RetryDecorator, "lint", generator.Options{"wrapErrors": "wrap"})
+		require.ErrorContains(t, err, `invalid wrapErrors option "wrap"`)
+	})
+}
+
+func TestReturns(t *testing.T) {
+	decogentest.Run(t, decogentest.Case{
+		Source:     "testdata/tx.go",
+		Interface:  "DB",
+		Decorators: []string{"returns"},
+		Options:    map[string]map[string]interface{}{"returns": {"wrap": []interface{}{"Transaction", "Hook"}}},
+		Golden:     "testdata/tx_returns.golden",
+	})
+
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+	iface, err := parser.ParseInterface("testdata/tx.go", "DB")
+	require.NoError(t, err)
+
+	for options, want := range map[string]string{
+		"":                           "needs the wrap option",
+		"Conn":                       "no method returns Conn",
+		"Transaction,tx.Transaction": "would share the wrapper Transaction",
+		"[]Transaction":              "declare a named type for it",
+	} {
+		wrap := strings.Split(options, ",")
+		if options == "" {
+			wrap = nil
+		}
+		err := gen.Render(io.Discard, iface, generator.ReturnsDecorator, "tx", generator.Options{"wrap": wrap})
+		require.ErrorContains(t, err, want, options)
+	}
+}
+
+func TestMethodAlias(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/annotated.go", "Profiles")
+	require.NoError(t, err)
+
+	for _, dt := range []generator.DecoratorType{generator.RetryDecorator, generator.DedupeDecorator} {
+		t.Run(string(dt), func(t *testing.T) {
+			var buf strings.Builder
+			require.NoError(t, gen.Render(&buf, iface, dt, "annotated", generator.Options{"callmeta": true, "wrapErrors": generator.WrapMethod}))
+
+			code := buf.String()
+			require.Contains(t, code, `ctx = callmeta.WithName(ctx, "Profiles", "Count", "profiles.count", "`+string(dt)+`")`)
+			require.Contains(t, code, `fmt.Errorf("profiles.count: %w", err)`)
+			require.Contains(t, code, `ctx = callmeta.With(ctx, "Profiles", "Get", "`+string(dt)+`")`)
+			require.Contains(t, code, `fmt.Errorf("Profiles.Get: %w", err)`)
+		})
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface := generator.LintInterface()
+	iface.Methods = append(iface.Methods, &model.Method{
+		Name:    "Unwrap",
+		Results: []*model.Parameter{{Name: "result0", Type: "error"}},
+	})
+
+	// The interface method is decorated instead of clashing with the generated helper
+	for _, dt := range []generator.DecoratorType{generator.RetryDecorator, generator.DedupeDecorator, generator.CacheDecorator, generator.LastGoodDecorator, generator.AsyncDecorator, generator.ObservabilityDecorator, generator.ContextCheckDecorator, generator.ReturnsDecorator} {
+		var code strings.Builder
+		require.NoError(t, gen.Render(&code, iface, dt, "lint", generator.Options{"wrap": []string{"*Item"}}))
+		require.Equal(t, 1, strings.Count(code.String(), ") Unwrap() "), dt)
+		require.NotContains(t, code.String(), "Unwrap() Linted", dt)
+	}
+}
+
+func TestMinimalDeps(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+	minimal := generator.Options{"minimalDeps": true}
+
+	for _, dt := range []generator.DecoratorType{generator.RetryDecorator, generator.DedupeDecorator, generator.CacheDecorator, generator.LastGoodDecorator, generator.AsyncDecorator, generator.FakeDecorator} {
+		var code strings.Builder
+		require.NoError(t, gen.Render(&code, iface, dt, "storage", minimal), dt)
+		require.NotContains(t, code.String(), "decorators/defaults", dt)
+	}
+
+	// Decorators whose runtime or providers need third party packages are rejected
+	require.ErrorContains(t, gen.Render(io.Discard, iface, generator.ObservabilityDecorator, "storage", minimal), "the observability decorator cannot be generated with minimalDeps")
+	require.ErrorContains(t, gen.Render(io.Discard, iface, generator.RetryDecorator, "storage", generator.Options{"minimalDeps": true, "di": "wire"}), "imports github.com/google/wire")
+}
+
+// TestMinimalRuntimes tests that the runtimes allowed with minimalDeps only import the standard library and each other
+func TestMinimalRuntimes(t *testing.T) {
+	allowed := generator.MinimalRuntimes()
+	for _, runtime := range allowed {
+		dir := filepath.Join("..", "..", strings.TrimPrefix(runtime, "github.com/komandakycto/decogen/"))
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		require.NoError(t, err)
+		require.NotEmpty(t, files, runtime)
+
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			f, err := goparser.ParseFile(token.NewFileSet(), file, nil, goparser.ImportsOnly)
+			require.NoError(t, err)
+			for _, spec := range f.Imports {
+				path, err := strconv.Unquote(spec.Path.Value)
+				require.NoError(t, err)
+				std := !strings.Contains(strings.Split(path, "/")[0], ".")
+				require.True(t, std || slices.Contains(allowed, path), "%s imports %s", file, path)
+			}
+		}
+	}
+}
+
+// TestGeneratorDependencies tests that the generator imports no third party packages, even through runtime packages
+// Services then get the same dependencies from this module as from a module holding only the runtime packages
+func TestGeneratorDependencies(t *testing.T) {
+	for _, root := range []string{filepath.Join("..", "..", "cmd"), filepath.Join("..", "..", "internal")} {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") || strings.Contains(path, "testdata") {
+				return err
+			}
+
+			f, err := goparser.ParseFile(token.NewFileSet(), path, nil, goparser.ImportsOnly)
+			if err != nil {
+				return err
+			}
+			for _, spec := range f.Imports {
+				imp, err := strconv.Unquote(spec.Path.Value)
+				if err != nil {
+					return err
+				}
+				std := !strings.Contains(strings.Split(imp, "/")[0], ".")
+				own := strings.HasPrefix(imp, "github.com/komandakycto/decogen/internal/") || slices.Contains(generator.MinimalRuntimes(), imp)
+				require.True(t, std || own, "%s imports %s", path, imp)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "storage.go")
+	require.NoError(t, os.WriteFile(source, []byte("package storage\n\ntype Storage interface {\n\tGet(id string) (string, error)\n}\n\ntype Clock interface {\n\tNow() int64\n}\n"), 0644))
+
+	iface, err := parser.ParseInterface(source, "Storage")
+	require.NoError(t, err)
+	require.NoError(t, gen.Generate(iface, []generator.DecoratorType{generator.RetryDecorator}, "storage", filepath.Join(dir, "storage_retry.go"), nil))
+	options := map[generator.DecoratorType]generator.Options{generator.CacheDecorator: {"style": generator.StyleFunctional}}
+	require.NoError(t, gen.Generate(iface, []generator.DecoratorType{generator.CacheDecorator}, "storage", filepath.Join(dir, "storage_cache.go"), options))
+
+	coverage, err := gen.Coverage(dir)
+	require.NoError(t, err)
+	require.Equal(t, []generator.Coverage{
+		{Interface: "Storage", Source: source, Decorators: []generator.DecoratorType{generator.CacheDecorator, generator.RetryDecorator}},
+		{Interface: "Clock", Source: source},
+	}, coverage)
+}
+
+func TestBuildTagVariant(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+	output := filepath.Join(t.TempDir(), "storage_observability.go")
+	decorators := []generator.DecoratorType{generator.ObservabilityDecorator}
+
+	options := map[generator.DecoratorType]generator.Options{generator.ObservabilityDecorator: {"buildTag": "otel"}}
+	require.NoError(t, gen.Generate(iface, decorators, "storage", output, options))
+	require.FileExists(t, generator.VariantPath(output))
+
+	// Dropping the build tag removes the variant, which would clash with the decorator
+	require.NoError(t, gen.Generate(iface, decorators, "storage", output, nil))
+	require.NoFileExists(t, generator.VariantPath(output))
+
+	options[generator.ObservabilityDecorator]["buildTag"] = "otel &&"
+	require.ErrorContains(t, gen.Generate(iface, decorators, "storage", output, options), "invalid buildTag")
+
+	options = map[generator.DecoratorType]generator.Options{generator.FakeDecorator: {"buildTag": "otel"}}
+	require.Error(t, gen.Generate(iface, []generator.DecoratorType{generator.FakeDecorator}, "storage", output, options))
+}
+
+func TestCheckSourceHash(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "storage.go")
+	require.NoError(t, os.WriteFile(source, []byte("package storage\n\ntype Storage interface {\n\tGet(id string) (string, error)\n}\n"), 0644))
+
+	iface, err := parser.ParseInterface(source, "Storage")
+	require.NoError(t, err)
+	output := filepath.Join(dir, "storage_retry.go")
+	options := map[generator.DecoratorType]generator.Options{generator.RetryDecorator: {"assertSource": true}}
+	require.NoError(t, gen.Generate(iface, []generator.DecoratorType{generator.RetryDecorator}, "storage", output, options))
+
+	code, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.Contains(t, string(code), `sourcehash.Assert("storage.go", "Storage", "`+iface.SourceHash+`")`)
+	require.NoError(t, generator.CheckSourceHash(output, iface))
+
+	// Changing the interface makes the generated file stale
+	require.NoError(t, os.WriteFile(source, []byte("package storage\n\ntype Storage interface {\n\tGet(id int) (string, error)\n}\n"), 0644))
+	changed, err := parser.ParseInterface(source, "Storage")
+	require.NoError(t, err)
+	require.ErrorContains(t, generator.CheckSourceHash(output, changed), "is stale")
+}
+
+func TestArgsHeader(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+	args := `-C=.. -interface=Storage "-set=cache.ttl=5m 30s"`
+	for name, options := range map[string]generator.Options{
+		"plain":     {"args": args},
+		"build tag": {"args": args, "buildTag": "otel"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, gen.Render(&buf, iface, generator.RetryDecorator, "storage", options))
+
+			recorded, ok := generator.ArgsFromHeader(buf.Bytes())
+			require.True(t, ok)
+			require.Equal(t, args, recorded)
+			hash, ok := sourcehash.FromHeader(buf.Bytes())
+			require.True(t, ok)
+			require.Equal(t, iface.SourceHash, hash)
+		})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, gen.Render(&buf, iface, generator.RetryDecorator, "storage", nil))
+	_, ok := generator.ArgsFromHeader(buf.Bytes())
+	require.False(t, ok)
+}
+
+// TestLineEndings checks generation is independent of the line endings of checkouts, as Windows checkouts use CRLF
+func TestLineEndings(t *testing.T) {
+	src, err := os.ReadFile("testdata/storage.go")
+	require.NoError(t, err)
+	golden, err := os.ReadFile("testdata/storage_retry.golden")
+	require.NoError(t, err)
+
+	for name, eol := range map[string]string{"lf": "\n", "crlf": "\r\n"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			source := filepath.Join(dir, "storage.go")
+			require.NoError(t, os.WriteFile(source, bytes.ReplaceAll(src, []byte("\n"), []byte(eol)), 0644))
+			goldenCopy := filepath.Join(dir, "storage_retry.golden")
+			require.NoError(t, os.WriteFile(goldenCopy, bytes.ReplaceAll(golden, []byte("\n"), []byte(eol)), 0644))
+
+			decogentest.Run(t, decogentest.Case{
+				Source:     source,
+				Interface:  "UserStorage",
+				Decorators: []string{"retry"},
+				Golden:     goldenCopy,
+			})
+		})
+	}
+}
+
+func TestPartialUnknownMethod(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	err = gen.Render(io.Discard, generator.LintInterface(), generator.RetryDecorator, "lint", generator.Options{"methods": []interface{}{"Get", "Fetch"}})
+	require.ErrorContains(t, err, "Linted has no method Fetch")
+}
+
+func TestStyle(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	err = gen.Render(io.Discard, generator.LintInterface(), generator.RetryDecorator, "lint", generator.Options{"style": "builder"})
+	require.ErrorContains(t, err, `unknown style "builder"`)
+
+	err = gen.Render(io.Discard, generator.LintInterface(), generator.RetryDecorator, "lint", generator.Options{"style": generator.StyleFunctional, "di": generator.DIWire})
+	require.ErrorContains(t, err, "does not support dependency injection")
+}
+
+func TestKeyWarnings(t *testing.T) {
+	iface := &model.Interface{
+		Name: "Feed",
+		Methods: []*model.Method{
+			{
+				Name: "Poll",
+				Parameters: []*model.Parameter{
+					{Name: "ctx", Type: "context.Context"},
+					{Name: "topic", Type: "string"},
+					{Name: "onItem", Type: "func()"},
+				},
+				Results: []*model.Parameter{{Name: "result0", Type: "int"}, {Name: "result1", Type: "error"}},
+			},
+			{
+				Name: "Match",
+				Parameters: []*model.Parameter{
+					{Name: "ctx", Type: "context.Context"},
+					{Name: "filter", Type: "interface{}"},
+				},
+				Results: []*model.Parameter{{Name: "result0", Type: "bool"}, {Name: "result1", Type: "error"}},
+			},
+			{
+				Name: "Count",
+				Parameters: []*model.Parameter{
+					{Name: "ctx", Type: "context.Context"},
+					{Name: "topic", Type: "string"},
+				},
+				Results: []*model.Parameter{{Name: "result0", Type: "int"}, {Name: "result1", Type: "error"}},
+			},
+		},
+	}
+
+	warnings := generator.KeyWarnings(generator.CacheDecorator, iface, generator.Options{})
+	require.Len(t, warnings, 2)
+	require.Contains(t, warnings[0], "Feed.Poll: cache key ignores onItem")
+	require.Contains(t, warnings[1], "Feed.Match: cache key formats filter")
+
+	// Key templates and other decorators are not checked
+	options := generator.Options{"keys": map[string]interface{}{"Poll": "poll:{{.topic}}", "Match": "match"}}
+	require.Empty(t, generator.KeyWarnings(generator.CacheDecorator, iface, options))
+	require.Empty(t, generator.KeyWarnings(generator.RetryDecorator, iface, generator.Options{}))
+
+	// Configured types are skipped as well
+	options = generator.Options{"skipTypes": []interface{}{"string"}}
+	warnings = generator.KeyWarnings(generator.CacheDecorator, iface, options)
+	require.Len(t, warnings, 3)
+	require.Contains(t, warnings[2], "Feed.Count: cache key ignores topic")
+}
+
+func TestGenerateRaceTest(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "user_storage_race_test.go")
+	decorators := []generator.DecoratorType{generator.RetryDecorator, generator.DedupeDecorator, generator.CacheDecorator}
+	require.NoError(t, gen.GenerateRaceTest(iface, decorators, "storage", output, nil))
+
+	code, err := os.ReadFile(output)
+	require.NoError(t, err)
+	decogentest.AssertGolden(t, "testdata/storage_race_test.golden", code)
+}
+
+func TestGenerateCtxTest(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+
+	// The async decorator detaches calls from their context by design and is left out
+	output := filepath.Join(t.TempDir(), "user_storage_ctx_test.go")
+	decorators := []generator.DecoratorType{generator.RetryDecorator, generator.AsyncDecorator, generator.CacheDecorator}
+	require.NoError(t, gen.GenerateCtxTest(iface, decorators, "storage", output, nil))
+
+	code, err := os.ReadFile(output)
+	require.NoError(t, err)
+	decogentest.AssertGolden(t, "testdata/storage_ctx_test.golden", code)
+}
+
+func TestGenerateCtxTestWithoutCancellableDecorator(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+
+	// No decorator is left to build, the test must still compile and skips itself
+	output := filepath.Join(t.TempDir(), "user_storage_ctx_test.go")
+	decorators := []generator.DecoratorType{generator.AsyncDecorator}
+	require.NoError(t, gen.GenerateCtxTest(iface, decorators, "storage", output, nil))
+
+	code, err := os.ReadFile(output)
+	require.NoError(t, err)
+	decogentest.AssertGolden(t, "testdata/storage_ctx_test_skip.golden", code)
+}
+
+func TestGenerateMethodNames(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+
+	for _, style := range []string{generator.MethodNamesConst, generator.MethodNamesTyped} {
+		t.Run(style, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "user_storage_methods.go")
+			require.NoError(t, gen.GenerateMethodNames(iface, "storage", output, style))
+
+			code, err := os.ReadFile(output)
+			require.NoError(t, err)
+			decogentest.AssertGolden(t, "testdata/storage_methods_"+style+".golden", code)
+		})
+	}
+
+	t.Run("unknown style", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "user_storage_methods.go")
+		require.ErrorContains(t, gen.GenerateMethodNames(iface, "storage", output, "enum"), "unknown method names style")
+	})
+}
+
+func TestGenerateDoc(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+
+	decorators := []generator.DecoratorType{
+		generator.ObservabilityDecorator, generator.DedupeDecorator, generator.RetryDecorator,
+		generator.LastGoodDecorator, generator.AsyncDecorator, generator.CacheDecorator, generator.FakeDecorator,
+	}
+	options := map[generator.DecoratorType]generator.Options{generator.RetryDecorator: {"di": generator.DIWire}}
+	for _, docFormat := range []string{generator.DocGo, generator.DocMarkdown} {
+		t.Run(docFormat, func(t *testing.T) {
+			output := generator.DocPath(filepath.Join(t.TempDir(), "user_storage.go"), docFormat)
+			require.NoError(t, gen.GenerateDoc(iface, decorators, "storage", output, options, docFormat))
+
+			code, err := os.ReadFile(output)
+			require.NoError(t, err)
+			decogentest.AssertGolden(t, "testdata/storage_doc_"+docFormat+".golden", code)
+		})
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "user_storage.txt")
+		require.ErrorContains(t, gen.GenerateDoc(iface, decorators, "storage", output, nil, "txt"), "unknown documentation format")
+	})
+}
+
+func TestGenerateGuard(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "user_storage_guard.go")
+	require.NoError(t, gen.GenerateGuard(iface, output, []string{"*PostgresStorage", " MemoryStorage"}))
+
+	code, err := os.ReadFile(output)
+	require.NoError(t, err)
+	decogentest.AssertGolden(t, "testdata/storage_guard.golden", code)
+
+	require.ErrorContains(t, gen.GenerateGuard(iface, output, []string{"sql.DB"}), `invalid implementation "sql.DB"`)
+	require.ErrorContains(t, gen.GenerateGuard(iface, output, nil), "no implementations")
+}
+
+// benchmarkCorpus returns interfaces shaped like LintInterface under distinct names
+func benchmarkCorpus(size int) []*model.Interface {
+	corpus := make([]*model.Interface, size)
+	for i := range corpus {
+		iface := generator.LintInterface()
+		iface.Name = fmt.Sprintf("Service%d", i)
+		corpus[i] = iface
+	}
+	return corpus
+}
+
+func BenchmarkRender(b *testing.B) {
+	gen, err := generator.NewGenerator()
+	require.NoError(b, err)
+
+	corpus := benchmarkCorpus(500)
+	decorators := []generator.DecoratorType{generator.RetryDecorator, generator.DedupeDecorator, generator.CacheDecorator}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, iface := range corpus {
+			for _, dt := range decorators {
+				if err := gen.Render(io.Discard, iface, dt, "bench", nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+	b.ReportMetric(float64(b.N*len(corpus))/b.Elapsed().Seconds(), "interfaces/s")
+}
+
+func BenchmarkNewGenerator(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := generator.NewGenerator(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateWritesAtomically(t *testing.T) {
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+
+	iface, err := parser.ParseInterface("testdata/storage.go", "UserStorage")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "user_storage_retry.go")
+	require.NoError(t, os.WriteFile(output, []byte("stale"), 0600))
+	require.NoError(t, os.WriteFile(output+".unformatted", []byte("broken"), 0600))
+
+	require.NoError(t, gen.Generate(iface, []generator.DecoratorType{generator.RetryDecorator}, "storage", output, nil))
+
+	// Existing permissions are kept and nothing but the output is left behind
+	info, err := os.Stat(output)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "user_storage_retry.go", entries[0].Name())
+}
@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// guardImplementation is an implementation asserted by the guard file
+type guardImplementation struct {
+	// Type is the name of the implementation type
+	Type string
+
+	// Pointer is set when the pointer to the type implements the interface rather than the type itself
+	Pointer bool
+}
+
+// guardData is the data of the guard template
+type guardData struct {
+	*TemplateData
+
+	// Implementations are the asserted implementations, in the configured order
+	Implementations []guardImplementation
+}
+
+// GenerateGuard generates assertions that hand-written implementations still satisfy an interface,
+// so that a refactor breaking one fails to compile in the package declaring the interface
+// Implementations are type names of that package as written in Go: "*PostgresStore" asserts the pointer,
+// "MemoryStore" the value
+func (g *Generator) GenerateGuard(
+	interfaceModel *model.Interface,
+	outputPath string,
+	implementations []string,
+) error {
+	if len(implementations) == 0 {
+		return fmt.Errorf("no implementations of %s to guard", interfaceModel.Name)
+	}
+
+	data := &guardData{TemplateData: templateData(interfaceModel, interfaceModel.PackageName, nil, "")}
+	for _, name := range implementations {
+		name = strings.TrimSpace(name)
+		typeName, pointer := strings.CutPrefix(name, "*")
+		if !token.IsIdentifier(typeName) {
+			return fmt.Errorf("invalid implementation %q of %s: want a type name of package %s, optionally with *",
+				name, interfaceModel.Name, interfaceModel.PackageName)
+		}
+		data.Implementations = append(data.Implementations, guardImplementation{Type: typeName, Pointer: pointer})
+	}
+
+	var buf bytes.Buffer
+	if err := execute(g.guard, &buf, data); err != nil {
+		return newTemplateError("guard", g.guard, interfaceModel, data, data.TemplateData, err)
+	}
+
+	formattedCode, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format generated guard: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := writeFile(outputPath, formattedCode); err != nil {
+		return fmt.Errorf("failed to write generated guard: %w", err)
+	}
+
+	return nil
+}
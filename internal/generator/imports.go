@@ -0,0 +1,238 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// importsTemplate is the name of the template block declaring the imports a decorator template needs
+// It renders one import per line, either "path" or "name path"
+const importsTemplate = "imports"
+
+// Import groups of the generated file, separated by blank lines
+const (
+	groupStd = iota
+	groupThirdParty
+	groupLocal
+)
+
+// Import is an import of the generated file
+type Import struct {
+	// Name is the explicit package name, empty to use the default one
+	Name string
+
+	// Path is the import path
+	Path string
+
+	// Group orders the imports in groups: standard library, third party, then local packages
+	Group int
+}
+
+// Std reports whether the import belongs to the standard library
+func (i Import) Std() bool {
+	first, _, _ := strings.Cut(i.Path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// qualifierPattern matches package qualifiers in type expressions such as "*time.Time"
+var qualifierPattern = regexp.MustCompile(`\b([A-Za-z_]\w*)\.`)
+
+// resolveImports merges the imports declared by the template with the interface imports used in method signatures
+// Duplicates are removed and the result is sorted with standard library imports first
+func resolveImports(tmpl *template.Template, data interface{}, iface *model.Interface) ([]Import, error) {
+	byPath := make(map[string]Import)
+
+	if manifest := tmpl.Lookup(importsTemplate); manifest != nil {
+		var buf bytes.Buffer
+		if err := execute(manifest, &buf, data); err != nil {
+			return nil, fmt.Errorf("failed to execute imports template: %w", err)
+		}
+
+		for _, line := range strings.Split(buf.String(), "\n") {
+			fields := strings.Fields(line)
+			switch len(fields) {
+			case 0:
+				continue
+			case 1:
+				byPath[fields[0]] = Import{Path: fields[0]}
+			case 2:
+				byPath[fields[1]] = Import{Name: fields[0], Path: fields[1]}
+			default:
+				return nil, fmt.Errorf("invalid import in imports template: %q", line)
+			}
+		}
+	}
+
+	// Only interface imports referenced by the signatures are needed
+	for name := range usedQualifiers(iface) {
+		importPath, ok := iface.Imports[name]
+		if !ok {
+			continue
+		}
+		imp := Import{Path: importPath}
+		if name != path.Base(importPath) {
+			imp.Name = name
+		}
+		if _, ok := byPath[importPath]; !ok || imp.Name != "" {
+			byPath[importPath] = imp
+		}
+	}
+
+	return sortedImports(byPath), nil
+}
+
+// mergeImports merges lists of imports, keeping the first import of each path
+func mergeImports(lists ...[]Import) []Import {
+	byPath := make(map[string]Import)
+	for _, list := range lists {
+		for _, imp := range list {
+			if _, ok := byPath[imp.Path]; !ok {
+				byPath[imp.Path] = imp
+			}
+		}
+	}
+	return sortedImports(byPath)
+}
+
+// sortedImports returns the imports sorted with standard library imports first
+func sortedImports(byPath map[string]Import) []Import {
+	imports := make([]Import, 0, len(byPath))
+	for _, imp := range byPath {
+		imports = append(imports, imp)
+	}
+	return groupImports(imports, nil)
+}
+
+// groupImports assigns the imports to groups and sorts them by group and path
+// Imports starting with one of the local prefixes are grouped after third party imports, like goimports -local
+func groupImports(imports []Import, local []string) []Import {
+	for i := range imports {
+		imports[i].Group = groupThirdParty
+		if imports[i].Std() {
+			imports[i].Group = groupStd
+		}
+		for _, prefix := range local {
+			if imports[i].Path == prefix || strings.HasPrefix(imports[i].Path, strings.TrimSuffix(prefix, "/")+"/") {
+				imports[i].Group = groupLocal
+			}
+		}
+	}
+
+	sort.Slice(imports, func(i, j int) bool {
+		if imports[i].Group != imports[j].Group {
+			return imports[i].Group < imports[j].Group
+		}
+		return imports[i].Path < imports[j].Path
+	})
+	return imports
+}
+
+// usedQualifiers returns the package names referenced by the parameter and result types of the interface
+func usedQualifiers(iface *model.Interface) map[string]bool {
+	used := make(map[string]bool)
+	for _, m := range iface.Methods {
+		for _, params := range [][]*model.Parameter{m.Parameters, m.Results} {
+			for _, p := range params {
+				for _, match := range qualifierPattern.FindAllStringSubmatch(p.Type, -1) {
+					used[match[1]] = true
+				}
+			}
+		}
+	}
+	return used
+}
+
+// pruneImports removes the imports that the generated code does not reference
+// Templates can then declare every import they may need without tracking which branches were rendered
+func pruneImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	// Collect the lines of the unused imports, or of whole declarations left without imports
+	removed := make(map[int]bool)
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+
+		var unused []ast.Node
+		for _, spec := range genDecl.Specs {
+			if name := importName(spec.(*ast.ImportSpec)); name != "" && !used[name] {
+				unused = append(unused, spec)
+			}
+		}
+
+		nodes := unused
+		if len(unused) == len(genDecl.Specs) {
+			nodes = []ast.Node{genDecl}
+		}
+		for _, node := range nodes {
+			for line := fset.Position(node.Pos()).Line; line <= fset.Position(node.End()).Line; line++ {
+				removed[line] = true
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return src, nil
+	}
+
+	// Drop the lines rather than printing the syntax tree, which would leave blank lines behind
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	var buf bytes.Buffer
+	for i, line := range lines {
+		if !removed[i+1] {
+			buf.Write(line)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// importName returns the name an import is referenced by
+// Blank and dot imports are always kept
+func importName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		if imp.Name.Name == "_" || imp.Name.Name == "." {
+			return ""
+		}
+		return imp.Name.Name
+	}
+
+	importPath, _ := strconv.Unquote(imp.Path.Value)
+	return packageName(importPath)
+}
+
+// packageName guesses the name of the package at an import path
+func packageName(importPath string) string {
+	name := path.Base(importPath)
+	// Major version suffixes are not part of the package name
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return strings.TrimPrefix(name, "go-")
+}
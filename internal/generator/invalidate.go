@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// cacheInvalidation is a cached call whose result a write method invalidates
+type cacheInvalidation struct {
+	// Method is the name of the cached method
+	Method string
+
+	// Args are the arguments of the call passed to the cache key method of the cached method
+	Args string
+}
+
+// cacheInvalidations returns the cached calls a write method invalidates
+//
+// The "invalidates" option maps method names to calls such as "Get(user.ID)",
+// and the "invalidates" parameter of a cache annotation takes precedence.
+// Several calls are separated by semicolons. The arguments of a call are the
+// non-context arguments of the cached method, and reference the parameters of
+// the write method or their fields, or are literals.
+func cacheInvalidations(options Options, methods []*model.Method, m *model.Method) ([]cacheInvalidation, error) {
+	params, err := annotation(CacheDecorator, m)
+	if err != nil {
+		return nil, err
+	}
+	text, ok := params["invalidates"]
+	if !ok {
+		invalidates, _ := options["invalidates"].(map[string]interface{})
+		if text, ok = invalidates[m.Name].(string); !ok {
+			return nil, nil
+		}
+	}
+	if cached(m) {
+		return nil, fmt.Errorf("cache invalidation of %s: only methods without cached results invalidate others", m.Name)
+	}
+
+	known := make(map[string]bool, len(m.Parameters))
+	for _, p := range m.Parameters {
+		known[p.Name] = true
+	}
+
+	var invalidations []cacheInvalidation
+	for _, call := range strings.Split(text, ";") {
+		if call = strings.TrimSpace(call); call == "" {
+			continue
+		}
+		invalidation, err := parseInvalidation(methods, known, call)
+		if err != nil {
+			return nil, fmt.Errorf("cache invalidation of %s: %w", m.Name, err)
+		}
+		invalidations = append(invalidations, invalidation)
+	}
+	return invalidations, nil
+}
+
+// parseInvalidation parses an invalidated call such as Get(user.ID)
+func parseInvalidation(methods []*model.Method, known map[string]bool, call string) (cacheInvalidation, error) {
+	expr, err := parser.ParseExpr(call)
+	if err != nil {
+		return cacheInvalidation{}, fmt.Errorf("invalid call %q: %w", call, err)
+	}
+	c, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return cacheInvalidation{}, fmt.Errorf("invalid call %q, want a call such as Get(user.ID)", call)
+	}
+	name, ok := c.Fun.(*ast.Ident)
+	if !ok {
+		return cacheInvalidation{}, fmt.Errorf("invalid call %q, want a method of the interface", call)
+	}
+
+	var target *model.Method
+	for _, method := range methods {
+		if method.Name == name.Name {
+			target = method
+		}
+	}
+	if target == nil || !cached(target) {
+		return cacheInvalidation{}, fmt.Errorf("%s is not a cached method", name.Name)
+	}
+
+	params := warmParams(target)
+	variadic := len(params) > 0 && strings.HasPrefix(params[len(params)-1].Type, "...")
+	if len(c.Args) != len(params) && (!variadic || len(c.Args) < len(params)-1) {
+		return cacheInvalidation{}, fmt.Errorf("%s takes %d arguments besides the context, got %d in %q", target.Name, len(params), len(c.Args), call)
+	}
+
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		if root := rootIdent(arg); root != nil && !known[root.Name] {
+			return cacheInvalidation{}, fmt.Errorf("%s is not a parameter in %q", root.Name, call)
+		} else if root == nil {
+			if _, ok := arg.(*ast.BasicLit); !ok {
+				return cacheInvalidation{}, fmt.Errorf("unsupported argument in %q, only parameters, their fields and literals are allowed", call)
+			}
+		}
+		args[i] = call[arg.Pos()-1 : arg.End()-1]
+	}
+	if c.Ellipsis.IsValid() {
+		args[len(args)-1] += "..."
+	}
+
+	return cacheInvalidation{Method: target.Name, Args: strings.Join(args, ", ")}, nil
+}
+
+// rootIdent returns the identifier a parameter or field reference such as user.ID starts with
+func rootIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.SelectorExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// cacheInvalidated reports whether a write method invalidates results of a cached method,
+// which then has a method returning its cache key
+// Invalid invalidations are skipped here and reported when their write method is generated
+func cacheInvalidated(options Options, methods []*model.Method, m *model.Method) bool {
+	for _, method := range methods {
+		invalidations, _ := cacheInvalidations(options, methods, method)
+		for _, invalidation := range invalidations {
+			if invalidation.Method == m.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cached reports whether the cache decorator caches the results of a method
+func cached(m *model.Method) bool {
+	return m.HasErrorReturn() && len(m.Results) >= 2
+}
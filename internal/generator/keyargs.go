@@ -0,0 +1,113 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// defaultSkipTypes are the argument types left out of generated keys because they have no stable value
+// A rule ending with "*" matches every type starting with the rest of the rule
+var defaultSkipTypes = []string{"context.Context", "func*", "chan*", "<-chan*"}
+
+// unstableTypes are the argument types whose formatting may differ between equal calls
+var unstableTypes = []string{"interface{}", "any"}
+
+// skipTypes returns the default rules merged with the rules of the "skipTypes" option
+func skipTypes(options Options) []string {
+	rules := append([]string(nil), defaultSkipTypes...)
+	if extra, ok := options["skipTypes"].([]interface{}); ok {
+		for _, rule := range extra {
+			if s, ok := rule.(string); ok {
+				rules = append(rules, s)
+			}
+		}
+	}
+	return rules
+}
+
+// matchType reports whether a parameter type matches one of the rules
+func matchType(rules []string, typ string) bool {
+	for _, rule := range rules {
+		if prefix, ok := strings.CutSuffix(rule, "*"); ok {
+			if strings.HasPrefix(typ, prefix) {
+				return true
+			}
+		} else if typ == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// keyParams returns the parameters of a method that take part in generated keys
+func keyParams(options Options, m *model.Method) []*model.Parameter {
+	rules := skipTypes(options)
+
+	var params []*model.Parameter
+	for _, p := range m.Parameters {
+		if !matchType(rules, p.Type) {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// keyArgs formats the names of the parameters taking part in generated keys as a comma-separated list
+func keyArgs(options Options, m *model.Method) string {
+	var names []string
+	for _, p := range keyParams(options, m) {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// KeyWarnings reports the methods whose generated keys may not be stable
+// A key is not stable when arguments other than the context were skipped, or when a kept argument
+// has a dynamic type whose formatting may differ between equal calls
+func KeyWarnings(dt DecoratorType, iface *model.Interface, options Options) []string {
+	if dt != CacheDecorator && dt != LastGoodDecorator {
+		return nil
+	}
+
+	keys, _ := options["keys"].(map[string]interface{})
+	rules := skipTypes(options)
+
+	var warnings []string
+	for _, m := range iface.Methods {
+		if !m.HasErrorReturn() || len(m.Results) < 2 {
+			continue // Not cached or remembered
+		}
+		if _, ok := keys[m.Name]; ok {
+			continue // The key template decides
+		}
+		if _, ok := m.Annotation(string(CacheDecorator))["key"]; ok {
+			continue
+		}
+
+		var skipped, unstable []string
+		for _, p := range m.Parameters {
+			switch {
+			case p.Type == "context.Context":
+			case matchType(rules, p.Type):
+				skipped = append(skipped, p.Name)
+			case matchType(unstableTypes, p.Type):
+				unstable = append(unstable, p.Name)
+			}
+		}
+
+		if len(skipped) > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s.%s: cache key ignores %s, calls differing only by them share an entry; set a key template",
+				iface.Name, m.Name, strings.Join(skipped, ", ")))
+		}
+		if len(unstable) > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s.%s: cache key formats %s with %%v, which may not be stable; set a key template",
+				iface.Name, m.Name, strings.Join(unstable, ", ")))
+		}
+	}
+
+	return warnings
+}
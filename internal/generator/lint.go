@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// LintInterface returns a synthetic interface covering the method shapes templates must handle
+func LintInterface() *model.Interface {
+	return &model.Interface{
+		Name:        "Linted",
+		PackageName: "lint",
+		Comments:    "Linted is a synthetic interface used to lint templates\n",
+		Imports: map[string]string{
+			"context": "context",
+			"time":    "time",
+		},
+		Methods: []*model.Method{
+			{
+				Name:       "Get",
+				Comments:   "Get has a context, a value result and an error\n",
+				Parameters: []*model.Parameter{{Name: "ctx", Type: "context.Context"}, {Name: "id", Type: "string"}},
+				Results:    []*model.Parameter{{Name: "result0", Type: "*Item"}, {Name: "result1", Type: "error"}},
+			},
+			{
+				Name:     "List",
+				Comments: "List has grouped parameters, several value results and annotations\n",
+				Parameters: []*model.Parameter{
+					{Name: "ctx", Type: "context.Context"},
+					{Name: "offset", Type: "int", Grouped: true},
+					{Name: "limit", Type: "int"},
+				},
+				Results: []*model.Parameter{{Name: "result0", Type: "[]Item"}, {Name: "result1", Type: "int"}, {Name: "result2", Type: "error"}},
+				Annotations: map[string]map[string]string{
+					"retry": {"max_attempts": "5", "backoff": "exp(50ms,5s)"},
+					"cache": {"ttl": "30s", "negative_ttl": "5s", "error_ttl": "1s"},
+				},
+			},
+			{
+				Name:         "Find",
+				Comments:     "Find has named results and annotations\n",
+				Parameters:   []*model.Parameter{{Name: "ctx", Type: "context.Context"}, {Name: "filter", Type: "map[string]interface{}"}},
+				Results:      []*model.Parameter{{Name: "items", Type: "map[string]*Item"}, {Name: "err", Type: "error"}},
+				NamedResults: true,
+				Annotations: map[string]map[string]string{
+					"retry": {"policy": "reads", "backoff": "const(1s)"},
+					"cache": {"key": "find:{{.filter}}"},
+				},
+			},
+			{
+				Name:       "Wait",
+				Comments:   "Wait uses an imported type, only returns an error and is renamed\n",
+				Parameters: []*model.Parameter{{Name: "ctx", Type: "context.Context"}, {Name: "d", Type: "time.Duration"}},
+				Results:    []*model.Parameter{{Name: "result0", Type: "error"}},
+				Annotations: map[string]map[string]string{
+					"retry": {"max_elapsed": "2m30s"},
+				},
+				Alias: "lint.wait",
+			},
+			{
+				Name:       "Send",
+				Comments:   "Send is variadic\n",
+				Parameters: []*model.Parameter{{Name: "ctx", Type: "context.Context"}, {Name: "msgs", Type: "...string"}},
+				Results:    []*model.Parameter{{Name: "result0", Type: "error"}},
+			},
+			{
+				Name:       "Process",
+				Comments:   "Process has unnamed parameters\n",
+				Parameters: []*model.Parameter{{Name: "param0", Type: "context.Context"}, {Name: "param1", Type: "string"}},
+				Results:    []*model.Parameter{{Name: "result0", Type: "error"}},
+			},
+			{
+				Name:    "Ping",
+				Results: []*model.Parameter{{Name: "result0", Type: "error"}},
+			},
+			{
+				Name:       "Lookup",
+				Comments:   "Lookup has no context and no error\n",
+				Parameters: []*model.Parameter{{Name: "key", Type: "string"}},
+				Results:    []*model.Parameter{{Name: "result0", Type: "string"}, {Name: "result1", Type: "bool"}},
+			},
+			{
+				Name:    "Name",
+				Results: []*model.Parameter{{Name: "result0", Type: "string"}},
+			},
+			{
+				Name:     "Close",
+				Comments: "Close has no parameters and no results\n",
+			},
+		},
+	}
+}
+
+// lintOptions are the option sets every template is rendered with
+var lintOptions = []Options{
+	{},
+	{"di": DIWire},
+	{"di": DIFx},
+	{"policies": map[string]interface{}{"Get": "reads", "Send": "writes"}},
+	{"callmeta": true, "wrapErrors": WrapMethod},
+	{"methodNames": MethodNamesConst},
+	{"style": StyleFunctional},
+	{"codec": CodecGob},
+	{"streams": StreamsOpen},
+}
+
+// lintRequired are the options templates cannot be rendered without, added to every option set
+var lintRequired = map[DecoratorType]Options{
+	ReturnsDecorator: {"wrap": []string{"*Item"}},
+}
+
+// Lint renders every template against LintInterface with several option sets
+// It reports template errors, generated code that is not valid Go, interface methods the output does not implement
+// and documentation blocks that fail to render
+func (g *Generator) Lint() error {
+	iface := LintInterface()
+
+	var errs []error
+	for _, dt := range g.DecoratorTypes() {
+		for _, options := range lintOptions {
+			if required, ok := lintRequired[dt]; ok {
+				options = maps.Clone(options)
+				maps.Copy(options, required)
+			}
+			code, err := g.render(dt, g.templates[dt], iface, iface.PackageName, options)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s template with options %v: %w", dt, options, err))
+				continue
+			}
+			if missing := missingMethods(code, iface); len(missing) > 0 {
+				errs = append(errs, fmt.Errorf("%s template with options %v: methods not implemented: %v", dt, options, missing))
+			}
+			if _, err := g.docDecorator(dt, iface, iface.PackageName, options); err != nil {
+				errs = append(errs, fmt.Errorf("%s template with options %v: documentation: %w", dt, options, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// missingMethods returns the interface methods that have no method declaration in the generated code
+func missingMethods(code []byte, iface *model.Interface) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, 0)
+	if err != nil {
+		return nil // Already reported by formatting
+	}
+
+	declared := make(map[string]bool)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil {
+			declared[fn.Name.Name] = true
+		}
+	}
+
+	var missing []string
+	for _, m := range iface.Methods {
+		if !declared[m.Name] {
+			missing = append(missing, m.Name)
+		}
+	}
+	return missing
+}
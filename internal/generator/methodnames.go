@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// Styles of the "methodNames" option
+// With either style, generated decorators refer to method names through the constants of GenerateMethodNames
+const (
+	// MethodNamesConst declares the method names as untyped string constants
+	MethodNamesConst = "const"
+	// MethodNamesTyped declares the method names as constants of a string type named after the interface
+	MethodNamesTyped = "typed"
+)
+
+// MethodNames returns the style selected with the "methodNames" option, or an empty string
+func (o Options) MethodNames() (string, error) {
+	style, _ := o["methodNames"].(string)
+	switch style {
+	case "", MethodNamesConst, MethodNamesTyped:
+		return style, nil
+	default:
+		return "", fmt.Errorf("unknown method names style %q: want %q or %q", style, MethodNamesConst, MethodNamesTyped)
+	}
+}
+
+// methodName returns the expression of a method name in generated code:
+// a string literal, or the string value of its constant when the "methodNames" option is set
+func methodName(options Options, iface string, m *model.Method) (string, error) {
+	style, err := options.MethodNames()
+	if err != nil {
+		return "", err
+	}
+
+	switch style {
+	case MethodNamesConst:
+		return iface + "Method" + m.Name, nil
+	case MethodNamesTyped:
+		return "string(" + iface + "Method" + m.Name + ")", nil
+	default:
+		return strconv.Quote(m.Name), nil
+	}
+}
+
+// GenerateMethodNames generates the constants naming the methods of an interface in the given style
+// Decorators generated with the same "methodNames" option refer to them
+func (g *Generator) GenerateMethodNames(
+	interfaceModel *model.Interface,
+	outputPackage string,
+	outputPath string,
+	style string,
+) error {
+	if _, err := (Options{"methodNames": style}).MethodNames(); err != nil {
+		return err
+	}
+	if style == "" {
+		style = MethodNamesConst
+	}
+
+	data := &methodNamesData{
+		TemplateData: templateData(interfaceModel, outputPackage, nil, ""),
+		Style:        style,
+	}
+
+	var buf bytes.Buffer
+	if err := execute(g.methodNames, &buf, data); err != nil {
+		return newTemplateError("method names", g.methodNames, interfaceModel, data, data.TemplateData, err)
+	}
+
+	formattedCode, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format generated method names: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := writeFile(outputPath, formattedCode); err != nil {
+		return fmt.Errorf("failed to write generated method names: %w", err)
+	}
+
+	return nil
+}
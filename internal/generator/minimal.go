@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// runtimePrefix is the import path prefix of the decogen runtime packages
+const runtimePrefix = "github.com/komandakycto/decogen/pkg/"
+
+// minimalRuntimes are the runtime packages depending on the standard library only
+// Code generated with the "minimalDeps" option may import no other package outside the standard library;
+// the decorators needing others, such as observability with OpenTelemetry, cannot be generated with it
+var minimalRuntimes = []string{
+	runtimePrefix + "backoff",
+	runtimePrefix + "decorators",
+	runtimePrefix + "decorators/async",
+	runtimePrefix + "decorators/bulkhead",
+	runtimePrefix + "decorators/cache",
+	runtimePrefix + "decorators/callmeta",
+	runtimePrefix + "decorators/chain",
+	runtimePrefix + "decorators/circuitbreaker",
+	runtimePrefix + "decorators/dedupe",
+	runtimePrefix + "decorators/lastgood",
+	runtimePrefix + "decorators/metrics",
+	runtimePrefix + "decorators/ratelimit",
+	runtimePrefix + "decorators/redact",
+	runtimePrefix + "decorators/retry",
+	runtimePrefix + "sourcehash",
+}
+
+// MinimalRuntimes returns the runtime packages code generated with the "minimalDeps" option may import
+func MinimalRuntimes() []string {
+	return slices.Clone(minimalRuntimes)
+}
+
+// MinimalDeps reports whether the "minimalDeps" option is set
+// Decorators generated with it depend only on the standard library and the runtimes listed by MinimalRuntimes,
+// for teams with strict dependency allowlists; templates select branches avoiding the defaults package
+func (o Options) MinimalDeps() bool {
+	enabled, _ := o["minimalDeps"].(bool)
+	return enabled
+}
+
+// checkMinimalImports returns an error naming the first import of generated code outside the minimal dependencies
+// Imports of the application, such as the packages of types in the interface, are its own dependencies and allowed
+func checkMinimalImports(dt DecoratorType, code []byte, application []string) error {
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.ImportsOnly)
+	if err != nil {
+		return fmt.Errorf("failed to parse generated imports: %w", err)
+	}
+
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return fmt.Errorf("failed to parse generated imports: %w", err)
+		}
+		if (Import{Path: path}).Std() || slices.Contains(minimalRuntimes, path) || slices.Contains(application, path) {
+			continue
+		}
+		if strings.HasPrefix(path, runtimePrefix) {
+			return fmt.Errorf("the %s decorator cannot be generated with minimalDeps: its runtime %s depends on packages outside the standard library", dt, path)
+		}
+		return fmt.Errorf("the %s decorator cannot be generated with minimalDeps: it imports %s", dt, path)
+	}
+	return nil
+}
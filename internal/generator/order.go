@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Decorators are listed outermost first: the first decorator wraps all the others
+// and the last one wraps the underlying implementation directly.
+
+// Decorators of the runtime stack without a template that still take part in ordering rules
+const (
+	timeoutDecorator DecoratorType = "timeout"
+	authzDecorator   DecoratorType = "authz"
+)
+
+// Severity tells whether an ordering problem only warrants a warning or fails generation
+type Severity string
+
+const (
+	// SeverityWarning is used for stacks that work but are likely not what was meant
+	SeverityWarning Severity = "warning"
+	// SeverityError is used for stacks that are wrong whatever the intent
+	SeverityError Severity = "error"
+)
+
+// OrderIssue describes a problem with the order of a decorator stack
+type OrderIssue struct {
+	Severity Severity
+	Message  string
+}
+
+// Error implements the error interface
+func (i OrderIssue) Error() string {
+	return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+}
+
+// orderRule requires Outer to wrap Inner when both are in the stack
+type orderRule struct {
+	Outer    DecoratorType
+	Inner    DecoratorType
+	Severity Severity
+	Reason   string
+}
+
+// orderRules are the ordering constraints between pairs of decorators
+var orderRules = []orderRule{
+	{
+		Outer:    authzDecorator,
+		Inner:    CacheDecorator,
+		Severity: SeverityError,
+		Reason:   "a cache outside authorization serves results to callers that were never authorized",
+	},
+	{
+		Outer:    DedupeDecorator,
+		Inner:    RetryDecorator,
+		Severity: SeverityError,
+		Reason:   "retries inside dedupe are rejected as duplicates of the first attempt",
+	},
+	{
+		Outer:    LastGoodDecorator,
+		Inner:    RetryDecorator,
+		Severity: SeverityWarning,
+		Reason:   "retry outside lastgood never sees failures as stale results are served before retrying",
+	},
+	{
+		Outer:    AsyncDecorator,
+		Inner:    RetryDecorator,
+		Severity: SeverityWarning,
+		Reason:   "retry outside async only retries queueing calls, whose failures are reported after they return",
+	},
+	{
+		Outer:    RetryDecorator,
+		Inner:    timeoutDecorator,
+		Severity: SeverityWarning,
+		Reason:   "a timeout outside retry bounds all attempts together instead of each attempt",
+	},
+}
+
+// positionRules name the decorators that belong at either end of the stack
+var positionRules = []struct {
+	Decorator DecoratorType
+	Outermost bool
+	Reason    string
+}{
+	{
+		Decorator: MetricsDecorator,
+		Outermost: true,
+		Reason:    "metrics should be outermost to measure what callers observe",
+	},
+	{
+		Decorator: ObservabilityDecorator,
+		Outermost: true,
+		Reason:    "observability should be outermost to report what callers observe",
+	},
+	{
+		Decorator: CacheDecorator,
+		Outermost: false,
+		Reason:    "cache should be innermost so hits skip every other decorator",
+	},
+}
+
+// CheckOrder validates the order of a decorator stack listed outermost first
+// It returns every issue found, warnings included
+func CheckOrder(decorators []DecoratorType) []OrderIssue {
+	var issues []OrderIssue
+
+	// Fakes are generated alongside the stack without wrapping anything
+	decorators = slices.DeleteFunc(slices.Clone(decorators), func(dt DecoratorType) bool {
+		return dt == FakeDecorator
+	})
+
+	position := make(map[DecoratorType]int, len(decorators))
+	for i, dt := range decorators {
+		if _, ok := position[dt]; ok {
+			issues = append(issues, OrderIssue{
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s decorator is listed more than once", dt),
+			})
+			continue
+		}
+		position[dt] = i
+	}
+
+	for _, rule := range orderRules {
+		outer, ok := position[rule.Outer]
+		if !ok {
+			continue
+		}
+		inner, ok := position[rule.Inner]
+		if !ok || outer < inner {
+			continue
+		}
+		issues = append(issues, OrderIssue{
+			Severity: rule.Severity,
+			Message:  fmt.Sprintf("%s must wrap %s: %s", rule.Outer, rule.Inner, rule.Reason),
+		})
+	}
+
+	for _, rule := range positionRules {
+		i, ok := position[rule.Decorator]
+		if !ok {
+			continue
+		}
+		if (rule.Outermost && i != 0) || (!rule.Outermost && i != len(decorators)-1) {
+			issues = append(issues, OrderIssue{
+				Severity: SeverityWarning,
+				Message:  rule.Reason,
+			})
+		}
+	}
+
+	return issues
+}
+
+// OrderError joins the issues of a decorator stack that fail generation, or returns nil
+func OrderError(issues []OrderIssue) error {
+	var errs []error
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid decorator order: %w", errors.Join(errs...))
+}
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// Methods returns the method names selected with the "methods" option
+// A decorator with selected methods implements only those and embeds the interface for the others
+func (o Options) Methods() []string {
+	var names []string
+	switch v := o["methods"].(type) {
+	case []string:
+		names = v
+	case []interface{}:
+		for _, name := range v {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+	return names
+}
+
+// decoratedMethods returns the methods a decorator implements itself, in declaration order,
+// and whether that is only a subset of the interface
+func decoratedMethods(interfaceModel *model.Interface, options Options) ([]*model.Method, bool, error) {
+	names := options.Methods()
+	if len(names) == 0 {
+		return interfaceModel.Methods, false, nil
+	}
+
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !hasMethod(interfaceModel.Methods, name) {
+			return nil, false, fmt.Errorf("methods option: %s has no method %s", interfaceModel.Name, name)
+		}
+		selected[name] = true
+	}
+
+	var methods []*model.Method
+	for _, m := range interfaceModel.Methods {
+		if selected[m.Name] {
+			methods = append(methods, m)
+		}
+	}
+	return methods, len(methods) < len(interfaceModel.Methods), nil
+}
+
+// selectMethods restricts template data to the methods a decorator implements itself
+// It sets .Partial when the other methods are served by the embedded interface
+func selectMethods(data *TemplateData, interfaceModel *model.Interface, options Options) error {
+	methods, partial, err := decoratedMethods(interfaceModel, options)
+	if err != nil {
+		return err
+	}
+	data.Methods = methods
+	data.Partial = partial
+	return nil
+}
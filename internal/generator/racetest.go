@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// raceTemplate is the name of the template block building a decorator for the concurrency test
+// It renders statements declaring "decorated" from "underlying", and may call t.Fatal
+const raceTemplate = "race"
+
+// raceDecorator is a decorator exercised by the concurrency test
+type raceDecorator struct {
+	// Type is the decorator type, used as the subtest name
+	Type DecoratorType
+
+	// Setup declares the decorated value
+	Setup string
+}
+
+// GenerateRaceTest generates a test calling every method of the generated decorators from several goroutines
+// Decorators whose template has no race block are left out, the test skips itself when none is left
+func (g *Generator) GenerateRaceTest(
+	interfaceModel *model.Interface,
+	decoratorTypes []DecoratorType,
+	outputPackage string,
+	outputPath string,
+	options map[DecoratorType]Options,
+) error {
+	return g.generateDecoratorTest("race test", g.raceTest, raceTemplate, interfaceModel, decoratorTypes, outputPackage, outputPath, options)
+}
+
+// generateDecoratorTest generates a test exercising the decorators set up by the block of their templates,
+// or by their race block when a template does not define it
+// A block rendering nothing leaves out a decorator the test does not apply to
+func (g *Generator) generateDecoratorTest(
+	kind string,
+	test *template.Template,
+	block string,
+	interfaceModel *model.Interface,
+	decoratorTypes []DecoratorType,
+	outputPackage string,
+	outputPath string,
+	options map[DecoratorType]Options,
+) error {
+	data := &raceTestData{TemplateData: templateData(interfaceModel, outputPackage, nil, "")}
+
+	imports, err := resolveImports(test, data, interfaceModel)
+	if err != nil {
+		return fmt.Errorf("failed to resolve imports: %w", err)
+	}
+
+	var decorators []raceDecorator
+	var local []string
+	for _, dt := range decoratorTypes {
+		tmpl, ok := g.templates[dt]
+		if !ok {
+			return fmt.Errorf("unknown decorator type: %s", dt)
+		}
+		setup := tmpl.Lookup(block)
+		if setup == nil {
+			setup = tmpl.Lookup(raceTemplate)
+		}
+		if setup == nil {
+			continue
+		}
+
+		// Providers are not needed to build the decorator
+		decoratorData := templateData(interfaceModel, outputPackage, options[dt], "")
+		if err := selectMethods(decoratorData, interfaceModel, options[dt]); err != nil {
+			return err
+		}
+		if err := setStyle(decoratorData, dt, interfaceModel, options[dt]); err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := execute(setup, &buf, decoratorData); err != nil {
+			return newTemplateError(dt, tmpl, interfaceModel, decoratorData, decoratorData, err)
+		}
+		if strings.TrimSpace(buf.String()) == "" {
+			continue
+		}
+
+		decoratorImports, err := resolveImports(tmpl, decoratorData, interfaceModel)
+		if err != nil {
+			return fmt.Errorf("failed to resolve imports: %w", err)
+		}
+		imports = mergeImports(imports, decoratorImports)
+		local = append(local, options[dt].Local()...)
+		decorators = append(decorators, raceDecorator{Type: dt, Setup: buf.String()})
+	}
+
+	data.ImportSpecs = groupImports(imports, local)
+	data.Decorators = decorators
+
+	var buf bytes.Buffer
+	if err := execute(test, &buf, data); err != nil {
+		return newTemplateError(DecoratorType(kind), test, interfaceModel, data, data.TemplateData, err)
+	}
+
+	code := buf.Bytes()
+	if pruned, err := pruneImports(code); err == nil {
+		code = pruned
+	}
+
+	formattedCode, err := format.Source(code)
+	if err != nil {
+		return fmt.Errorf("failed to format generated %s: %w", kind, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := writeFile(outputPath, formattedCode); err != nil {
+		return fmt.Errorf("failed to write generated %s: %w", kind, err)
+	}
+
+	return nil
+}
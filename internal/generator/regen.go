@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"bytes"
+	"strings"
+)
+
+// ArgsHeaderPrefix starts the header line of generated files holding the arguments they were generated with
+const ArgsHeaderPrefix = "// decogen args: "
+
+// Args returns the command-line arguments recorded with the "args" option
+// They are written to the header of the generated file, so that decogen regen can generate it again
+func (o Options) Args() string {
+	args, _ := o["args"].(string)
+	return strings.TrimSpace(args)
+}
+
+// addArgsHeader appends the line recording the arguments to the header comments of generated code
+func addArgsHeader(code []byte, args string) []byte {
+	i := bytes.Index(code, []byte("\n\n"))
+	if i < 0 || !bytes.HasPrefix(code, []byte(generatedHeader)) {
+		return code
+	}
+	line := []byte(ArgsHeaderPrefix + args + "\n")
+	return bytes.Join([][]byte{code[:i+1], line, code[i+1:]}, nil)
+}
+
+// ArgsFromHeader returns the arguments recorded in the header of a generated file
+func ArgsFromHeader(code []byte) (string, bool) {
+	if !bytes.HasPrefix(code, []byte(generatedHeader)) {
+		return "", false
+	}
+	for _, line := range strings.SplitN(string(code), "\n", 5) {
+		if args, ok := strings.CutPrefix(strings.TrimSpace(line), ArgsHeaderPrefix); ok {
+			return args, true
+		}
+	}
+	return "", false
+}
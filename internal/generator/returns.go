@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// returnWrapper is a result type the returns decorator wraps, such as a Transaction returned by Tx
+type returnWrapper struct {
+	// Field names the wrapping function in the generated wrappers struct
+	Field string
+
+	// Type is the result type as written in the interface
+	Type string
+}
+
+// wrappedResult is a result of a method the returns decorator wraps
+type wrappedResult struct {
+	// Var is the variable holding the result
+	Var string
+
+	// Field names the wrapping function in the generated wrappers struct
+	Field string
+}
+
+// Wrap returns the result types set with the "wrap" option, e.g. ["Transaction", "*Conn"]
+func (o Options) Wrap() []string {
+	var types []string
+	switch v := o["wrap"].(type) {
+	case []string:
+		types = v
+	case []interface{}:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	return types
+}
+
+// returnWrappers returns the result types the returns decorator wraps, with the fields of their wrapping functions
+// Each type must be returned by a method and be comparable to nil, such as an interface, a pointer or a function;
+// its field is named after the type without package qualifier, e.g. Transaction for *tx.Transaction
+func returnWrappers(options Options, methods []*model.Method) ([]returnWrapper, error) {
+	types := options.Wrap()
+	if len(types) == 0 {
+		return nil, fmt.Errorf("the returns decorator needs the wrap option listing the result types to wrap")
+	}
+
+	wrappers := make([]returnWrapper, 0, len(types))
+	fields := make(map[string]string, len(types))
+	for _, typ := range types {
+		field, ok := wrapperField(typ)
+		if !ok {
+			return nil, fmt.Errorf("wrap option: cannot name the wrapper of %s, declare a named type for it", typ)
+		}
+		if other, ok := fields[field]; ok {
+			return nil, fmt.Errorf("wrap option: %s and %s would share the wrapper %s", other, typ, field)
+		}
+		if !returnsType(methods, typ) {
+			return nil, fmt.Errorf("wrap option: no method returns %s", typ)
+		}
+		fields[field] = typ
+		wrappers = append(wrappers, returnWrapper{Field: field, Type: typ})
+	}
+	return wrappers, nil
+}
+
+// wrapperField returns the field of the wrapping function of a result type
+func wrapperField(typ string) (string, bool) {
+	name := strings.TrimPrefix(typ, "*")
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	if !token.IsIdentifier(name) {
+		return "", false
+	}
+	return strings.ToUpper(name[:1]) + name[1:], true
+}
+
+// wrappedResults returns the results of a method the returns decorator wraps
+func wrappedResults(options Options, m *model.Method) []wrappedResult {
+	var results []wrappedResult
+	for _, r := range m.Results {
+		for _, typ := range options.Wrap() {
+			if field, ok := wrapperField(typ); ok && r.Type == typ {
+				results = append(results, wrappedResult{Var: r.Name, Field: field})
+			}
+		}
+	}
+	return results
+}
+
+// returnsType reports whether a method has a result of the type
+func returnsType(methods []*model.Method, typ string) bool {
+	for _, m := range methods {
+		for _, r := range m.Results {
+			if r.Type == typ {
+				return true
+			}
+		}
+	}
+	return false
+}
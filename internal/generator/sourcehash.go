@@ -0,0 +1,34 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/komandakycto/decogen/internal/model"
+	"github.com/komandakycto/decogen/pkg/sourcehash"
+)
+
+// AssertSource reports whether the "assertSource" option is set
+// Decorators generated with it check at init that their interface did not change since generation
+func (o Options) AssertSource() bool {
+	enabled, _ := o["assertSource"].(bool)
+	return enabled
+}
+
+// CheckSourceHash reports whether a generated file was generated from the current declaration of an interface
+// It fails when the file has no source hash in its header or when the hash differs
+func CheckSourceHash(path string, interfaceModel *model.Interface) error {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read generated file: %w", err)
+	}
+
+	hash, ok := sourcehash.FromHeader(code)
+	if !ok {
+		return fmt.Errorf("%s has no source hash, regenerate it", path)
+	}
+	if hash != interfaceModel.SourceHash {
+		return fmt.Errorf("%s is stale: %s changed in %s since it was generated", path, interfaceModel.Name, interfaceModel.Source)
+	}
+	return nil
+}
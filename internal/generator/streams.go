@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// Settings of the retry "streams" option, for methods returning a channel or an iterator
+const (
+	// StreamsSkip delegates methods returning a stream without retries, the default
+	// Items fail after the call returns, where the decorator cannot retry them
+	StreamsSkip = "skip"
+	// StreamsOpen retries the call until it returns a stream; failures of its items are not retried
+	StreamsOpen = "open"
+)
+
+// Streams returns the setting of the "streams" option, StreamsSkip by default
+func (o Options) Streams() (string, error) {
+	streams, _ := o["streams"].(string)
+	switch streams {
+	case "", StreamsSkip:
+		return StreamsSkip, nil
+	case StreamsOpen:
+		return StreamsOpen, nil
+	default:
+		return "", fmt.Errorf("unknown streams setting %q: want %q or %q", streams, StreamsSkip, StreamsOpen)
+	}
+}
+
+// retrySkipsStream reports whether the retry decorator delegates a method returning a stream without retries
+func retrySkipsStream(options Options, m *model.Method) (bool, error) {
+	if !m.ReturnsStream() {
+		return false, nil
+	}
+	streams, err := options.Streams()
+	if err != nil {
+		return false, err
+	}
+	return streams == StreamsSkip, nil
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// Output styles of the "style" option
+const (
+	// StyleStruct exports the decorator struct and a New constructor returning it
+	StyleStruct = "struct"
+	// StyleFunctional keeps the decorator struct unexported behind a function returning the interface,
+	// such as func UserStorageWithRetry(next UserStorage, config retry.Config) UserStorage
+	StyleFunctional = "functional"
+)
+
+// Style returns the output style selected with the "style" option, StyleStruct by default
+func (o Options) Style() (string, error) {
+	style, _ := o["style"].(string)
+	switch style {
+	case "", StyleStruct:
+		return StyleStruct, nil
+	case StyleFunctional:
+		return StyleFunctional, nil
+	default:
+		return "", fmt.Errorf("unknown style %q: want %q or %q", style, StyleStruct, StyleFunctional)
+	}
+}
+
+// setStyle sets .Type, the name of the decorator struct, and .Functional in template data
+// Functional decorators are unexported structs named after the decorator, e.g. retryUserStorage
+func setStyle(data *TemplateData, dt DecoratorType, interfaceModel *model.Interface, options Options) error {
+	if dt == FakeDecorator {
+		return setFake(data, interfaceModel)
+	}
+
+	style, err := options.Style()
+	if err != nil {
+		return err
+	}
+
+	functional := style == StyleFunctional
+	if functional && data.DI != "" {
+		return fmt.Errorf("the %s style does not support dependency injection providers", style)
+	}
+
+	data.Functional = functional
+	if functional {
+		data.Type = string(dt) + interfaceModel.Name
+	} else {
+		data.Type = interfaceModel.Name + "With" + decoratorTitle(dt)
+	}
+	return nil
+}
+
+// decoratorTitle returns the decorator name as used in exported identifiers, e.g. Retry or LastGood
+func decoratorTitle(dt DecoratorType) string {
+	switch dt {
+	case LastGoodDecorator:
+		return "LastGood"
+	case ContextCheckDecorator:
+		return "ContextCheck"
+	}
+	return strings.ToUpper(string(dt[:1])) + string(dt[1:])
+}
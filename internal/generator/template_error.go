@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// TemplateError is returned when a decorator template fails to render
+type TemplateError struct {
+	// Decorator is the decorator whose template failed
+	Decorator DecoratorType
+
+	// Template, Line and Column locate the failing action, when text/template reports it
+	Template string
+	Line     int
+	Column   int
+
+	// Interface is the interface being decorated
+	Interface string
+
+	// Method is the method being rendered, empty when the failure is outside the methods
+	Method string
+
+	// Data is a short description of the template data the failure was found with
+	Data string
+
+	// Err is the underlying text/template error
+	Err error
+}
+
+// Error returns the error message
+func (e *TemplateError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s template", e.Decorator)
+	if e.Template != "" {
+		fmt.Fprintf(&b, " (%s:%d", e.Template, e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, ":%d", e.Column)
+		}
+		b.WriteString(")")
+	}
+	fmt.Fprintf(&b, " failed for %s", e.Interface)
+	if e.Method != "" {
+		fmt.Fprintf(&b, ".%s", e.Method)
+	}
+	// The location is already reported above
+	fmt.Fprintf(&b, ": %s", locationPattern.ReplaceAllString(e.Err.Error(), ""))
+	if e.Data != "" {
+		fmt.Fprintf(&b, "\n%s", e.Data)
+	}
+	return b.String()
+}
+
+// Unwrap returns the text/template error
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// locationPattern matches the location text/template puts in front of its messages
+var locationPattern = regexp.MustCompile(`template: ([^:\s]+):(\d+)(?::(\d+))?: `)
+
+// execute runs a template, turning panics of the functions it calls into errors
+func execute(tmpl *template.Template, w io.Writer, data interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while rendering: %v", r)
+		}
+	}()
+	return tmpl.Execute(w, data)
+}
+
+// newTemplateError describes a failed execution of a decorator template with root, the data holding data
+// The failing method is found by rendering without methods, then with each method alone
+func newTemplateError(dt DecoratorType, tmpl *template.Template, iface *model.Interface, root interface{}, data *TemplateData, err error) *TemplateError {
+	templateErr := &TemplateError{
+		Decorator: dt,
+		Interface: iface.Name,
+		Err:       err,
+	}
+
+	if match := locationPattern.FindStringSubmatch(err.Error()); match != nil {
+		templateErr.Template = match[1]
+		templateErr.Line, _ = strconv.Atoi(match[2])
+		templateErr.Column, _ = strconv.Atoi(match[3])
+	}
+
+	methods := data.Methods
+	defer func() { data.Methods = methods }()
+
+	data.Methods = []*model.Method{}
+	if execute(tmpl, io.Discard, root) != nil {
+		templateErr.Data = fmt.Sprintf("\tmethods: %d\n\toptions: %v", len(iface.Methods), data.Options)
+		return templateErr
+	}
+	for _, m := range iface.Methods {
+		data.Methods = []*model.Method{m}
+		if execute(tmpl, io.Discard, root) != nil {
+			templateErr.Method = m.Name
+			templateErr.Data = fmt.Sprintf("\tmethod: %s\n\toptions: %v", m.FormatMethodSignature(), data.Options)
+			return templateErr
+		}
+	}
+
+	templateErr.Data = fmt.Sprintf("\tmethods: %d\n\toptions: %v", len(iface.Methods), data.Options)
+	return templateErr
+}
@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// TemplateDataVersion is the version of the TemplateData contract
+//
+// Compatibility policy: within a version, fields are only added, never renamed, removed or given
+// another type or meaning; any such change increments the version. Templates relying on fields
+// added later can check .Version, as an older decogen fails on the unknown field.
+const TemplateDataVersion = 1
+
+// TemplateData is the data decorator templates are executed with
+type TemplateData struct {
+	// Version is TemplateDataVersion
+	Version int
+
+	// PackageName is the package of the generated file
+	PackageName string
+
+	// Name is the name of the decorated interface
+	Name string
+
+	// Type is the name of the decorator struct, e.g. UserStorageWithRetry, or retryUserStorage in the functional style
+	Type string
+
+	// Methods are the methods the decorator implements itself, in declaration order
+	Methods []*model.Method
+
+	// Partial is set when Methods is a subset of the interface methods and the decorator embeds the interface
+	Partial bool
+
+	// Functional is set when the decorator is built by functions returning the interface rather than exported
+	// constructors returning the struct
+	Functional bool
+
+	// Imports maps the package names used in the interface source file to their import paths
+	Imports map[string]string
+
+	// ImportSpecs are the imports of the generated file, grouped and sorted
+	ImportSpecs []Import
+
+	// Comments is the doc comment of the interface
+	Comments string
+
+	// Source is the base name of the file declaring the interface
+	Source string
+
+	// SourceHash is the sourcehash.Sum of the interface declaration, empty when unknown
+	SourceHash string
+
+	// Options are the settings of the decorator
+	Options Options
+
+	// DI is the dependency injection framework to emit providers for, empty for none
+	DI string
+}
+
+// methodNamesData is the data of the method names template
+type methodNamesData struct {
+	*TemplateData
+
+	// Style is the "methodNames" style
+	Style string
+}
+
+// raceTestData is the data of the concurrency test template
+type raceTestData struct {
+	*TemplateData
+
+	// Decorators are the decorators the test exercises
+	Decorators []raceDecorator
+}
+
+// templateData returns the data templates are executed with
+func templateData(interfaceModel *model.Interface, outputPackage string, options Options, di string) *TemplateData {
+	return &TemplateData{
+		Version:     TemplateDataVersion,
+		PackageName: outputPackage,
+		Name:        interfaceModel.Name,
+		Methods:     interfaceModel.Methods,
+		Imports:     interfaceModel.Imports,
+		Comments:    interfaceModel.Comments,
+		Source:      interfaceModel.Source,
+		SourceHash:  interfaceModel.SourceHash,
+		Options:     options,
+		DI:          di,
+	}
+}
@@ -0,0 +1,92 @@
+package generator_test
+
+import (
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/internal/generator"
+	"github.com/komandakycto/decogen/internal/parser"
+)
+
+// storeSource declares the interface the template tests generate decorators for
+const storeSource = `package store
+
+import "context"
+
+// Store keeps values by ID
+type Store interface {
+	Get(ctx context.Context, id string) (string, error)
+	Save(ctx context.Context, id string, value string) error
+	Len() int
+}
+`
+
+// generateStore generates a decorator of Store and returns its code, which must parse
+func generateStore(t *testing.T, dt generator.DecoratorType, options generator.Options) string {
+	t.Helper()
+
+	output := filepath.Join(t.TempDir(), "store_"+string(dt)+".go")
+	require.NoError(t, generateStoreTo(t, output, dt, options))
+
+	code, err := os.ReadFile(output)
+	require.NoError(t, err)
+	_, err = goparser.ParseFile(token.NewFileSet(), output, code, goparser.AllErrors)
+	require.NoError(t, err)
+	return string(code)
+}
+
+// generateStoreTo generates a decorator of Store to output
+func generateStoreTo(t *testing.T, output string, dt generator.DecoratorType, options generator.Options) error {
+	t.Helper()
+
+	source := filepath.Join(t.TempDir(), "store.go")
+	require.NoError(t, os.WriteFile(source, []byte(storeSource), 0644))
+	iface, err := parser.ParseInterface(source, "Store")
+	require.NoError(t, err)
+
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err)
+	return gen.Generate(iface, []generator.DecoratorType{dt}, "store", output, map[generator.DecoratorType]generator.Options{dt: options})
+}
+
+func TestRetryTemplate(t *testing.T) {
+	code := generateStore(t, generator.RetryDecorator, nil)
+
+	require.Contains(t, code, `"github.com/komandakycto/decogen/pkg/decorators/retry"`)
+	require.Contains(t, code, "retry.DoWithValue(ctx,", "Get should retry returning its value")
+	require.Contains(t, code, "retry.Do(ctx,", "Save should retry")
+	require.Contains(t, code, "return r.underlying.Len()", "Len returns no error and should not retry")
+}
+
+func TestDIProviders(t *testing.T) {
+	t.Run("wire", func(t *testing.T) {
+		code := generateStore(t, generator.RetryDecorator, generator.Options{"di": generator.DIWire})
+		require.Contains(t, code, `"github.com/google/wire"`)
+		require.Contains(t, code, "var StoreRetrySet = wire.NewSet(NewStoreWithRetry)")
+
+		code = generateStore(t, generator.DedupeDecorator, generator.Options{"di": generator.DIWire})
+		require.Contains(t, code, "var StoreDedupeSet = wire.NewSet(NewStoreWithDedupe)")
+	})
+
+	t.Run("fx", func(t *testing.T) {
+		code := generateStore(t, generator.RetryDecorator, generator.Options{"di": generator.DIFx})
+		require.Contains(t, code, `"go.uber.org/fx"`)
+		require.Contains(t, code, "func ProvideStoreWithRetry(")
+		require.Contains(t, code, "var StoreRetryModule = fx.Decorate(ProvideStoreWithRetry)")
+	})
+
+	t.Run("none", func(t *testing.T) {
+		code := generateStore(t, generator.RetryDecorator, nil)
+		require.NotContains(t, code, "ProvideStoreWithRetry")
+	})
+
+	t.Run("unknown framework", func(t *testing.T) {
+		err := generateStoreTo(t, filepath.Join(t.TempDir(), "store_retry.go"), generator.RetryDecorator, generator.Options{"di": "dig"})
+		require.ErrorContains(t, err, "unknown dependency injection framework: dig")
+	})
+}
@@ -0,0 +1,30 @@
+package annotated
+
+import (
+	"context"
+)
+
+// Profile is returned by Profiles
+type Profile struct {
+	ID   string
+	Name string
+}
+
+// Profiles keeps per-method decorator parameters next to the methods
+type Profiles interface {
+	// Get reads a profile
+	//decogen:retry max_attempts=5 backoff=exp(50ms,5s)
+	//decogen:cache ttl=30s negative_ttl=5s key="profile: {{.id}}"
+	//decogen:observability log_successes=100 trace_ratio=0.01
+	Get(ctx context.Context, id string) (*Profile, error)
+
+	// Update writes a profile
+	//decogen:retry policy=writes max_elapsed=10s idempotent=false
+	//decogen:observability redact=profile
+	//decogen:cache invalidates=Get(profile.ID)
+	Update(ctx context.Context, profile Profile) error
+
+	// Count is reported as profiles.count
+	//decogen:name profiles.count
+	Count(ctx context.Context) (int, error) //decogen:cache ttl=1h error_ttl=1m
+}
@@ -0,0 +1,24 @@
+package clock
+
+import (
+	stdlog "log"
+	"strings"
+	"time"
+)
+
+// Clock has no context parameters and uses aliased imports
+type Clock interface {
+	Sleep(d time.Duration) error
+	Logger() (*stdlog.Logger, error)
+	Now() time.Time
+}
+
+// upper uses an import the generated code does not need
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+// Names does not need any template import besides its decorator package
+type Names interface {
+	Name() string
+}
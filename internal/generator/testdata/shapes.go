@@ -0,0 +1,57 @@
+package shapes
+
+import (
+	"context"
+)
+
+// Stats is returned by Shapes
+type Stats struct {
+	Calls int
+}
+
+// Shapes declares a method for every result shape decorators must handle
+type Shapes interface {
+	// Close returns nothing
+	Close()
+
+	// Snapshot returns a value without an error
+	Snapshot(ctx context.Context) Stats
+
+	// Bounds returns several values without an error
+	Bounds(ctx context.Context) (int, int)
+
+	// LastError returns an error that is not the trailing result
+	LastError(ctx context.Context) (error, bool)
+
+	// Ping only returns an error
+	Ping(ctx context.Context) error
+
+	// Load returns a value and an error
+	Load(ctx context.Context, id string) (Stats, error)
+
+	// Range returns several values and an error
+	Range(ctx context.Context) (int, int, error)
+
+	// Audit returns a value, an error that is not the trailing result and an error
+	Audit(ctx context.Context) (bool, error, error)
+
+	// Window returns three values and an error
+	Window(ctx context.Context) (Stats, int, bool, error)
+
+	// Quartiles returns four values and an error
+	Quartiles(ctx context.Context) (int, int, int, int, error)
+
+	// Triple returns named results
+	Triple(ctx context.Context) (a, b string, c int, err error)
+
+	// Len returns a single named result
+	Len(ctx context.Context) (n int)
+
+	// Refresh panics on failure
+	//decogen:retry panics=true max_attempts=5
+	Refresh(ctx context.Context)
+
+	// Current panics on failure and returns values
+	//decogen:retry panics=true
+	Current() (Stats, bool)
+}
@@ -0,0 +1,31 @@
+package storage
+
+import "context"
+
+// User is a stored user
+type User struct {
+	ID string
+}
+
+// IdempotencyKey makes users usable as dedupe keys
+func (u User) IdempotencyKey() string {
+	return u.ID
+}
+
+// UserStorage stores users
+type UserStorage interface {
+	// Get returns a user by ID
+	Get(ctx context.Context, id string) (*User, error)
+
+	// Save stores a user
+	Save(ctx context.Context, user User) error
+
+	// Search returns matching users and the total count
+	Search(ctx context.Context, query string, offset, limit int) ([]User, int, error)
+
+	// Ping checks the connection
+	Ping() error
+
+	// Name returns the name of the storage
+	Name() string
+}
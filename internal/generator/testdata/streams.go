@@ -0,0 +1,23 @@
+package streams
+
+import (
+	"context"
+	"iter"
+)
+
+// Event is delivered by Events
+type Event struct {
+	ID string
+}
+
+// Events declares methods returning streams, whose items arrive after the call returns
+type Events interface {
+	// Subscribe returns the events of a topic as they are published
+	Subscribe(ctx context.Context, topic string) (<-chan Event, error)
+
+	// History iterates over the past events of a topic
+	History(ctx context.Context, topic string) iter.Seq2[Event, error]
+
+	// Publish sends an event
+	Publish(ctx context.Context, topic string, event Event) error
+}
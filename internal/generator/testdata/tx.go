@@ -0,0 +1,25 @@
+package tx
+
+import "context"
+
+// Transaction runs queries until it is committed or rolled back
+type Transaction interface {
+	Exec(ctx context.Context, query string) error
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// Hook is called after a transaction is committed
+type Hook func(ctx context.Context) error
+
+// DB opens transactions
+type DB interface {
+	// Begin starts a transaction
+	Begin(ctx context.Context) (Transaction, error)
+
+	// AfterCommit returns the hook registered under a name
+	AfterCommit(name string) Hook
+
+	// Ping checks the connection
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,14 @@
+package generator
+
+import "github.com/komandakycto/decogen/internal/model"
+
+// hasMethod reports whether one of the methods has the given name
+// Templates use it to leave out helper methods that would clash with the interface, such as Unwrap
+func hasMethod(methods []*model.Method, name string) bool {
+	for _, m := range methods {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Origin describes what produced a generated file
+type Origin struct {
+	// Decorator is the template that produced the file
+	Decorator DecoratorType
+
+	// Interface is the decorated interface
+	Interface string
+
+	// Source is the file declaring the interface
+	Source string
+}
+
+// Diagnostic is a compiler message about a generated file
+type Diagnostic struct {
+	// File, Line and Column locate the message in the generated file
+	File   string
+	Line   int
+	Column int
+
+	// Message is the compiler message
+	Message string
+
+	// Origin is the template and interface that produced the file
+	Origin Origin
+
+	// Method is the interface method whose generated code contains the message, if any
+	Method string
+}
+
+// String formats the diagnostic with its origin
+func (d Diagnostic) String() string {
+	target := d.Origin.Interface
+	if d.Method != "" {
+		target += "." + d.Method
+	}
+	return fmt.Sprintf("%s:%d:%d: %s (%s template for %s declared in %s)",
+		d.File, d.Line, d.Column, d.Message, d.Origin.Decorator, target, d.Origin.Source)
+}
+
+// VerifyError is returned when generated code does not compile
+type VerifyError struct {
+	// Diagnostics are the messages about generated files
+	Diagnostics []Diagnostic
+
+	// Output is the full output of the check
+	Output string
+}
+
+// Error returns the error message
+func (e *VerifyError) Error() string {
+	lines := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		lines[i] = d.String()
+	}
+	return "generated code does not compile:\n" + strings.Join(lines, "\n")
+}
+
+// diagnosticPattern matches "file.go:line:col: message" lines of go build output
+var diagnosticPattern = regexp.MustCompile(`^(.+?\.go):(\d+):(\d+): (.*)$`)
+
+// funcPattern matches generated method declarations and captures the method name
+var funcPattern = regexp.MustCompile(`^func \([^)]*\) (\w+)\(`)
+
+// Verify type-checks the package in dir with go build
+// Diagnostics in generated files are mapped back to the template and interface that produced them,
+// errors located in other files of the package are not reported
+// origins is keyed by the path of each generated file
+func Verify(dir string, origins map[string]Origin) error {
+	// -e reports every error rather than the first ten, the package is compiled and discarded
+	cmd := exec.Command("go", "build", "-gcflags=-e", "-o", os.DevNull, ".")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*exec.ExitError); !ok {
+		return fmt.Errorf("failed to run go build: %w", err)
+	}
+
+	absOrigins := make(map[string]Origin, len(origins))
+	for path, origin := range origins {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		absOrigins[pathKey(abs)] = origin
+	}
+
+	verifyErr := &VerifyError{Output: string(output)}
+	located := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		match := diagnosticPattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		located = true
+
+		file := match[1]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		file, _ = filepath.Abs(file)
+
+		origin, ok := absOrigins[pathKey(file)]
+		if !ok {
+			continue // Not a generated file
+		}
+
+		line, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		verifyErr.Diagnostics = append(verifyErr.Diagnostics, Diagnostic{
+			File:    file,
+			Line:    line,
+			Column:  column,
+			Message: match[4],
+			Origin:  origin,
+			Method:  enclosingMethod(file, line),
+		})
+	}
+
+	if !located {
+		// The package could not be built, e.g. for a missing module, without telling anything of the generated code
+		return fmt.Errorf("failed to type-check %s: %s", dir, strings.TrimSpace(string(output)))
+	}
+	if len(verifyErr.Diagnostics) == 0 {
+		return nil
+	}
+	return verifyErr
+}
+
+// pathKey returns the key of a cleaned absolute path in the origins of Verify
+// Paths are case-insensitive on Windows, where go build may not report the drive letter in the case filepath.Abs returns
+func pathKey(path string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// enclosingMethod returns the name of the method declared above the given line of a generated file
+func enclosingMethod(file string, line int) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if line > len(lines) {
+		line = len(lines)
+	}
+	for i := line - 1; i >= 0; i-- {
+		if match := funcPattern.FindStringSubmatch(lines[i]); match != nil {
+			return match[1]
+		}
+		if strings.HasPrefix(lines[i], "}") && i < line-1 {
+			return "" // Outside of any method
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/komandakycto/decogen/internal/model"
+)
+
+// Cache decorators have a Warm method calling the cached methods with the arguments listed
+// in a generated keys struct, which holds per method the argument of a single-parameter method,
+// an args struct for methods with more parameters, or a bool for methods without parameters
+
+// warmParams returns the parameters listed to warm a method, all but the context
+func warmParams(m *model.Method) []*model.Parameter {
+	var params []*model.Parameter
+	for _, p := range m.Parameters {
+		if p.Type != "context.Context" {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// warmCall returns the statement calling a method to warm its cache, e.g. _, err := c.Get(ctx, key)
+// The context is passed as ctx and the other parameters are read from value, or its fields
+// when the method has several of them
+func warmCall(m *model.Method, receiver, value string) string {
+	params := warmParams(m)
+
+	var args []string
+	for _, p := range m.Parameters {
+		arg := value
+		switch {
+		case p.Type == "context.Context":
+			arg = "ctx"
+		case len(params) > 1:
+			arg = value + "." + p.FieldName()
+		}
+		if strings.HasPrefix(p.Type, "...") {
+			arg += "..."
+		}
+		args = append(args, arg)
+	}
+
+	blanks := strings.Repeat("_, ", len(m.Results)-1)
+	return fmt.Sprintf("%serr := %s.%s(%s)", blanks, receiver, m.Name, strings.Join(args, ", "))
+}
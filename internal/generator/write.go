@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// unformattedSuffix is appended to the output path to keep code that gofmt rejected
+// The file is not a .go file, so it cannot break the build of the package
+const unformattedSuffix = ".unformatted"
+
+// defaultFileMode is the mode of generated files that did not exist before
+const defaultFileMode fs.FileMode = 0644
+
+// writeFile atomically replaces the file at path with data
+// The data is written to a temporary file in the same directory and renamed into place,
+// so readers never see a truncated file. An existing file keeps its permissions.
+func writeFile(path string, data []byte) (err error) {
+	mode := defaultFileMode
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// A leading dot keeps the go tool from building a temporary file left behind by a crash
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
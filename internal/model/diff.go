@@ -0,0 +1,86 @@
+package model
+
+import (
+	"strings"
+)
+
+// InterfaceDiff lists the methods that differ between two versions of an interface
+type InterfaceDiff struct {
+	Interface string         `json:"interface"`
+	Added     []MethodChange `json:"added"`
+	Removed   []MethodChange `json:"removed"`
+	Changed   []MethodChange `json:"changed"`
+
+	// Breaking is set when callers of the old version may fail to compile against the new one
+	// Added methods are not counted: they break implementations, not callers
+	Breaking bool `json:"breaking"`
+}
+
+// MethodChange describes a method that was added, removed or whose signature changed
+// Old is empty for added methods and New for removed ones
+type MethodChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// Diff compares an interface with a later version of it
+// A method changed when the types of its parameters or results changed; renaming parameters is not a change
+// Added and changed methods are in the order of to, removed ones in the order of from
+func Diff(from, to *Interface) *InterfaceDiff {
+	diff := &InterfaceDiff{
+		Interface: to.Name,
+		Added:     []MethodChange{},
+		Removed:   []MethodChange{},
+		Changed:   []MethodChange{},
+	}
+
+	oldMethods := make(map[string]*Method, len(from.Methods))
+	for _, m := range from.Methods {
+		oldMethods[m.Name] = m
+	}
+	newMethods := make(map[string]*Method, len(to.Methods))
+	for _, m := range to.Methods {
+		newMethods[m.Name] = m
+	}
+
+	for _, m := range to.Methods {
+		prev, ok := oldMethods[m.Name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, MethodChange{Name: m.Name, New: m.Signature()})
+		case prev.typeSignature() != m.typeSignature():
+			diff.Changed = append(diff.Changed, MethodChange{Name: m.Name, Old: prev.Signature(), New: m.Signature()})
+		}
+	}
+	for _, m := range from.Methods {
+		if _, ok := newMethods[m.Name]; !ok {
+			diff.Removed = append(diff.Removed, MethodChange{Name: m.Name, Old: m.Signature()})
+		}
+	}
+
+	diff.Breaking = len(diff.Removed) > 0 || len(diff.Changed) > 0
+	return diff
+}
+
+// Empty reports whether both versions declare the same methods
+func (d *InterfaceDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Signature returns the method signature as declared, without trailing space
+func (m *Method) Signature() string {
+	return strings.TrimSpace(m.FormatMethodSignature())
+}
+
+// typeSignature returns the parameter and result types of the method
+func (m *Method) typeSignature() string {
+	var params, results []string
+	for _, p := range m.Parameters {
+		params = append(params, p.Type)
+	}
+	for _, r := range m.Results {
+		results = append(results, r.Type)
+	}
+	return "(" + strings.Join(params, ",") + ")(" + strings.Join(results, ",") + ")"
+}
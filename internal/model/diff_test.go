@@ -0,0 +1,57 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func method(name string, params []string, results ...string) *Method {
+	m := &Method{Name: name}
+	for i, p := range params {
+		m.Parameters = append(m.Parameters, &Parameter{Name: string(rune('a' + i)), Type: p})
+	}
+	for i, r := range results {
+		m.Results = append(m.Results, &Parameter{Name: "result" + string(rune('0'+i)), Type: r})
+	}
+	return m
+}
+
+func TestDiff(t *testing.T) {
+	old := &Interface{
+		Name: "Storage",
+		Methods: []*Method{
+			method("Get", []string{"context.Context", "string"}, "*User", "error"),
+			method("Save", []string{"context.Context", "User"}, "error"),
+			method("Ping", nil, "error"),
+		},
+	}
+	renamed := method("Save", []string{"context.Context", "User"}, "error")
+	renamed.Parameters[1].Name = "user"
+	updated := &Interface{
+		Name: "Storage",
+		Methods: []*Method{
+			method("Get", []string{"context.Context", "int"}, "*User", "error"),
+			renamed,
+			method("Count", []string{"context.Context"}, "int", "error"),
+		},
+	}
+
+	diff := Diff(old, updated)
+	require.Equal(t, &InterfaceDiff{
+		Interface: "Storage",
+		Added:     []MethodChange{{Name: "Count", New: "Count(a context.Context) (int, error)"}},
+		Removed:   []MethodChange{{Name: "Ping", Old: "Ping() error"}},
+		Changed: []MethodChange{{
+			Name: "Get",
+			Old:  "Get(a context.Context, b string) (*User, error)",
+			New:  "Get(a context.Context, b int) (*User, error)",
+		}},
+		Breaking: true,
+	}, diff)
+	require.False(t, diff.Empty())
+
+	same := Diff(old, old)
+	require.True(t, same.Empty())
+	require.False(t, same.Breaking)
+}
@@ -12,6 +12,12 @@ type Interface struct {
 	Methods     []*Method
 	Comments    string
 	Imports     map[string]string
+
+	// Source is the base name of the file declaring the interface
+	Source string
+
+	// SourceHash is the sourcehash.Sum of the interface declaration
+	SourceHash string
 }
 
 // Method represents a method in an interface
@@ -20,30 +26,71 @@ type Method struct {
 	Parameters []*Parameter
 	Results    []*Parameter
 	Comments   string
+
+	// Annotations holds the parameters of "//decogen:<decorator> key=value" comments, keyed by decorator
+	Annotations map[string]map[string]string
+
+	// Alias is the name set by a "//decogen:name" comment, empty when the method has none
+	Alias string
+
+	// NamedResults is set when the source names the results, as in "(items []Item, err error)"
+	NamedResults bool
 }
 
 // Parameter represents a parameter or result in a method
 type Parameter struct {
 	Name string
 	Type string
+
+	// Grouped is set when the source declares the parameter together with the next one, as in "offset, limit int"
+	Grouped bool
+}
+
+// FieldName returns the name of the struct field holding the parameter or result
+func (p *Parameter) FieldName() string {
+	if p.Name == "" {
+		return ""
+	}
+	return strings.ToUpper(p.Name[:1]) + p.Name[1:]
+}
+
+// VarType returns the type of the parameter inside the method body, where variadic parameters are slices
+func (p *Parameter) VarType() string {
+	if elem, ok := strings.CutPrefix(p.Type, "..."); ok {
+		return "[]" + elem
+	}
+	return p.Type
 }
 
 // FormatMethodSignature formats a method signature for code generation
 func (m *Method) FormatMethodSignature() string {
 	var params []string
-	for _, p := range m.Parameters {
+	for i, p := range m.Parameters {
+		// Keep the grouping of the source so the signature reads as hand-written
+		if p.Grouped && i+1 < len(m.Parameters) && m.Parameters[i+1].Type == p.Type {
+			params = append(params, p.Name)
+			continue
+		}
 		params = append(params, fmt.Sprintf("%s %s", p.Name, p.Type))
 	}
 
+	// Results are named and grouped as the source declares them
 	var results []string
-	for _, r := range m.Results {
-		results = append(results, r.Type)
+	for i, r := range m.Results {
+		switch {
+		case !m.NamedResults:
+			results = append(results, r.Type)
+		case r.Grouped && i+1 < len(m.Results) && m.Results[i+1].Type == r.Type:
+			results = append(results, r.Name)
+		default:
+			results = append(results, fmt.Sprintf("%s %s", r.Name, r.Type))
+		}
 	}
 
 	resultStr := ""
-	if len(results) == 1 {
+	if len(results) == 1 && !m.NamedResults {
 		resultStr = results[0]
-	} else if len(results) > 1 {
+	} else if len(results) > 0 {
 		resultStr = fmt.Sprintf("(%s)", strings.Join(results, ", "))
 	}
 
@@ -54,12 +101,42 @@ func (m *Method) FormatMethodSignature() string {
 func (m *Method) FormatMethodCall() string {
 	var params []string
 	for _, p := range m.Parameters {
+		// Variadic arguments are passed on as they were received
+		if strings.HasPrefix(p.Type, "...") {
+			params = append(params, p.Name+"...")
+			continue
+		}
 		params = append(params, p.Name)
 	}
 
 	return fmt.Sprintf("%s(%s)", m.Name, strings.Join(params, ", "))
 }
 
+// FormatParamNames formats the parameter names as a comma-separated list
+func (m *Method) FormatParamNames() string {
+	var names []string
+	for _, p := range m.Parameters {
+		names = append(names, p.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// FormatResultAssignment formats the left-hand side of an assignment of all results
+// The trailing error result is assigned to errorVar
+func (m *Method) FormatResultAssignment(errorVar string) string {
+	var names []string
+	for i, r := range m.Results {
+		if m.isErrorResult(i) {
+			names = append(names, errorVar)
+		} else {
+			names = append(names, r.Name)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
 // HasReturnValue checks if the method has at least one return value
 func (m *Method) HasReturnValue() bool {
 	return len(m.Results) > 0
@@ -82,8 +159,8 @@ func (m *Method) FormatResultDeclarations() string {
 	}
 
 	var decls []string
-	for _, r := range m.Results {
-		if r.Type == "error" {
+	for i, r := range m.Results {
+		if m.isErrorResult(i) {
 			continue // We'll handle errors separately
 		}
 		decls = append(decls, fmt.Sprintf("var %s %s", r.Name, r.Type))
@@ -96,6 +173,39 @@ func (m *Method) FormatResultDeclarations() string {
 	return strings.Join(decls, "\n\t")
 }
 
+// FormatLocalDeclarations generates variable declarations for the results a method body assigns
+// Named results are declared by the signature already
+func (m *Method) FormatLocalDeclarations() string {
+	if m.NamedResults {
+		return ""
+	}
+	return m.FormatResultDeclarations()
+}
+
+// FormatDefinition formats the start of a short variable declaration of lhs, a comma-separated list of names,
+// or of an assignment when the signature declares every name as a result
+func (m *Method) FormatDefinition(lhs string) string {
+	for _, name := range strings.Split(lhs, ", ") {
+		if !m.isNamedResult(name) {
+			return lhs + " :="
+		}
+	}
+	return lhs + " ="
+}
+
+// isNamedResult reports whether the signature declares a result with the given name
+func (m *Method) isNamedResult(name string) bool {
+	if !m.NamedResults {
+		return false
+	}
+	for _, r := range m.Results {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // FormatResultReturn formats the return statement
 func (m *Method) FormatResultReturn(errorVar string) string {
 	if !m.HasReturnValue() {
@@ -103,8 +213,8 @@ func (m *Method) FormatResultReturn(errorVar string) string {
 	}
 
 	var returns []string
-	for _, r := range m.Results {
-		if r.Type == "error" {
+	for i, r := range m.Results {
+		if m.isErrorResult(i) {
 			returns = append(returns, errorVar)
 		} else {
 			returns = append(returns, r.Name)
@@ -114,6 +224,102 @@ func (m *Method) FormatResultReturn(errorVar string) string {
 	return fmt.Sprintf("return %s", strings.Join(returns, ", "))
 }
 
+// Receiver returns the preferred receiver name, numbered when a parameter or named result already uses it
+func (m *Method) Receiver(preferred string) string {
+	taken := make(map[string]bool, len(m.Parameters)+len(m.Results))
+	for _, p := range m.Parameters {
+		taken[p.Name] = true
+	}
+	if m.NamedResults {
+		for _, r := range m.Results {
+			taken[r.Name] = true
+		}
+	}
+
+	name := preferred
+	for i := 1; taken[name]; i++ {
+		name = fmt.Sprintf("%s%d", preferred, i)
+	}
+	return name
+}
+
+// isErrorResult reports whether the result at index i is the trailing error
+// Errors returned before other results are values like any other
+func (m *Method) isErrorResult(i int) bool {
+	return i == len(m.Results)-1 && m.HasErrorReturn()
+}
+
+// ValueResults returns the results that are not the trailing error
+func (m *Method) ValueResults() []*Parameter {
+	if m.HasErrorReturn() {
+		return m.Results[:len(m.Results)-1]
+	}
+	return m.Results
+}
+
+// ReturnsStream reports whether a value result is a channel or an iterator, whose items arrive after the call returns
+func (m *Method) ReturnsStream() bool {
+	for _, r := range m.ValueResults() {
+		if isStreamType(r.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStreamType reports whether a type is a channel or an iter.Seq or iter.Seq2 iterator
+func isStreamType(t string) bool {
+	for _, prefix := range []string{"chan ", "<-chan ", "chan<- ", "iter.Seq[", "iter.Seq2["} {
+		if strings.HasPrefix(t, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatValueTypes formats the types of the value results as a comma-separated list
+func (m *Method) FormatValueTypes() string {
+	var types []string
+	for _, r := range m.ValueResults() {
+		types = append(types, r.Type)
+	}
+
+	return strings.Join(types, ", ")
+}
+
+// FormatFieldAssignment formats the left-hand side of an assignment of all results
+// Value results are assigned to the fields of structVar named by FieldName and the error to errorVar
+func (m *Method) FormatFieldAssignment(structVar, errorVar string) string {
+	var names []string
+	for i, r := range m.Results {
+		if m.isErrorResult(i) {
+			names = append(names, errorVar)
+		} else {
+			names = append(names, structVar+"."+r.FieldName())
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// FormatFieldReturn formats a return statement of the fields of structVar named by FieldName and errorVar
+func (m *Method) FormatFieldReturn(structVar, errorVar string) string {
+	return "return " + m.FormatFieldAssignment(structVar, errorVar)
+}
+
+// Annotation returns the parameters annotated for a decorator, or nil
+func (m *Method) Annotation(decorator string) map[string]string {
+	return m.Annotations[decorator]
+}
+
+// Op returns the name of the method in metrics, traces and errors: its alias, or "Interface.Method"
+func (m *Method) Op(iface string) string {
+	if m.Alias != "" {
+		return m.Alias
+	}
+	return iface + "." + m.Name
+}
+
 // FormatContextParam returns the context parameter name if one exists
 func (m *Method) FormatContextParam() string {
 	for _, p := range m.Parameters {
@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// DirectivePrefix marks an interface for bulk generation, e.g. "//decogen:decorate retry,cache"
+const DirectivePrefix = "//decogen:decorate"
+
+// AnnotationPrefix starts a method annotation setting decorator parameters for that method,
+// e.g. "//decogen:retry max_attempts=5 backoff=exp(50ms,5s)"
+const AnnotationPrefix = "//decogen:"
+
+// nameAnnotation is the annotation renaming a method in metrics, traces and errors, e.g. "//decogen:name user.fetch"
+const nameAnnotation = "name"
+
+// Directive is a request to generate decorators for an interface, found in its doc comment
+type Directive struct {
+	// Interface is the name of the annotated interface
+	Interface string
+
+	// Decorators are the decorator names listed in the directive
+	Decorators []string
+}
+
+// FindDirectives returns the directives of the annotated interfaces in a Go source file
+func FindDirectives(sourcePath string) ([]Directive, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, sourcePath, nil, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source file: %w", err)
+	}
+
+	var directives []Directive
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			if _, ok := typeSpec.Type.(*ast.InterfaceType); !ok {
+				continue
+			}
+
+			// A single spec takes the comment of its declaration
+			doc := typeSpec.Doc
+			if doc == nil && len(genDecl.Specs) == 1 {
+				doc = genDecl.Doc
+			}
+
+			decorators, found, err := parseDirective(doc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: interface %s: %w", fset.Position(typeSpec.Pos()), typeSpec.Name.Name, err)
+			}
+			if found {
+				directives = append(directives, Directive{
+					Interface:  typeSpec.Name.Name,
+					Decorators: decorators,
+				})
+			}
+		}
+	}
+
+	return directives, nil
+}
+
+// parseDirective extracts the decorator names from the directive line of a doc comment
+func parseDirective(doc *ast.CommentGroup) ([]string, bool, error) {
+	if doc == nil {
+		return nil, false, nil
+	}
+
+	for _, comment := range doc.List {
+		rest, ok := strings.CutPrefix(comment.Text, DirectivePrefix)
+		if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+
+		var decorators []string
+		for _, name := range strings.Split(strings.TrimSpace(rest), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				decorators = append(decorators, name)
+			}
+		}
+		if len(decorators) == 0 {
+			return nil, true, fmt.Errorf("directive lists no decorators")
+		}
+		return decorators, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// parseAnnotations collects the method annotations of the given comments by decorator,
+// and the name set by a name annotation
+// Values containing spaces may be quoted as Go strings, e.g. key="user: {{.id}}"
+func parseAnnotations(groups ...*ast.CommentGroup) (map[string]map[string]string, string, error) {
+	var annotations map[string]map[string]string
+	var name string
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+
+		for _, comment := range group.List {
+			rest, ok := strings.CutPrefix(comment.Text, AnnotationPrefix)
+			if !ok {
+				continue
+			}
+
+			decorator, args := rest, ""
+			if i := strings.IndexAny(rest, " \t"); i >= 0 {
+				decorator, args = rest[:i], rest[i+1:]
+			}
+			if decorator == "" {
+				return nil, "", fmt.Errorf("annotation %q names no decorator", comment.Text)
+			}
+
+			tokens, err := splitAnnotation(args)
+			if err != nil {
+				return nil, "", fmt.Errorf("annotation %q: %w", comment.Text, err)
+			}
+
+			if decorator == nameAnnotation {
+				if name != "" {
+					return nil, "", fmt.Errorf("annotation %q: method is already named %q", comment.Text, name)
+				}
+				if len(tokens) != 1 {
+					return nil, "", fmt.Errorf("annotation %q: expected a single name", comment.Text)
+				}
+				name = tokens[0]
+				if strings.HasPrefix(name, `"`) {
+					if name, err = strconv.Unquote(name); err != nil {
+						return nil, "", fmt.Errorf("annotation %q: %w", comment.Text, err)
+					}
+				}
+				continue
+			}
+
+			if annotations == nil {
+				annotations = make(map[string]map[string]string)
+			}
+			params := annotations[decorator]
+			if params == nil {
+				params = make(map[string]string)
+				annotations[decorator] = params
+			}
+			for _, token := range tokens {
+				key, value, ok := strings.Cut(token, "=")
+				if !ok || key == "" {
+					return nil, "", fmt.Errorf("annotation %q: expected key=value, got %q", comment.Text, token)
+				}
+				if _, exists := params[key]; exists {
+					return nil, "", fmt.Errorf("annotation %q: duplicate parameter %q", comment.Text, key)
+				}
+				if strings.HasPrefix(value, `"`) {
+					if value, err = strconv.Unquote(value); err != nil {
+						return nil, "", fmt.Errorf("annotation %q: parameter %s: %w", comment.Text, key, err)
+					}
+				}
+				params[key] = value
+			}
+		}
+	}
+
+	return annotations, name, nil
+}
+
+// splitAnnotation splits annotation parameters on spaces outside of quoted values
+func splitAnnotation(args string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	quoted, escaped := false, false
+	for _, r := range args {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t'):
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+			continue
+		}
+		token.WriteRune(r)
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quoted value")
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
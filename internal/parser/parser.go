@@ -5,10 +5,13 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/komandakycto/decogen/internal/model"
+	"github.com/komandakycto/decogen/pkg/sourcehash"
 )
 
 // ParseInterface parses a Go source file and extracts the specified interface
@@ -17,7 +20,11 @@ func ParseInterface(sourcePath, interfaceName string) (*model.Interface, error)
 	fset := token.NewFileSet()
 
 	// Parse the source file
-	file, err := parser.ParseFile(fset, sourcePath, nil, parser.ParseComments)
+	src, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %w", err)
+	}
+	file, err := parser.ParseFile(fset, sourcePath, src, parser.ParseComments)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse source file: %w", err)
 	}
@@ -27,6 +34,7 @@ func ParseInterface(sourcePath, interfaceName string) (*model.Interface, error)
 
 	// Look for the interface declaration
 	var interfaceType *ast.InterfaceType
+	var interfaceSpec *ast.TypeSpec
 	var comments *ast.CommentGroup
 
 	// Inspect the file to find our interface
@@ -47,6 +55,7 @@ func ParseInterface(sourcePath, interfaceName string) (*model.Interface, error)
 			// Check if it's an interface
 			if it, ok := typeSpec.Type.(*ast.InterfaceType); ok {
 				interfaceType = it
+				interfaceSpec = typeSpec
 				comments = genDecl.Doc // Get doc comments from the general declaration
 				if comments == nil && typeSpec.Doc != nil {
 					comments = typeSpec.Doc // Fallback to typeSpec comments if available
@@ -82,6 +91,8 @@ func ParseInterface(sourcePath, interfaceName string) (*model.Interface, error)
 		PackageName: packageName,
 		Methods:     make([]*model.Method, 0),
 		Imports:     imports,
+		Source:      filepath.Base(sourcePath),
+		SourceHash:  sourcehash.Sum(src[fset.Position(interfaceSpec.Pos()).Offset:fset.Position(interfaceSpec.End()).Offset]),
 	}
 
 	// Add comments if available
@@ -104,12 +115,20 @@ func ParseInterface(sourcePath, interfaceName string) (*model.Interface, error)
 		}
 
 		// Extract method comments if available
+		// Text leaves out directives, so annotations do not end up in the generated comments
 		if method.Doc != nil {
 			methodModel.Comments = method.Doc.Text()
 		} else if method.Comment != nil {
 			methodModel.Comments = method.Comment.Text()
 		}
 
+		annotations, alias, err := parseAnnotations(method.Doc, method.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("%s: method %s: %w", fset.Position(method.Pos()), methodModel.Name, err)
+		}
+		methodModel.Annotations = annotations
+		methodModel.Alias = alias
+
 		// Extract parameters
 		if funcType.Params != nil {
 			for i, param := range funcType.Params.List {
@@ -126,10 +145,11 @@ func ParseInterface(sourcePath, interfaceName string) (*model.Interface, error)
 					paramNames = append(paramNames, fmt.Sprintf("param%d", i))
 				}
 
-				for _, name := range paramNames {
+				for j, name := range paramNames {
 					methodModel.Parameters = append(methodModel.Parameters, &model.Parameter{
-						Name: name,
-						Type: paramType,
+						Name:    name,
+						Type:    paramType,
+						Grouped: j < len(paramNames)-1,
 					})
 				}
 			}
@@ -137,22 +157,28 @@ func ParseInterface(sourcePath, interfaceName string) (*model.Interface, error)
 
 		// Extract results
 		if funcType.Results != nil {
-			for i, result := range funcType.Results.List {
+			for _, result := range funcType.Results.List {
 				resultType := extractType(result.Type)
-				resultName := ""
+				resultNames := make([]string, 0)
 
-				// Extract result name if available
+				// Extract result names if available, including grouped ones like "a, b int"
 				if len(result.Names) > 0 {
-					resultName = result.Names[0].Name
+					methodModel.NamedResults = true
+					for _, name := range result.Names {
+						resultNames = append(resultNames, name.Name)
+					}
 				} else {
 					// For unnamed results, generate a name
-					resultName = fmt.Sprintf("result%d", i)
+					resultNames = append(resultNames, fmt.Sprintf("result%d", len(methodModel.Results)))
 				}
 
-				methodModel.Results = append(methodModel.Results, &model.Parameter{
-					Name: resultName,
-					Type: resultType,
-				})
+				for j, name := range resultNames {
+					methodModel.Results = append(methodModel.Results, &model.Parameter{
+						Name:    name,
+						Type:    resultType,
+						Grouped: j < len(resultNames)-1,
+					})
+				}
 			}
 		}
 
@@ -180,10 +206,9 @@ func extractType(expr ast.Expr) string {
 		return fmt.Sprintf("map[%s]%s", extractType(t.Key), extractType(t.Value))
 	case *ast.InterfaceType:
 		return "interface{}"
-	case *ast.FuncType:
-		return "func()" // Simplified for brevity
-	case *ast.ChanType:
-		return "chan" // Simplified for brevity
+	case *ast.FuncType, *ast.ChanType, *ast.IndexExpr, *ast.IndexListExpr:
+		// Channels, functions and generic instantiations such as iter.Seq2[User, error] are kept as written
+		return types.ExprString(expr)
 	case *ast.Ellipsis:
 		return "..." + extractType(t.Elt)
 	default:
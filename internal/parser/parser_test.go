@@ -117,7 +117,7 @@ type UserStorage interface {
 						Comments: "List lists users with pagination\n",
 						Parameters: []*model.Parameter{
 							{Name: "ctx", Type: "context.Context"},
-							{Name: "offset", Type: "int"},
+							{Name: "offset", Type: "int", Grouped: true},
 							{Name: "limit", Type: "int"},
 						},
 						Results: []*model.Parameter{
@@ -168,6 +168,51 @@ type KeyStorage interface {
 							{Name: "found", Type: "bool"},
 							{Name: "err", Type: "error"},
 						},
+						NamedResults: true,
+					},
+				},
+				Imports: map[string]string{
+					"context": "context",
+				},
+			},
+			expectedError: false,
+		},
+		{
+			name: "Interface with grouped parameters and results",
+			fileContent: `
+package storage
+
+import (
+	"context"
+)
+
+// RangeStorage reads ranges
+type RangeStorage interface {
+	// Range returns the bounds of a range
+	Range(ctx context.Context, from, to string, limit int) (lo, hi int, err error)
+}
+`,
+			interfaceName: "RangeStorage",
+			expectedModel: &model.Interface{
+				Name:        "RangeStorage",
+				PackageName: "storage",
+				Comments:    "RangeStorage reads ranges\n",
+				Methods: []*model.Method{
+					{
+						Name:     "Range",
+						Comments: "Range returns the bounds of a range\n",
+						Parameters: []*model.Parameter{
+							{Name: "ctx", Type: "context.Context"},
+							{Name: "from", Type: "string", Grouped: true},
+							{Name: "to", Type: "string"},
+							{Name: "limit", Type: "int"},
+						},
+						Results: []*model.Parameter{
+							{Name: "lo", Type: "int", Grouped: true},
+							{Name: "hi", Type: "int"},
+							{Name: "err", Type: "error"},
+						},
+						NamedResults: true,
 					},
 				},
 				Imports: map[string]string{
@@ -279,7 +324,7 @@ type ComplexStorage interface {
 						Comments: "ReceiveMessages handles a channel\n",
 						Parameters: []*model.Parameter{
 							{Name: "ctx", Type: "context.Context"},
-							{Name: "msgChan", Type: "chan"},
+							{Name: "msgChan", Type: "<-chan string"},
 						},
 						Results: []*model.Parameter{
 							{Name: "result0", Type: "error"},
@@ -290,7 +335,7 @@ type ComplexStorage interface {
 						Comments: "WithCallback accepts a callback function\n",
 						Parameters: []*model.Parameter{
 							{Name: "ctx", Type: "context.Context"},
-							{Name: "callback", Type: "func()"},
+							{Name: "callback", Type: "func(string) error"},
 						},
 						Results: []*model.Parameter{
 							{Name: "result0", Type: "error"},
@@ -674,6 +719,88 @@ type BatchStorage interface {
 			},
 			expectedError: false,
 		},
+		{
+			name: "Interface with annotations",
+			fileContent: `
+package storage
+
+import "context"
+
+// AnnotatedStorage sets decorator parameters per method
+type AnnotatedStorage interface {
+	// Get reads a value
+	//decogen:retry max_attempts=5 backoff=exp(50ms,5s)
+	//decogen:cache ttl=30s key="item: {{.id}}"
+	Get(ctx context.Context, id string) (string, error)
+
+	//decogen:name "storage count"
+	Count() (int, error) //decogen:cache ttl=1h
+}`,
+			interfaceName: "AnnotatedStorage",
+			expectedModel: &model.Interface{
+				Name:        "AnnotatedStorage",
+				PackageName: "storage",
+				Comments:    "AnnotatedStorage sets decorator parameters per method\n",
+				Methods: []*model.Method{
+					{
+						Name:     "Get",
+						Comments: "Get reads a value\n",
+						Parameters: []*model.Parameter{
+							{Name: "ctx", Type: "context.Context"},
+							{Name: "id", Type: "string"},
+						},
+						Results: []*model.Parameter{
+							{Name: "result0", Type: "string"},
+							{Name: "result1", Type: "error"},
+						},
+						Annotations: map[string]map[string]string{
+							"retry": {"max_attempts": "5", "backoff": "exp(50ms,5s)"},
+							"cache": {"ttl": "30s", "key": "item: {{.id}}"},
+						},
+					},
+					{
+						Name: "Count",
+						Results: []*model.Parameter{
+							{Name: "result0", Type: "int"},
+							{Name: "result1", Type: "error"},
+						},
+						Annotations: map[string]map[string]string{
+							"cache": {"ttl": "1h"},
+						},
+						Alias: "storage count",
+					},
+				},
+				Imports: map[string]string{"context": "context"},
+			},
+			expectedError: false,
+		},
+		{
+			name: "Interface with a method named twice",
+			fileContent: `
+package storage
+
+type RenamedStorage interface {
+	//decogen:name storage.get
+	//decogen:name storage.read
+	Get(id string) (string, error)
+}`,
+			interfaceName: "RenamedStorage",
+			expectedModel: nil,
+			expectedError: true,
+		},
+		{
+			name: "Interface with an invalid annotation",
+			fileContent: `
+package storage
+
+type InvalidStorage interface {
+	//decogen:retry max_attempts
+	Get(id string) (string, error)
+}`,
+			interfaceName: "InvalidStorage",
+			expectedModel: nil,
+			expectedError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -720,6 +847,9 @@ type BatchStorage interface {
 				}
 
 				assert.Equal(t, expectedMethod.Comments, actualMethod.Comments)
+				assert.Equal(t, expectedMethod.Annotations, actualMethod.Annotations)
+				assert.Equal(t, expectedMethod.Alias, actualMethod.Alias)
+				assert.Equal(t, expectedMethod.NamedResults, actualMethod.NamedResults)
 
 				// Compare parameters
 				assert.Equal(t, len(expectedMethod.Parameters), len(actualMethod.Parameters))
@@ -731,6 +861,7 @@ type BatchStorage interface {
 
 					assert.Equal(t, expectedParam.Name, actualParam.Name)
 					assert.Equal(t, expectedParam.Type, actualParam.Type)
+					assert.Equal(t, expectedParam.Grouped, actualParam.Grouped)
 				}
 
 				// Compare results
@@ -746,6 +877,7 @@ type BatchStorage interface {
 						assert.Equal(t, expectedResult.Name, actualResult.Name)
 					}
 					assert.Equal(t, expectedResult.Type, actualResult.Type)
+					assert.Equal(t, expectedResult.Grouped, actualResult.Grouped)
 				}
 			}
 
@@ -760,3 +892,58 @@ type BatchStorage interface {
 		})
 	}
 }
+
+func TestFindDirectives(t *testing.T) {
+	tempDir := t.TempDir()
+
+	source := `
+package storage
+
+import "context"
+
+// UserStorage stores users
+//
+//decogen:decorate retry, dedupe
+type UserStorage interface {
+	Get(ctx context.Context, id string) (string, error)
+}
+
+// Plain is not annotated
+type Plain interface {
+	Ping() error
+}
+
+type (
+	// Grouped is declared in a group
+	//decogen:decorate cache
+	Grouped interface {
+		Ping() error
+	}
+
+	// decogen:decorate retry is not a directive
+	Spaced interface {
+		Ping() error
+	}
+)
+
+//decogen:decorate retry
+type NotAnInterface struct{}
+`
+	sourcePath := filepath.Join(tempDir, "storage.go")
+	require.NoError(t, os.WriteFile(sourcePath, []byte(source), 0644))
+
+	directives, err := FindDirectives(sourcePath)
+	require.NoError(t, err)
+	assert.Equal(t, []Directive{
+		{Interface: "UserStorage", Decorators: []string{"retry", "dedupe"}},
+		{Interface: "Grouped", Decorators: []string{"cache"}},
+	}, directives)
+
+	t.Run("empty directive", func(t *testing.T) {
+		emptyPath := filepath.Join(tempDir, "empty.go")
+		require.NoError(t, os.WriteFile(emptyPath, []byte("package storage\n\n//decogen:decorate\ntype Empty interface{}\n"), 0644))
+
+		_, err := FindDirectives(emptyPath)
+		assert.Error(t, err)
+	})
+}
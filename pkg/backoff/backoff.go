@@ -10,7 +10,12 @@
 // to the delay, which helps distribute retry attempts over time.
 //
 // The BackOff implementation in this package is thread-safe and can be safely
-// used by multiple goroutines concurrently.
+// used by multiple goroutines concurrently. Delay is lock-free and does not
+// allocate, so a single BackOff can be shared by hot paths such as connection pools.
+//
+// Every strategy with jitter draws its seed from crypto/rand, so processes started
+// at the same time do not retry in lockstep. Tests reproduce delays by passing a
+// fixed seed to NewWithSeed, NewDecorrelatedWithSeed or WithJitterSeed.
 //
 // Example usage:
 //
@@ -40,31 +45,49 @@
 package backoff
 
 import (
-	"math/rand"
-	"sync"
+	"math"
 	"time"
 )
 
+// Strategy defines the interface for backoff strategies
+// It is shared with the retry package so both can never drift apart
+type Strategy interface {
+	// MinDelay returns the minimum delay duration
+	MinDelay() time.Duration
+
+	// Delay calculates the next delay based on the previous delay
+	Delay(previous time.Duration) time.Duration
+}
+
+// Ensure BackOff implements Strategy
+var _ Strategy = (*BackOff)(nil)
+
 // BackOff implements exponential backoff with jitter
 type BackOff struct {
 	minDelay time.Duration
 	maxDelay time.Duration
 	factor   float64
 	jitter   float64
-	rnd      *rand.Rand
-	mu       sync.Mutex // protects rnd
+	jitterFn JitterFunc
+	rnd      *randomSource
 }
 
 // New creates a new instance of BackOff
 func New(minDelay, maxDelay time.Duration, factor, jitter float64) *BackOff {
 	// Create a local random source with a unique seed
-	source := rand.NewSource(time.Now().UnixNano())
+	return NewWithSeed(minDelay, maxDelay, factor, jitter, newSeed())
+}
+
+// NewWithSeed creates a new instance of BackOff using the provided seed for jitter
+// A fixed seed makes the produced delays reproducible
+func NewWithSeed(minDelay, maxDelay time.Duration, factor, jitter float64, seed uint64) *BackOff {
 	return &BackOff{
 		minDelay: minDelay,
 		maxDelay: maxDelay,
 		factor:   factor,
 		jitter:   jitter,
-		rnd:      rand.New(source),
+		jitterFn: ProportionalJitter(jitter),
+		rnd:      newRandomSource(seed),
 	}
 }
 
@@ -98,6 +121,15 @@ func (b *BackOff) Jitter() float64 {
 	return b.jitter
 }
 
+// WithJitterFunc returns a copy of the BackOff that applies jitter using the given function
+// Delays never go below minDelay regardless of the jitter function
+// The copy draws its own seed, so it does not produce the same jitter as the BackOff or other copies
+func (b *BackOff) WithJitterFunc(jitterFn JitterFunc) *BackOff {
+	c := NewWithSeed(b.minDelay, b.maxDelay, b.factor, b.jitter, newSeed())
+	c.jitterFn = jitterFn
+	return c
+}
+
 // Delay calculates the next backoff delay using exponential backoff with jitter
 func (b *BackOff) Delay(previous time.Duration) time.Duration {
 	// Ensure we're starting with at least minDelay
@@ -105,23 +137,31 @@ func (b *BackOff) Delay(previous time.Duration) time.Duration {
 		previous = b.minDelay
 	}
 
-	// Calculate exponential backoff
-	delay := time.Duration(float64(previous) * b.factor)
+	// Calculate exponential backoff, capped at maxDelay before adding jitter
+	// The product is compared before conversion, which would overflow for large previous delays
+	delay := b.maxDelay
+	if d := float64(previous) * b.factor; d < float64(b.maxDelay) {
+		delay = time.Duration(d)
+	}
 
-	// Cap at maxDelay before adding jitter
-	if delay > b.maxDelay {
-		delay = b.maxDelay
+	return b.jittered(delay)
+}
+
+// DelayForAttempt returns the delay before retry n, counting from zero, without the previous delay
+// It is minDelay * factor^n capped at maxDelay, with jitter, so callers that track attempt counts,
+// such as message redelivery with an attempt header, need not carry the previous delay across restarts
+func (b *BackOff) DelayForAttempt(n uint) time.Duration {
+	delay := b.maxDelay
+	if d := float64(b.minDelay) * math.Pow(b.factor, float64(n)); d < float64(b.maxDelay) {
+		delay = time.Duration(d)
 	}
+	return b.jittered(delay)
+}
 
+// jittered adds jitter to a delay, keeping the result within [minDelay, maxDelay]
+func (b *BackOff) jittered(delay time.Duration) time.Duration {
 	// Add jitter (random variation to avoid thundering herd)
-	b.mu.Lock()
-	// Generate a random value in range [-jitter/2, jitter/2]
-	jitterFactor := (b.rnd.Float64() - 0.5) * b.jitter
-	b.mu.Unlock()
-
-	// Apply jitter as a percentage of current delay
-	jitterAmount := time.Duration(float64(delay) * jitterFactor)
-	delay += jitterAmount
+	delay = b.jitterFn(delay, b.rnd.Float64())
 
 	// Ensure we don't go below minDelay or above maxDelay after jitter
 	if delay < b.minDelay {
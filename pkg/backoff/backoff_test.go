@@ -192,6 +192,56 @@ func TestDelay_EdgeCases(t *testing.T) {
 	})
 }
 
+func TestDelay_Reproducible(t *testing.T) {
+	first := backoff.NewWithSeed(10*time.Millisecond, 10*time.Second, 2.0, 0.5, 42)
+	second := backoff.NewWithSeed(10*time.Millisecond, 10*time.Second, 2.0, 0.5, 42)
+	other := backoff.NewWithSeed(10*time.Millisecond, 10*time.Second, 2.0, 0.5, 7)
+
+	var differs bool
+	for i := 0; i < 20; i++ {
+		expected := first.Delay(time.Second)
+		assert.Equal(t, expected, second.Delay(time.Second), "Same seed should produce the same delays")
+		if other.Delay(time.Second) != expected {
+			differs = true
+		}
+	}
+	assert.True(t, differs, "Different seeds should produce different delays")
+
+	// Backoffs created at the same instant, as by replicas started together, are seeded independently
+	a := backoff.New(10*time.Millisecond, 10*time.Second, 2.0, 0.5)
+	b := backoff.New(10*time.Millisecond, 10*time.Second, 2.0, 0.5)
+	differs = false
+	for i := 0; i < 20; i++ {
+		if a.Delay(time.Second) != b.Delay(time.Second) {
+			differs = true
+		}
+	}
+	assert.True(t, differs, "Backoffs should not share a seed")
+}
+
+func TestDelayForAttempt(t *testing.T) {
+	b := backoff.New(100*time.Millisecond, 5*time.Second, 2.0, 0)
+
+	assert.Equal(t, 100*time.Millisecond, b.DelayForAttempt(0))
+	assert.Equal(t, 800*time.Millisecond, b.DelayForAttempt(3))
+	assert.Equal(t, 5*time.Second, b.DelayForAttempt(6), "Delays should be capped at maxDelay")
+	assert.Equal(t, 5*time.Second, b.DelayForAttempt(10000), "Huge attempts should not overflow")
+
+	// Without jitter the delays match the chain of Delay calls
+	delay := b.MinDelay()
+	for n := uint(0); n < 8; n++ {
+		assert.Equal(t, delay, b.DelayForAttempt(n), "attempt %d", n)
+		delay = b.Delay(delay)
+	}
+
+	jittered := backoff.NewWithSeed(100*time.Millisecond, 5*time.Second, 2.0, 0.5, 42)
+	for i := 0; i < 100; i++ {
+		d := jittered.DelayForAttempt(2)
+		assert.GreaterOrEqual(t, d, 300*time.Millisecond)
+		assert.LessOrEqual(t, d, 500*time.Millisecond)
+	}
+}
+
 func TestDelay_StatisticalDistribution(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping statistical tests in short mode")
@@ -241,3 +291,53 @@ func TestDelay_StatisticalDistribution(t *testing.T) {
 	assert.InDelta(t, expectedStdDev, stdDev, expectedStdDev*0.5,
 		"Standard deviation should be close to expected value for uniform distribution")
 }
+
+func TestDelay_JitterFuncs(t *testing.T) {
+	minDelay := 10 * time.Millisecond
+	maxDelay := 10 * time.Second
+	previous := time.Second
+	base := 2 * previous
+
+	t.Run("full jitter", func(t *testing.T) {
+		b := backoff.New(minDelay, maxDelay, 2.0, 0.1).WithJitterFunc(backoff.FullJitter(1))
+		assert.Equal(t, minDelay, b.MinDelay(), "WithJitterFunc should keep the configuration")
+
+		var belowHalf bool
+		for i := 0; i < 1000; i++ {
+			delay := b.Delay(previous)
+			assert.GreaterOrEqual(t, delay, minDelay, "Full jitter should respect minDelay")
+			assert.LessOrEqual(t, delay, base, "Full jitter should not exceed the computed delay")
+			if delay < base/2 {
+				belowHalf = true
+			}
+		}
+		assert.True(t, belowHalf, "Full jitter should use the whole range")
+	})
+
+	t.Run("equal jitter", func(t *testing.T) {
+		b := backoff.New(minDelay, maxDelay, 2.0, 0.1).WithJitterFunc(backoff.EqualJitter())
+
+		uniqueValues := make(map[time.Duration]bool)
+		for i := 0; i < 1000; i++ {
+			delay := b.Delay(previous)
+			assert.GreaterOrEqual(t, delay, base/2, "Equal jitter should keep half of the delay")
+			assert.LessOrEqual(t, delay, base, "Equal jitter should not exceed the computed delay")
+			uniqueValues[delay] = true
+		}
+		assert.Greater(t, len(uniqueValues), 1, "Equal jitter should produce varying delays")
+	})
+
+	t.Run("copies draw their own seed", func(t *testing.T) {
+		b := backoff.NewWithSeed(minDelay, maxDelay, 2.0, 0.1, 42)
+		first := b.WithJitterFunc(backoff.FullJitter(1))
+		second := b.WithJitterFunc(backoff.FullJitter(1))
+
+		same := true
+		for i := 0; i < 10; i++ {
+			if first.Delay(previous) != second.Delay(previous) {
+				same = false
+			}
+		}
+		assert.False(t, same, "Copies of a BackOff should not jitter in lockstep")
+	})
+}
@@ -0,0 +1,220 @@
+// Package backofftest provides a conformance suite for backoff strategies.
+//
+// Conformance checks the properties retry loops rely on, for the built-in
+// strategies and third-party ones alike:
+//
+//   - bounds: MinDelay and every delay are positive or zero, or backoff.Stop,
+//     delays never go below MinDelay, nor above MaxDelay when the strategy has one
+//   - monotonic capping: strategies without jitter never shorten the delay as the
+//     previous delay grows, up to the largest durations, so caps hold without overflow
+//   - jitter distribution: jittered delays spread over their range instead of
+//     clustering at a few values
+//   - concurrency safety: delays requested from many goroutines keep their bounds;
+//     run the tests with -race to detect data races
+//
+// Strategies with a Reset method, such as backoff.MaxRetries, are reset before
+// each check, so checks see them before they return Stop.
+//
+// Example usage:
+//
+//	func TestMyBackoff(t *testing.T) {
+//		backofftest.Conformance(t, mybackoff.New(100*time.Millisecond, 10*time.Second))
+//	}
+package backofftest
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+const (
+	// samples is the number of delays drawn to tell jittered strategies apart and check their distribution
+	samples = 1000
+
+	// buckets split the observed range of jittered delays; each must hold at least minShare of the samples
+	buckets  = 4
+	minShare = 0.01
+
+	// goroutines and callsPerGoroutine drive the concurrency check
+	goroutines        = 8
+	callsPerGoroutine = 200
+)
+
+// Conformance runs the conformance checks against a strategy as subtests
+func Conformance(t *testing.T, b backoff.Strategy) {
+	t.Helper()
+
+	t.Run("bounds", func(t *testing.T) {
+		reset(b)
+		if delay := b.MinDelay(); delay < 0 && delay != backoff.Stop {
+			t.Fatalf("MinDelay returned negative delay %s", delay)
+		}
+		for _, previous := range previousDelays(b) {
+			if err := Check(b, previous); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("monotonic capping", func(t *testing.T) {
+		reset(b)
+		var last time.Duration
+		var lastPrevious time.Duration
+		for i, previous := range previousDelays(b) {
+			delay, ok := deterministic(b, previous)
+			if !ok {
+				return
+			}
+			if i > 0 && delay < last {
+				t.Fatalf("Delay(%s) = %s is shorter than Delay(%s) = %s", previous, delay, lastPrevious, last)
+			}
+			last, lastPrevious = delay, previous
+		}
+	})
+
+	t.Run("jitter distribution", func(t *testing.T) {
+		reset(b)
+		previous := b.MinDelay()
+		if previous == backoff.Stop {
+			t.Skip("the strategy stops before the first retry")
+		}
+
+		delays := make([]time.Duration, 0, samples)
+		for range samples {
+			delay := b.Delay(previous)
+			if delay == backoff.Stop {
+				t.Skip("the strategy stops before enough delays were sampled")
+			}
+			delays = append(delays, delay)
+		}
+		if err := checkSpread(delays); err != nil {
+			t.Errorf("Delay(%s): %v", previous, err)
+		}
+	})
+
+	t.Run("concurrency safety", func(t *testing.T) {
+		reset(b)
+		previous := previousDelays(b)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, goroutines)
+		for g := range goroutines {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range callsPerGoroutine {
+					if err := Check(b, previous[(g+i)%len(previous)]); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+	})
+}
+
+// Check verifies the bounds of the delay returned for a previous delay
+// It is the single-call check of Conformance, for use in fuzz tests
+func Check(b backoff.Strategy, previous time.Duration) error {
+	delay := b.Delay(previous)
+	if delay == backoff.Stop {
+		return nil
+	}
+	if delay < 0 {
+		return fmt.Errorf("Delay(%s) returned negative delay %s", previous, delay)
+	}
+	if minDelay := b.MinDelay(); minDelay != backoff.Stop && delay < minDelay {
+		return fmt.Errorf("Delay(%s) = %s is below MinDelay %s", previous, delay, minDelay)
+	}
+	if maxDelay, ok := maxDelay(b); ok && delay > maxDelay {
+		return fmt.Errorf("Delay(%s) = %s is above MaxDelay %s", previous, delay, maxDelay)
+	}
+	return nil
+}
+
+// previousDelays returns increasing previous delays to check: zero, the delays of a sequence
+// starting at MinDelay, MaxDelay and durations large enough to overflow naive arithmetic
+func previousDelays(b backoff.Strategy) []time.Duration {
+	previous := []time.Duration{0}
+
+	delay := b.MinDelay()
+	for range 32 {
+		if delay == backoff.Stop {
+			break
+		}
+		previous = append(previous, delay)
+		delay = b.Delay(delay)
+	}
+	if maxDelay, ok := maxDelay(b); ok {
+		previous = append(previous, maxDelay, 2*maxDelay)
+	}
+	previous = append(previous, time.Duration(math.MaxInt64/4), time.Duration(math.MaxInt64/2), time.Duration(math.MaxInt64))
+
+	previous = slices.DeleteFunc(previous, func(d time.Duration) bool { return d < 0 })
+	slices.Sort(previous)
+	reset(b)
+	return slices.Compact(previous)
+}
+
+// deterministic returns the delay for a previous delay when repeated calls agree on it and it is not Stop
+func deterministic(b backoff.Strategy, previous time.Duration) (time.Duration, bool) {
+	delay := b.Delay(previous)
+	if delay == backoff.Stop {
+		return 0, false
+	}
+	for range 20 {
+		if b.Delay(previous) != delay {
+			return 0, false
+		}
+	}
+	return delay, true
+}
+
+// checkSpread verifies jittered delays cover their observed range
+// Delays that are all equal have no jitter and pass
+func checkSpread(delays []time.Duration) error {
+	lowest, highest := slices.Min(delays), slices.Max(delays)
+	if lowest == highest {
+		return nil
+	}
+
+	var counts [buckets]int
+	width := float64(highest-lowest) / buckets
+	for _, delay := range delays {
+		bucket := min(int(float64(delay-lowest)/width), buckets-1)
+		counts[bucket]++
+	}
+	for i, count := range counts {
+		if share := float64(count) / float64(len(delays)); share < minShare {
+			return fmt.Errorf("jitter clusters: %.1f%% of %d delays between %s and %s fall in quarter %d of the range",
+				share*100, len(delays), lowest, highest, i+1)
+		}
+	}
+	return nil
+}
+
+// maxDelay returns the maximum delay of strategies exposing one
+func maxDelay(b backoff.Strategy) (time.Duration, bool) {
+	if m, ok := b.(interface{ MaxDelay() time.Duration }); ok {
+		return m.MaxDelay(), true
+	}
+	return 0, false
+}
+
+// reset restarts stateful strategies such as backoff.MaxRetries and backoff.Elapsed
+func reset(b backoff.Strategy) {
+	if r, ok := b.(interface{ Reset() }); ok {
+		r.Reset()
+	}
+}
@@ -0,0 +1,54 @@
+package backofftest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+	"github.com/komandakycto/decogen/pkg/backoff/backofftest"
+)
+
+func TestConformance(t *testing.T) {
+	strategies := map[string]backoff.Strategy{
+		"exponential":       backoff.New(100*time.Millisecond, 10*time.Second, 2, 0.1),
+		"exponential exact": backoff.New(100*time.Millisecond, 10*time.Second, 2, 0),
+		"exponential full":  backoff.New(100*time.Millisecond, 10*time.Second, 2, 0).WithJitterFunc(backoff.FullJitter(1)),
+		"default":           backoff.Default(),
+		"decorrelated":      backoff.NewDecorrelated(100*time.Millisecond, 10*time.Second),
+		"constant":          backoff.NewConstant(time.Second),
+		"linear":            backoff.Linear(100*time.Millisecond, 50*time.Millisecond),
+		"schedule":          backoff.Schedule(time.Second, 5*time.Second, 30*time.Second),
+		"capped":            backoff.WithCap(backoff.Linear(time.Second, time.Second), 5*time.Second),
+		"jittered":          backoff.WithJitter(backoff.Linear(time.Second, time.Second), backoff.EqualJitter()),
+		"max retries":       backoff.WithMaxRetriesAsStop(backoff.Default(), 5),
+		"max elapsed":       backoff.WithMaxElapsed(backoff.Default(), time.Minute),
+	}
+	for name, b := range strategies {
+		t.Run(name, func(t *testing.T) {
+			backofftest.Conformance(t, b)
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, spec := range []string{
+		"exponential(min=100ms,max=10s,factor=2,jitter=0.1)",
+		"exponential(min=1ns,max=2562047h,factor=1000)",
+		"decorrelated(base=100ms,max=10s)",
+		"linear(min=100ms,step=50ms)",
+		"constant(delay=1s)",
+		"schedule(1s,5s,30s)",
+	} {
+		f.Add(spec, int64(time.Second))
+	}
+
+	f.Fuzz(func(t *testing.T, spec string, previous int64) {
+		b, err := backoff.Parse(spec)
+		if err != nil || previous < 0 {
+			return
+		}
+		if err := backofftest.Check(b, time.Duration(previous)); err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+func TestDelay_ZeroAllocations(t *testing.T) {
+	strategies := map[string]backoff.Strategy{
+		"exponential":  backoff.Default(),
+		"full jitter":  backoff.Default().WithJitterFunc(backoff.FullJitter(1)),
+		"decorrelated": backoff.NewDecorrelated(100*time.Millisecond, 10*time.Second),
+		"schedule":     backoff.Schedule(time.Second, 5*time.Second, 30*time.Second),
+		"wrapped":      backoff.WithCap(backoff.WithJitter(backoff.Linear(time.Second, time.Second), backoff.EqualJitter()), time.Minute),
+	}
+
+	for name, b := range strategies {
+		t.Run(name, func(t *testing.T) {
+			allocs := testing.AllocsPerRun(1000, func() {
+				b.Delay(time.Second)
+			})
+			assert.Equal(t, 0.0, allocs, "Delay should not allocate")
+		})
+	}
+}
+
+func BenchmarkDelay(b *testing.B) {
+	bo := backoff.Default()
+	b.ReportAllocs()
+
+	delay := bo.MinDelay()
+	for i := 0; i < b.N; i++ {
+		delay = bo.Delay(delay)
+	}
+}
+
+func BenchmarkDelay_Parallel(b *testing.B) {
+	bo := backoff.Default()
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+		delay := bo.MinDelay()
+		for pb.Next() {
+			delay = bo.Delay(delay)
+		}
+	})
+}
+
+func BenchmarkDecorrelated_Parallel(b *testing.B) {
+	bo := backoff.NewDecorrelated(100*time.Millisecond, 10*time.Second)
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+		delay := bo.MinDelay()
+		for pb.Next() {
+			delay = bo.Delay(delay)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package backoff
+
+import "time"
+
+// Ensure Decorrelated implements Strategy
+var _ Strategy = (*Decorrelated)(nil)
+
+// Decorrelated implements the "decorrelated jitter" backoff algorithm
+//
+// Each delay is drawn uniformly from [base, previous*3] and capped at maxDelay,
+// as described in the AWS Architecture Blog post "Exponential Backoff And Jitter".
+// Unlike percentage jitter, consecutive delays of competing clients quickly
+// diverge, which keeps retries decorrelated under heavy contention.
+type Decorrelated struct {
+	base     time.Duration
+	maxDelay time.Duration
+	rnd      *randomSource
+}
+
+// NewDecorrelated creates a new decorrelated jitter backoff
+func NewDecorrelated(base, maxDelay time.Duration) *Decorrelated {
+	return NewDecorrelatedWithSeed(base, maxDelay, newSeed())
+}
+
+// NewDecorrelatedWithSeed creates a new decorrelated jitter backoff using the provided seed
+// A fixed seed makes the produced delays reproducible
+func NewDecorrelatedWithSeed(base, maxDelay time.Duration, seed uint64) *Decorrelated {
+	return &Decorrelated{
+		base:     base,
+		maxDelay: maxDelay,
+		rnd:      newRandomSource(seed),
+	}
+}
+
+// MinDelay returns the base delay
+func (d *Decorrelated) MinDelay() time.Duration {
+	return d.base
+}
+
+// MaxDelay returns the maximum configured delay
+func (d *Decorrelated) MaxDelay() time.Duration {
+	return d.maxDelay
+}
+
+// Delay calculates the next delay as min(maxDelay, random(base, previous*3))
+func (d *Decorrelated) Delay(previous time.Duration) time.Duration {
+	// Ensure we're starting with at least the base delay
+	if previous < d.base {
+		previous = d.base
+	}
+
+	upper := previous * 3
+	if upper < previous || upper > d.maxDelay {
+		// Cap the upper bound (also guards against overflow)
+		upper = d.maxDelay
+	}
+
+	delay := d.base
+	if upper > d.base {
+		delay += time.Duration(d.rnd.Int64N(int64(upper-d.base) + 1))
+	}
+
+	if delay > d.maxDelay {
+		delay = d.maxDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,63 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+func TestDecorrelated_Bounds(t *testing.T) {
+	base := 100 * time.Millisecond
+	maxDelay := 2 * time.Second
+	b := backoff.NewDecorrelated(base, maxDelay)
+
+	assert.Equal(t, base, b.MinDelay(), "MinDelay should return the base delay")
+	assert.Equal(t, maxDelay, b.MaxDelay(), "MaxDelay should return the cap")
+
+	previous := b.MinDelay()
+	for i := 0; i < 1000; i++ {
+		delay := b.Delay(previous)
+		assert.GreaterOrEqual(t, delay, base, "Delay should never go below base")
+		assert.LessOrEqual(t, delay, maxDelay, "Delay should never exceed the cap")
+		assert.LessOrEqual(t, delay, previous*3, "Delay should not exceed three times the previous delay")
+		previous = delay
+	}
+}
+
+func TestDecorrelated_Decorrelates(t *testing.T) {
+	b := backoff.NewDecorrelated(10*time.Millisecond, 10*time.Second)
+
+	// Same previous delay should produce varying next delays
+	uniqueValues := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		uniqueValues[b.Delay(time.Second)] = true
+	}
+	assert.Greater(t, len(uniqueValues), 1, "Decorrelated jitter should produce varying delays")
+}
+
+func TestDecorrelated_Reproducible(t *testing.T) {
+	first := backoff.NewDecorrelatedWithSeed(10*time.Millisecond, time.Second, 42)
+	second := backoff.NewDecorrelatedWithSeed(10*time.Millisecond, time.Second, 42)
+
+	prevFirst, prevSecond := first.MinDelay(), second.MinDelay()
+	for i := 0; i < 10; i++ {
+		prevFirst, prevSecond = first.Delay(prevFirst), second.Delay(prevSecond)
+		assert.Equal(t, prevFirst, prevSecond, "Same seed should produce the same schedule")
+	}
+}
+
+func TestDecorrelated_EdgeCases(t *testing.T) {
+	t.Run("base equals cap", func(t *testing.T) {
+		b := backoff.NewDecorrelated(time.Second, time.Second)
+		assert.Equal(t, time.Second, b.Delay(time.Second))
+	})
+
+	t.Run("huge previous delay", func(t *testing.T) {
+		b := backoff.NewDecorrelated(time.Millisecond, time.Second)
+		delay := b.Delay(time.Duration(1 << 62))
+		assert.LessOrEqual(t, delay, time.Second, "Overflowing previous delay should still be capped")
+	})
+}
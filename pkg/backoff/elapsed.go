@@ -0,0 +1,83 @@
+package backoff
+
+import (
+	"sync"
+	"time"
+)
+
+// Ensure Elapsed implements Strategy
+var _ Strategy = (*Elapsed)(nil)
+
+// Elapsed wraps a strategy to return Stop once a wall-clock budget is used up
+//
+// The budget starts with the first call to MinDelay or Delay. A delay that would
+// end after the budget is replaced by Stop. Like MaxRetries, an instance tracks a
+// single sequence of retries, so it must not be shared between concurrent retry
+// loops; call Reset before reusing it or use the copy returned by NewSequence.
+type Elapsed struct {
+	base       Strategy
+	maxElapsed time.Duration
+	start      time.Time
+	mu         sync.Mutex // protects start
+}
+
+// WithMaxElapsed wraps a strategy to return Stop once maxElapsed has passed since the first delay was requested
+func WithMaxElapsed(base Strategy, maxElapsed time.Duration) *Elapsed {
+	return &Elapsed{
+		base:       base,
+		maxElapsed: maxElapsed,
+	}
+}
+
+// MinDelay returns the minimum delay of the wrapped strategy or Stop if it exceeds the budget
+func (e *Elapsed) MinDelay() time.Duration {
+	return e.limit(e.base.MinDelay())
+}
+
+// Delay returns the delay of the wrapped strategy or Stop if it exceeds the budget
+func (e *Elapsed) Delay(previous time.Duration) time.Duration {
+	return e.limit(e.base.Delay(previous))
+}
+
+// Elapsed returns the time passed since the budget started
+func (e *Elapsed) Elapsed() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.start.IsZero() {
+		return 0
+	}
+	return time.Since(e.start)
+}
+
+// Reset starts a new budget with the next requested delay
+func (e *Elapsed) Reset() {
+	e.mu.Lock()
+	e.start = time.Time{}
+	e.mu.Unlock()
+}
+
+// newSequence returns a copy whose budget starts with its first delay
+func (e *Elapsed) newSequence() (Strategy, bool) {
+	base, _ := newSequence(e.base)
+	return WithMaxElapsed(base, e.maxElapsed), true
+}
+
+// limit replaces delays ending after the budget with Stop
+func (e *Elapsed) limit(delay time.Duration) time.Duration {
+	if delay == Stop {
+		return Stop
+	}
+
+	e.mu.Lock()
+	if e.start.IsZero() {
+		e.start = time.Now()
+	}
+	elapsed := time.Since(e.start)
+	e.mu.Unlock()
+
+	if elapsed+delay > e.maxElapsed {
+		return Stop
+	}
+	return delay
+}
@@ -0,0 +1,42 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+func TestWithMaxElapsed(t *testing.T) {
+	t.Run("delays within budget pass through", func(t *testing.T) {
+		b := backoff.WithMaxElapsed(backoff.NewConstant(10*time.Millisecond), time.Minute)
+		assert.Equal(t, 10*time.Millisecond, b.MinDelay())
+		assert.Equal(t, 10*time.Millisecond, b.Delay(10*time.Millisecond))
+	})
+
+	t.Run("delay exceeding budget stops", func(t *testing.T) {
+		b := backoff.WithMaxElapsed(backoff.Linear(10*time.Millisecond, 50*time.Millisecond), 50*time.Millisecond)
+		assert.Equal(t, 10*time.Millisecond, b.MinDelay())
+		assert.Equal(t, backoff.Stop, b.Delay(10*time.Millisecond), "A 60ms delay should not fit a 50ms budget")
+	})
+
+	t.Run("elapsed time counts against the budget", func(t *testing.T) {
+		b := backoff.WithMaxElapsed(backoff.NewConstant(10*time.Millisecond), 30*time.Millisecond)
+		assert.Equal(t, 10*time.Millisecond, b.MinDelay())
+
+		time.Sleep(25 * time.Millisecond)
+		assert.GreaterOrEqual(t, b.Elapsed(), 25*time.Millisecond)
+		assert.Equal(t, backoff.Stop, b.Delay(10*time.Millisecond))
+
+		b.Reset()
+		assert.Equal(t, time.Duration(0), b.Elapsed())
+		assert.Equal(t, 10*time.Millisecond, b.Delay(10*time.Millisecond), "Reset should start a new budget")
+	})
+
+	t.Run("stop passes through", func(t *testing.T) {
+		b := backoff.WithMaxElapsed(backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Millisecond), 0), time.Minute)
+		assert.Equal(t, backoff.Stop, b.MinDelay())
+	})
+}
@@ -0,0 +1,50 @@
+package backoff
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// Delays returns an iterator driving a retry loop with the given strategy
+//
+// The first value is yielded immediately and is zero. Every following value is
+// yielded after waiting for it, so the loop body can simply make one attempt per
+// iteration and break on success:
+//
+//	for delay := range backoff.Delays(ctx, b, 5) {
+//		if err = operation(); err == nil {
+//			break
+//		}
+//		log.Printf("attempt failed after waiting %s: %v", delay, err)
+//	}
+//
+// Iteration ends after maxAttempts values (zero means no limit), when the
+// strategy returns Stop, or when the context is done; check ctx.Err() after the
+// loop to tell those apart.
+func Delays(ctx context.Context, b Strategy, maxAttempts uint) iter.Seq[time.Duration] {
+	return func(yield func(time.Duration) bool) {
+		if ctx.Err() != nil || !yield(0) {
+			return
+		}
+
+		delay := b.MinDelay()
+		for attempt := uint(1); maxAttempts == 0 || attempt < maxAttempts; attempt++ {
+			if delay == Stop {
+				return
+			}
+			if err := Sleep(ctx, delay); err != nil {
+				return
+			}
+			if !yield(delay) {
+				return
+			}
+			delay = b.Delay(delay)
+		}
+	}
+}
+
+// Delays returns an iterator driving a retry loop with this backoff, see the package-level Delays
+func (b *BackOff) Delays(ctx context.Context, maxAttempts uint) iter.Seq[time.Duration] {
+	return Delays(ctx, b, maxAttempts)
+}
@@ -0,0 +1,63 @@
+package backoff_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+func TestDelays(t *testing.T) {
+	t.Run("yields until the attempt cap", func(t *testing.T) {
+		b := backoff.Linear(time.Millisecond, time.Millisecond)
+
+		var delays []time.Duration
+		for delay := range backoff.Delays(context.Background(), b, 4) {
+			delays = append(delays, delay)
+		}
+
+		assert.Equal(t, []time.Duration{0, time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}, delays)
+	})
+
+	t.Run("stops on break", func(t *testing.T) {
+		b := backoff.NewWithSeed(time.Millisecond, 10*time.Millisecond, 2.0, 0, 1)
+
+		attempts := 0
+		for range b.Delays(context.Background(), 0) {
+			attempts++
+			if attempts == 3 {
+				break
+			}
+		}
+
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("stops when the strategy stops", func(t *testing.T) {
+		b := backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Millisecond), 2)
+
+		attempts := 0
+		for range backoff.Delays(context.Background(), b, 0) {
+			attempts++
+		}
+
+		assert.Equal(t, 3, attempts, "Should yield the first attempt plus two retries")
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		attempts := 0
+		for range backoff.Delays(ctx, backoff.NewConstant(time.Hour), 0) {
+			attempts++
+		}
+
+		assert.Equal(t, 1, attempts, "Only the immediate first attempt should be yielded")
+		require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,46 @@
+package backoff
+
+import "time"
+
+// JitterFunc applies random variation to a delay
+// The random value is uniformly distributed in [0, 1)
+type JitterFunc func(delay time.Duration, random float64) time.Duration
+
+// NoJitter returns the delay unchanged
+func NoJitter() JitterFunc {
+	return func(delay time.Duration, _ float64) time.Duration {
+		return delay
+	}
+}
+
+// ProportionalJitter varies the delay by ±jitter/2 of its value
+// This is the scheme used by BackOff by default
+func ProportionalJitter(jitter float64) JitterFunc {
+	return func(delay time.Duration, random float64) time.Duration {
+		// Generate a random value in range [-jitter/2, jitter/2]
+		jitterFactor := (random - 0.5) * jitter
+
+		// Apply jitter as a percentage of current delay
+		return delay + time.Duration(float64(delay)*jitterFactor)
+	}
+}
+
+// FullJitter randomizes the given fraction of the delay
+// FullJitter(1) picks the delay uniformly in [0, delay], smaller fractions keep
+// the rest of the delay fixed and pick uniformly in [delay*(1-fraction), delay]
+func FullJitter(fraction float64) JitterFunc {
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+
+	return func(delay time.Duration, random float64) time.Duration {
+		return delay - time.Duration(float64(delay)*fraction*random)
+	}
+}
+
+// EqualJitter keeps half of the delay and picks the other half uniformly in [0, delay/2]
+func EqualJitter() JitterFunc {
+	return FullJitter(0.5)
+}
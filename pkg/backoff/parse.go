@@ -0,0 +1,393 @@
+package backoff
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parse creates a backoff strategy from a textual specification
+//
+// Supported specifications (parameters may be given in any order):
+//
+//	exponential(min=100ms,max=10s,factor=2,jitter=0.1)
+//	decorrelated(base=100ms,max=10s)
+//	linear(min=100ms,step=50ms)
+//	constant(delay=1s)
+//	schedule(1s,5s,30s)
+//
+// The wrappers take the specification of the strategy they wrap first:
+//
+//	jitter(linear(min=100ms,step=50ms),full=0.5)
+//	jitter(constant(delay=1s),proportional=0.2)
+//	cap(linear(min=100ms,step=50ms),max=5s)
+//	maxretries(constant(delay=1s),retries=3)
+//	maxelapsed(exponential(min=100ms,max=10s),max=1m)
+//
+// Omitted exponential parameters take the values of Default.
+// The String method of every strategy returned by Parse produces a specification
+// that parses back into an equivalent strategy.
+func Parse(spec string) (Strategy, error) {
+	name, args, err := splitSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "exponential":
+		params, err := parseParams(args, "min", "max", "factor", "jitter")
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponential backoff: %w", err)
+		}
+		def := Default()
+		minDelay, err := params.duration("min", def.MinDelay())
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponential backoff: %w", err)
+		}
+		maxDelay, err := params.duration("max", def.MaxDelay())
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponential backoff: %w", err)
+		}
+		factor, err := params.float("factor", def.Factor())
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponential backoff: %w", err)
+		}
+		jitter, err := params.float("jitter", def.Jitter())
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponential backoff: %w", err)
+		}
+		if maxDelay < minDelay {
+			return nil, fmt.Errorf("invalid exponential backoff: max %s is lower than min %s", maxDelay, minDelay)
+		}
+		return New(minDelay, maxDelay, factor, jitter), nil
+
+	case "decorrelated":
+		params, err := parseParams(args, "base", "max")
+		if err != nil {
+			return nil, fmt.Errorf("invalid decorrelated backoff: %w", err)
+		}
+		base, err := params.requiredDuration("base")
+		if err != nil {
+			return nil, fmt.Errorf("invalid decorrelated backoff: %w", err)
+		}
+		maxDelay, err := params.requiredDuration("max")
+		if err != nil {
+			return nil, fmt.Errorf("invalid decorrelated backoff: %w", err)
+		}
+		if maxDelay < base {
+			return nil, fmt.Errorf("invalid decorrelated backoff: max %s is lower than base %s", maxDelay, base)
+		}
+		return NewDecorrelated(base, maxDelay), nil
+
+	case "linear":
+		params, err := parseParams(args, "min", "step")
+		if err != nil {
+			return nil, fmt.Errorf("invalid linear backoff: %w", err)
+		}
+		minDelay, err := params.requiredDuration("min")
+		if err != nil {
+			return nil, fmt.Errorf("invalid linear backoff: %w", err)
+		}
+		step, err := params.requiredDuration("step")
+		if err != nil {
+			return nil, fmt.Errorf("invalid linear backoff: %w", err)
+		}
+		return Linear(minDelay, step), nil
+
+	case "constant":
+		params, err := parseParams(args, "delay")
+		if err != nil {
+			return nil, fmt.Errorf("invalid constant backoff: %w", err)
+		}
+		delay, err := params.requiredDuration("delay")
+		if err != nil {
+			return nil, fmt.Errorf("invalid constant backoff: %w", err)
+		}
+		return NewConstant(delay), nil
+
+	case "schedule":
+		delays := make([]time.Duration, 0, len(args))
+		for _, arg := range args {
+			d, err := time.ParseDuration(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schedule backoff: %w", err)
+			}
+			if d < 0 {
+				return nil, fmt.Errorf("invalid schedule backoff: delay %s must not be negative", d)
+			}
+			if n := len(delays); n > 0 && d < delays[n-1] {
+				return nil, fmt.Errorf("invalid schedule backoff: delay %s is lower than the previous delay %s", d, delays[n-1])
+			}
+			delays = append(delays, d)
+		}
+		if len(delays) == 0 {
+			return nil, fmt.Errorf("invalid schedule backoff: at least one delay is required")
+		}
+		return Schedule(delays...), nil
+
+	case "jitter":
+		base, params, err := parseWrapped(args, "full", "proportional")
+		if err != nil {
+			return nil, fmt.Errorf("invalid jitter backoff: %w", err)
+		}
+		if len(params) != 1 {
+			return nil, fmt.Errorf("invalid jitter backoff: exactly one of the parameters full and proportional is required")
+		}
+		if _, ok := params["full"]; ok {
+			fraction, err := params.float("full", 0)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jitter backoff: %w", err)
+			}
+			if fraction > 1 {
+				return nil, fmt.Errorf("invalid jitter backoff: parameter full must not exceed 1")
+			}
+			j := WithJitter(base, FullJitter(fraction))
+			j.spec = "full=" + formatFloat(fraction)
+			return j, nil
+		}
+		jitter, err := params.float("proportional", 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jitter backoff: %w", err)
+		}
+		j := WithJitter(base, ProportionalJitter(jitter))
+		j.spec = "proportional=" + formatFloat(jitter)
+		return j, nil
+
+	case "cap":
+		base, params, err := parseWrapped(args, "max")
+		if err != nil {
+			return nil, fmt.Errorf("invalid cap backoff: %w", err)
+		}
+		maxDelay, err := params.requiredDuration("max")
+		if err != nil {
+			return nil, fmt.Errorf("invalid cap backoff: %w", err)
+		}
+		return WithCap(base, maxDelay), nil
+
+	case "maxretries":
+		base, params, err := parseWrapped(args, "retries")
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxretries backoff: %w", err)
+		}
+		value, ok := params["retries"]
+		if !ok {
+			return nil, fmt.Errorf("invalid maxretries backoff: parameter retries is required")
+		}
+		retries, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxretries backoff: parameter retries: %w", err)
+		}
+		return WithMaxRetriesAsStop(base, uint(retries)), nil
+
+	case "maxelapsed":
+		base, params, err := parseWrapped(args, "max")
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxelapsed backoff: %w", err)
+		}
+		maxElapsed, err := params.requiredDuration("max")
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxelapsed backoff: %w", err)
+		}
+		return WithMaxElapsed(base, maxElapsed), nil
+
+	default:
+		return nil, fmt.Errorf("unknown backoff type: %s", name)
+	}
+}
+
+// MustParse is like Parse but panics if the specification is invalid
+// It is meant for package-level variables, such as the backoffs of generated decorators
+func MustParse(spec string) Strategy {
+	b, err := Parse(spec)
+	if err != nil {
+		panic(fmt.Sprintf("backoff: MustParse(%q): %v", spec, err))
+	}
+	return b
+}
+
+// String returns the specification of the backoff, as accepted by Parse
+func (b *BackOff) String() string {
+	return fmt.Sprintf("exponential(min=%s,max=%s,factor=%s,jitter=%s)",
+		b.minDelay, b.maxDelay, formatFloat(b.factor), formatFloat(b.jitter))
+}
+
+// String returns the specification of the backoff, as accepted by Parse
+func (d *Decorrelated) String() string {
+	return fmt.Sprintf("decorrelated(base=%s,max=%s)", d.base, d.maxDelay)
+}
+
+// String returns the specification of the backoff, as accepted by Parse
+func (l *LinearBackOff) String() string {
+	return fmt.Sprintf("linear(min=%s,step=%s)", l.minDelay, l.step)
+}
+
+// String returns the specification of the backoff, as accepted by Parse
+func (c *Constant) String() string {
+	return fmt.Sprintf("constant(delay=%s)", c.delay)
+}
+
+// String returns the specification of the backoff, as accepted by Parse
+func (s *Scheduled) String() string {
+	delays := make([]string, 0, len(s.delays))
+	for _, d := range s.delays {
+		delays = append(delays, d.String())
+	}
+	return fmt.Sprintf("schedule(%s)", strings.Join(delays, ","))
+}
+
+// String returns the specification of the backoff, as accepted by Parse
+// Only jitter created by Parse is part of the specification, jitter functions passed to WithJitter are not
+func (j *Jittered) String() string {
+	if j.spec == "" {
+		return fmt.Sprintf("jitter(%v)", j.base)
+	}
+	return fmt.Sprintf("jitter(%v,%s)", j.base, j.spec)
+}
+
+// String returns the specification of the backoff, as accepted by Parse
+func (c *Capped) String() string {
+	return fmt.Sprintf("cap(%v,max=%s)", c.base, c.maxDelay)
+}
+
+// String returns the specification of the backoff, as accepted by Parse
+func (m *MaxRetries) String() string {
+	return fmt.Sprintf("maxretries(%v,retries=%d)", m.base, m.maxRetries)
+}
+
+// String returns the specification of the backoff, as accepted by Parse
+func (e *Elapsed) String() string {
+	return fmt.Sprintf("maxelapsed(%v,max=%s)", e.base, e.maxElapsed)
+}
+
+// splitSpec splits "name(arg1,arg2)" into its name and arguments
+// Commas inside the parentheses of a nested specification do not split arguments
+func splitSpec(spec string) (string, []string, error) {
+	spec = strings.TrimSpace(spec)
+
+	open := strings.Index(spec, "(")
+	if open < 0 || !strings.HasSuffix(spec, ")") {
+		return "", nil, fmt.Errorf("invalid backoff specification %q: expected name(parameters)", spec)
+	}
+
+	name := strings.ToLower(strings.TrimSpace(spec[:open]))
+	body := strings.TrimSpace(spec[open+1 : len(spec)-1])
+
+	var args []string
+	if body != "" {
+		depth, start := 0, 0
+		for i, r := range body {
+			switch r {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			case ',':
+				if depth == 0 {
+					args = append(args, strings.TrimSpace(body[start:i]))
+					start = i + 1
+				}
+			}
+		}
+		args = append(args, strings.TrimSpace(body[start:]))
+	}
+
+	return name, args, nil
+}
+
+// parseWrapped parses the arguments of a wrapper: the specification of the wrapped strategy and key=value parameters
+func parseWrapped(args []string, allowed ...string) (Strategy, specParams, error) {
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("the specification of the wrapped backoff is required")
+	}
+	base, err := Parse(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	params, err := parseParams(args[1:], allowed...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return base, params, nil
+}
+
+// specParams holds key=value parameters of a backoff specification
+type specParams map[string]string
+
+// parseParams parses key=value arguments, rejecting unknown or duplicate keys
+func parseParams(args []string, allowed ...string) (specParams, error) {
+	params := make(specParams, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value parameter, got %q", arg)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		known := false
+		for _, a := range allowed {
+			if key == a {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown parameter %q", key)
+		}
+		if _, exists := params[key]; exists {
+			return nil, fmt.Errorf("duplicate parameter %q", key)
+		}
+
+		params[key] = strings.TrimSpace(value)
+	}
+	return params, nil
+}
+
+// duration returns a duration parameter or the default value if it is missing
+func (p specParams) duration(key string, def time.Duration) (time.Duration, error) {
+	value, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s: %w", key, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("parameter %s must not be negative", key)
+	}
+	return d, nil
+}
+
+// requiredDuration returns a duration parameter that must be present
+func (p specParams) requiredDuration(key string) (time.Duration, error) {
+	if _, ok := p[key]; !ok {
+		return 0, fmt.Errorf("parameter %s is required", key)
+	}
+	return p.duration(key, 0)
+}
+
+// float returns a float parameter or the default value if it is missing
+func (p specParams) float(key string, def float64) (float64, error) {
+	value, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s: %w", key, err)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("parameter %s must be a finite number", key)
+	}
+	if f < 0 {
+		return 0, fmt.Errorf("parameter %s must not be negative", key)
+	}
+	return f, nil
+}
+
+// formatFloat formats a float with the minimal number of digits
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,172 @@
+package backoff_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected string
+	}{
+		{
+			name:     "exponential",
+			spec:     "exponential(min=100ms,max=10s,factor=2,jitter=0.1)",
+			expected: "exponential(min=100ms,max=10s,factor=2,jitter=0.1)",
+		},
+		{
+			name:     "exponential with defaults and spaces",
+			spec:     " Exponential( factor = 1.5 ) ",
+			expected: "exponential(min=100ms,max=10s,factor=1.5,jitter=0.1)",
+		},
+		{
+			name:     "decorrelated",
+			spec:     "decorrelated(max=5s,base=50ms)",
+			expected: "decorrelated(base=50ms,max=5s)",
+		},
+		{
+			name:     "linear",
+			spec:     "linear(min=1s,step=500ms)",
+			expected: "linear(min=1s,step=500ms)",
+		},
+		{
+			name:     "constant",
+			spec:     "constant(delay=2s)",
+			expected: "constant(delay=2s)",
+		},
+		{
+			name:     "schedule",
+			spec:     "schedule(1s, 5s, 30s)",
+			expected: "schedule(1s,5s,30s)",
+		},
+		{
+			name:     "full jitter",
+			spec:     "jitter(linear(min=100ms,step=50ms),full=0.5)",
+			expected: "jitter(linear(min=100ms,step=50ms),full=0.5)",
+		},
+		{
+			name:     "proportional jitter",
+			spec:     "jitter( constant(delay=1s) , proportional=0.2)",
+			expected: "jitter(constant(delay=1s),proportional=0.2)",
+		},
+		{
+			name:     "cap",
+			spec:     "cap(linear(min=100ms,step=50ms),max=5s)",
+			expected: "cap(linear(min=100ms,step=50ms),max=5s)",
+		},
+		{
+			name:     "maxretries",
+			spec:     "MaxRetries(schedule(1s, 5s), retries=3)",
+			expected: "maxretries(schedule(1s,5s),retries=3)",
+		},
+		{
+			name:     "maxelapsed",
+			spec:     "maxelapsed(exponential(min=100ms,max=10s),max=1m)",
+			expected: "maxelapsed(exponential(min=100ms,max=10s,factor=2,jitter=0.1),max=1m0s)",
+		},
+		{
+			name:     "nested wrappers",
+			spec:     "maxretries(cap(jitter(decorrelated(base=50ms,max=5s),full=1),max=2s),retries=5)",
+			expected: "maxretries(cap(jitter(decorrelated(base=50ms,max=5s),full=1),max=2s),retries=5)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := backoff.Parse(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fmt.Sprint(b))
+
+			// String output should parse back into the same specification
+			again, err := backoff.Parse(fmt.Sprint(b))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fmt.Sprint(again))
+		})
+	}
+}
+
+func TestParse_Values(t *testing.T) {
+	b, err := backoff.Parse("exponential(min=50ms,max=1s,factor=3,jitter=0)")
+	require.NoError(t, err)
+
+	exp, ok := b.(*backoff.BackOff)
+	require.True(t, ok)
+	assert.Equal(t, 50*time.Millisecond, exp.MinDelay())
+	assert.Equal(t, time.Second, exp.MaxDelay())
+	assert.Equal(t, 3.0, exp.Factor())
+	assert.Equal(t, 0.0, exp.Jitter())
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		spec    string
+		message string
+	}{
+		{spec: "exponential", message: "expected name(parameters)"},
+		{spec: "fibonacci(min=1s)", message: "unknown backoff type"},
+		{spec: "exponential(min=fast)", message: "parameter min"},
+		{spec: "exponential(speed=1)", message: "unknown parameter"},
+		{spec: "exponential(min=1s,min=2s)", message: "duplicate parameter"},
+		{spec: "exponential(min=10s,max=1s)", message: "lower than min"},
+		{spec: "exponential(factor=-1)", message: "must not be negative"},
+		{spec: "exponential(100ms)", message: "expected key=value"},
+		{spec: "decorrelated(base=1s)", message: "parameter max is required"},
+		{spec: "constant()", message: "parameter delay is required"},
+		{spec: "schedule()", message: "at least one delay"},
+		{spec: "schedule(1s,soon)", message: "invalid schedule backoff"},
+		{spec: "schedule(-1s)", message: "must not be negative"},
+		{spec: "schedule(5s,1s)", message: "lower than the previous delay"},
+		{spec: "exponential(factor=NaN)", message: "must be a finite number"},
+		{spec: "exponential(jitter=+Inf)", message: "must be a finite number"},
+		{spec: "decorrelated(base=10s,max=1s)", message: "lower than base"},
+		{spec: "jitter()", message: "specification of the wrapped backoff is required"},
+		{spec: "jitter(constant(delay=1s))", message: "exactly one of the parameters full and proportional"},
+		{spec: "jitter(constant(delay=1s),full=0.5,proportional=0.1)", message: "exactly one of the parameters full and proportional"},
+		{spec: "jitter(constant(delay=1s),full=2)", message: "must not exceed 1"},
+		{spec: "jitter(constant(delay=1s),proportional=Inf)", message: "must be a finite number"},
+		{spec: "cap(constant(delay=1s))", message: "parameter max is required"},
+		{spec: "cap(constant(),max=1s)", message: "invalid constant backoff"},
+		{spec: "cap(linear(min=1s,step=1s,max=5s)", message: "expected name(parameters)"},
+		{spec: "maxretries(constant(delay=1s),retries=-1)", message: "parameter retries"},
+		{spec: "maxelapsed(constant(delay=1s),timeout=1m)", message: "unknown parameter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := backoff.Parse(tt.spec)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestParse_Wrappers(t *testing.T) {
+	b, err := backoff.Parse("maxretries(constant(delay=1s),retries=2)")
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, b.MinDelay())
+	assert.Equal(t, time.Second, b.Delay(time.Second))
+	assert.Equal(t, backoff.Stop, b.Delay(time.Second))
+
+	b, err = backoff.Parse("cap(jitter(constant(delay=1s),full=1),max=500ms)")
+	require.NoError(t, err)
+	for range 10 {
+		assert.LessOrEqual(t, b.Delay(0), 500*time.Millisecond)
+	}
+
+	// Jitter functions of WithJitter are not part of the specification
+	custom := backoff.WithJitter(backoff.NewConstant(time.Second), backoff.EqualJitter())
+	assert.Equal(t, "jitter(constant(delay=1s))", custom.String())
+}
+
+func TestMustParse(t *testing.T) {
+	assert.Equal(t, "constant(delay=1s)", fmt.Sprint(backoff.MustParse("constant(delay=1s)")))
+	assert.Panics(t, func() { backoff.MustParse("constant()") })
+}
@@ -0,0 +1,51 @@
+package backoff
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// randomSource produces random numbers for jitter without locking
+//
+// Every call seeds a fresh PCG generator from the source seed and a call counter,
+// so concurrent callers never share generator state and the sequence of values
+// is reproducible for a given seed.
+type randomSource struct {
+	seed    uint64
+	counter atomic.Uint64
+}
+
+// newRandomSource creates a random source with the given seed
+func newRandomSource(seed uint64) *randomSource {
+	return &randomSource{seed: seed}
+}
+
+// newSeed returns a seed for a new random source
+// Seeds are read from crypto/rand so that replicas started at the same instant,
+// e.g. by an orchestrator, never share seeds and produce uncorrelated jitter
+func newSeed() uint64 {
+	var b [8]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		// The runtime generator is seeded from the operating system too
+		return rand.Uint64()
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Float64 returns a random value in the range [0, 1)
+func (r *randomSource) Float64() float64 {
+	var pcg rand.PCG
+	pcg.Seed(r.seed, r.counter.Add(1))
+	// Use the top 53 bits for a uniformly distributed float
+	return float64(pcg.Uint64()>>11) / (1 << 53)
+}
+
+// Int64N returns a random value in the range [0, n)
+func (r *randomSource) Int64N(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	return int64(r.Float64() * float64(n))
+}
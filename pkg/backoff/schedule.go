@@ -0,0 +1,70 @@
+package backoff
+
+import "time"
+
+// Ensure Scheduled implements Strategy
+var _ Strategy = (*Scheduled)(nil)
+
+// Scheduled implements a backoff that follows an explicit list of delays
+//
+// The delays are returned in order and the last one is repeated once the
+// schedule is exhausted. The position in the schedule is derived from the
+// previous delay, so consecutive duplicate delays are collapsed into one.
+type Scheduled struct {
+	delays []time.Duration
+}
+
+// Schedule creates a backoff returning the given delays in order and then capping at the last one
+func Schedule(delays ...time.Duration) *Scheduled {
+	s := &Scheduled{
+		delays: make([]time.Duration, len(delays)),
+	}
+	copy(s.delays, delays)
+	return s
+}
+
+// Delays returns a copy of the configured schedule
+func (s *Scheduled) Delays() []time.Duration {
+	delays := make([]time.Duration, len(s.delays))
+	copy(delays, s.delays)
+	return delays
+}
+
+// MinDelay returns the first delay of the schedule
+func (s *Scheduled) MinDelay() time.Duration {
+	if len(s.delays) == 0 {
+		return 0
+	}
+	return s.delays[0]
+}
+
+// Delay returns the delay that follows the previous one in the schedule
+func (s *Scheduled) Delay(previous time.Duration) time.Duration {
+	if len(s.delays) == 0 {
+		return 0
+	}
+
+	last := s.delays[len(s.delays)-1]
+
+	// Find the position of the previous delay and return the next different one
+	for i, d := range s.delays {
+		if d != previous {
+			continue
+		}
+		for _, next := range s.delays[i+1:] {
+			if next != previous {
+				return next
+			}
+		}
+		return last
+	}
+
+	// The previous delay is not part of the schedule, continue with the first larger delay
+	for _, d := range s.delays {
+		if d > previous {
+			return d
+		}
+	}
+
+	return last
+}
@@ -0,0 +1,60 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+func TestSchedule_FollowsDelays(t *testing.T) {
+	b := backoff.Schedule(time.Second, 5*time.Second, 30*time.Second)
+
+	assert.Equal(t, time.Second, b.MinDelay(), "MinDelay should return the first scheduled delay")
+
+	delay := b.MinDelay()
+	var got []time.Duration
+	for i := 0; i < 5; i++ {
+		got = append(got, delay)
+		delay = b.Delay(delay)
+	}
+
+	assert.Equal(t, []time.Duration{
+		time.Second,
+		5 * time.Second,
+		30 * time.Second,
+		30 * time.Second,
+		30 * time.Second,
+	}, got, "Schedule should be followed in order and cap at the last delay")
+}
+
+func TestSchedule_UnknownPrevious(t *testing.T) {
+	b := backoff.Schedule(time.Second, 5*time.Second, 30*time.Second)
+
+	assert.Equal(t, time.Second, b.Delay(0), "Previous below the schedule should start from the first delay")
+	assert.Equal(t, 5*time.Second, b.Delay(2*time.Second), "Previous between delays should continue with the next larger delay")
+	assert.Equal(t, 30*time.Second, b.Delay(time.Minute), "Previous above the schedule should cap at the last delay")
+}
+
+func TestSchedule_EdgeCases(t *testing.T) {
+	t.Run("empty schedule", func(t *testing.T) {
+		b := backoff.Schedule()
+		assert.Equal(t, time.Duration(0), b.MinDelay())
+		assert.Equal(t, time.Duration(0), b.Delay(time.Second))
+	})
+
+	t.Run("duplicate delays", func(t *testing.T) {
+		b := backoff.Schedule(time.Second, time.Second, 2*time.Second)
+		assert.Equal(t, 2*time.Second, b.Delay(time.Second), "Consecutive duplicates should not stall the schedule")
+	})
+
+	t.Run("input is copied", func(t *testing.T) {
+		delays := []time.Duration{time.Second, 2 * time.Second}
+		b := backoff.Schedule(delays...)
+		delays[0] = time.Hour
+		assert.Equal(t, time.Second, b.MinDelay())
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, b.Delays())
+	})
+}
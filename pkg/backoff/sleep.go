@@ -0,0 +1,44 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrStopped is returned when a strategy signals that no more retries should be made
+var ErrStopped = errors.New("backoff stopped")
+
+// Sleep waits for the given duration or until the context is done
+// It returns the context error if the wait was interrupted
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SleepNext computes the delay following previous, waits for it and returns it
+// It returns ErrStopped without waiting if the strategy signals Stop,
+// and the context error if the wait was interrupted
+func SleepNext(ctx context.Context, b Strategy, previous time.Duration) (time.Duration, error) {
+	delay := b.Delay(previous)
+	if delay == Stop {
+		return Stop, ErrStopped
+	}
+
+	if err := Sleep(ctx, delay); err != nil {
+		return delay, err
+	}
+
+	return delay, nil
+}
@@ -0,0 +1,64 @@
+package backoff_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+func TestSleep(t *testing.T) {
+	t.Run("waits the full duration", func(t *testing.T) {
+		start := time.Now()
+		err := backoff.Sleep(context.Background(), 20*time.Millisecond)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("returns early on cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := backoff.Sleep(ctx, time.Minute)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("zero duration reports context state", func(t *testing.T) {
+		require.NoError(t, backoff.Sleep(context.Background(), 0))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, backoff.Sleep(ctx, 0), context.Canceled)
+	})
+}
+
+func TestSleepNext(t *testing.T) {
+	t.Run("returns the waited delay", func(t *testing.T) {
+		b := backoff.Linear(time.Millisecond, time.Millisecond)
+		delay, err := backoff.SleepNext(context.Background(), b, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Millisecond, delay)
+	})
+
+	t.Run("stops when the strategy stops", func(t *testing.T) {
+		b := backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Hour), 1)
+		delay, err := backoff.SleepNext(context.Background(), b, time.Hour)
+		require.ErrorIs(t, err, backoff.ErrStopped)
+		assert.Equal(t, backoff.Stop, delay)
+	})
+
+	t.Run("returns early on cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		delay, err := backoff.SleepNext(ctx, backoff.NewConstant(time.Hour), 0)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, time.Hour, delay)
+	})
+}
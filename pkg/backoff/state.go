@@ -0,0 +1,132 @@
+package backoff
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stateVersion is the first byte of the binary encoding of State
+const stateVersion = 1
+
+// State is the progress of a retry schedule
+//
+// Strategies are stateless: each delay is computed from the previous one. Long-running
+// workflows such as queue consumers and durable tasks persist a State between attempts,
+// with MarshalText or MarshalBinary, to resume the schedule after a restart instead of
+// starting over at MinDelay:
+//
+//	var state backoff.State
+//	_ = state.UnmarshalText([]byte(task.RetryState))
+//	delay := state.Next(b)
+//	task.RetryState, _ = state.MarshalText()
+type State struct {
+	// Attempt is the number of delays handed out by Next
+	Attempt uint
+
+	// Delay is the last delay handed out by Next, zero before the first one
+	Delay time.Duration
+}
+
+// Next returns the delay to wait before the next attempt and records it in the state
+// The first delay is the MinDelay of the strategy; when the strategy returns Stop, Stop is
+// returned and the state is left unchanged
+func (s *State) Next(b Strategy) time.Duration {
+	delay := b.MinDelay()
+	if s.Attempt > 0 {
+		delay = b.Delay(s.Delay)
+	}
+	if delay == Stop {
+		return Stop
+	}
+
+	s.Attempt++
+	s.Delay = delay
+	return delay
+}
+
+// Reset restarts the schedule, e.g. after a successful attempt
+func (s *State) Reset() {
+	*s = State{}
+}
+
+// String returns the textual encoding of the state
+func (s State) String() string {
+	return fmt.Sprintf("attempt=%d,delay=%s", s.Attempt, s.Delay)
+}
+
+// MarshalText encodes the state as text such as "attempt=3,delay=400ms"
+// JSON and YAML encoders use it, so a State field is persisted as a readable string
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state encoded by MarshalText
+func (s *State) UnmarshalText(text []byte) error {
+	var args []string
+	if body := strings.TrimSpace(string(text)); body != "" {
+		args = strings.Split(body, ",")
+	}
+	params, err := parseParams(args, "attempt", "delay")
+	if err != nil {
+		return fmt.Errorf("invalid backoff state %q: %w", text, err)
+	}
+
+	var state State
+	if value, ok := params["attempt"]; ok {
+		attempt, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid backoff state %q: attempt: %w", text, err)
+		}
+		state.Attempt = uint(attempt)
+	}
+	if state.Delay, err = params.duration("delay", 0); err != nil {
+		return fmt.Errorf("invalid backoff state %q: %w", text, err)
+	}
+
+	*s = state
+	return nil
+}
+
+// MarshalBinary encodes the state in a compact versioned binary form
+func (s State) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 1, 1+2*binary.MaxVarintLen64)
+	buf[0] = stateVersion
+	buf = binary.AppendUvarint(buf, uint64(s.Attempt))
+	buf = binary.AppendUvarint(buf, uint64(s.Delay))
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a state encoded by MarshalBinary
+func (s *State) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("invalid backoff state: empty data")
+	}
+	if data[0] != stateVersion {
+		return fmt.Errorf("invalid backoff state: unknown version %d", data[0])
+	}
+	data = data[1:]
+
+	attempt, n := binary.Uvarint(data)
+	if n <= 0 {
+		return errors.New("invalid backoff state: malformed attempt")
+	}
+	data = data[n:]
+
+	delay, n := binary.Uvarint(data)
+	if n <= 0 || delay > uint64(maxDuration) {
+		return errors.New("invalid backoff state: malformed delay")
+	}
+	if n != len(data) {
+		return errors.New("invalid backoff state: trailing data")
+	}
+
+	*s = State{Attempt: uint(attempt), Delay: time.Duration(delay)}
+	return nil
+}
+
+// maxDuration is the largest time.Duration
+const maxDuration = time.Duration(1<<63 - 1)
@@ -0,0 +1,72 @@
+package backoff_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+func TestState(t *testing.T) {
+	b := backoff.New(100*time.Millisecond, time.Second, 2.0, 0)
+
+	var state backoff.State
+	assert.Equal(t, 100*time.Millisecond, state.Next(b))
+	assert.Equal(t, 200*time.Millisecond, state.Next(b))
+
+	// A restarted worker resumes the schedule from the persisted state
+	text, err := state.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "attempt=2,delay=200ms", string(text))
+
+	var resumed backoff.State
+	require.NoError(t, resumed.UnmarshalText(text))
+	assert.Equal(t, state, resumed)
+	assert.Equal(t, 400*time.Millisecond, resumed.Next(b))
+	assert.Equal(t, uint(3), resumed.Attempt)
+
+	data, err := resumed.MarshalBinary()
+	require.NoError(t, err)
+	var decoded backoff.State
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, resumed, decoded)
+
+	resumed.Reset()
+	assert.Equal(t, backoff.State{}, resumed)
+
+	t.Run("stop leaves the state unchanged", func(t *testing.T) {
+		limited := backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Second), 1)
+		var state backoff.State
+		assert.Equal(t, time.Second, state.Next(limited))
+		before := state
+		assert.Equal(t, backoff.Stop, state.Next(limited))
+		assert.Equal(t, before, state)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		type task struct {
+			Retry backoff.State `json:"retry"`
+		}
+		data, err := json.Marshal(task{Retry: backoff.State{Attempt: 1, Delay: time.Second}})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"retry":"attempt=1,delay=1s"}`, string(data))
+
+		var decoded task
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, backoff.State{Attempt: 1, Delay: time.Second}, decoded.Retry)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var state backoff.State
+		assert.Error(t, state.UnmarshalText([]byte("attempt=x")))
+		assert.Error(t, state.UnmarshalText([]byte("delay=-1s")))
+		assert.Error(t, state.UnmarshalText([]byte("step=1s")))
+		assert.Error(t, state.UnmarshalBinary(nil))
+		assert.Error(t, state.UnmarshalBinary([]byte{9, 0, 0}))
+		assert.Error(t, state.UnmarshalBinary([]byte{1, 0, 0, 0}))
+	})
+}
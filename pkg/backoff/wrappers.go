@@ -0,0 +1,244 @@
+package backoff
+
+import (
+	"sync"
+	"time"
+)
+
+// Stop is returned by strategies that want the caller to stop retrying
+const Stop time.Duration = -1
+
+// Ensure the wrappers implement Strategy
+var (
+	_ Strategy = (*Constant)(nil)
+	_ Strategy = (*LinearBackOff)(nil)
+	_ Strategy = (*Jittered)(nil)
+	_ Strategy = (*Capped)(nil)
+	_ Strategy = (*MaxRetries)(nil)
+)
+
+// Constant implements a backoff that always waits the same delay
+type Constant struct {
+	delay time.Duration
+}
+
+// NewConstant creates a backoff that always returns the given delay
+func NewConstant(delay time.Duration) *Constant {
+	return &Constant{delay: delay}
+}
+
+// MinDelay returns the constant delay
+func (c *Constant) MinDelay() time.Duration {
+	return c.delay
+}
+
+// Delay returns the constant delay
+func (c *Constant) Delay(time.Duration) time.Duration {
+	return c.delay
+}
+
+// LinearBackOff implements a backoff that grows by a fixed step after each attempt
+type LinearBackOff struct {
+	minDelay time.Duration
+	step     time.Duration
+}
+
+// Linear creates a backoff starting at minDelay and growing by step
+// Combine it with WithCap to bound the delay
+func Linear(minDelay, step time.Duration) *LinearBackOff {
+	return &LinearBackOff{
+		minDelay: minDelay,
+		step:     step,
+	}
+}
+
+// MinDelay returns the minimum configured delay
+func (l *LinearBackOff) MinDelay() time.Duration {
+	return l.minDelay
+}
+
+// Delay returns the previous delay increased by the step
+func (l *LinearBackOff) Delay(previous time.Duration) time.Duration {
+	if previous < l.minDelay {
+		previous = l.minDelay
+	}
+
+	delay := previous + l.step
+	if delay < previous {
+		// Overflow, stay at the previous delay
+		return previous
+	}
+	return delay
+}
+
+// Jittered wraps a strategy to apply jitter to every delay
+type Jittered struct {
+	base     Strategy
+	jitterFn JitterFunc
+	rnd      *randomSource
+	spec     string // parameter of the jitter in the specification of Parse, empty for other jitter functions
+}
+
+// WithJitter wraps a strategy to apply the given jitter to every delay
+// Jittered delays never go below the MinDelay of the wrapped strategy
+func WithJitter(base Strategy, jitterFn JitterFunc) *Jittered {
+	return WithJitterSeed(base, jitterFn, newSeed())
+}
+
+// WithJitterSeed is like WithJitter using the provided seed for jitter
+// A fixed seed makes the produced delays reproducible
+func WithJitterSeed(base Strategy, jitterFn JitterFunc, seed uint64) *Jittered {
+	return &Jittered{
+		base:     base,
+		jitterFn: jitterFn,
+		rnd:      newRandomSource(seed),
+	}
+}
+
+// MinDelay returns the minimum delay of the wrapped strategy
+func (j *Jittered) MinDelay() time.Duration {
+	return j.base.MinDelay()
+}
+
+// Delay returns the jittered delay of the wrapped strategy
+func (j *Jittered) Delay(previous time.Duration) time.Duration {
+	delay := j.base.Delay(previous)
+	if delay == Stop {
+		return Stop
+	}
+
+	delay = j.jitterFn(delay, j.rnd.Float64())
+	if minDelay := j.base.MinDelay(); delay < minDelay {
+		delay = minDelay
+	}
+	return delay
+}
+
+// newSequence copies the wrapper when the wrapped strategy keeps state, sharing the random source
+func (j *Jittered) newSequence() (Strategy, bool) {
+	base, ok := newSequence(j.base)
+	if !ok {
+		return j, false
+	}
+	return &Jittered{base: base, jitterFn: j.jitterFn, rnd: j.rnd, spec: j.spec}, true
+}
+
+// Capped wraps a strategy to never exceed a maximum delay
+type Capped struct {
+	base     Strategy
+	maxDelay time.Duration
+}
+
+// WithCap wraps a strategy to never return a delay above maxDelay
+func WithCap(base Strategy, maxDelay time.Duration) *Capped {
+	return &Capped{
+		base:     base,
+		maxDelay: maxDelay,
+	}
+}
+
+// MinDelay returns the minimum delay of the wrapped strategy, capped at the maximum delay
+func (c *Capped) MinDelay() time.Duration {
+	return min(c.base.MinDelay(), c.maxDelay)
+}
+
+// MaxDelay returns the maximum configured delay
+func (c *Capped) MaxDelay() time.Duration {
+	return c.maxDelay
+}
+
+// Delay returns the delay of the wrapped strategy, capped at the maximum delay
+func (c *Capped) Delay(previous time.Duration) time.Duration {
+	delay := c.base.Delay(previous)
+	if delay == Stop {
+		return Stop
+	}
+	return min(delay, c.maxDelay)
+}
+
+// newSequence copies the wrapper when the wrapped strategy keeps state
+func (c *Capped) newSequence() (Strategy, bool) {
+	base, ok := newSequence(c.base)
+	if !ok {
+		return c, false
+	}
+	return WithCap(base, c.maxDelay), true
+}
+
+// MaxRetries wraps a strategy to return Stop after a number of retries
+//
+// Unlike the other strategies, MaxRetries keeps track of how many delays it has
+// returned, so an instance must not be shared between concurrent retry loops.
+// Call Reset before reusing it for a new sequence of retries, or use the copy
+// returned by NewSequence, as retry.Do does for each call.
+type MaxRetries struct {
+	base       Strategy
+	maxRetries uint
+	retries    uint
+	mu         sync.Mutex // protects retries
+}
+
+// WithMaxRetriesAsStop wraps a strategy to return Stop once maxRetries delays have been returned
+func WithMaxRetriesAsStop(base Strategy, maxRetries uint) *MaxRetries {
+	return &MaxRetries{
+		base:       base,
+		maxRetries: maxRetries,
+	}
+}
+
+// MinDelay returns the minimum delay of the wrapped strategy, or Stop when no retries are allowed
+func (m *MaxRetries) MinDelay() time.Duration {
+	if m.maxRetries == 0 {
+		return Stop
+	}
+	return m.base.MinDelay()
+}
+
+// Delay returns the delay of the wrapped strategy or Stop once the retries are used up
+// The first retry waits MinDelay, so Delay provides the delays from the second retry on
+func (m *MaxRetries) Delay(previous time.Duration) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.retries+1 >= m.maxRetries {
+		return Stop
+	}
+	m.retries++
+	return m.base.Delay(previous)
+}
+
+// Reset starts a new sequence of retries
+func (m *MaxRetries) Reset() {
+	m.mu.Lock()
+	m.retries = 0
+	m.mu.Unlock()
+}
+
+// newSequence returns a copy counting no retries
+func (m *MaxRetries) newSequence() (Strategy, bool) {
+	base, _ := newSequence(m.base)
+	return WithMaxRetriesAsStop(base, m.maxRetries), true
+}
+
+// sequenced is implemented by strategies keeping state across the delays of a sequence of retries,
+// and by the wrappers around strategies that may
+type sequenced interface {
+	// newSequence returns the strategy for a new sequence and whether it is a copy holding fresh state
+	newSequence() (Strategy, bool)
+}
+
+// NewSequence returns the strategy to use for a new sequence of retries
+// Strategies keeping state across delays, such as MaxRetries and Elapsed, are copied with their state reset,
+// along with the wrappers around them, so that each retry loop has its own; other strategies are returned as is
+func NewSequence(s Strategy) Strategy {
+	fresh, _ := newSequence(s)
+	return fresh
+}
+
+// newSequence returns the strategy for a new sequence and whether it is a copy holding fresh state
+func newSequence(s Strategy) (Strategy, bool) {
+	if seq, ok := s.(sequenced); ok {
+		return seq.newSequence()
+	}
+	return s, false
+}
@@ -0,0 +1,122 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+func TestJitterFuncs(t *testing.T) {
+	delay := time.Second
+
+	assert.Equal(t, delay, backoff.NoJitter()(delay, 0.7), "NoJitter should keep the delay")
+
+	assert.Equal(t, 950*time.Millisecond, backoff.ProportionalJitter(0.1)(delay, 0), "Proportional jitter lower bound")
+	assert.Equal(t, 1050*time.Millisecond, backoff.ProportionalJitter(0.1)(delay, 1), "Proportional jitter upper bound")
+
+	assert.Equal(t, delay, backoff.FullJitter(1)(delay, 0), "Full jitter upper bound")
+	assert.Equal(t, time.Duration(0), backoff.FullJitter(1)(delay, 1), "Full jitter lower bound")
+	assert.Equal(t, 800*time.Millisecond, backoff.FullJitter(0.2)(delay, 1), "Partial full jitter lower bound")
+	assert.Equal(t, delay, backoff.FullJitter(-1)(delay, 1), "Negative fraction should disable jitter")
+
+	assert.Equal(t, 500*time.Millisecond, backoff.EqualJitter()(delay, 1), "Equal jitter lower bound")
+}
+
+func TestConstant(t *testing.T) {
+	b := backoff.NewConstant(time.Second)
+	assert.Equal(t, time.Second, b.MinDelay())
+	assert.Equal(t, time.Second, b.Delay(time.Hour))
+}
+
+func TestLinear(t *testing.T) {
+	b := backoff.Linear(100*time.Millisecond, 50*time.Millisecond)
+
+	assert.Equal(t, 100*time.Millisecond, b.MinDelay())
+	assert.Equal(t, 150*time.Millisecond, b.Delay(b.MinDelay()))
+	assert.Equal(t, 200*time.Millisecond, b.Delay(150*time.Millisecond))
+	assert.Equal(t, 150*time.Millisecond, b.Delay(0), "Previous below minDelay should start from minDelay")
+}
+
+func TestWithJitter(t *testing.T) {
+	b := backoff.WithJitter(backoff.Linear(100*time.Millisecond, time.Second), backoff.FullJitter(0.2))
+
+	assert.Equal(t, 100*time.Millisecond, b.MinDelay())
+
+	uniqueValues := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		delay := b.Delay(time.Second)
+		assert.GreaterOrEqual(t, delay, 1600*time.Millisecond, "Jitter should only randomize 20% of the delay")
+		assert.LessOrEqual(t, delay, 2*time.Second, "Jitter should not exceed the base delay")
+		uniqueValues[delay] = true
+	}
+	assert.Greater(t, len(uniqueValues), 1, "Jitter should produce varying delays")
+
+	t.Run("respects min delay", func(t *testing.T) {
+		b := backoff.WithJitter(backoff.NewConstant(time.Second), backoff.FullJitter(1))
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, time.Second, b.Delay(time.Second))
+		}
+	})
+
+	t.Run("reproducible with a seed", func(t *testing.T) {
+		first := backoff.WithJitterSeed(backoff.NewConstant(time.Second), backoff.FullJitter(0.5), 42)
+		second := backoff.WithJitterSeed(backoff.NewConstant(time.Second), backoff.FullJitter(0.5), 42)
+		for i := 0; i < 20; i++ {
+			assert.Equal(t, first.Delay(2*time.Second), second.Delay(2*time.Second))
+		}
+	})
+}
+
+func TestWithCap(t *testing.T) {
+	b := backoff.WithCap(backoff.Linear(100*time.Millisecond, time.Second), 1500*time.Millisecond)
+
+	assert.Equal(t, 100*time.Millisecond, b.MinDelay())
+	assert.Equal(t, 1500*time.Millisecond, b.MaxDelay())
+	assert.Equal(t, 1100*time.Millisecond, b.Delay(100*time.Millisecond))
+	assert.Equal(t, 1500*time.Millisecond, b.Delay(1100*time.Millisecond), "Delay should be capped")
+
+	assert.Equal(t, time.Second, backoff.WithCap(backoff.NewConstant(time.Minute), time.Second).MinDelay(),
+		"MinDelay should be capped too")
+}
+
+func TestWithMaxRetriesAsStop(t *testing.T) {
+	b := backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Second), 3)
+
+	// The first retry waits MinDelay, the next two come from Delay
+	assert.Equal(t, time.Second, b.MinDelay())
+	assert.Equal(t, time.Second, b.Delay(time.Second))
+	assert.Equal(t, time.Second, b.Delay(time.Second))
+	assert.Equal(t, backoff.Stop, b.Delay(time.Second))
+
+	b.Reset()
+	assert.Equal(t, time.Second, b.Delay(time.Second), "Reset should start a new sequence")
+
+	t.Run("zero retries", func(t *testing.T) {
+		b := backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Second), 0)
+		assert.Equal(t, backoff.Stop, b.MinDelay())
+	})
+
+	t.Run("stop passes through wrappers", func(t *testing.T) {
+		stopping := backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Second), 1)
+		b := backoff.WithCap(backoff.WithJitter(stopping, backoff.FullJitter(1)), time.Minute)
+		assert.Equal(t, backoff.Stop, b.Delay(time.Second))
+	})
+
+	t.Run("new sequences count their own retries", func(t *testing.T) {
+		limited := backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Second), 2)
+		b := backoff.WithJitter(limited, backoff.FullJitter(0))
+		assert.Equal(t, time.Second, b.Delay(time.Second))
+		assert.Equal(t, backoff.Stop, b.Delay(time.Second))
+
+		fresh := backoff.NewSequence(b)
+		assert.Equal(t, time.Second, fresh.Delay(time.Second), "A new sequence should not share the count")
+		assert.Equal(t, backoff.Stop, fresh.Delay(time.Second))
+		assert.Equal(t, backoff.Stop, b.Delay(time.Second), "The original should keep its count")
+
+		constant := backoff.NewConstant(time.Second)
+		assert.Same(t, constant, backoff.NewSequence(constant), "Stateless strategies should be returned as is")
+	})
+}
@@ -0,0 +1,141 @@
+// Package decogentest runs decogen against fixture interfaces and compares the output with golden files.
+//
+// Teams maintaining their own decorator configurations test them the same way
+// decogen tests its templates: a fixture source file, the generated code checked
+// in as a golden file, and "go test -update" to refresh the golden files after
+// an intended change.
+//
+// Example usage:
+//
+//	func TestRetryDecorator(t *testing.T) {
+//		decogentest.Run(t, decogentest.Case{
+//			Source:     "testdata/storage.go",
+//			Interface:  "UserStorage",
+//			Decorators: []string{"retry"},
+//			Golden:     "testdata/storage_retry.golden",
+//		})
+//	}
+package decogentest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/internal/config"
+	"github.com/komandakycto/decogen/internal/generator"
+	"github.com/komandakycto/decogen/internal/parser"
+)
+
+// update rewrites golden files with the generated output instead of comparing them
+var update = flag.Bool("update", false, "update golden files")
+
+// Case describes a single generation to check
+type Case struct {
+	// Source is the fixture file declaring the interface
+	Source string
+
+	// Interface is the name of the interface to decorate
+	Interface string
+
+	// Decorators are the decorator names to generate, in order
+	Decorators []string
+
+	// Options holds the settings of each decorator by name, as in the configuration file
+	Options map[string]map[string]interface{}
+
+	// DI selects a dependency injection framework to emit providers for
+	DI string
+
+	// Package is the package of the generated code
+	// Defaults to the package of the fixture
+	Package string
+
+	// Golden is the file holding the expected output
+	Golden string
+}
+
+// Generate runs decogen for the case and returns the generated code
+func Generate(t testing.TB, c Case) []byte {
+	t.Helper()
+
+	cfg := &config.Config{DI: c.DI}
+	cfg.Interface.Name = c.Interface
+	cfg.Interface.Source = c.Source
+	for _, name := range c.Decorators {
+		cfg.Decorators = append(cfg.Decorators, struct {
+			Name   string                 `json:"name"`
+			Config map[string]interface{} `json:"config"`
+		}{
+			Name:   name,
+			Config: c.Options[name],
+		})
+	}
+
+	decoratorTypes, err := cfg.GetDecoratorTypes()
+	require.NoError(t, err, "invalid decorators")
+	options, err := cfg.GetDecoratorOptions()
+	require.NoError(t, err, "invalid decorator options")
+
+	interfaceModel, err := parser.ParseInterface(c.Source, c.Interface)
+	require.NoError(t, err, "failed to parse fixture")
+
+	pkg := c.Package
+	if pkg == "" {
+		pkg = interfaceModel.PackageName
+	}
+
+	gen, err := generator.NewGenerator()
+	require.NoError(t, err, "failed to create generator")
+
+	// Decorators are generated one file each and concatenated in order
+	var out []byte
+	for _, dt := range decoratorTypes {
+		outputPath := filepath.Join(t.TempDir(), string(dt)+".go")
+		err := gen.Generate(interfaceModel, []generator.DecoratorType{dt}, pkg, outputPath, options)
+		require.NoError(t, err, "failed to generate %s decorator", dt)
+
+		code, err := os.ReadFile(outputPath)
+		require.NoError(t, err)
+		out = append(out, code...)
+
+		// Decorators with a build constraint are followed by their no-op variant
+		if variant, err := os.ReadFile(generator.VariantPath(outputPath)); err == nil {
+			out = append(out, variant...)
+		}
+	}
+
+	return out
+}
+
+// Run generates the code for the case and compares it with the golden file
+// With -update, the golden file is rewritten instead
+func Run(t testing.TB, c Case) {
+	t.Helper()
+
+	AssertGolden(t, c.Golden, Generate(t, c))
+}
+
+// AssertGolden compares got with the content of the golden file
+// With -update, the golden file is rewritten instead
+func AssertGolden(t testing.TB, golden string, got []byte) {
+	t.Helper()
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0755))
+		require.NoError(t, os.WriteFile(golden, got, 0644), "failed to update golden file")
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err, "failed to read golden file, run the test with -update to create it")
+
+	// Golden files checked out with CRLF line endings, as on Windows, still match the generated code
+	want = bytes.ReplaceAll(want, []byte("\r\n"), []byte("\n"))
+	assert.Equal(t, string(want), string(got), "generated code differs from %s, run the test with -update to accept it", golden)
+}
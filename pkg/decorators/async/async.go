@@ -0,0 +1,260 @@
+// Package async provides the runtime used by generated async decorators.
+//
+// A Pool runs fire-and-forget calls, such as analytics or audit writes, on a
+// bounded set of workers so that callers return as soon as the call is queued.
+// When the queue is full the Overflow policy decides whether Submit waits,
+// drops the call or returns ErrQueueFull. Failures of queued calls cannot reach
+// the caller anymore and are reported to Config.OnError.
+//
+// Example usage:
+//
+//	pool := async.New(async.Config{
+//		Name:      "audit",
+//		Workers:   4,
+//		QueueSize: 1000,
+//		Overflow:  async.OverflowDrop,
+//		OnError: func(name string, err error) {
+//			log.Printf("%s: %v", name, err)
+//		},
+//	})
+//	defer pool.Close(context.Background())
+//
+//	err := pool.Submit(ctx, func(ctx context.Context) error {
+//		return sink.Record(ctx, event)
+//	})
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Common errors returned by the pool
+var (
+	// ErrQueueFull is returned by Submit when the queue is full and the overflow policy is OverflowError
+	ErrQueueFull = errors.New("async queue is full")
+
+	// ErrClosed is returned by Submit after Close
+	ErrClosed = errors.New("async pool is closed")
+)
+
+// Overflow is the policy applied when a call is submitted to a full queue
+type Overflow int
+
+const (
+	// OverflowBlock waits for room in the queue, or for the context of the call to be done
+	OverflowBlock Overflow = iota
+	// OverflowDrop discards the call and reports success
+	OverflowDrop
+	// OverflowError discards the call and returns ErrQueueFull
+	OverflowError
+)
+
+// String returns the name of the policy
+func (o Overflow) String() string {
+	switch o {
+	case OverflowBlock:
+		return "block"
+	case OverflowDrop:
+		return "drop"
+	case OverflowError:
+		return "error"
+	default:
+		return fmt.Sprintf("Overflow(%d)", int(o))
+	}
+}
+
+// Config holds the configuration of a pool
+type Config struct {
+	// Name identifies the pool in callbacks
+	Name string
+
+	// Workers is the number of calls run at once
+	// Defaults to 1
+	Workers int
+
+	// QueueSize is the number of calls waiting for a worker
+	// Zero means calls are only accepted when a worker is free
+	QueueSize int
+
+	// Overflow is the policy applied when the queue is full
+	Overflow Overflow
+
+	// OnError is an optional callback called with the error of every failed call
+	// A panicking call is reported as an error
+	OnError func(name string, err error)
+
+	// OnDrop is an optional callback called when a call is discarded because the queue is full
+	OnDrop func(name string)
+}
+
+// task is a queued call
+type task struct {
+	ctx context.Context
+	op  func(context.Context) error
+}
+
+// Pool runs submitted calls on a bounded set of workers
+type Pool struct {
+	config Config
+	queue  chan task
+
+	closeMu sync.RWMutex // held for reading while sending to queue, for writing while closing it
+	closed  bool
+
+	mu      sync.Mutex // protects pending and idle
+	pending int
+	idle    []chan struct{} // closed when pending drops to zero
+
+	workers sync.WaitGroup
+}
+
+// New creates a pool with the given configuration and starts its workers
+func New(config Config) *Pool {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.QueueSize < 0 {
+		config.QueueSize = 0
+	}
+
+	p := &Pool{
+		config: config,
+		queue:  make(chan task, config.QueueSize),
+	}
+	p.workers.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Name returns the name of the pool
+func (p *Pool) Name() string {
+	return p.config.Name
+}
+
+// Describe summarizes the configuration of the pool, for decorators.Info
+func (p *Pool) Describe() string {
+	return fmt.Sprintf("name=%q workers=%d queue=%d overflow=%s", p.config.Name, p.config.Workers, p.config.QueueSize, p.config.Overflow)
+}
+
+// Submit queues op to run on a worker and returns without waiting for it
+// op runs with a context detached from the cancellation of ctx, as the caller does not wait for it
+func (p *Pool) Submit(ctx context.Context, op func(context.Context) error) error {
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	t := task{ctx: context.WithoutCancel(ctx), op: op}
+	p.add(1)
+
+	select {
+	case p.queue <- t:
+		return nil
+	default:
+	}
+
+	if p.config.Overflow == OverflowBlock {
+		select {
+		case p.queue <- t:
+			return nil
+		case <-ctx.Done():
+			p.add(-1)
+			return ctx.Err()
+		}
+	}
+
+	p.add(-1)
+	if p.config.OnDrop != nil {
+		p.config.OnDrop(p.config.Name)
+	}
+	if p.config.Overflow == OverflowDrop {
+		return nil
+	}
+	return ErrQueueFull
+}
+
+// Flush waits until every call submitted so far has run, or until ctx is done
+func (p *Pool) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	if p.pending == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	idle := make(chan struct{})
+	p.idle = append(p.idle, idle)
+	p.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting calls and waits until the queued calls have run, or until ctx is done
+// Calls still queued when ctx is done keep running in the background
+func (p *Pool) Close(ctx context.Context) error {
+	p.closeMu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.closeMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// add changes the number of pending calls, waking Flush callers when it drops to zero
+func (p *Pool) add(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pending += delta
+	if p.pending == 0 {
+		for _, idle := range p.idle {
+			close(idle)
+		}
+		p.idle = nil
+	}
+}
+
+// work runs queued calls until the queue is closed
+func (p *Pool) work() {
+	defer p.workers.Done()
+
+	for t := range p.queue {
+		if err := p.run(t); err != nil && p.config.OnError != nil {
+			p.config.OnError(p.config.Name, err)
+		}
+		p.add(-1)
+	}
+}
+
+// run runs a call, turning a panic into an error so that the worker survives it
+func (p *Pool) run(t task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("async call panicked: %v", r)
+		}
+	}()
+	return t.op(t.ctx)
+}
@@ -0,0 +1,105 @@
+package async_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/async"
+)
+
+// TestPool tests running submitted calls and the overflow policies
+func TestPool(t *testing.T) {
+	t.Run("runs calls and reports errors", func(t *testing.T) {
+		var (
+			mu     sync.Mutex
+			errs   []error
+			called atomic.Int32
+		)
+		failure := errors.New("audit: sink down")
+		pool := async.New(async.Config{
+			Name:    "audit",
+			Workers: 2,
+			OnError: func(name string, err error) {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				mu.Unlock()
+			},
+		})
+		require.Equal(t, `name="audit" workers=2 queue=0 overflow=block`, pool.Describe())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		for i := 0; i < 10; i++ {
+			require.NoError(t, pool.Submit(ctx, func(ctx context.Context) error {
+				called.Add(1)
+				return ctx.Err()
+			}))
+		}
+		cancel() // Queued calls do not inherit the cancellation of the caller
+		require.NoError(t, pool.Submit(context.Background(), func(context.Context) error { return failure }))
+		require.NoError(t, pool.Submit(context.Background(), func(context.Context) error { panic("boom") }))
+
+		require.NoError(t, pool.Flush(context.Background()))
+		require.Equal(t, int32(10), called.Load())
+		require.Len(t, errs, 2)
+		require.ErrorContains(t, errors.Join(errs...), "audit: sink down")
+		require.ErrorContains(t, errors.Join(errs...), "async call panicked: boom")
+
+		require.NoError(t, pool.Close(context.Background()))
+		require.ErrorIs(t, pool.Submit(context.Background(), func(context.Context) error { return nil }), async.ErrClosed)
+	})
+
+	for _, tt := range []struct {
+		overflow async.Overflow
+		err      error
+	}{
+		{overflow: async.OverflowDrop},
+		{overflow: async.OverflowError, err: async.ErrQueueFull},
+		{overflow: async.OverflowBlock, err: context.DeadlineExceeded},
+	} {
+		t.Run("overflow "+tt.overflow.String(), func(t *testing.T) {
+			var dropped int
+			pool := async.New(async.Config{
+				QueueSize: 1,
+				Overflow:  tt.overflow,
+				OnDrop:    func(string) { dropped++ },
+			})
+
+			release := make(chan struct{})
+			started := make(chan struct{})
+			require.NoError(t, pool.Submit(context.Background(), func(context.Context) error {
+				close(started)
+				<-release
+				return nil
+			}))
+			<-started
+			require.NoError(t, pool.Submit(context.Background(), func(context.Context) error { return nil }))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			err := pool.Submit(ctx, func(context.Context) error { return nil })
+			if tt.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.err)
+			}
+			if tt.overflow != async.OverflowBlock {
+				require.Equal(t, 1, dropped)
+			}
+
+			flushCtx, flushCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer flushCancel()
+			require.ErrorIs(t, pool.Flush(flushCtx), context.DeadlineExceeded)
+
+			close(release)
+			require.NoError(t, pool.Close(context.Background()))
+			require.NoError(t, pool.Flush(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// Package bulkhead provides the runtime used by generated bulkhead decorators.
+//
+// A Bulkhead is a weighted semaphore limiting how many calls to a dependency
+// run at once, so a slow dependency cannot exhaust the goroutines, connections
+// or memory of the whole service. Callers over the limit wait in FIFO order for
+// at most QueueTimeout; the queue itself can be bounded with MaxQueue.
+//
+// A Set hands out one bulkhead per method (partition), so a slow method cannot
+// starve the others.
+//
+// Example usage:
+//
+//	b := bulkhead.New(bulkhead.Config{
+//		Name:          "users-db",
+//		MaxConcurrent: 10,
+//		MaxQueue:      100,
+//		QueueTimeout:  50 * time.Millisecond,
+//	})
+//
+//	user, err := bulkhead.ExecuteWithValue(ctx, b, func(ctx context.Context) (*User, error) {
+//		return storage.GetByID(ctx, id)
+//	})
+package bulkhead
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Common errors returned by the bulkhead
+var (
+	// ErrFull is returned when a call is rejected because the bulkhead and its queue are full
+	ErrFull = errors.New("bulkhead is full")
+
+	// ErrQueueTimeout is returned when a call waited QueueTimeout without getting a slot
+	ErrQueueTimeout = errors.New("bulkhead queue timeout")
+)
+
+// Hooks are optional callbacks reporting bulkhead activity, typically to metrics
+type Hooks struct {
+	// OnAcquire is called when a call gets a slot, with the time spent queued
+	// and the weight in use after acquiring
+	OnAcquire func(name string, waited time.Duration, inUse int64)
+
+	// OnReject is called when a call is rejected with ErrFull, ErrQueueTimeout or a context error
+	OnReject func(name string, err error)
+
+	// OnRelease is called when a call releases its slot, with the weight still in use
+	OnRelease func(name string, inUse int64)
+}
+
+// Config holds the configuration of a bulkhead
+type Config struct {
+	// Name identifies the bulkhead in hooks
+	Name string
+
+	// MaxConcurrent is the total weight of calls allowed to run at once
+	MaxConcurrent int64
+
+	// MaxQueue is the maximum number of waiting calls
+	// Zero means the queue is unbounded
+	MaxQueue int
+
+	// QueueTimeout is the maximum time a call waits for a slot
+	// Zero means calls are rejected immediately when the bulkhead is full
+	QueueTimeout time.Duration
+
+	// Hooks are optional callbacks reporting activity
+	Hooks Hooks
+}
+
+// Stats is a point-in-time view of a bulkhead's saturation
+type Stats struct {
+	// InUse is the weight currently held by running calls
+	InUse int64 `json:"in_use"`
+
+	// Waiting is the number of queued calls
+	Waiting int `json:"waiting"`
+
+	// Capacity is the configured MaxConcurrent
+	Capacity int64 `json:"capacity"`
+}
+
+// Saturation returns the fraction of the capacity in use, between 0 and 1
+func (s Stats) Saturation() float64 {
+	if s.Capacity <= 0 {
+		return 0
+	}
+	return float64(s.InUse) / float64(s.Capacity)
+}
+
+// Bulkhead limits the concurrency of calls with a weighted semaphore
+type Bulkhead struct {
+	config Config
+
+	mu      sync.Mutex // protects inUse and waiters
+	inUse   int64
+	waiters list.List // of *waiter, in arrival order
+}
+
+// waiter is a queued call
+type waiter struct {
+	weight int64
+	ready  chan struct{} // closed when the weight was granted
+}
+
+// New creates a bulkhead with the given configuration
+func New(config Config) *Bulkhead {
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = 1
+	}
+	return &Bulkhead{config: config}
+}
+
+// Name returns the name of the bulkhead
+func (b *Bulkhead) Name() string {
+	return b.config.Name
+}
+
+// Stats returns the current saturation of the bulkhead
+func (b *Bulkhead) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return Stats{
+		InUse:    b.inUse,
+		Waiting:  b.waiters.Len(),
+		Capacity: b.config.MaxConcurrent,
+	}
+}
+
+// TryAcquire acquires the given weight without waiting, reporting whether it succeeded
+func (b *Bulkhead) TryAcquire(weight int64) bool {
+	b.mu.Lock()
+	ok := b.fits(weight) && b.waiters.Len() == 0
+	if ok {
+		b.inUse += weight
+	}
+	inUse := b.inUse
+	b.mu.Unlock()
+
+	if ok {
+		b.acquired(0, inUse)
+	} else {
+		b.rejected(ErrFull)
+	}
+	return ok
+}
+
+// Acquire acquires the given weight, waiting in the queue for at most QueueTimeout
+// Every successful Acquire must be followed by a Release of the same weight
+func (b *Bulkhead) Acquire(ctx context.Context, weight int64) error {
+	if weight > b.config.MaxConcurrent {
+		err := fmt.Errorf("%w: weight %d exceeds capacity %d", ErrFull, weight, b.config.MaxConcurrent)
+		b.rejected(err)
+		return err
+	}
+
+	b.mu.Lock()
+	if b.fits(weight) && b.waiters.Len() == 0 {
+		b.inUse += weight
+		inUse := b.inUse
+		b.mu.Unlock()
+		b.acquired(0, inUse)
+		return nil
+	}
+
+	if b.config.QueueTimeout <= 0 || (b.config.MaxQueue > 0 && b.waiters.Len() >= b.config.MaxQueue) {
+		b.mu.Unlock()
+		b.rejected(ErrFull)
+		return ErrFull
+	}
+
+	w := &waiter{weight: weight, ready: make(chan struct{})}
+	elem := b.waiters.PushBack(w)
+	b.mu.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(b.config.QueueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-w.ready:
+		b.mu.Lock()
+		inUse := b.inUse
+		b.mu.Unlock()
+		b.acquired(time.Since(start), inUse)
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = ErrQueueTimeout
+	}
+
+	b.mu.Lock()
+	select {
+	case <-w.ready:
+		// The weight was granted while giving up, hand it back
+		b.inUse -= weight
+		b.notifyWaiters()
+	default:
+		isFront := b.waiters.Front() == elem
+		b.waiters.Remove(elem)
+		// A large waiter at the front may have been blocking smaller ones
+		if isFront {
+			b.notifyWaiters()
+		}
+	}
+	b.mu.Unlock()
+
+	b.rejected(err)
+	return err
+}
+
+// Release releases weight acquired by a previous call to Acquire or TryAcquire
+func (b *Bulkhead) Release(weight int64) {
+	b.mu.Lock()
+	b.inUse -= weight
+	if b.inUse < 0 {
+		b.mu.Unlock()
+		panic("bulkhead: released more than held")
+	}
+	b.notifyWaiters()
+	inUse := b.inUse
+	b.mu.Unlock()
+
+	if b.config.Hooks.OnRelease != nil {
+		b.config.Hooks.OnRelease(b.config.Name, inUse)
+	}
+}
+
+// fits reports whether the weight is available
+// Must be called with mu held
+func (b *Bulkhead) fits(weight int64) bool {
+	return b.inUse+weight <= b.config.MaxConcurrent
+}
+
+// notifyWaiters grants weight to queued calls in FIFO order
+// Must be called with mu held
+func (b *Bulkhead) notifyWaiters() {
+	for {
+		front := b.waiters.Front()
+		if front == nil {
+			return
+		}
+
+		w := front.Value.(*waiter)
+		if !b.fits(w.weight) {
+			// Keep FIFO order so large calls are not starved by small ones
+			return
+		}
+
+		b.inUse += w.weight
+		b.waiters.Remove(front)
+		close(w.ready)
+	}
+}
+
+// acquired reports an acquired slot
+func (b *Bulkhead) acquired(waited time.Duration, inUse int64) {
+	if b.config.Hooks.OnAcquire != nil {
+		b.config.Hooks.OnAcquire(b.config.Name, waited, inUse)
+	}
+}
+
+// rejected reports a rejected call
+func (b *Bulkhead) rejected(err error) {
+	if b.config.Hooks.OnReject != nil {
+		b.config.Hooks.OnReject(b.config.Name, err)
+	}
+}
+
+// Execute runs the operation holding a slot of weight one
+func Execute(ctx context.Context, b *Bulkhead, op func(context.Context) error) error {
+	if err := b.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	defer b.Release(1)
+
+	return op(ctx)
+}
+
+// ExecuteWithValue runs the operation returning a value holding a slot of weight one
+func ExecuteWithValue[T any](ctx context.Context, b *Bulkhead, op func(context.Context) (T, error)) (T, error) {
+	if err := b.Acquire(ctx, 1); err != nil {
+		var zero T
+		return zero, err
+	}
+	defer b.Release(1)
+
+	return op(ctx)
+}
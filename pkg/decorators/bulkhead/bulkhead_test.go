@@ -0,0 +1,167 @@
+package bulkhead_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/bulkhead"
+)
+
+// TestBulkhead tests acquiring and releasing slots
+func TestBulkhead(t *testing.T) {
+	t.Run("rejects immediately without a queue timeout", func(t *testing.T) {
+		var rejected int
+		b := bulkhead.New(bulkhead.Config{
+			Name:          "db",
+			MaxConcurrent: 2,
+			Hooks: bulkhead.Hooks{
+				OnReject: func(name string, err error) {
+					require.Equal(t, "db", name)
+					rejected++
+				},
+			},
+		})
+
+		require.NoError(t, b.Acquire(context.Background(), 1))
+		require.NoError(t, b.Acquire(context.Background(), 1))
+		require.ErrorIs(t, b.Acquire(context.Background(), 1), bulkhead.ErrFull)
+		require.False(t, b.TryAcquire(1))
+		require.Equal(t, 2, rejected)
+
+		stats := b.Stats()
+		require.Equal(t, int64(2), stats.InUse)
+		require.Equal(t, 1.0, stats.Saturation())
+
+		b.Release(1)
+		require.True(t, b.TryAcquire(1))
+	})
+
+	t.Run("weight larger than capacity is rejected", func(t *testing.T) {
+		b := bulkhead.New(bulkhead.Config{MaxConcurrent: 2, QueueTimeout: time.Second})
+		require.ErrorIs(t, b.Acquire(context.Background(), 3), bulkhead.ErrFull)
+	})
+
+	t.Run("queued call gets the released slot", func(t *testing.T) {
+		var waited time.Duration
+		b := bulkhead.New(bulkhead.Config{
+			MaxConcurrent: 1,
+			QueueTimeout:  time.Second,
+			Hooks: bulkhead.Hooks{
+				OnAcquire: func(_ string, d time.Duration, _ int64) { waited = d },
+			},
+		})
+		require.NoError(t, b.Acquire(context.Background(), 1))
+
+		done := make(chan error)
+		go func() { done <- b.Acquire(context.Background(), 1) }()
+
+		require.Eventually(t, func() bool { return b.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		b.Release(1)
+
+		require.NoError(t, <-done)
+		require.GreaterOrEqual(t, waited, 10*time.Millisecond)
+		require.Equal(t, bulkhead.Stats{InUse: 1, Waiting: 0, Capacity: 1}, b.Stats())
+	})
+
+	t.Run("queue timeout", func(t *testing.T) {
+		b := bulkhead.New(bulkhead.Config{MaxConcurrent: 1, QueueTimeout: 10 * time.Millisecond})
+		require.NoError(t, b.Acquire(context.Background(), 1))
+
+		require.ErrorIs(t, b.Acquire(context.Background(), 1), bulkhead.ErrQueueTimeout)
+		require.Equal(t, 0, b.Stats().Waiting)
+	})
+
+	t.Run("bounded queue", func(t *testing.T) {
+		b := bulkhead.New(bulkhead.Config{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Second})
+		require.NoError(t, b.Acquire(context.Background(), 1))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- b.Acquire(ctx, 1) }()
+		require.Eventually(t, func() bool { return b.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+
+		require.ErrorIs(t, b.Acquire(context.Background(), 1), bulkhead.ErrFull, "Queue should be full")
+
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+		require.Equal(t, bulkhead.Stats{InUse: 1, Waiting: 0, Capacity: 1}, b.Stats())
+	})
+
+	t.Run("waiters are served in order", func(t *testing.T) {
+		b := bulkhead.New(bulkhead.Config{MaxConcurrent: 3, QueueTimeout: time.Second})
+		require.NoError(t, b.Acquire(context.Background(), 3))
+
+		large := make(chan error)
+		go func() { large <- b.Acquire(context.Background(), 3) }()
+		require.Eventually(t, func() bool { return b.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+
+		require.False(t, b.TryAcquire(1), "Small calls should not overtake queued ones")
+
+		b.Release(3)
+		require.NoError(t, <-large)
+	})
+}
+
+// TestExecute tests running operations through a bulkhead
+func TestExecute(t *testing.T) {
+	b := bulkhead.New(bulkhead.Config{MaxConcurrent: 3, QueueTimeout: time.Second})
+
+	var running, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := bulkhead.Execute(context.Background(), b, func(ctx context.Context) error {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, peak.Load(), int64(3))
+	require.Equal(t, int64(0), b.Stats().InUse)
+
+	value, err := bulkhead.ExecuteWithValue(context.Background(), b, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+}
+
+// TestSet tests per-method partitions
+func TestSet(t *testing.T) {
+	s := bulkhead.NewSet(
+		bulkhead.Config{MaxConcurrent: 1},
+		map[string]bulkhead.Config{"Search": {MaxConcurrent: 5}},
+	)
+
+	require.True(t, s.Get("GetByID").TryAcquire(1))
+	require.False(t, s.Get("GetByID").TryAcquire(1))
+	require.True(t, s.Get("Create").TryAcquire(1), "Partitions should not share capacity")
+
+	search := s.Get("Search")
+	require.Equal(t, "Search", search.Name())
+	require.Equal(t, int64(5), search.Stats().Capacity)
+
+	stats := s.Stats()
+	require.Len(t, stats, 3)
+	require.Equal(t, int64(1), stats["GetByID"].InUse)
+}
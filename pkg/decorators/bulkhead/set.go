@@ -0,0 +1,56 @@
+package bulkhead
+
+import "sync"
+
+// Set hands out named bulkheads partitioning the capacity per method
+// Partitions override the configuration of individual names; other names use the default
+type Set struct {
+	config     Config
+	partitions map[string]Config
+	bulkheads  map[string]*Bulkhead
+	mu         sync.Mutex // protects bulkheads
+}
+
+// NewSet creates a set of bulkheads created on demand
+// The default config is used for names without an entry in partitions
+func NewSet(config Config, partitions map[string]Config) *Set {
+	return &Set{
+		config:     config,
+		partitions: partitions,
+		bulkheads:  make(map[string]*Bulkhead),
+	}
+}
+
+// Get returns the bulkhead with the given name, creating it on first use
+func (s *Set) Get(name string) *Bulkhead {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.bulkheads[name]
+	if !ok {
+		config, ok := s.partitions[name]
+		if !ok {
+			config = s.config
+		}
+		config.Name = name
+		b = New(config)
+		s.bulkheads[name] = b
+	}
+	return b
+}
+
+// Stats returns the current saturation of every bulkhead in the set
+func (s *Set) Stats() map[string]Stats {
+	s.mu.Lock()
+	bulkheads := make([]*Bulkhead, 0, len(s.bulkheads))
+	for _, b := range s.bulkheads {
+		bulkheads = append(bulkheads, b)
+	}
+	s.mu.Unlock()
+
+	stats := make(map[string]Stats, len(bulkheads))
+	for _, b := range bulkheads {
+		stats[b.Name()] = b.Stats()
+	}
+	return stats
+}
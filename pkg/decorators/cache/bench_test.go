@@ -0,0 +1,40 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/decorators/cache"
+)
+
+// keyArgs are argument lists of common method signatures
+var keyArgs = map[string][]any{
+	"id":      {"user-42"},
+	"scalars": {"tenant-7", 10, int64(20), true},
+	"time":    {"events", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	"struct":  {struct{ Name, Email string }{"Ada", "ada@example.com"}},
+}
+
+// BenchmarkKey measures the per-call cost of string keys
+func BenchmarkKey(b *testing.B) {
+	for name, args := range keyArgs {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = cache.Key("Get", args...)
+			}
+		})
+	}
+}
+
+// BenchmarkHash measures the per-call cost of hashed keys
+func BenchmarkHash(b *testing.B) {
+	for name, args := range keyArgs {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = cache.Hash("Get", args...)
+			}
+		})
+	}
+}
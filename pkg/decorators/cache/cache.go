@@ -0,0 +1,112 @@
+// Package cache provides the runtime used by generated cache decorators.
+//
+// Generated code stays thin: it builds a key from the method arguments with Key,
+// and calls GetOrLoad with the underlying method as the loader. Storage, expiry,
+// eviction and hit/miss reporting are implemented here.
+//
+// Generated decorators also have Warm and Refresh methods calling the methods
+// with listed arguments, to pre-populate hot keys at startup and reload them in
+// the background with Refresh, which skips cached values under WithRefresh.
+//
+// Hash builds uint64 keys instead of strings, with a Hasher that callers can
+// replace or extend for custom argument types; they cost a fraction of Key
+// and suit caches and the Group coalescing their loads alike.
+//
+// Example usage:
+//
+//	users := cache.NewMemory[string, *User](cache.MemoryConfig{
+//		MaxEntries: 10000,
+//		DefaultTTL: time.Minute,
+//	})
+//
+//	user, err := cache.GetOrLoad(ctx, users, cache.Key("GetByID", id), 0,
+//		func(ctx context.Context) (*User, error) {
+//			return storage.GetByID(ctx, id)
+//		})
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache defines a generic key-value cache with per-entry expiration
+type Cache[K comparable, V any] interface {
+	// Get returns the cached value and whether it was found
+	Get(ctx context.Context, key K) (V, bool)
+
+	// Set stores a value for the given time to live
+	// A zero ttl uses the cache default
+	Set(ctx context.Context, key K, value V, ttl time.Duration)
+
+	// Delete removes a value from the cache
+	Delete(ctx context.Context, key K)
+}
+
+// EvictionReason describes why an entry left the cache
+type EvictionReason string
+
+const (
+	// EvictionExpired is used when an entry outlived its time to live
+	EvictionExpired EvictionReason = "expired"
+	// EvictionCapacity is used when the least recently used entry made room for a new one
+	EvictionCapacity EvictionReason = "capacity"
+)
+
+// Hooks are optional callbacks reporting cache activity, typically to metrics
+type Hooks struct {
+	// OnHit is called when a value is found in the cache
+	OnHit func()
+
+	// OnMiss is called when a value is not found in the cache
+	OnMiss func()
+
+	// OnEvict is called when an entry is removed by the cache itself
+	OnEvict func(reason EvictionReason)
+}
+
+// hit reports a cache hit
+func (h Hooks) hit() {
+	if h.OnHit != nil {
+		h.OnHit()
+	}
+}
+
+// miss reports a cache miss
+func (h Hooks) miss() {
+	if h.OnMiss != nil {
+		h.OnMiss()
+	}
+}
+
+// evict reports an eviction
+func (h Hooks) evict(reason EvictionReason) {
+	if h.OnEvict != nil {
+		h.OnEvict(reason)
+	}
+}
+
+// GetOrLoad returns the cached value for the key or loads, caches and returns it
+// Errors returned by the loader are not cached; contexts returned by WithRefresh always load
+func GetOrLoad[K comparable, V any](
+	ctx context.Context,
+	c Cache[K, V],
+	key K,
+	ttl time.Duration,
+	load func(context.Context) (V, error),
+) (V, error) {
+	if !Refreshing(ctx) {
+		if value, ok := c.Get(ctx, key); ok {
+			return value, nil
+		}
+	}
+
+	value, err := load(ctx)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	c.Set(ctx, key, value, ttl)
+	return value, nil
+}
@@ -0,0 +1,702 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/cache"
+)
+
+// fakeClock is a manually advanced time source for testing expiration
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// TestMemory tests the in-memory cache
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("set and get", func(t *testing.T) {
+		c := cache.NewMemory[string, int](cache.MemoryConfig{})
+
+		_, ok := c.Get(ctx, "a")
+		require.False(t, ok)
+
+		c.Set(ctx, "a", 1, 0)
+		value, ok := c.Get(ctx, "a")
+		require.True(t, ok)
+		require.Equal(t, 1, value)
+
+		c.Set(ctx, "a", 2, 0)
+		value, _ = c.Get(ctx, "a")
+		require.Equal(t, 2, value, "Set should overwrite existing values")
+		require.Equal(t, 1, c.Len())
+
+		c.Delete(ctx, "a")
+		_, ok = c.Get(ctx, "a")
+		require.False(t, ok)
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		clock := newFakeClock()
+		var evictions []cache.EvictionReason
+		c := cache.NewMemory[string, int](cache.MemoryConfig{
+			DefaultTTL: time.Minute,
+			Now:        clock.Now,
+			Hooks: cache.Hooks{
+				OnEvict: func(reason cache.EvictionReason) {
+					evictions = append(evictions, reason)
+				},
+			},
+		})
+
+		c.Set(ctx, "default", 1, 0)
+		c.Set(ctx, "short", 2, time.Second)
+		c.Set(ctx, "forever", 3, -1)
+
+		clock.Advance(2 * time.Second)
+		_, ok := c.Get(ctx, "short")
+		require.False(t, ok, "Entry with explicit TTL should expire")
+		_, ok = c.Get(ctx, "default")
+		require.True(t, ok, "Entry with default TTL should still be cached")
+
+		clock.Advance(time.Hour)
+		_, ok = c.Get(ctx, "default")
+		require.False(t, ok, "Entry with default TTL should expire")
+		_, ok = c.Get(ctx, "forever")
+		require.True(t, ok, "Entry with negative TTL should never expire")
+
+		require.Equal(t, []cache.EvictionReason{cache.EvictionExpired, cache.EvictionExpired}, evictions)
+	})
+
+	t.Run("least recently used entries are evicted", func(t *testing.T) {
+		evicted := 0
+		c := cache.NewMemory[int, string](cache.MemoryConfig{
+			MaxEntries: 2,
+			Hooks: cache.Hooks{
+				OnEvict: func(reason cache.EvictionReason) {
+					require.Equal(t, cache.EvictionCapacity, reason)
+					evicted++
+				},
+			},
+		})
+
+		c.Set(ctx, 1, "one", 0)
+		c.Set(ctx, 2, "two", 0)
+		_, _ = c.Get(ctx, 1) // 1 is now more recently used than 2
+		c.Set(ctx, 3, "three", 0)
+
+		require.Equal(t, 2, c.Len())
+		require.Equal(t, 1, evicted)
+		_, ok := c.Get(ctx, 2)
+		require.False(t, ok, "Least recently used entry should be evicted")
+		_, ok = c.Get(ctx, 1)
+		require.True(t, ok)
+		_, ok = c.Get(ctx, 3)
+		require.True(t, ok)
+
+		c.Purge()
+		require.Equal(t, 0, c.Len())
+	})
+
+	t.Run("hooks report hits and misses", func(t *testing.T) {
+		hits, misses := 0, 0
+		c := cache.NewMemory[string, int](cache.MemoryConfig{
+			Hooks: cache.Hooks{
+				OnHit:  func() { hits++ },
+				OnMiss: func() { misses++ },
+			},
+		})
+
+		_, _ = c.Get(ctx, "a")
+		c.Set(ctx, "a", 1, 0)
+		_, _ = c.Get(ctx, "a")
+		_, _ = c.Get(ctx, "a")
+
+		require.Equal(t, 2, hits)
+		require.Equal(t, 1, misses)
+	})
+
+	t.Run("concurrent access", func(t *testing.T) {
+		c := cache.NewMemory[int, int](cache.MemoryConfig{MaxEntries: 50})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					c.Set(ctx, i*100+j, j, 0)
+					_, _ = c.Get(ctx, i*100+j)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		require.LessOrEqual(t, c.Len(), 50)
+	})
+}
+
+// TestGetOrLoad tests the read-through helper
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemory[string, string](cache.MemoryConfig{})
+
+	loads := 0
+	load := func(ctx context.Context) (string, error) {
+		loads++
+		return "value", nil
+	}
+
+	value, err := cache.GetOrLoad[string, string](ctx, c, "key", 0, load)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	value, err = cache.GetOrLoad[string, string](ctx, c, "key", 0, load)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+	require.Equal(t, 1, loads, "Second call should be served from the cache")
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		loadErr := errors.New("backend down")
+		_, err := cache.GetOrLoad[string, string](ctx, c, "missing", 0, func(ctx context.Context) (string, error) {
+			return "", loadErr
+		})
+		require.ErrorIs(t, err, loadErr)
+
+		_, ok := c.Get(ctx, "missing")
+		require.False(t, ok)
+	})
+
+	t.Run("refresh reloads cached values", func(t *testing.T) {
+		value, err := cache.GetOrLoad[string, string](cache.WithRefresh(ctx), c, "key", 0, func(ctx context.Context) (string, error) {
+			return "fresh", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "fresh", value)
+
+		cached, _ := c.Get(ctx, "key")
+		require.Equal(t, "fresh", cached)
+	})
+}
+
+// stringer is a test type implementing fmt.Stringer
+type stringer struct{ id int }
+
+func (s stringer) String() string { return "id-" + string(rune('0'+s.id)) }
+
+// TestKey tests composite key building
+func TestKey(t *testing.T) {
+	require.Equal(t, "Get", cache.Key("Get"))
+	require.Equal(t, `GetByID:"42"`, cache.Key("GetByID", "42"))
+	require.Equal(t, `List:10:20:true:1.5`, cache.Key("List", 10, int64(20), true, 1.5))
+	require.Equal(t, `Find:<nil>:"id-7"`, cache.Key("Find", nil, stringer{id: 7}))
+	require.Equal(t, `At:2024-01-01T00:00:00Z`, cache.Key("At", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.Equal(t, `Tags:[a b]`, cache.Key("Tags", []string{"a", "b"}))
+
+	// Quoting prevents separator collisions between arguments
+	require.NotEqual(t, cache.Key("Get", "a:b", "c"), cache.Key("Get", "a", "b:c"))
+}
+
+func TestHash(t *testing.T) {
+	require.Equal(t, cache.Hash("GetByID", "42"), cache.Hash("GetByID", "42"))
+	require.NotEqual(t, cache.Hash("GetByID", "42"), cache.Hash("GetByID", "43"))
+	require.NotEqual(t, cache.Hash("Get"), cache.Hash("List"))
+
+	// Lengths and type tags keep arguments apart
+	require.NotEqual(t, cache.Hash("Get", "a", "bc"), cache.Hash("Get", "ab", "c"))
+	require.NotEqual(t, cache.Hash("Get", 1), cache.Hash("Get", "1"))
+	require.NotEqual(t, cache.Hash("Get", 1), cache.Hash("Get", uint(1)))
+	require.Equal(t, cache.Hash("Get", 1), cache.Hash("Get", int64(1)))
+
+	// Equal instants hash the same in any location
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, cache.Hash("At", at), cache.Hash("At", at.In(time.FixedZone("CET", 3600))))
+
+	// Other types are hashed from their key representation unless Encode handles them
+	require.Equal(t, cache.Hash("Find", stringer{id: 7}), cache.Hash("Find", stringer{id: 7}))
+	hasher := cache.FNV{Encode: func(arg any) (string, bool) {
+		s, ok := arg.(stringer)
+		return strconv.Itoa(s.id % 2), ok
+	}}
+	require.Equal(t, hasher.Hash("Find", stringer{id: 1}), hasher.Hash("Find", stringer{id: 3}))
+	require.Equal(t, hasher.Hash("Tags", []string{"a"}), cache.Hash("Tags", []string{"a"}))
+
+	// Scalar arguments are hashed without allocating
+	args := []any{"tenant-7", 10, int64(20), true, 1.5, at}
+	allocs := testing.AllocsPerRun(100, func() {
+		cache.Hash("List", args...)
+	})
+	require.Zero(t, allocs)
+}
+
+// TestHashedLoader tests hashed keys with a cache and the Group coalescing its loads
+func TestHashedLoader(t *testing.T) {
+	users := cache.NewMemory[uint64, string](cache.MemoryConfig{DefaultTTL: time.Minute})
+	loader := cache.NewLoader[uint64, string](users, cache.LoaderConfig{})
+
+	loads := 0
+	load := func(context.Context) (string, error) {
+		loads++
+		return "Ada", nil
+	}
+	for i := 0; i < 2; i++ {
+		name, err := loader.Load(context.Background(), cache.Hash("GetByID", "42"), 0, load)
+		require.NoError(t, err)
+		require.Equal(t, "Ada", name)
+	}
+	require.Equal(t, 1, loads)
+}
+
+// memoryStore is an in-memory RemoteStore for testing
+type memoryStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttls map[string]time.Duration
+	err  error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{data: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, false, s.err
+	}
+	data, ok := s.data[key]
+	return data, ok, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.data[key] = value
+	s.ttls[key] = ttl
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return s.err
+}
+
+// user is a test value type
+type user struct {
+	ID   string
+	Name string
+}
+
+// TestRemote tests the remote store backed cache
+func TestRemote(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("missing store", func(t *testing.T) {
+		_, err := cache.NewRemote[user](cache.RemoteConfig[user]{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "remote store is required")
+	})
+
+	codecs := map[string]cache.Codec[user]{
+		"json": cache.JSONCodec[user]{},
+		"gob":  cache.GobCodec[user]{},
+	}
+	for name, codec := range codecs {
+		t.Run(name+" round trip", func(t *testing.T) {
+			store := newMemoryStore()
+			c, err := cache.NewRemote(cache.RemoteConfig[user]{
+				Store:      store,
+				Codec:      codec,
+				Prefix:     "users:",
+				DefaultTTL: time.Minute,
+			})
+			require.NoError(t, err)
+
+			c.Set(ctx, "1", user{ID: "1", Name: "Ada"}, 0)
+			require.Contains(t, store.data, "users:1", "Keys should be prefixed")
+			require.Equal(t, time.Minute, store.ttls["users:1"], "Default TTL should be used")
+
+			value, ok := c.Get(ctx, "1")
+			require.True(t, ok)
+			require.Equal(t, user{ID: "1", Name: "Ada"}, value)
+
+			c.Delete(ctx, "1")
+			_, ok = c.Get(ctx, "1")
+			require.False(t, ok)
+		})
+	}
+
+	t.Run("default codec is json", func(t *testing.T) {
+		store := newMemoryStore()
+		c, err := cache.NewRemote(cache.RemoteConfig[user]{Store: store})
+		require.NoError(t, err)
+
+		c.Set(ctx, "1", user{ID: "1"}, 0)
+		require.JSONEq(t, `{"ID":"1","Name":""}`, string(store.data["1"]))
+	})
+
+	t.Run("shared options", func(t *testing.T) {
+		store := newMemoryStore()
+		c, err := cache.NewRemoteWithCodec(store, cache.GobCodec[user]{}, cache.RemoteOptions{
+			Prefix:     "users:",
+			DefaultTTL: time.Minute,
+		})
+		require.NoError(t, err)
+
+		c.Set(ctx, "1", user{ID: "1", Name: "Ada"}, 0)
+		require.Equal(t, time.Minute, store.ttls["users:1"])
+		value, ok := c.Get(ctx, "1")
+		require.True(t, ok)
+		require.Equal(t, user{ID: "1", Name: "Ada"}, value)
+	})
+
+	t.Run("custom codec", func(t *testing.T) {
+		store := newMemoryStore()
+		c, err := cache.NewRemote(cache.RemoteConfig[string]{
+			Store: store,
+			Codec: cache.CodecFuncs[string]{
+				MarshalFunc:   func(v string) ([]byte, error) { return []byte("v:" + v), nil },
+				UnmarshalFunc: func(data []byte) (string, error) { return string(data[2:]), nil },
+			},
+		})
+		require.NoError(t, err)
+
+		c.Set(ctx, "k", "value", 0)
+		require.Equal(t, "v:value", string(store.data["k"]))
+		value, ok := c.Get(ctx, "k")
+		require.True(t, ok)
+		require.Equal(t, "value", value)
+	})
+
+	t.Run("store errors degrade to misses", func(t *testing.T) {
+		store := newMemoryStore()
+		store.err = errors.New("connection refused")
+
+		var reported []error
+		misses := 0
+		c, err := cache.NewRemote(cache.RemoteConfig[user]{
+			Store:   store,
+			OnError: func(err error) { reported = append(reported, err) },
+			Hooks:   cache.Hooks{OnMiss: func() { misses++ }},
+		})
+		require.NoError(t, err)
+
+		c.Set(ctx, "1", user{ID: "1"}, 0)
+		_, ok := c.Get(ctx, "1")
+		require.False(t, ok)
+		require.Equal(t, 1, misses)
+		require.Len(t, reported, 2)
+		require.ErrorIs(t, reported[0], store.err)
+	})
+
+	t.Run("decode errors degrade to misses", func(t *testing.T) {
+		store := newMemoryStore()
+		store.data["1"] = []byte("not json")
+
+		var reported error
+		c, err := cache.NewRemote(cache.RemoteConfig[user]{
+			Store:   store,
+			OnError: func(err error) { reported = err },
+		})
+		require.NoError(t, err)
+
+		_, ok := c.Get(ctx, "1")
+		require.False(t, ok)
+		require.Error(t, reported)
+		require.Contains(t, reported.Error(), "failed to decode")
+	})
+}
+
+// TestGroup tests call coalescing
+func TestGroup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("concurrent calls share one execution", func(t *testing.T) {
+		g := cache.NewGroup[string, int](0)
+		release := make(chan struct{})
+		var executions atomic.Int32
+
+		var wg sync.WaitGroup
+		results := make([]int, 10)
+		sharedCount := atomic.Int32{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				value, shared, err := g.Do(ctx, "key", func() (int, error) {
+					executions.Add(1)
+					<-release
+					return 42, nil
+				})
+				require.NoError(t, err)
+				if shared {
+					sharedCount.Add(1)
+				}
+				results[i] = value
+			}(i)
+		}
+
+		// Give the callers time to join the in-flight call
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Equal(t, int32(1), executions.Load(), "Function should execute exactly once")
+		require.Equal(t, int32(9), sharedCount.Load())
+		for _, value := range results {
+			require.Equal(t, 42, value)
+		}
+	})
+
+	t.Run("waiters time out", func(t *testing.T) {
+		g := cache.NewGroup[string, int](10 * time.Millisecond)
+		release := make(chan struct{})
+		defer close(release)
+
+		started := make(chan struct{})
+		go func() {
+			_, _, _ = g.Do(ctx, "key", func() (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			})
+		}()
+		<-started
+
+		_, shared, err := g.Do(ctx, "key", func() (int, error) { return 2, nil })
+		require.True(t, shared)
+		require.ErrorIs(t, err, cache.ErrWaitTimeout)
+	})
+
+	t.Run("waiters respect their context", func(t *testing.T) {
+		g := cache.NewGroup[string, int](0)
+		release := make(chan struct{})
+		defer close(release)
+
+		started := make(chan struct{})
+		go func() {
+			_, _, _ = g.Do(ctx, "key", func() (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			})
+		}()
+		<-started
+
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, _, err := g.Do(waitCtx, "key", func() (int, error) { return 2, nil })
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("panics release waiters", func(t *testing.T) {
+		g := cache.NewGroup[string, int](0)
+		require.Panics(t, func() {
+			_, _, _ = g.Do(ctx, "key", func() (int, error) { panic("boom") })
+		})
+
+		// The key is free again after the panic
+		value, shared, err := g.Do(ctx, "key", func() (int, error) { return 3, nil })
+		require.NoError(t, err)
+		require.False(t, shared)
+		require.Equal(t, 3, value)
+	})
+}
+
+// TestLoader tests stampede protected read-through loading
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemory[string, string](cache.MemoryConfig{})
+	l := cache.NewLoader[string, string](c, cache.LoaderConfig{})
+	require.Equal(t, cache.Cache[string, string](c), l.Cache())
+
+	var loads atomic.Int32
+	load := func(ctx context.Context) (string, error) {
+		loads.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := l.Load(ctx, "key", 0, load)
+			require.NoError(t, err)
+			require.Equal(t, "value", value)
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(1), loads.Load(), "Concurrent misses should trigger exactly one load")
+
+	value, err := l.Load(ctx, "key", 0, load)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+	require.Equal(t, int32(1), loads.Load(), "Later calls should be served from the cache")
+}
+
+// TestLoaderErrorCaching tests negative and error caching policies
+func TestLoaderErrorCaching(t *testing.T) {
+	ctx := context.Background()
+	errNotFound := errors.New("not found")
+	errInvalid := errors.New("invalid id")
+	errUnavailable := errors.New("unavailable")
+
+	newLoader := func() *cache.Loader[string, string] {
+		return cache.NewLoader[string, string](cache.NewMemory[string, string](cache.MemoryConfig{}), cache.LoaderConfig{
+			IsNotFound:  func(err error) bool { return errors.Is(err, errNotFound) },
+			NegativeTTL: 30 * time.Millisecond,
+			CacheError:  func(err error) bool { return errors.Is(err, errInvalid) },
+			ErrorTTL:    time.Minute,
+		})
+	}
+
+	t.Run("not found results are cached for the negative ttl", func(t *testing.T) {
+		l := newLoader()
+		loads := 0
+		load := func(ctx context.Context) (string, error) {
+			loads++
+			return "", errNotFound
+		}
+
+		_, err := l.Load(ctx, "missing", 0, load)
+		require.ErrorIs(t, err, errNotFound)
+		_, err = l.Load(ctx, "missing", 0, load)
+		require.ErrorIs(t, err, errNotFound)
+		require.Equal(t, 1, loads, "Not found result should be cached")
+
+		time.Sleep(40 * time.Millisecond)
+		_, err = l.Load(ctx, "missing", 0, load)
+		require.ErrorIs(t, err, errNotFound)
+		require.Equal(t, 2, loads, "Not found result should expire after the negative ttl")
+	})
+
+	t.Run("selected errors are cached", func(t *testing.T) {
+		l := newLoader()
+		loads := 0
+		_, err := l.Load(ctx, "bad", 0, func(ctx context.Context) (string, error) {
+			loads++
+			return "", errInvalid
+		})
+		require.ErrorIs(t, err, errInvalid)
+		_, err = l.Load(ctx, "bad", 0, func(ctx context.Context) (string, error) {
+			loads++
+			return "", errInvalid
+		})
+		require.ErrorIs(t, err, errInvalid)
+		require.Equal(t, 1, loads)
+
+		// Invalidation clears cached errors too
+		l.Invalidate(ctx, "bad")
+		value, err := l.Load(ctx, "bad", 0, func(ctx context.Context) (string, error) {
+			return "fixed", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "fixed", value)
+	})
+
+	t.Run("cached errors are bounded", func(t *testing.T) {
+		l := cache.NewLoader[string, string](cache.NewMemory[string, string](cache.MemoryConfig{}), cache.LoaderConfig{
+			IsNotFound:  func(err error) bool { return errors.Is(err, errNotFound) },
+			NegativeTTL: time.Minute,
+			MaxErrors:   2,
+		})
+		loads := 0
+		load := func(ctx context.Context) (string, error) {
+			loads++
+			return "", errNotFound
+		}
+
+		for _, key := range []string{"a", "b", "c"} {
+			_, err := l.Load(ctx, key, 0, load)
+			require.ErrorIs(t, err, errNotFound)
+		}
+		require.Equal(t, 3, loads)
+
+		// The least recently cached result was evicted to make room
+		_, _ = l.Load(ctx, "c", 0, load)
+		require.Equal(t, 3, loads)
+		_, _ = l.Load(ctx, "a", 0, load)
+		require.Equal(t, 4, loads)
+	})
+
+	t.Run("other errors are not cached", func(t *testing.T) {
+		l := newLoader()
+		loads := 0
+		load := func(ctx context.Context) (string, error) {
+			loads++
+			return "", errUnavailable
+		}
+
+		_, _ = l.Load(ctx, "key", 0, load)
+		_, _ = l.Load(ctx, "key", 0, load)
+		require.Equal(t, 2, loads)
+	})
+}
+
+// TestRefresh tests periodic refreshes with jitter
+func TestRefresh(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var refreshes atomic.Int32
+	errRefresh := errors.New("backend down")
+	errs := make(chan error, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Refresh(ctx, cache.RefreshConfig{
+			Interval: 5 * time.Millisecond,
+			Jitter:   0.5,
+			OnError:  func(err error) { errs <- err },
+		}, func(ctx context.Context) error {
+			assert.True(t, cache.Refreshing(ctx))
+			if refreshes.Add(1) == 2 {
+				return errRefresh
+			}
+			return nil
+		})
+	}()
+
+	require.ErrorIs(t, <-errs, errRefresh)
+	require.Eventually(t, func() bool { return refreshes.Load() >= 3 }, time.Second, time.Millisecond,
+		"Refreshes continue after a failure")
+
+	cancel()
+	<-done
+	require.False(t, cache.Refreshing(ctx))
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec converts cached values to and from bytes for remote stores
+type Codec[V any] interface {
+	// Marshal encodes a value
+	Marshal(value V) ([]byte, error)
+
+	// Unmarshal decodes a value
+	Unmarshal(data []byte) (V, error)
+}
+
+// JSONCodec encodes values with encoding/json
+type JSONCodec[V any] struct{}
+
+// Marshal implements Codec
+func (JSONCodec[V]) Marshal(value V) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Unmarshal implements Codec
+func (JSONCodec[V]) Unmarshal(data []byte) (V, error) {
+	var value V
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// GobCodec encodes values with encoding/gob
+type GobCodec[V any] struct{}
+
+// Marshal implements Codec
+func (GobCodec[V]) Marshal(value V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal implements Codec
+func (GobCodec[V]) Unmarshal(data []byte) (V, error) {
+	var value V
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// CodecFuncs adapts a pair of functions to the Codec interface
+type CodecFuncs[V any] struct {
+	MarshalFunc   func(value V) ([]byte, error)
+	UnmarshalFunc func(data []byte) (V, error)
+}
+
+// Marshal implements Codec
+func (c CodecFuncs[V]) Marshal(value V) ([]byte, error) {
+	return c.MarshalFunc(value)
+}
+
+// Unmarshal implements Codec
+func (c CodecFuncs[V]) Unmarshal(data []byte) (V, error) {
+	return c.UnmarshalFunc(data)
+}
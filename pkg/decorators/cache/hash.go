@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Hasher hashes the method name and arguments of a call into a key
+// Hashed keys are cheaper to build, store and compare than the strings of Key, and suit both caches and
+// the Group coalescing loads, e.g. Memory[uint64, V] and Group[uint64, V]. Distinct calls hashing to the
+// same key would share results, which 64 bits make unlikely but not impossible
+// Implementations must be safe for concurrent use
+type Hasher interface {
+	// Hash returns the key of a call, equal for calls with equal arguments
+	Hash(method string, args ...any) uint64
+}
+
+// FNV is the default Hasher, computing 64-bit FNV-1a over an encoding of the arguments
+// Strings, byte slices, times and the scalar types handled by Key are hashed from their bytes without
+// allocating. Other arguments are hashed from their Key representation unless Encode handles them
+type FNV struct {
+	// Encode optionally returns the encoding of arguments of custom types, such as an ID of a large struct,
+	// reporting false for the arguments it leaves to the default encoding
+	Encode func(arg any) (string, bool)
+}
+
+// Ensure FNV implements Hasher
+var _ Hasher = FNV{}
+
+// FNV-1a parameters for 64-bit hashes
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// Tags written before each argument so that values of different types with the same bytes hash differently
+const (
+	tagNil byte = iota
+	tagString
+	tagInt
+	tagUint
+	tagBool
+	tagFloat
+	tagTime
+	tagCustom
+	tagOther
+)
+
+// Hash hashes the method name and arguments with the default FNV hasher
+func Hash(method string, args ...any) uint64 {
+	return FNV{}.Hash(method, args...)
+}
+
+// Hash implements Hasher
+func (f FNV) Hash(method string, args ...any) uint64 {
+	h := hashString(fnvOffset, method)
+	for _, arg := range args {
+		h = f.hashArg(h, arg)
+	}
+	return h
+}
+
+// hashArg hashes a single argument, handling common scalar types without reflection or allocation
+func (f FNV) hashArg(h uint64, arg any) uint64 {
+	switch v := arg.(type) {
+	case nil:
+		return hashByte(h, tagNil)
+	case string:
+		return hashString(hashByte(h, tagString), v)
+	case []byte:
+		return hashBytes(hashByte(h, tagString), v)
+	case int:
+		return hashUint64(hashByte(h, tagInt), uint64(v))
+	case int64:
+		return hashUint64(hashByte(h, tagInt), uint64(v))
+	case int32:
+		return hashUint64(hashByte(h, tagInt), uint64(v))
+	case uint:
+		return hashUint64(hashByte(h, tagUint), uint64(v))
+	case uint64:
+		return hashUint64(hashByte(h, tagUint), v)
+	case uint32:
+		return hashUint64(hashByte(h, tagUint), uint64(v))
+	case bool:
+		if v {
+			return hashByte(hashByte(h, tagBool), 1)
+		}
+		return hashByte(hashByte(h, tagBool), 0)
+	case float64:
+		return hashUint64(hashByte(h, tagFloat), math.Float64bits(v))
+	case time.Time:
+		// Equal instants hash the same whatever their location, as in Key
+		return hashUint64(hashByte(h, tagTime), uint64(v.UnixNano()))
+	}
+
+	if f.Encode != nil {
+		if encoded, ok := f.Encode(arg); ok {
+			return hashString(hashByte(h, tagCustom), encoded)
+		}
+	}
+
+	var b strings.Builder
+	writeKeyPart(&b, arg)
+	return hashString(hashByte(h, tagOther), b.String())
+}
+
+// hashByte adds a byte to an FNV-1a hash
+func hashByte(h uint64, b byte) uint64 {
+	return (h ^ uint64(b)) * fnvPrime
+}
+
+// hashUint64 adds the 8 bytes of v to an FNV-1a hash
+func hashUint64(h, v uint64) uint64 {
+	for i := 0; i < 8; i++ {
+		h = hashByte(h, byte(v))
+		v >>= 8
+	}
+	return h
+}
+
+// hashString adds the length and bytes of s to an FNV-1a hash
+// The length keeps consecutive strings from colliding, as quoting does in Key
+func hashString(h uint64, s string) uint64 {
+	h = hashUint64(h, uint64(len(s)))
+	for i := 0; i < len(s); i++ {
+		h = hashByte(h, s[i])
+	}
+	return h
+}
+
+// hashBytes adds the length and bytes of b to an FNV-1a hash, like hashString
+func hashBytes(h uint64, b []byte) uint64 {
+	h = hashUint64(h, uint64(len(b)))
+	for _, c := range b {
+		h = hashByte(h, c)
+	}
+	return h
+}
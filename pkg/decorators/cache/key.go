@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keySeparator separates the parts of a composite key
+const keySeparator = ":"
+
+// Key builds a composite cache key from a method name and its arguments
+//
+// Strings are quoted so that arguments containing the separator cannot collide,
+// and common scalar types are formatted without reflection. Other values are
+// formatted with %v, so arguments such as contexts, callbacks or large structs
+// should be left out or replaced with a stable identifier by the caller.
+func Key(method string, args ...any) string {
+	var b strings.Builder
+	b.WriteString(method)
+
+	for _, arg := range args {
+		b.WriteString(keySeparator)
+		writeKeyPart(&b, arg)
+	}
+
+	return b.String()
+}
+
+// writeKeyPart appends the key representation of a single argument
+func writeKeyPart(b *strings.Builder, arg any) {
+	switch v := arg.(type) {
+	case nil:
+		b.WriteString("<nil>")
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case []byte:
+		b.WriteString(strconv.Quote(string(v)))
+	case int:
+		b.WriteString(strconv.Itoa(v))
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case int32:
+		b.WriteString(strconv.FormatInt(int64(v), 10))
+	case uint:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		b.WriteString(strconv.FormatUint(v, 10))
+	case uint32:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case float64:
+		b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	case time.Time:
+		b.WriteString(v.UTC().Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		b.WriteString(strconv.Quote(v.String()))
+	default:
+		fmt.Fprintf(b, "%v", v)
+	}
+}
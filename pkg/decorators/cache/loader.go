@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultMaxErrors bounds the errors cached by a Loader whose config leaves MaxErrors unset
+const DefaultMaxErrors = 1024
+
+// LoaderConfig holds configuration for a Loader
+type LoaderConfig struct {
+	// WaitTimeout bounds how long a caller waits for a concurrent load of the same key
+	// Zero means callers wait until the load completes or their context is done
+	WaitTimeout time.Duration
+
+	// IsNotFound classifies load errors meaning "no such value" for negative caching
+	IsNotFound func(error) bool
+
+	// NegativeTTL is how long "not found" errors are cached
+	// Zero disables negative caching
+	NegativeTTL time.Duration
+
+	// CacheError classifies other load errors that should be cached, e.g. validation errors
+	CacheError func(error) bool
+
+	// ErrorTTL is how long errors accepted by CacheError are cached
+	// Zero disables error caching
+	ErrorTTL time.Duration
+
+	// MaxErrors bounds the number of cached "not found" results and errors
+	// The least recently used one is evicted when the limit is reached; zero means DefaultMaxErrors
+	MaxErrors int
+}
+
+// Loader is a read-through cache that executes exactly one load per key for concurrent misses
+// It can optionally cache "not found" results and selected errors to protect the backend
+type Loader[K comparable, V any] struct {
+	cache  Cache[K, V]
+	errors *Memory[K, error]
+	group  *Group[K, V]
+	config LoaderConfig
+}
+
+// NewLoader creates a read-through loader protecting the cache against stampedes
+func NewLoader[K comparable, V any](c Cache[K, V], config LoaderConfig) *Loader[K, V] {
+	if config.MaxErrors <= 0 {
+		config.MaxErrors = DefaultMaxErrors
+	}
+
+	return &Loader[K, V]{
+		cache:  c,
+		errors: NewMemory[K, error](MemoryConfig{MaxEntries: config.MaxErrors}),
+		group:  NewGroup[K, V](config.WaitTimeout),
+		config: config,
+	}
+}
+
+// Cache returns the underlying cache
+func (l *Loader[K, V]) Cache() Cache[K, V] {
+	return l.cache
+}
+
+// Load returns the cached value for the key or loads, caches and returns it
+// Concurrent misses for the same key share a single load, executed with the context of the first caller
+func (l *Loader[K, V]) Load(ctx context.Context, key K, ttl time.Duration, load func(context.Context) (V, error)) (V, error) {
+	if !Refreshing(ctx) {
+		if value, ok := l.cache.Get(ctx, key); ok {
+			return value, nil
+		}
+
+		// Serve cached "not found" results and errors
+		if err, ok := l.errors.Get(ctx, key); ok {
+			var zero V
+			return zero, err
+		}
+	}
+
+	value, _, err := l.group.Do(ctx, key, func() (V, error) {
+		value, err := GetOrLoad(ctx, l.cache, key, ttl, load)
+		if err != nil {
+			l.cacheError(ctx, key, err)
+		}
+		return value, err
+	})
+	return value, err
+}
+
+// Invalidate removes the cached value and any cached error for the key
+func (l *Loader[K, V]) Invalidate(ctx context.Context, key K) {
+	l.cache.Delete(ctx, key)
+	l.errors.Delete(ctx, key)
+}
+
+// cacheError stores a load error if the configured policy allows it
+func (l *Loader[K, V]) cacheError(ctx context.Context, key K, err error) {
+	switch {
+	case l.config.NegativeTTL > 0 && l.config.IsNotFound != nil && l.config.IsNotFound(err):
+		l.errors.Set(ctx, key, err, l.config.NegativeTTL)
+	case l.config.ErrorTTL > 0 && l.config.CacheError != nil && l.config.CacheError(err):
+		l.errors.Set(ctx, key, err, l.config.ErrorTTL)
+	}
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Ensure Memory implements Cache
+var _ Cache[string, any] = (*Memory[string, any])(nil)
+
+// MemoryConfig holds configuration for the in-memory cache
+type MemoryConfig struct {
+	// MaxEntries bounds the number of cached entries
+	// The least recently used entry is evicted when the cache is full; zero means no limit
+	MaxEntries int
+
+	// DefaultTTL is used for entries stored without an explicit time to live
+	// Zero means such entries never expire
+	DefaultTTL time.Duration
+
+	// Hooks optionally report hits, misses and evictions
+	Hooks Hooks
+
+	// Now returns the current time
+	// If not provided, time.Now is used
+	Now func() time.Time
+}
+
+// Memory implements an in-memory cache with per-entry TTL and LRU eviction
+// It is safe for concurrent use
+type Memory[K comparable, V any] struct {
+	config  MemoryConfig
+	entries map[K]*list.Element
+	order   *list.List // front is the most recently used entry
+	mu      sync.Mutex // protects entries and order
+}
+
+// memoryEntry is a cached value with its expiration time
+type memoryEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time // zero means no expiration
+}
+
+// NewMemory creates a new in-memory cache
+func NewMemory[K comparable, V any](config MemoryConfig) *Memory[K, V] {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &Memory[K, V]{
+		config:  config,
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the cached value and whether it was found
+func (m *Memory[K, V]) Get(_ context.Context, key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		m.config.Hooks.miss()
+		var zero V
+		return zero, false
+	}
+
+	entry := elem.Value.(*memoryEntry[K, V])
+	if m.expired(entry) {
+		m.remove(elem)
+		m.config.Hooks.evict(EvictionExpired)
+		m.config.Hooks.miss()
+		var zero V
+		return zero, false
+	}
+
+	m.order.MoveToFront(elem)
+	m.config.Hooks.hit()
+	return entry.value, true
+}
+
+// Set stores a value for the given time to live
+// A zero ttl uses the configured default
+func (m *Memory[K, V]) Set(_ context.Context, key K, value V, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = m.config.DefaultTTL
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = m.config.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Update an existing entry in place
+	if elem, ok := m.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(elem)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry[K, V]{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+
+	// Evict the least recently used entries if the cache is over capacity
+	for m.config.MaxEntries > 0 && m.order.Len() > m.config.MaxEntries {
+		m.remove(m.order.Back())
+		m.config.Hooks.evict(EvictionCapacity)
+	}
+}
+
+// Delete removes a value from the cache
+func (m *Memory[K, V]) Delete(_ context.Context, key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+}
+
+// Len returns the number of cached entries, including expired ones not yet removed
+func (m *Memory[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+// Purge removes all entries from the cache
+func (m *Memory[K, V]) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[K]*list.Element)
+	m.order.Init()
+}
+
+// expired checks if an entry outlived its time to live
+func (m *Memory[K, V]) expired(entry *memoryEntry[K, V]) bool {
+	return !entry.expiresAt.IsZero() && !m.config.Now().Before(entry.expiresAt)
+}
+
+// remove deletes an element from both the index and the LRU list
+func (m *Memory[K, V]) remove(elem *list.Element) {
+	entry := m.order.Remove(elem).(*memoryEntry[K, V])
+	delete(m.entries, entry.key)
+}
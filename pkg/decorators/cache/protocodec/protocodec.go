@@ -0,0 +1,44 @@
+// Package protocodec provides a cache.Codec encoding protocol buffer messages.
+//
+// It lives in its own package so that only applications caching protocol
+// buffer messages depend on google.golang.org/protobuf.
+//
+// Example usage:
+//
+//	users, err := cache.NewRemoteWithCodec(store, protocodec.Codec[*pb.User]{}, cache.RemoteOptions{
+//		Prefix: "users:",
+//	})
+package protocodec
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/komandakycto/decogen/pkg/decorators/cache"
+)
+
+// Ensure Codec implements cache.Codec
+var _ cache.Codec[proto.Message] = Codec[proto.Message]{}
+
+// Codec encodes messages with the protocol buffer wire format
+// V is a generated message pointer type such as *pb.User
+type Codec[V proto.Message] struct{}
+
+// Marshal implements cache.Codec
+func (Codec[V]) Marshal(value V) ([]byte, error) {
+	return proto.Marshal(value)
+}
+
+// Unmarshal implements cache.Codec
+func (Codec[V]) Unmarshal(data []byte) (V, error) {
+	var zero V
+	value, ok := zero.ProtoReflect().Type().New().Interface().(V)
+	if !ok {
+		return zero, fmt.Errorf("cannot create a %T message", zero)
+	}
+	if err := proto.Unmarshal(data, value); err != nil {
+		return zero, err
+	}
+	return value, nil
+}
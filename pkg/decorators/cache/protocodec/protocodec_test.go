@@ -0,0 +1,27 @@
+package protocodec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/komandakycto/decogen/pkg/decorators/cache/protocodec"
+)
+
+func TestCodec(t *testing.T) {
+	codec := protocodec.Codec[*timestamppb.Timestamp]{}
+	value := timestamppb.New(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	data, err := codec.Marshal(value)
+	require.NoError(t, err)
+
+	decoded, err := codec.Unmarshal(data)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(value, decoded))
+
+	_, err = codec.Unmarshal([]byte{0xff})
+	require.Error(t, err)
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RemoteStore defines a byte-oriented key-value store such as Redis or memcached
+// Applications adapt their own client to this interface
+type RemoteStore interface {
+	// Get returns the stored bytes and whether the key was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores bytes for the given time to live
+	// A zero ttl means no expiration
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes a key
+	Delete(ctx context.Context, key string) error
+}
+
+// Ensure Remote implements Cache
+var _ Cache[string, any] = (*Remote[any])(nil)
+
+// RemoteConfig holds configuration for a cache backed by a remote store
+type RemoteConfig[V any] struct {
+	// Store is the remote store holding the encoded values
+	Store RemoteStore
+
+	// Codec converts values to bytes
+	// If not provided, values are encoded as JSON
+	Codec Codec[V]
+
+	// Prefix is prepended to every key, e.g. to namespace entries per service
+	Prefix string
+
+	// DefaultTTL is used for entries stored without an explicit time to live
+	DefaultTTL time.Duration
+
+	// Hooks optionally report hits and misses
+	Hooks Hooks
+
+	// OnError is called when the store or codec fails
+	// A failing read is reported as a miss and a failing write is ignored,
+	// so an unavailable store degrades to calling the underlying implementation
+	OnError func(err error)
+}
+
+// Remote implements Cache on top of a RemoteStore
+type Remote[V any] struct {
+	config RemoteConfig[V]
+}
+
+// NewRemote creates a cache backed by a remote store
+func NewRemote[V any](config RemoteConfig[V]) (*Remote[V], error) {
+	if config.Store == nil {
+		return nil, fmt.Errorf("remote store is required")
+	}
+
+	if config.Codec == nil {
+		config.Codec = JSONCodec[V]{}
+	}
+
+	return &Remote[V]{config: config}, nil
+}
+
+// RemoteOptions holds the settings shared by the remote caches of a generated decorator
+// Each method has its own value type, so the caches differ only by their codec
+type RemoteOptions struct {
+	// Prefix is prepended to every key, e.g. to namespace entries per service
+	Prefix string
+
+	// DefaultTTL is used for entries stored without an explicit time to live
+	DefaultTTL time.Duration
+
+	// Hooks optionally report hits and misses
+	Hooks Hooks
+
+	// OnError is called when the store or codec fails
+	OnError func(err error)
+}
+
+// NewRemoteWithCodec creates a cache backed by a remote store encoding values with codec
+func NewRemoteWithCodec[V any](store RemoteStore, codec Codec[V], options RemoteOptions) (*Remote[V], error) {
+	return NewRemote(RemoteConfig[V]{
+		Store:      store,
+		Codec:      codec,
+		Prefix:     options.Prefix,
+		DefaultTTL: options.DefaultTTL,
+		Hooks:      options.Hooks,
+		OnError:    options.OnError,
+	})
+}
+
+// Get returns the cached value and whether it was found
+func (r *Remote[V]) Get(ctx context.Context, key string) (V, bool) {
+	var zero V
+
+	data, ok, err := r.config.Store.Get(ctx, r.config.Prefix+key)
+	if err != nil {
+		r.fail(fmt.Errorf("failed to get %s from remote store: %w", key, err))
+		r.config.Hooks.miss()
+		return zero, false
+	}
+	if !ok {
+		r.config.Hooks.miss()
+		return zero, false
+	}
+
+	value, err := r.config.Codec.Unmarshal(data)
+	if err != nil {
+		r.fail(fmt.Errorf("failed to decode %s: %w", key, err))
+		r.config.Hooks.miss()
+		return zero, false
+	}
+
+	r.config.Hooks.hit()
+	return value, true
+}
+
+// Set stores a value for the given time to live
+// A zero ttl uses the configured default
+func (r *Remote[V]) Set(ctx context.Context, key string, value V, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = r.config.DefaultTTL
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	data, err := r.config.Codec.Marshal(value)
+	if err != nil {
+		r.fail(fmt.Errorf("failed to encode %s: %w", key, err))
+		return
+	}
+
+	if err := r.config.Store.Set(ctx, r.config.Prefix+key, data, ttl); err != nil {
+		r.fail(fmt.Errorf("failed to set %s in remote store: %w", key, err))
+	}
+}
+
+// Delete removes a value from the cache
+func (r *Remote[V]) Delete(ctx context.Context, key string) {
+	if err := r.config.Store.Delete(ctx, r.config.Prefix+key); err != nil {
+		r.fail(fmt.Errorf("failed to delete %s from remote store: %w", key, err))
+	}
+}
+
+// fail reports an error to the configured handler
+func (r *Remote[V]) fail(err error) {
+	if r.config.OnError != nil {
+		r.config.OnError(err)
+	}
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrWaitTimeout is returned when waiting for a concurrent load of the same key takes too long
+var ErrWaitTimeout = errors.New("timed out waiting for concurrent load")
+
+// Group coalesces concurrent calls for the same key into a single execution
+// It is safe for concurrent use
+type Group[K comparable, V any] struct {
+	waitTimeout time.Duration
+	calls       map[K]*call[V]
+	mu          sync.Mutex // protects calls
+}
+
+// call is an in-flight or completed execution shared by a Group
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// NewGroup creates a new call group
+// Callers waiting for another caller's execution give up with ErrWaitTimeout after waitTimeout; zero means no limit
+func NewGroup[K comparable, V any](waitTimeout time.Duration) *Group[K, V] {
+	return &Group[K, V]{
+		waitTimeout: waitTimeout,
+		calls:       make(map[K]*call[V]),
+	}
+}
+
+// Do executes fn once for all concurrent callers using the same key
+// The shared result reports whether the result was produced by another caller
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func() (V, error)) (value V, shared bool, err error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		value, err = g.wait(ctx, c)
+		return value, true, err
+	}
+
+	c := &call[V]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	g.execute(key, c, fn)
+	return c.value, false, c.err
+}
+
+// execute runs fn and publishes its result to the waiters
+func (g *Group[K, V]) execute(key K, c *call[V], fn func() (V, error)) {
+	finished := false
+	defer func() {
+		if !finished {
+			// fn panicked, release the waiters before propagating the panic
+			c.err = fmt.Errorf("shared call panicked: %v", recover())
+		}
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+
+		if !finished {
+			panic(c.err)
+		}
+	}()
+
+	c.value, c.err = fn()
+	finished = true
+}
+
+// wait blocks until the shared call completes, the context is done or the wait times out
+func (g *Group[K, V]) wait(ctx context.Context, c *call[V]) (V, error) {
+	var zero V
+
+	var timeout <-chan time.Time
+	if g.waitTimeout > 0 {
+		timer := time.NewTimer(g.waitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case <-timeout:
+		return zero, ErrWaitTimeout
+	}
+}
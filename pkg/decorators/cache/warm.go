@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// refreshContextKey is the context key marking calls that reload cached values
+type refreshContextKey struct{}
+
+// WithRefresh returns a context whose calls through GetOrLoad and Loader.Load skip the cached value,
+// load it again and store the result, e.g. to refresh hot keys before they expire
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshContextKey{}, true)
+}
+
+// Refreshing reports whether the context was returned by WithRefresh
+func Refreshing(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshContextKey{}).(bool)
+	return refresh
+}
+
+// RefreshConfig holds configuration for Refresh
+type RefreshConfig struct {
+	// Interval is the average time between refreshes
+	Interval time.Duration
+
+	// Jitter spreads each wait uniformly over Interval ± Jitter*Interval, from 0 to 1,
+	// so instances started together do not refresh at the same time
+	// The first refresh also waits a random part of the interval
+	Jitter float64
+
+	// OnError is called with the error of a failed refresh; the next refresh runs as planned
+	OnError func(error)
+}
+
+// Refresh calls refresh with a WithRefresh context every interval until ctx is done
+// It blocks, so it is usually started in its own goroutine after warming the cache once
+func Refresh(ctx context.Context, config RefreshConfig, refresh func(context.Context) error) {
+	if config.Interval <= 0 {
+		return
+	}
+	config.Jitter = min(max(config.Jitter, 0), 1)
+
+	// Start at a random point of the first interval, so the refreshes of instances spread out
+	wait := jitter(config.Interval, config.Jitter)
+	if config.Jitter > 0 {
+		wait = time.Duration(rand.Int64N(int64(wait)) + 1)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if err := refresh(WithRefresh(ctx)); err != nil && config.OnError != nil {
+			config.OnError(err)
+		}
+		timer.Reset(jitter(config.Interval, config.Jitter))
+	}
+}
+
+// jitter returns interval spread uniformly over interval ± ratio*interval
+func jitter(interval time.Duration, ratio float64) time.Duration {
+	if ratio <= 0 {
+		return interval
+	}
+	spread := float64(interval) * ratio
+	return max(time.Duration(float64(interval)-spread+rand.Float64()*2*spread), 1)
+}
@@ -0,0 +1,78 @@
+// Package callmeta carries metadata about decorated calls in the context.
+//
+// Generated decorators built with the "callmeta" option record the interface
+// and method being called and every decorator the call passes through, so
+// logging and tracing layers below them can tell where a call came from
+// without each service inventing its own context keys.
+//
+// Example usage:
+//
+//	func (s *store) Get(ctx context.Context, id string) (*User, error) {
+//		if meta, ok := callmeta.From(ctx); ok {
+//			log.Printf("%s: loading user %s", meta, id)
+//		}
+//		...
+//	}
+package callmeta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Meta describes a call made through generated decorators
+type Meta struct {
+	// Interface is the name of the decorated interface
+	Interface string
+
+	// Method is the name of the called method
+	Method string
+
+	// Name is the name of the call in metrics, traces and logs: "Interface.Method",
+	// or the name annotated on the method with //decogen:name
+	Name string
+
+	// Decorators lists the decorators the call passed through, outermost first
+	Decorators []string
+}
+
+// String formats the metadata as "Name (decorator, ...)"
+func (m Meta) String() string {
+	name := m.Name
+	if name == "" {
+		name = m.Interface + "." + m.Method
+	}
+	if len(m.Decorators) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(m.Decorators, ", "))
+}
+
+// metaContextKey is the context key for the call metadata
+type metaContextKey struct{}
+
+// With returns a context recording that a call to iface.method passes through decorator
+// When the context already describes the same call, the decorator is appended to its stack;
+// otherwise the metadata of the new call replaces the one of an enclosing call
+func With(ctx context.Context, iface, method, decorator string) context.Context {
+	return WithName(ctx, iface, method, iface+"."+method, decorator)
+}
+
+// WithName is like With for a method named name in metrics, traces and logs
+func WithName(ctx context.Context, iface, method, name, decorator string) context.Context {
+	meta := Meta{Interface: iface, Method: method, Name: name}
+	if prev, ok := From(ctx); ok && prev.Interface == iface && prev.Method == method {
+		meta.Decorators = make([]string, len(prev.Decorators), len(prev.Decorators)+1)
+		copy(meta.Decorators, prev.Decorators)
+	}
+	meta.Decorators = append(meta.Decorators, decorator)
+
+	return context.WithValue(ctx, metaContextKey{}, meta)
+}
+
+// From returns the metadata of the call stored with With
+func From(ctx context.Context) (Meta, bool) {
+	meta, ok := ctx.Value(metaContextKey{}).(Meta)
+	return meta, ok
+}
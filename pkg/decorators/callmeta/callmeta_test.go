@@ -0,0 +1,54 @@
+package callmeta_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/callmeta"
+)
+
+func TestWith(t *testing.T) {
+	_, ok := callmeta.From(context.Background())
+	assert.False(t, ok)
+
+	outer := callmeta.With(context.Background(), "UserStorage", "Get", "retry")
+	inner := callmeta.With(outer, "UserStorage", "Get", "cache")
+
+	meta, ok := callmeta.From(inner)
+	require.True(t, ok)
+	assert.Equal(t, callmeta.Meta{Interface: "UserStorage", Method: "Get", Name: "UserStorage.Get", Decorators: []string{"retry", "cache"}}, meta)
+	assert.Equal(t, "UserStorage.Get (retry, cache)", meta.String())
+
+	// The outer context keeps its own stack
+	meta, _ = callmeta.From(outer)
+	assert.Equal(t, []string{"retry"}, meta.Decorators)
+
+	// A call made by the implementation starts a new stack
+	nested := callmeta.With(inner, "AuditLog", "Record", "dedupe")
+	meta, _ = callmeta.From(nested)
+	assert.Equal(t, "AuditLog.Record (dedupe)", meta.String())
+}
+
+func TestWithSharedPrefix(t *testing.T) {
+	outer := callmeta.With(context.Background(), "UserStorage", "Get", "retry")
+	a := callmeta.With(outer, "UserStorage", "Get", "cache")
+	b := callmeta.With(outer, "UserStorage", "Get", "dedupe")
+
+	metaA, _ := callmeta.From(a)
+	metaB, _ := callmeta.From(b)
+	assert.Equal(t, []string{"retry", "cache"}, metaA.Decorators)
+	assert.Equal(t, []string{"retry", "dedupe"}, metaB.Decorators)
+}
+
+func TestWithName(t *testing.T) {
+	ctx := callmeta.WithName(context.Background(), "UserStorage", "Get", "user.fetch", "retry")
+	ctx = callmeta.WithName(ctx, "UserStorage", "Get", "user.fetch", "cache")
+
+	meta, ok := callmeta.From(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "Get", meta.Method)
+	assert.Equal(t, "user.fetch (retry, cache)", meta.String())
+}
@@ -0,0 +1,65 @@
+// Package chain composes decorators around an implementation.
+//
+// Generated constructors have the shape func(next T) T once their
+// dependencies are bound, so generated and hand-written decorators compose with
+// the same Middleware type and the order is explicit in one place.
+//
+// Example usage:
+//
+//	storage := chain.Chain[UserStorage](db,
+//		func(next UserStorage) UserStorage { return NewUserStorageWithMetrics(next, rec) },
+//		func(next UserStorage) UserStorage { return NewUserStorageWithRetry(next, retryConfig) },
+//	)
+//
+// Calls go through metrics first, then retry, then db.
+package chain
+
+// Middleware decorates an implementation of T
+type Middleware[T any] func(next T) T
+
+// Chain wraps base with the middlewares
+// The first middleware is the outermost one and sees every call first
+// Nil middlewares are skipped
+func Chain[T any](base T, mws ...Middleware[T]) T {
+	result := base
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i] != nil {
+			result = mws[i](result)
+		}
+	}
+	return result
+}
+
+// Compose combines middlewares into one, applied in the same order as Chain
+func Compose[T any](mws ...Middleware[T]) Middleware[T] {
+	return func(next T) T {
+		return Chain(next, mws...)
+	}
+}
+
+// When returns the middleware if the condition holds and nil otherwise,
+// to toggle decorators from configuration
+func When[T any](condition bool, mw Middleware[T]) Middleware[T] {
+	if !condition {
+		return nil
+	}
+	return mw
+}
+
+// Unwrapper is implemented by decorators exposing the implementation they wrap
+// Generated decorators implement it unless the interface declares an Unwrap method itself
+type Unwrapper[T any] interface {
+	Unwrap() T
+}
+
+// Unwrap returns the base implementation under a stack of decorators implementing Unwrapper
+// Values that do not implement Unwrapper are returned unchanged
+func Unwrap[T any](v T) T {
+	for {
+		u, ok := any(v).(Unwrapper[T])
+		if !ok {
+			return v
+		}
+		v = u.Unwrap()
+	}
+}
@@ -0,0 +1,81 @@
+package chain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/chain"
+)
+
+type greeter interface {
+	Greet(name string) string
+}
+
+type base struct{}
+
+func (base) Greet(name string) string {
+	return name
+}
+
+// tag is a decorator recording its position in the call path
+type tag struct {
+	next  greeter
+	label string
+}
+
+func (t tag) Greet(name string) string {
+	return t.label + "(" + t.next.Greet(name) + ")"
+}
+
+func tagged(label string) chain.Middleware[greeter] {
+	return func(next greeter) greeter {
+		return tag{next: next, label: label}
+	}
+}
+
+// TestChain tests the order middlewares are applied in
+func TestChain(t *testing.T) {
+	g := chain.Chain[greeter](base{}, tagged("outer"), tagged("inner"))
+	require.Equal(t, "outer(inner(bob))", g.Greet("bob"))
+
+	require.Equal(t, "bob", chain.Chain[greeter](base{}).Greet("bob"), "No middleware should return the base")
+}
+
+// TestCompose tests combining middlewares
+func TestCompose(t *testing.T) {
+	mw := chain.Compose(tagged("a"), tagged("b"))
+	g := chain.Chain[greeter](base{}, mw, tagged("c"))
+	require.Equal(t, "a(b(c(bob)))", g.Greet("bob"))
+}
+
+// TestWhen tests toggling middlewares
+func TestWhen(t *testing.T) {
+	g := chain.Chain[greeter](base{},
+		chain.When(false, tagged("disabled")),
+		chain.When(true, tagged("enabled")),
+	)
+	require.Equal(t, "enabled(bob)", g.Greet("bob"))
+}
+
+// unwrapping is a decorator exposing the implementation it wraps
+type unwrapping struct {
+	tag
+}
+
+func (u unwrapping) Unwrap() greeter {
+	return u.next
+}
+
+// TestUnwrap tests reaching the base implementation under a stack of decorators
+func TestUnwrap(t *testing.T) {
+	g := chain.Chain[greeter](base{},
+		func(next greeter) greeter { return unwrapping{tag{next: next, label: "outer"}} },
+		func(next greeter) greeter { return unwrapping{tag{next: next, label: "inner"}} },
+	)
+	require.Equal(t, base{}, chain.Unwrap(g))
+
+	// A decorator without Unwrap stops the walk
+	opaque := unwrapping{tag{next: tag{next: base{}, label: "opaque"}, label: "outer"}}
+	require.Equal(t, tag{next: base{}, label: "opaque"}, chain.Unwrap[greeter](opaque))
+}
@@ -0,0 +1,384 @@
+// Package circuitbreaker provides the runtime used by generated circuit breaker decorators.
+//
+// A Breaker tracks the outcome of calls to a dependency and stops calling it
+// ("opens") when it is failing, giving it time to recover. After OpenTimeout it
+// lets a limited number of probe calls through ("half-open") and closes again
+// once enough of them succeed.
+//
+// Two trip policies are supported and can be combined: a number of consecutive
+// failures, and a failure rate over a sliding time window.
+//
+// Example usage:
+//
+//	b := circuitbreaker.New(circuitbreaker.Config{
+//		Name:                 "users-db",
+//		ConsecutiveFailures:  5,
+//		FailureRateThreshold: 0.5,
+//		MinimumRequests:      20,
+//		OpenTimeout:          30 * time.Second,
+//	})
+//
+//	user, err := circuitbreaker.ExecuteWithValue(ctx, b, func(ctx context.Context) (*User, error) {
+//		return storage.GetByID(ctx, id)
+//	})
+//
+// A Keyed set keeps a breaker per downstream host, tenant or shard, so one
+// failing shard does not reject the calls to the others:
+//
+//	shards := circuitbreaker.NewKeyed(circuitbreaker.KeyedConfig{
+//		Breaker: circuitbreaker.Config{Name: "users-db", ConsecutiveFailures: 5},
+//		MaxKeys: 1000,
+//	})
+//
+//	ctx = circuitbreaker.WithKey(ctx, tenant)
+//	err := circuitbreaker.Execute(ctx, shards.For(ctx, "Save"), func(ctx context.Context) error {
+//		return storage.Save(ctx, user)
+//	})
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Common errors returned by the circuit breaker
+var (
+	// ErrOpen is returned when a call is rejected because the breaker is open
+	ErrOpen = errors.New("circuit breaker is open")
+
+	// ErrTooManyProbes is returned when a call is rejected because the half-open probe limit is reached
+	ErrTooManyProbes = errors.New("circuit breaker is half-open and the probe limit is reached")
+)
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// StateClosed lets all calls through
+	StateClosed State = iota
+	// StateOpen rejects all calls
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Config holds configuration for a circuit breaker
+type Config struct {
+	// Name identifies the breaker in state change callbacks
+	Name string
+
+	// ConsecutiveFailures trips the breaker after this many failures in a row
+	// Zero disables the consecutive failures policy
+	ConsecutiveFailures uint
+
+	// FailureRateThreshold trips the breaker when the failure rate in the window reaches it (0 to 1)
+	// Zero disables the failure rate policy
+	FailureRateThreshold float64
+
+	// MinimumRequests is the number of calls required in the window before the failure rate is evaluated
+	MinimumRequests uint
+
+	// Window is the duration of the sliding window used for the failure rate
+	// If not provided, 60 seconds is used
+	Window time.Duration
+
+	// WindowBuckets is the number of buckets the window is divided into
+	// If not provided, 10 buckets are used
+	WindowBuckets int
+
+	// OpenTimeout is how long the breaker stays open before letting probes through
+	// If not provided, 30 seconds is used
+	OpenTimeout time.Duration
+
+	// HalfOpenMaxProbes is the number of concurrent probe calls allowed when half-open
+	// If not provided, 1 probe is allowed
+	HalfOpenMaxProbes uint
+
+	// HalfOpenSuccesses is the number of successful probes required to close the breaker
+	// If not provided, HalfOpenMaxProbes is used
+	HalfOpenSuccesses uint
+
+	// IsFailure determines if a call error counts as a failure
+	// If not provided, all errors except context cancellation count as failures
+	IsFailure func(error) bool
+
+	// OnStateChange is an optional callback called after every state transition
+	// It runs once the breaker is unlocked, so it may call State or Allow
+	OnStateChange func(name string, from, to State)
+
+	// Now returns the current time
+	// If not provided, time.Now is used
+	Now func() time.Time
+}
+
+// Breaker implements a circuit breaker
+// It is safe for concurrent use
+type Breaker struct {
+	config Config
+
+	state       State
+	generation  uint64 // incremented on every state change to ignore outcomes of older calls
+	openedAt    time.Time
+	consecutive uint
+	probes      uint
+	successes   uint
+	window      *window
+	transitions []transition // made since the lock was taken, reported by unlock
+	mu          sync.Mutex   // protects all the fields above
+}
+
+// transition is a state change reported to OnStateChange
+type transition struct {
+	from, to State
+}
+
+// New creates a new circuit breaker
+func New(config Config) *Breaker {
+	if config.Window <= 0 {
+		config.Window = 60 * time.Second
+	}
+	if config.WindowBuckets <= 0 {
+		config.WindowBuckets = 10
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenMaxProbes == 0 {
+		config.HalfOpenMaxProbes = 1
+	}
+	if config.HalfOpenSuccesses == 0 {
+		config.HalfOpenSuccesses = config.HalfOpenMaxProbes
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = defaultIsFailure
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &Breaker{
+		config: config,
+		window: newWindow(config.Window, config.WindowBuckets),
+	}
+}
+
+// Name returns the configured name
+func (b *Breaker) Name() string {
+	return b.config.Name
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.unlock()
+
+	b.refresh(b.config.Now())
+	return b.state
+}
+
+// closed reports whether the breaker is closed
+// Unlike State it does not report transitions, so it is safe to call with other locks held
+func (b *Breaker) closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == StateClosed
+}
+
+// Allow asks the breaker for permission to make a call
+// On success the returned function must be called exactly once with the call result
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := b.config.Now()
+	b.refresh(now)
+
+	switch b.state {
+	case StateOpen:
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.probes >= b.config.HalfOpenMaxProbes {
+			return nil, ErrTooManyProbes
+		}
+		b.probes++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			b.record(generation, err)
+		})
+	}, nil
+}
+
+// Reset closes the breaker and forgets all recorded outcomes
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.unlock()
+
+	b.window.reset()
+	b.setState(StateClosed, b.config.Now())
+}
+
+// record updates the breaker with the outcome of a call
+func (b *Breaker) record(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := b.config.Now()
+	b.refresh(now)
+
+	// Ignore outcomes of calls started before the last state change
+	if generation != b.generation {
+		return
+	}
+
+	failed := err != nil && b.config.IsFailure(err)
+
+	switch b.state {
+	case StateClosed:
+		b.window.add(now, failed)
+		if failed {
+			b.consecutive++
+		} else {
+			b.consecutive = 0
+		}
+		if b.shouldTrip(now) {
+			b.setState(StateOpen, now)
+		}
+
+	case StateHalfOpen:
+		b.probes--
+		if failed {
+			b.setState(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.HalfOpenSuccesses {
+			b.setState(StateClosed, now)
+		}
+	}
+}
+
+// shouldTrip checks the trip policies in the closed state
+func (b *Breaker) shouldTrip(now time.Time) bool {
+	if b.config.ConsecutiveFailures > 0 && b.consecutive >= b.config.ConsecutiveFailures {
+		return true
+	}
+
+	if b.config.FailureRateThreshold > 0 {
+		total, failures := b.window.counts(now)
+		if total > 0 && total >= uint64(b.config.MinimumRequests) &&
+			float64(failures)/float64(total) >= b.config.FailureRateThreshold {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refresh moves an open breaker to half-open once the open timeout has passed
+func (b *Breaker) refresh(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.config.OpenTimeout {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+// unlock releases the lock, then reports the state transitions made while holding it to OnStateChange
+func (b *Breaker) unlock() {
+	transitions := b.transitions
+	b.transitions = nil
+	b.mu.Unlock()
+
+	if b.config.OnStateChange == nil {
+		return
+	}
+	for _, t := range transitions {
+		b.config.OnStateChange(b.config.Name, t.from, t.to)
+	}
+}
+
+// setState transitions to a new state, reported to the callback once unlocked
+func (b *Breaker) setState(state State, now time.Time) {
+	from := b.state
+
+	b.state = state
+	b.generation++
+	b.consecutive = 0
+	b.probes = 0
+	b.successes = 0
+
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.window.reset()
+	}
+
+	if from != state && b.config.OnStateChange != nil {
+		b.transitions = append(b.transitions, transition{from: from, to: state})
+	}
+}
+
+// Execute runs an operation through the breaker
+// It returns ErrOpen or ErrTooManyProbes without running the operation when the call is rejected
+func Execute(ctx context.Context, b *Breaker, op func(context.Context) error) error {
+	_, err := ExecuteWithValue(ctx, b, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, op(ctx)
+	})
+	return err
+}
+
+// ExecuteWithValue runs an operation returning a value through the breaker
+// It returns ErrOpen or ErrTooManyProbes without running the operation when the call is rejected
+func ExecuteWithValue[T any](ctx context.Context, b *Breaker, op func(context.Context) (T, error)) (T, error) {
+	var zero T
+
+	// Don't consume a call slot for an already canceled context
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	done, err := b.Allow()
+	if err != nil {
+		return zero, err
+	}
+
+	result, err := func() (result T, err error) {
+		// Count panics as failures before propagating them
+		defer func() {
+			if r := recover(); r != nil {
+				done(fmt.Errorf("panic: %v", r))
+				panic(r)
+			}
+		}()
+		return op(ctx)
+	}()
+
+	done(err)
+	return result, err
+}
+
+// defaultIsFailure counts all errors except context cancellation as failures
+func defaultIsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,336 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/circuitbreaker"
+)
+
+// fakeClock is a manually advanced time source
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+var errBackend = errors.New("backend error")
+
+func fail(ctx context.Context) error    { return errBackend }
+func succeed(ctx context.Context) error { return nil }
+
+// TestConsecutiveFailures tests the consecutive failures policy
+func TestConsecutiveFailures(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	var transitions []string
+	b := circuitbreaker.New(circuitbreaker.Config{
+		Name:                "test",
+		ConsecutiveFailures: 3,
+		OpenTimeout:         time.Minute,
+		Now:                 clock.Now,
+		OnStateChange: func(name string, from, to circuitbreaker.State) {
+			transitions = append(transitions, name+":"+from.String()+"->"+to.String())
+		},
+	})
+
+	// Successes reset the consecutive failure count
+	require.ErrorIs(t, circuitbreaker.Execute(ctx, b, fail), errBackend)
+	require.ErrorIs(t, circuitbreaker.Execute(ctx, b, fail), errBackend)
+	require.NoError(t, circuitbreaker.Execute(ctx, b, succeed))
+	require.ErrorIs(t, circuitbreaker.Execute(ctx, b, fail), errBackend)
+	require.ErrorIs(t, circuitbreaker.Execute(ctx, b, fail), errBackend)
+	require.Equal(t, circuitbreaker.StateClosed, b.State())
+
+	// The third failure in a row trips the breaker
+	require.ErrorIs(t, circuitbreaker.Execute(ctx, b, fail), errBackend)
+	require.Equal(t, circuitbreaker.StateOpen, b.State())
+
+	called := false
+	err := circuitbreaker.Execute(ctx, b, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, circuitbreaker.ErrOpen)
+	require.False(t, called, "Open breaker should not run the operation")
+
+	// After the open timeout a probe closes the breaker again
+	clock.Advance(time.Minute)
+	require.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+	require.NoError(t, circuitbreaker.Execute(ctx, b, succeed))
+	require.Equal(t, circuitbreaker.StateClosed, b.State())
+
+	require.Equal(t, []string{
+		"test:closed->open",
+		"test:open->half-open",
+		"test:half-open->closed",
+	}, transitions)
+}
+
+// TestStateChangeCallback tests that OnStateChange may use the breaker
+func TestStateChangeCallback(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		b    *circuitbreaker.Breaker
+		seen []circuitbreaker.State
+	)
+	b = circuitbreaker.New(circuitbreaker.Config{
+		ConsecutiveFailures: 1,
+		OpenTimeout:         time.Minute,
+		OnStateChange: func(name string, from, to circuitbreaker.State) {
+			seen = append(seen, b.State())
+			_, err := b.Allow()
+			require.ErrorIs(t, err, circuitbreaker.ErrOpen)
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = circuitbreaker.Execute(ctx, b, fail)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnStateChange deadlocked on the breaker")
+	}
+	require.Equal(t, []circuitbreaker.State{circuitbreaker.StateOpen}, seen)
+}
+
+// TestFailureRate tests the sliding window failure rate policy
+func TestFailureRate(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	b := circuitbreaker.New(circuitbreaker.Config{
+		FailureRateThreshold: 0.5,
+		MinimumRequests:      4,
+		Window:               10 * time.Second,
+		WindowBuckets:        10,
+		Now:                  clock.Now,
+	})
+
+	// Not enough requests to evaluate the rate
+	require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+	require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+	require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+	require.Equal(t, circuitbreaker.StateClosed, b.State())
+
+	// Old failures slide out of the window
+	clock.Advance(20 * time.Second)
+	require.NoError(t, circuitbreaker.Execute(ctx, b, succeed))
+	require.NoError(t, circuitbreaker.Execute(ctx, b, succeed))
+	require.NoError(t, circuitbreaker.Execute(ctx, b, succeed))
+	require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+	require.Equal(t, circuitbreaker.StateClosed, b.State(), "25% failure rate should not trip")
+
+	require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+	require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+	require.Equal(t, circuitbreaker.StateOpen, b.State(), "50% failure rate should trip")
+}
+
+// TestHalfOpen tests probe limits in the half-open state
+func TestHalfOpen(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	b := circuitbreaker.New(circuitbreaker.Config{
+		ConsecutiveFailures: 1,
+		OpenTimeout:         time.Second,
+		HalfOpenMaxProbes:   2,
+		HalfOpenSuccesses:   2,
+		Now:                 clock.Now,
+	})
+
+	require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+	clock.Advance(time.Second)
+
+	t.Run("probe limit", func(t *testing.T) {
+		first, err := b.Allow()
+		require.NoError(t, err)
+		second, err := b.Allow()
+		require.NoError(t, err)
+		_, err = b.Allow()
+		require.ErrorIs(t, err, circuitbreaker.ErrTooManyProbes)
+
+		first(nil)
+		require.Equal(t, circuitbreaker.StateHalfOpen, b.State(), "One success should not close the breaker")
+		second(nil)
+		require.Equal(t, circuitbreaker.StateClosed, b.State())
+	})
+
+	t.Run("failed probe reopens", func(t *testing.T) {
+		require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+		clock.Advance(time.Second)
+		require.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+
+		require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+		require.Equal(t, circuitbreaker.StateOpen, b.State())
+	})
+
+	t.Run("outcomes of older calls are ignored", func(t *testing.T) {
+		b.Reset()
+		done, err := b.Allow()
+		require.NoError(t, err)
+
+		require.Error(t, circuitbreaker.Execute(ctx, b, fail))
+		require.Equal(t, circuitbreaker.StateOpen, b.State())
+
+		done(nil)
+		done(nil)
+		require.Equal(t, circuitbreaker.StateOpen, b.State())
+	})
+}
+
+// TestExecuteWithValue tests the generic execution helper
+func TestExecuteWithValue(t *testing.T) {
+	ctx := context.Background()
+	b := circuitbreaker.New(circuitbreaker.Config{ConsecutiveFailures: 1})
+
+	value, err := circuitbreaker.ExecuteWithValue(ctx, b, func(ctx context.Context) (string, error) {
+		return "value", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	t.Run("canceled context is not a failure", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := circuitbreaker.ExecuteWithValue(canceled, b, func(ctx context.Context) (string, error) {
+			return "", ctx.Err()
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, circuitbreaker.StateClosed, b.State())
+
+		_, err = circuitbreaker.ExecuteWithValue(ctx, b, func(ctx context.Context) (string, error) {
+			return "", context.Canceled
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, circuitbreaker.StateClosed, b.State())
+	})
+
+	t.Run("panics count as failures", func(t *testing.T) {
+		require.Panics(t, func() {
+			_, _ = circuitbreaker.ExecuteWithValue(ctx, b, func(ctx context.Context) (string, error) {
+				panic("boom")
+			})
+		})
+		require.Equal(t, circuitbreaker.StateOpen, b.State())
+	})
+}
+
+// TestSet tests named breaker management
+func TestSet(t *testing.T) {
+	ctx := context.Background()
+	s := circuitbreaker.NewSet(circuitbreaker.Config{ConsecutiveFailures: 1})
+
+	get := s.Get("Get")
+	require.Same(t, get, s.Get("Get"))
+	require.Equal(t, "Get", get.Name())
+
+	require.Error(t, circuitbreaker.Execute(ctx, get, fail))
+	require.NoError(t, circuitbreaker.Execute(ctx, s.Get("List"), succeed))
+
+	require.Equal(t, map[string]circuitbreaker.State{
+		"Get":  circuitbreaker.StateOpen,
+		"List": circuitbreaker.StateClosed,
+	}, s.States())
+}
+
+// TestKeyed tests breakers keyed per shard
+func TestKeyed(t *testing.T) {
+	ctx := context.Background()
+	k := circuitbreaker.NewKeyed(circuitbreaker.KeyedConfig{
+		Breaker: circuitbreaker.Config{Name: "users", ConsecutiveFailures: 1},
+		MaxKeys: 2,
+	})
+
+	east := circuitbreaker.WithKey(ctx, "east")
+	west := circuitbreaker.WithKey(ctx, "west")
+	require.Same(t, k.Get("east"), k.For(east, "Get"))
+	require.Equal(t, "users/east", k.Get("east").Name())
+
+	require.Error(t, circuitbreaker.Execute(east, k.For(east, "Get"), fail))
+	require.ErrorIs(t, circuitbreaker.Execute(east, k.For(east, "Get"), succeed), circuitbreaker.ErrOpen)
+	require.NoError(t, circuitbreaker.Execute(west, k.For(west, "Get"), succeed), "A failing shard should not open the breaker of the others")
+
+	require.Equal(t, map[string]circuitbreaker.State{
+		"east": circuitbreaker.StateOpen,
+		"west": circuitbreaker.StateClosed,
+	}, k.States())
+
+	// The least recently used closed breaker is dropped beyond MaxKeys, the open one keeps protecting its shard
+	k.Get("west")
+	k.For(ctx, "Get")
+	require.Equal(t, 2, k.Len())
+	require.Equal(t, map[string]circuitbreaker.State{
+		"east": circuitbreaker.StateOpen,
+		"":     circuitbreaker.StateClosed,
+	}, k.States())
+	require.ErrorIs(t, circuitbreaker.Execute(east, k.For(east, "Get"), succeed), circuitbreaker.ErrOpen)
+
+	// Without closed breakers the least recently used one is dropped
+	require.Error(t, circuitbreaker.Execute(ctx, k.For(ctx, "Get"), fail))
+	k.Get("north")
+	require.Equal(t, map[string]circuitbreaker.State{
+		"":      circuitbreaker.StateOpen,
+		"north": circuitbreaker.StateClosed,
+	}, k.States())
+
+	byArg := circuitbreaker.NewKeyed(circuitbreaker.KeyedConfig{
+		Key: func(_ context.Context, method string, args ...any) string { return args[0].(string) },
+	})
+	require.Equal(t, "tenant-1", byArg.For(ctx, "Get", "tenant-1").Name())
+}
+
+// TestConcurrentUse tests the breaker under concurrent load
+func TestConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	b := circuitbreaker.New(circuitbreaker.Config{
+		FailureRateThreshold: 0.9,
+		MinimumRequests:      1000,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if (i+j)%2 == 0 {
+					_ = circuitbreaker.Execute(ctx, b, fail)
+				} else {
+					_ = circuitbreaker.Execute(ctx, b, succeed)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, circuitbreaker.StateClosed, b.State())
+	require.Equal(t, "unknown(7)", circuitbreaker.State(7).String())
+}
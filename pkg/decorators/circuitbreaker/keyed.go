@@ -0,0 +1,135 @@
+package circuitbreaker
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// KeyedConfig holds configuration for breakers keyed by downstream host, tenant or shard
+type KeyedConfig struct {
+	// Breaker is the configuration of every breaker
+	// Breakers are named Name/key, or key when Name is empty
+	Breaker Config
+
+	// Key derives the key of a call from its context, method and arguments
+	// If not provided, the key set with WithKey is used; calls without one share the "" breaker
+	Key func(ctx context.Context, method string, args ...any) string
+
+	// MaxKeys bounds the number of breakers
+	// The least recently used closed breaker is dropped when the set is full, so that open breakers keep
+	// protecting their key; the least recently used breaker is dropped when none is closed. Zero means no limit
+	MaxKeys int
+}
+
+// Keyed hands out a breaker per key, so one failing shard does not open the breaker of all traffic
+// It is safe for concurrent use
+type Keyed struct {
+	config   KeyedConfig
+	breakers map[string]*list.Element
+	order    *list.List // front is the most recently used breaker
+	mu       sync.Mutex // protects breakers and order
+}
+
+// keyedEntry is a breaker with its key
+type keyedEntry struct {
+	key     string
+	breaker *Breaker
+}
+
+// NewKeyed creates a set of breakers created on demand for each key
+func NewKeyed(config KeyedConfig) *Keyed {
+	if config.Key == nil {
+		config.Key = func(ctx context.Context, _ string, _ ...any) string {
+			key, _ := KeyFromContext(ctx)
+			return key
+		}
+	}
+
+	return &Keyed{
+		config:   config,
+		breakers: make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// For returns the breaker of a call, keyed by KeyedConfig.Key
+func (k *Keyed) For(ctx context.Context, method string, args ...any) *Breaker {
+	return k.Get(k.config.Key(ctx, method, args...))
+}
+
+// Get returns the breaker with the given key, creating it on first use
+func (k *Keyed) Get(key string) *Breaker {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if elem, ok := k.breakers[key]; ok {
+		k.order.MoveToFront(elem)
+		return elem.Value.(*keyedEntry).breaker
+	}
+
+	config := k.config.Breaker
+	if config.Name == "" {
+		config.Name = key
+	} else {
+		config.Name += "/" + key
+	}
+	b := New(config)
+	k.breakers[key] = k.order.PushFront(&keyedEntry{key: key, breaker: b})
+
+	// Drop the least recently used breakers if the set is over capacity
+	for k.config.MaxKeys > 0 && k.order.Len() > k.config.MaxKeys {
+		entry := k.order.Remove(k.evictable()).(*keyedEntry)
+		delete(k.breakers, entry.key)
+	}
+	return b
+}
+
+// evictable returns the least recently used closed breaker, or the least recently used breaker when none is closed
+// The breaker at the front, just handed out, is never dropped
+func (k *Keyed) evictable() *list.Element {
+	for elem := k.order.Back(); elem != k.order.Front(); elem = elem.Prev() {
+		if elem.Value.(*keyedEntry).breaker.closed() {
+			return elem
+		}
+	}
+	return k.order.Back()
+}
+
+// Len returns the number of breakers
+func (k *Keyed) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.order.Len()
+}
+
+// States returns the current state of every breaker by key
+func (k *Keyed) States() map[string]State {
+	k.mu.Lock()
+	entries := make([]*keyedEntry, 0, k.order.Len())
+	for elem := k.order.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, elem.Value.(*keyedEntry))
+	}
+	k.mu.Unlock()
+
+	states := make(map[string]State, len(entries))
+	for _, entry := range entries {
+		states[entry.key] = entry.breaker.State()
+	}
+	return states
+}
+
+// keyContextKey is the context key for the breaker key of a call
+type keyContextKey struct{}
+
+// WithKey returns a context selecting the breaker of the calls made with it, e.g. a tenant or host
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFromContext returns the breaker key set with WithKey
+func KeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(keyContextKey{}).(string)
+	return key, ok
+}
@@ -0,0 +1,51 @@
+package circuitbreaker
+
+import "sync"
+
+// Set hands out named breakers sharing one configuration
+// Generated decorators use it to keep one breaker per method, or a single
+// breaker for the whole interface when every method uses the same name.
+type Set struct {
+	config   Config
+	breakers map[string]*Breaker
+	mu       sync.Mutex // protects breakers
+}
+
+// NewSet creates a set of breakers created on demand from the given configuration
+func NewSet(config Config) *Set {
+	return &Set{
+		config:   config,
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+// Get returns the breaker with the given name, creating it on first use
+func (s *Set) Get(name string) *Breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[name]
+	if !ok {
+		config := s.config
+		config.Name = name
+		b = New(config)
+		s.breakers[name] = b
+	}
+	return b
+}
+
+// States returns the current state of every breaker in the set
+func (s *Set) States() map[string]State {
+	s.mu.Lock()
+	breakers := make([]*Breaker, 0, len(s.breakers))
+	for _, b := range s.breakers {
+		breakers = append(breakers, b)
+	}
+	s.mu.Unlock()
+
+	states := make(map[string]State, len(breakers))
+	for _, b := range breakers {
+		states[b.Name()] = b.State()
+	}
+	return states
+}
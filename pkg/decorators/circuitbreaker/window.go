@@ -0,0 +1,65 @@
+package circuitbreaker
+
+import "time"
+
+// window counts call outcomes over a sliding time window split into buckets
+type window struct {
+	bucketSize time.Duration
+	buckets    []bucket
+}
+
+// bucket holds the outcomes recorded during one slice of the window
+type bucket struct {
+	start    time.Time
+	total    uint64
+	failures uint64
+}
+
+// newWindow creates a sliding window of the given duration
+func newWindow(size time.Duration, buckets int) *window {
+	bucketSize := size / time.Duration(buckets)
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+
+	return &window{
+		bucketSize: bucketSize,
+		buckets:    make([]bucket, buckets),
+	}
+}
+
+// add records an outcome at the given time
+func (w *window) add(now time.Time, failed bool) {
+	start := now.Truncate(w.bucketSize)
+	b := &w.buckets[int(start.UnixNano()/int64(w.bucketSize))%len(w.buckets)]
+
+	// Reuse a bucket that belongs to an older slice of time
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+
+	b.total++
+	if failed {
+		b.failures++
+	}
+}
+
+// counts returns the number of calls and failures within the window
+func (w *window) counts(now time.Time) (total, failures uint64) {
+	oldest := now.Truncate(w.bucketSize).Add(-w.bucketSize * time.Duration(len(w.buckets)-1))
+	for _, b := range w.buckets {
+		if b.start.Before(oldest) || b.start.After(now) {
+			continue
+		}
+		total += b.total
+		failures += b.failures
+	}
+	return total, failures
+}
+
+// reset forgets all recorded outcomes
+func (w *window) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
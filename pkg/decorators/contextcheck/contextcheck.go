@@ -0,0 +1,172 @@
+// Package contextcheck provides the runtime used by generated context check decorators.
+//
+// A Checker verifies that the context of every call carries what the service
+// relies on across boundaries, such as a deadline, a trace span or a tenant ID,
+// and logs the calls violating a requirement, or fails them with a
+// *ViolationError. It is meant for development and staging, to find the call
+// sites that drop or forget to set context values; a nil Checker checks nothing,
+// so production wiring can leave it out without changing the decorator stack.
+//
+// Example usage:
+//
+//	checker := contextcheck.New(contextcheck.Config{
+//		Requirements: []contextcheck.Requirement{
+//			contextcheck.Deadline(),
+//			contextcheck.Span(),
+//			contextcheck.Value("tenant", tenantKey{}),
+//		},
+//		Fail: true,
+//	})
+//
+//	if err := checker.Check(ctx, "UserStorage", "Get"); err != nil {
+//		return nil, err
+//	}
+package contextcheck
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Requirement is a property the context of every call must have
+type Requirement struct {
+	// Name identifies the requirement in logs and errors, e.g. "deadline"
+	Name string
+
+	// Check reports whether the context meets the requirement
+	Check func(ctx context.Context) bool
+}
+
+// Deadline requires the context to have a deadline, so calls cannot hang forever
+func Deadline() Requirement {
+	return Requirement{
+		Name: "deadline",
+		Check: func(ctx context.Context) bool {
+			_, ok := ctx.Deadline()
+			return ok
+		},
+	}
+}
+
+// Span requires the context to carry a valid trace span, so calls show up in the trace of their caller
+func Span() Requirement {
+	return Requirement{
+		Name: "span",
+		Check: func(ctx context.Context) bool {
+			return trace.SpanContextFromContext(ctx).IsValid()
+		},
+	}
+}
+
+// Value requires the context to carry a value for key, e.g. a tenant or request ID
+func Value(name string, key any) Requirement {
+	return Requirement{
+		Name: name,
+		Check: func(ctx context.Context) bool {
+			return ctx.Value(key) != nil
+		},
+	}
+}
+
+// Config holds configuration for a Checker
+type Config struct {
+	// Requirements are checked on the context of every call
+	// Defaults to Deadline and Span
+	Requirements []Requirement
+
+	// Fail returns a *ViolationError from calls violating a requirement instead of only logging them
+	// Generated decorators panic with it in methods without an error result
+	Fail bool
+
+	// Logger logs the calls violating a requirement at warning level
+	// If not provided, slog.Default is used
+	Logger *slog.Logger
+
+	// OnViolation is an optional callback called for every violation, e.g. to count them
+	OnViolation func(err *ViolationError)
+}
+
+// ViolationError is returned for calls whose context misses requirements when Config.Fail is set
+type ViolationError struct {
+	// Interface and Method name the call
+	Interface string
+	Method    string
+
+	// Missing are the names of the requirements the context does not meet
+	Missing []string
+}
+
+// Error implements the error interface
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("context of %s.%s misses %s", e.Interface, e.Method, strings.Join(e.Missing, ", "))
+}
+
+// Checker checks the context of calls against requirements
+// It is safe for concurrent use
+type Checker struct {
+	config Config
+}
+
+// New creates a checker with the given configuration
+func New(config Config) *Checker {
+	if len(config.Requirements) == 0 {
+		config.Requirements = []Requirement{Deadline(), Span()}
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	return &Checker{config: config}
+}
+
+// Check checks the context of a call to iface.method
+// Violations are logged, and returned as a *ViolationError when Config.Fail is set
+// A nil Checker checks nothing
+func (c *Checker) Check(ctx context.Context, iface, method string) error {
+	if c == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, r := range c.config.Requirements {
+		if !r.Check(ctx) {
+			missing = append(missing, r.Name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	err := &ViolationError{Interface: iface, Method: method, Missing: missing}
+	c.config.Logger.WarnContext(ctx, "context check failed",
+		slog.String("interface", iface),
+		slog.String("method", method),
+		slog.String("missing", strings.Join(missing, ",")))
+	if c.config.OnViolation != nil {
+		c.config.OnViolation(err)
+	}
+	if c.config.Fail {
+		return err
+	}
+	return nil
+}
+
+// Describe summarizes the requirements of the checker, for decorators.Info
+func (c *Checker) Describe() string {
+	if c == nil {
+		return "disabled"
+	}
+
+	names := make([]string, len(c.config.Requirements))
+	for i, r := range c.config.Requirements {
+		names[i] = r.Name
+	}
+	summary := "requires=" + strings.Join(names, ",")
+	if c.config.Fail {
+		summary += " fail"
+	}
+	return summary
+}
@@ -0,0 +1,68 @@
+package contextcheck_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/komandakycto/decogen/pkg/decorators/contextcheck"
+)
+
+type tenantKey struct{}
+
+func TestChecker(t *testing.T) {
+	var logs bytes.Buffer
+	var violations []*contextcheck.ViolationError
+	checker := contextcheck.New(contextcheck.Config{
+		Requirements: []contextcheck.Requirement{
+			contextcheck.Deadline(),
+			contextcheck.Span(),
+			contextcheck.Value("tenant", tenantKey{}),
+		},
+		Logger:      slog.New(slog.NewTextHandler(&logs, nil)),
+		OnViolation: func(err *contextcheck.ViolationError) { violations = append(violations, err) },
+	})
+	require.Equal(t, "requires=deadline,span,tenant", checker.Describe())
+
+	t.Run("violations are logged", func(t *testing.T) {
+		require.NoError(t, checker.Check(context.Background(), "UserStorage", "Get"))
+		require.Contains(t, logs.String(), "missing=deadline,span,tenant")
+		require.Len(t, violations, 1)
+		require.Equal(t, "context of UserStorage.Get misses deadline, span, tenant", violations[0].Error())
+	})
+
+	t.Run("complete contexts pass", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		ctx = context.WithValue(ctx, tenantKey{}, "acme")
+		ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  trace.SpanID{1},
+		}))
+
+		violations = nil
+		require.NoError(t, checker.Check(ctx, "UserStorage", "Get"))
+		require.Empty(t, violations)
+	})
+
+	t.Run("violations fail calls", func(t *testing.T) {
+		strict := contextcheck.New(contextcheck.Config{Fail: true, Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+		require.Equal(t, "requires=deadline,span fail", strict.Describe())
+
+		err := strict.Check(context.Background(), "UserStorage", "Save")
+		var violation *contextcheck.ViolationError
+		require.ErrorAs(t, err, &violation)
+		require.Equal(t, []string{"deadline", "span"}, violation.Missing)
+	})
+
+	t.Run("nil checker", func(t *testing.T) {
+		var disabled *contextcheck.Checker
+		require.NoError(t, disabled.Check(context.Background(), "UserStorage", "Get"))
+		require.Equal(t, "disabled", disabled.Describe())
+	})
+}
@@ -0,0 +1,75 @@
+// Package decorators describes stacks of generated decorators at runtime.
+//
+// Every generated decorator has a DescribeDecorators method returning its own
+// Info followed by the Info of the decorators it wraps, so calling it on the
+// outermost wrapper lists the whole stack, outermost first. Hand-written
+// decorators take part by implementing Describer, or chain.Unwrapper to be
+// listed by type only.
+//
+// Example usage:
+//
+//	http.HandleFunc("/debug/decorators", func(w http.ResponseWriter, r *http.Request) {
+//		json.NewEncoder(w).Encode(map[string][]decorators.Info{
+//			"UserStorage": decorators.Describe(storage),
+//		})
+//	})
+package decorators
+
+import (
+	"fmt"
+
+	"github.com/komandakycto/decogen/pkg/decorators/chain"
+)
+
+// Info describes a decorator of a stack
+type Info struct {
+	// Name is the kind of decorator, e.g. retry or cache; empty for hand-written decorators
+	Name string `json:"name,omitempty"`
+
+	// Type is the Go type of the decorator
+	Type string `json:"type"`
+
+	// Order is the position of the decorator in the stack, 0 for the outermost one
+	Order int `json:"order"`
+
+	// Config summarizes the configuration of the decorator, e.g. the retry policy of each method
+	Config string `json:"config,omitempty"`
+}
+
+// Describer is implemented by decorators describing the stack they head
+type Describer interface {
+	// DescribeDecorators returns the decorators from this one inward, outermost first
+	DescribeDecorators() []Info
+}
+
+// Describe returns the decorators of a stack, outermost first, or nil when v is not a decorator
+func Describe(v any) []Info {
+	if d, ok := v.(Describer); ok {
+		return d.DescribeDecorators()
+	}
+	return nil
+}
+
+// Stack returns the decorators of a stack headed by the decorator described by info and wrapping next
+// Generated DescribeDecorators methods use it; decorators implementing chain.Unwrapper but not
+// Describer are listed by type, and Order is set from the position of each decorator
+func Stack[T any](info Info, next T) []Info {
+	infos := []Info{info}
+	for {
+		if d, ok := any(next).(Describer); ok {
+			infos = append(infos, d.DescribeDecorators()...)
+			break
+		}
+		u, ok := any(next).(chain.Unwrapper[T])
+		if !ok {
+			break
+		}
+		infos = append(infos, Info{Type: fmt.Sprintf("%T", next)})
+		next = u.Unwrap()
+	}
+
+	for i := range infos {
+		infos[i].Order = i
+	}
+	return infos
+}
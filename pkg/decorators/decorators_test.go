@@ -0,0 +1,59 @@
+package decorators_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators"
+)
+
+type greeter interface {
+	Greet(name string) string
+}
+
+type base struct{}
+
+func (base) Greet(name string) string {
+	return name
+}
+
+// described is a decorator describing itself like generated decorators do
+type described struct {
+	next greeter
+	name string
+}
+
+func (d described) Greet(name string) string {
+	return d.next.Greet(name)
+}
+
+func (d described) DescribeDecorators() []decorators.Info {
+	return decorators.Stack(decorators.Info{Name: d.name, Type: "described", Config: "config of " + d.name}, d.next)
+}
+
+// unwrapper is a hand-written decorator that only implements chain.Unwrapper
+type unwrapper struct {
+	next greeter
+}
+
+func (u unwrapper) Greet(name string) string {
+	return u.next.Greet(name)
+}
+
+func (u unwrapper) Unwrap() greeter {
+	return u.next
+}
+
+// TestDescribe tests describing a stack of decorators
+func TestDescribe(t *testing.T) {
+	var g greeter = described{name: "retry", next: unwrapper{next: described{name: "cache", next: base{}}}}
+
+	require.Equal(t, []decorators.Info{
+		{Name: "retry", Type: "described", Order: 0, Config: "config of retry"},
+		{Type: "decorators_test.unwrapper", Order: 1},
+		{Name: "cache", Type: "described", Order: 2, Config: "config of cache"},
+	}, decorators.Describe(g))
+
+	require.Nil(t, decorators.Describe(base{}), "A base implementation has no decorators")
+}
@@ -0,0 +1,158 @@
+// Package dedupe provides the runtime used by generated dedupe decorators.
+//
+// A Deduper suppresses duplicate executions of an operation carrying the same
+// idempotency key within a time window, giving at-most-once semantics to
+// webhook and message handlers that may receive the same delivery twice.
+//
+// Keys are taken from the context (WithKey) or from the first method argument
+// implementing Keyer. Calls without a key are always executed.
+//
+// Example usage:
+//
+//	d := dedupe.New(dedupe.Config{
+//		Store:  dedupe.NewMemoryStore(dedupe.MemoryStoreConfig{}),
+//		Window: 24 * time.Hour,
+//	})
+//
+//	err := d.Do(ctx, dedupe.KeyFrom(ctx, event), func(ctx context.Context) error {
+//		return handler.Handle(ctx, event)
+//	})
+//	if errors.Is(err, dedupe.ErrDuplicate) {
+//		return nil // already handled
+//	}
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDuplicate is returned when an operation with the same key was already executed within the window
+var ErrDuplicate = errors.New("duplicate execution suppressed")
+
+// Store records the idempotency keys that were seen
+// Implementations backed by a shared database or cache make deduplication work across instances
+type Store interface {
+	// Reserve atomically records the key for the given time to live,
+	// reporting false if the key is already recorded
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Release removes the key so that the operation can be executed again
+	Release(ctx context.Context, key string) error
+}
+
+// Keyer is implemented by method arguments carrying an idempotency key
+type Keyer interface {
+	IdempotencyKey() string
+}
+
+// keyContextKey is the context key for the idempotency key
+type keyContextKey struct{}
+
+// WithKey returns a context carrying an idempotency key
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFromContext returns the key stored with WithKey, or an empty string
+func KeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(keyContextKey{}).(string)
+	return key
+}
+
+// KeyFrom returns the idempotency key of a call
+// The context key takes precedence over the first non-empty key of an argument implementing Keyer
+func KeyFrom(ctx context.Context, args ...any) string {
+	if key := KeyFromContext(ctx); key != "" {
+		return key
+	}
+
+	for _, arg := range args {
+		if k, ok := arg.(Keyer); ok {
+			if key := k.IdempotencyKey(); key != "" {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// Config holds the configuration of a Deduper
+type Config struct {
+	// Store records the seen keys
+	Store Store
+
+	// Window is how long a key suppresses duplicates
+	Window time.Duration
+
+	// Prefix is prepended to every key, e.g. to separate methods sharing a store
+	Prefix string
+
+	// ReleaseOnError removes the key when the operation fails so that a redelivery can try again
+	// When false, a failed operation is not executed again within the window
+	ReleaseOnError bool
+
+	// OnDuplicate is an optional callback called when a duplicate is suppressed
+	OnDuplicate func(key string)
+}
+
+// Deduper suppresses duplicate executions of operations
+type Deduper struct {
+	config Config
+}
+
+// New creates a Deduper with the given configuration
+func New(config Config) (*Deduper, error) {
+	if config.Store == nil {
+		return nil, errors.New("store is required")
+	}
+	if config.Window <= 0 {
+		return nil, errors.New("window must be positive")
+	}
+
+	return &Deduper{config: config}, nil
+}
+
+// Describe summarizes the configuration of the deduper, for decorators.Info
+func (d *Deduper) Describe() string {
+	summary := fmt.Sprintf("window=%s store=%T", d.config.Window, d.config.Store)
+	if d.config.Prefix != "" {
+		summary += fmt.Sprintf(" prefix=%q", d.config.Prefix)
+	}
+	if d.config.ReleaseOnError {
+		summary += " release_on_error"
+	}
+	return summary
+}
+
+// Do runs the operation unless another operation with the same key ran within the window
+// An empty key always runs the operation
+func (d *Deduper) Do(ctx context.Context, key string, op func(context.Context) error) error {
+	if key == "" {
+		return op(ctx)
+	}
+	key = d.config.Prefix + key
+
+	ok, err := d.config.Store.Reserve(ctx, key, d.config.Window)
+	if err != nil {
+		return fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if !ok {
+		if d.config.OnDuplicate != nil {
+			d.config.OnDuplicate(key)
+		}
+		return ErrDuplicate
+	}
+
+	if err := op(ctx); err != nil {
+		if d.config.ReleaseOnError {
+			// The operation error is more relevant than a failed release
+			_ = d.config.Store.Release(context.WithoutCancel(ctx), key)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package dedupe_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/dedupe"
+)
+
+type event struct {
+	ID string
+}
+
+func (e event) IdempotencyKey() string {
+	return e.ID
+}
+
+// TestKeyFrom tests idempotency key extraction
+func TestKeyFrom(t *testing.T) {
+	ctx := context.Background()
+
+	require.Equal(t, "", dedupe.KeyFrom(ctx, "plain", 1))
+	require.Equal(t, "evt-1", dedupe.KeyFrom(ctx, "plain", event{ID: "evt-1"}))
+	require.Equal(t, "evt-2", dedupe.KeyFrom(ctx, event{}, event{ID: "evt-2"}), "Empty keys should be skipped")
+	require.Equal(t, "ctx-key", dedupe.KeyFrom(dedupe.WithKey(ctx, "ctx-key"), event{ID: "evt-1"}),
+		"Context key should take precedence")
+}
+
+// TestDeduper tests suppressing duplicate executions
+func TestDeduper(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := dedupe.NewMemoryStore(dedupe.MemoryStoreConfig{Now: func() time.Time { return now }})
+
+	var duplicates []string
+	d, err := dedupe.New(dedupe.Config{
+		Store:       store,
+		Window:      time.Minute,
+		Prefix:      "Handle:",
+		OnDuplicate: func(key string) { duplicates = append(duplicates, key) },
+	})
+	require.NoError(t, err)
+	require.Equal(t, `window=1m0s store=*dedupe.MemoryStore prefix="Handle:"`, d.Describe())
+
+	calls := 0
+	op := func(context.Context) error {
+		calls++
+		return nil
+	}
+
+	require.NoError(t, d.Do(context.Background(), "evt-1", op))
+	require.ErrorIs(t, d.Do(context.Background(), "evt-1", op), dedupe.ErrDuplicate)
+	require.NoError(t, d.Do(context.Background(), "evt-2", op))
+	require.NoError(t, d.Do(context.Background(), "", op), "Calls without a key should always run")
+	require.NoError(t, d.Do(context.Background(), "", op))
+	require.Equal(t, 4, calls)
+	require.Equal(t, []string{"Handle:evt-1"}, duplicates)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, d.Do(context.Background(), "evt-1", op), "Key should expire after the window")
+	require.Equal(t, 1, store.Len(), "Expired keys should be swept")
+
+	t.Run("concurrent duplicates run once", func(t *testing.T) {
+		d, err := dedupe.New(dedupe.Config{Store: dedupe.NewMemoryStore(dedupe.MemoryStoreConfig{}), Window: time.Minute})
+		require.NoError(t, err)
+
+		var runs atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = d.Do(context.Background(), "evt-concurrent", func(context.Context) error {
+					runs.Add(1)
+					return nil
+				})
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, int32(1), runs.Load())
+	})
+}
+
+// TestDeduperErrors tests how failed operations are handled
+func TestDeduperErrors(t *testing.T) {
+	failure := errors.New("boom")
+	failing := func(context.Context) error { return failure }
+
+	t.Run("failed operations keep the key by default", func(t *testing.T) {
+		d, err := dedupe.New(dedupe.Config{Store: dedupe.NewMemoryStore(dedupe.MemoryStoreConfig{}), Window: time.Minute})
+		require.NoError(t, err)
+
+		require.ErrorIs(t, d.Do(context.Background(), "evt-1", failing), failure)
+		require.ErrorIs(t, d.Do(context.Background(), "evt-1", failing), dedupe.ErrDuplicate)
+	})
+
+	t.Run("release on error", func(t *testing.T) {
+		d, err := dedupe.New(dedupe.Config{
+			Store:          dedupe.NewMemoryStore(dedupe.MemoryStoreConfig{}),
+			Window:         time.Minute,
+			ReleaseOnError: true,
+		})
+		require.NoError(t, err)
+
+		require.ErrorIs(t, d.Do(context.Background(), "evt-1", failing), failure)
+		require.ErrorIs(t, d.Do(context.Background(), "evt-1", failing), failure, "Failed operation should run again")
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := dedupe.New(dedupe.Config{Window: time.Minute})
+		require.Error(t, err)
+		_, err = dedupe.New(dedupe.Config{Store: dedupe.NewMemoryStore(dedupe.MemoryStoreConfig{})})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,86 @@
+package dedupe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ensure MemoryStore implements Store
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStoreConfig holds configuration for the in-memory store
+type MemoryStoreConfig struct {
+	// Now returns the current time
+	// If not provided, time.Now is used
+	Now func() time.Time
+}
+
+// MemoryStore implements Store in memory for a single instance
+// Expired keys are removed lazily
+type MemoryStore struct {
+	config    MemoryStoreConfig
+	expiresAt map[string]time.Time
+	lastSweep time.Time
+	mu        sync.Mutex // protects expiresAt and lastSweep
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore(config MemoryStoreConfig) *MemoryStore {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &MemoryStore{
+		config:    config,
+		expiresAt: make(map[string]time.Time),
+	}
+}
+
+// Reserve records the key unless it is already recorded and not expired
+func (s *MemoryStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := s.config.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now, ttl)
+
+	if expiresAt, ok := s.expiresAt[key]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	s.expiresAt[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Release removes the key
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.expiresAt, key)
+	return nil
+}
+
+// Len returns the number of recorded keys, including expired ones not yet removed
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.expiresAt)
+}
+
+// sweep removes expired keys at most once per ttl so the cost is amortized
+// Must be called with mu held
+func (s *MemoryStore) sweep(now time.Time, ttl time.Duration) {
+	if now.Sub(s.lastSweep) < ttl {
+		return
+	}
+	s.lastSweep = now
+
+	for key, expiresAt := range s.expiresAt {
+		if !now.Before(expiresAt) {
+			delete(s.expiresAt, key)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package defaults holds the settings generated decorators fall back to.
+//
+// Applications register their retry policies, cache settings and metrics
+// recorder once at startup; generated constructors called without an explicit
+// config then use them, so the same settings need not be wired into every
+// decorator. Settings are read when a decorator is constructed, so register
+// them before building the decorators.
+//
+// Example usage:
+//
+//	defaults.SetRetry(retry.Single(retry.DefaultExponential()))
+//	defaults.SetCache(cache.MemoryConfig{MaxEntries: 10000, DefaultTTL: time.Minute})
+//	defaults.SetMetrics(prommetrics.New(prommetrics.Config{Namespace: "users"}))
+//
+//	storage := NewUserStorageWithRetryPolicies(base, nil)
+//	cached := NewUserStorageWithCache(storage, UserStorageCaches{})
+//	observed := NewUserStorageWithObservability(cached, nil)
+package defaults
+
+import (
+	"sync"
+
+	"github.com/komandakycto/decogen/pkg/decorators/cache"
+	"github.com/komandakycto/decogen/pkg/decorators/metrics"
+	"github.com/komandakycto/decogen/pkg/decorators/observe"
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+)
+
+var (
+	mu       sync.RWMutex
+	policies retry.PolicySource
+	memory   *cache.MemoryConfig
+	recorder metrics.Recorder
+	observer *observe.Observer
+)
+
+// SetRetry registers the retry policies of decorators constructed without policies
+func SetRetry(source retry.PolicySource) {
+	mu.Lock()
+	defer mu.Unlock()
+	policies = source
+}
+
+// Retry returns the registered retry policies
+// Without registered policies every policy is an invalid config, so calls fail instead of silently not retrying
+func Retry() retry.PolicySource {
+	mu.RLock()
+	defer mu.RUnlock()
+	if policies == nil {
+		return retry.Policies{}
+	}
+	return policies
+}
+
+// RetryConfig returns a source serving config to every method,
+// or the registered retry policies when config has no backoff strategy, as the zero Config
+func RetryConfig(config retry.Config) retry.PolicySource {
+	if config.Backoff == nil {
+		return Retry()
+	}
+	return retry.Single(config)
+}
+
+// SetCache registers the settings of the in-memory caches of decorators constructed without caches
+func SetCache(config cache.MemoryConfig) {
+	mu.Lock()
+	defer mu.Unlock()
+	memory = &config
+}
+
+// NewCache creates an in-memory cache with the registered settings
+// It returns nil, which disables caching, when no settings were registered
+func NewCache[V any]() cache.Cache[string, V] {
+	mu.RLock()
+	config := memory
+	mu.RUnlock()
+	if config == nil {
+		return nil
+	}
+	return cache.NewMemory[string, V](*config)
+}
+
+// SetMetrics registers the recorder of decorators constructed without an observer
+func SetMetrics(r metrics.Recorder) {
+	mu.Lock()
+	defer mu.Unlock()
+	recorder = r
+}
+
+// Metrics returns the registered recorder, or metrics.Nop when none was registered
+func Metrics() metrics.Recorder {
+	mu.RLock()
+	defer mu.RUnlock()
+	if recorder == nil {
+		return metrics.Nop{}
+	}
+	return recorder
+}
+
+// SetObserver registers the observer of decorators constructed without one
+// It takes precedence over the recorder registered with SetMetrics
+func SetObserver(o *observe.Observer) {
+	mu.Lock()
+	defer mu.Unlock()
+	observer = o
+}
+
+// Observer returns the registered observer
+// Without one, it returns an observer reporting calls to the registered recorder only
+func Observer() *observe.Observer {
+	mu.RLock()
+	o := observer
+	mu.RUnlock()
+	if o != nil {
+		return o
+	}
+	return observe.New(observe.Config{Recorder: Metrics()})
+}
+
+// Reset forgets every registered setting
+// It is meant for tests registering their own settings
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	policies = nil
+	memory = nil
+	recorder = nil
+	observer = nil
+}
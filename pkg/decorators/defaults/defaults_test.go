@@ -0,0 +1,80 @@
+package defaults_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+	"github.com/komandakycto/decogen/pkg/decorators/cache"
+	"github.com/komandakycto/decogen/pkg/decorators/defaults"
+	"github.com/komandakycto/decogen/pkg/decorators/metrics"
+	"github.com/komandakycto/decogen/pkg/decorators/observe"
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+)
+
+func TestRetry(t *testing.T) {
+	t.Cleanup(defaults.Reset)
+
+	err := retry.Do(context.Background(), defaults.Retry().Policy("reads"), func() error { return nil })
+	require.Error(t, err, "Calls should fail without registered policies instead of silently not retrying")
+
+	reads := retry.Config{MaxAttempts: 3, Backoff: backoff.NewConstant(time.Millisecond)}
+	defaults.SetRetry(retry.Policies{retry.DefaultPolicy: reads})
+	require.Equal(t, uint(3), defaults.Retry().Policy("reads").MaxAttempts)
+	require.Equal(t, uint(3), defaults.RetryConfig(retry.Config{}).Policy("reads").MaxAttempts,
+		"A config without a backoff strategy should fall back to the registered policies")
+
+	explicit := retry.Config{MaxAttempts: 5, Backoff: backoff.NewConstant(time.Millisecond)}
+	require.Equal(t, uint(5), defaults.RetryConfig(explicit).Policy("reads").MaxAttempts)
+
+	calls := 0
+	err = retry.Do(context.Background(), defaults.Retry().Policy("reads"), func() error {
+		calls++
+		return errors.New("flaky")
+	})
+	require.Error(t, err)
+	require.Equal(t, 3, calls)
+}
+
+func TestCache(t *testing.T) {
+	t.Cleanup(defaults.Reset)
+
+	require.Nil(t, defaults.NewCache[int](), "No cache should be created without registered settings")
+
+	defaults.SetCache(cache.MemoryConfig{MaxEntries: 1})
+	c := defaults.NewCache[int]()
+	require.NotNil(t, c)
+
+	ctx := context.Background()
+	c.Set(ctx, "a", 1, 0)
+	c.Set(ctx, "b", 2, 0)
+	_, ok := c.Get(ctx, "a")
+	require.False(t, ok, "The registered settings should bound the cache")
+	value, ok := c.Get(ctx, "b")
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+
+	require.NotSame(t, c, defaults.NewCache[int](), "Every call should create its own cache")
+}
+
+func TestObserver(t *testing.T) {
+	t.Cleanup(defaults.Reset)
+
+	require.Equal(t, metrics.Nop{}, defaults.Metrics())
+
+	recorder := metrics.NewMemory()
+	defaults.SetMetrics(recorder)
+	_, call := defaults.Observer().Start(context.Background(), "UserStorage", "Get")
+	call.End(nil)
+	require.Equal(t, float64(1), recorder.CounterValue(metrics.CallsTotal, metrics.Labels{
+		"interface": "UserStorage", "method": "Get", "result": "success",
+	}))
+
+	observer := observe.New(observe.Config{})
+	defaults.SetObserver(observer)
+	require.Same(t, observer, defaults.Observer(), "A registered observer should take precedence over the recorder")
+}
@@ -0,0 +1,136 @@
+// Package fallback provides the runtime used by generated fallback and failover decorators.
+//
+// Do runs a primary operation and, when it fails with an error the config
+// classifies as eligible, runs a secondary one instead. Failover generalizes
+// this to an ordered list of alternatives.
+//
+// Example usage:
+//
+//	user, err := fallback.Do(ctx, fallback.Config{
+//		ShouldFallback: fallback.OnErrors(ErrUnavailable),
+//	},
+//		func(ctx context.Context) (*User, error) { return primary.GetByID(ctx, id) },
+//		func(ctx context.Context) (*User, error) { return replica.GetByID(ctx, id) },
+//	)
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Config holds the configuration of a fallback
+type Config struct {
+	// ShouldFallback decides whether an error of the primary operation triggers the fallback
+	// Defaults to every error except context cancellation and deadline errors
+	ShouldFallback func(err error) bool
+
+	// OnFallback is an optional callback called before running a fallback,
+	// with the index of the failed operation and its error
+	OnFallback func(index int, err error)
+
+	// AfterFallback is an optional callback called after a fallback ran,
+	// with the index of the fallback operation and its error (nil on success)
+	AfterFallback func(index int, err error)
+}
+
+// Error is returned when the primary operation and every fallback failed
+type Error struct {
+	// Errs holds the error of each operation, in the order they were run
+	Errs []error
+}
+
+// Error returns the error message
+func (e *Error) Error() string {
+	return fmt.Sprintf("all %d alternatives failed: %v", len(e.Errs), errors.Join(e.Errs...))
+}
+
+// Unwrap returns the errors of every operation
+func (e *Error) Unwrap() []error {
+	return e.Errs
+}
+
+// Do runs primary and, if it fails with an eligible error, secondary
+func Do[T any](ctx context.Context, config Config, primary, secondary func(context.Context) (T, error)) (T, error) {
+	return Failover(ctx, config, primary, secondary)
+}
+
+// Failover runs the operations in order until one succeeds or fails with an error that is not eligible for fallback
+// Errors that are not eligible are returned as is; if every operation fails, an *Error is returned
+func Failover[T any](ctx context.Context, config Config, ops ...func(context.Context) (T, error)) (T, error) {
+	var zero T
+	if len(ops) == 0 {
+		return zero, errors.New("no operations provided")
+	}
+
+	shouldFallback := config.ShouldFallback
+	if shouldFallback == nil {
+		shouldFallback = defaultShouldFallback
+	}
+
+	errs := make([]error, 0, len(ops))
+	for i, op := range ops {
+		if i > 0 {
+			if err := ctx.Err(); err != nil {
+				errs = append(errs, err)
+				return zero, &Error{Errs: errs}
+			}
+		}
+
+		result, err := op(ctx)
+
+		if i > 0 && config.AfterFallback != nil {
+			config.AfterFallback(i, err)
+		}
+
+		if err == nil {
+			return result, nil
+		}
+
+		if !shouldFallback(err) {
+			return zero, err
+		}
+
+		errs = append(errs, err)
+
+		if i < len(ops)-1 && config.OnFallback != nil {
+			config.OnFallback(i, err)
+		}
+	}
+
+	return zero, &Error{Errs: errs}
+}
+
+// OnErrors returns a ShouldFallback function matching any of the target errors with errors.Is
+func OnErrors(targets ...error) func(error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OnType returns a ShouldFallback function matching errors of type E with errors.As
+func OnType[E error]() func(error) bool {
+	return func(err error) bool {
+		var target E
+		return errors.As(err, &target)
+	}
+}
+
+// Except returns a ShouldFallback function matching every error except the target errors
+func Except(targets ...error) func(error) bool {
+	matches := OnErrors(targets...)
+	return func(err error) bool {
+		return !matches(err)
+	}
+}
+
+// defaultShouldFallback falls back on every error except context errors
+func defaultShouldFallback(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
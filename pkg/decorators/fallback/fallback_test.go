@@ -0,0 +1,147 @@
+package fallback_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/fallback"
+)
+
+var (
+	errUnavailable = errors.New("unavailable")
+	errNotFound    = errors.New("not found")
+)
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d", e.code)
+}
+
+func value(v string, err error) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		return v, err
+	}
+}
+
+// TestDo tests falling back to a secondary operation
+func TestDo(t *testing.T) {
+	t.Run("primary succeeds", func(t *testing.T) {
+		called := false
+		result, err := fallback.Do(context.Background(), fallback.Config{},
+			value("primary", nil),
+			func(context.Context) (string, error) {
+				called = true
+				return "secondary", nil
+			})
+		require.NoError(t, err)
+		require.Equal(t, "primary", result)
+		require.False(t, called)
+	})
+
+	t.Run("falls back and reports", func(t *testing.T) {
+		var failed, after []error
+		result, err := fallback.Do(context.Background(), fallback.Config{
+			OnFallback:    func(_ int, err error) { failed = append(failed, err) },
+			AfterFallback: func(_ int, err error) { after = append(after, err) },
+		}, value("", errUnavailable), value("secondary", nil))
+
+		require.NoError(t, err)
+		require.Equal(t, "secondary", result)
+		require.Equal(t, []error{errUnavailable}, failed)
+		require.Equal(t, []error{nil}, after)
+	})
+
+	t.Run("both fail", func(t *testing.T) {
+		_, err := fallback.Do(context.Background(), fallback.Config{},
+			value("", errUnavailable), value("", errNotFound))
+
+		var fe *fallback.Error
+		require.ErrorAs(t, err, &fe)
+		require.Len(t, fe.Errs, 2)
+		require.ErrorIs(t, err, errUnavailable)
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("context errors do not fall back by default", func(t *testing.T) {
+		_, err := fallback.Do(context.Background(), fallback.Config{},
+			value("", fmt.Errorf("query: %w", context.DeadlineExceeded)), value("secondary", nil))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// TestErrorClasses tests the error classification helpers
+func TestErrorClasses(t *testing.T) {
+	t.Run("errors", func(t *testing.T) {
+		config := fallback.Config{ShouldFallback: fallback.OnErrors(errUnavailable)}
+
+		result, err := fallback.Do(context.Background(), config,
+			value("", fmt.Errorf("wrapped: %w", errUnavailable)), value("secondary", nil))
+		require.NoError(t, err)
+		require.Equal(t, "secondary", result)
+
+		_, err = fallback.Do(context.Background(), config, value("", errNotFound), value("secondary", nil))
+		require.Equal(t, errNotFound, err, "Ineligible errors should be returned as is")
+	})
+
+	t.Run("type", func(t *testing.T) {
+		config := fallback.Config{ShouldFallback: fallback.OnType[*statusError]()}
+
+		result, err := fallback.Do(context.Background(), config,
+			value("", fmt.Errorf("call: %w", &statusError{code: 503})), value("secondary", nil))
+		require.NoError(t, err)
+		require.Equal(t, "secondary", result)
+
+		_, err = fallback.Do(context.Background(), config, value("", errNotFound), value("secondary", nil))
+		require.ErrorIs(t, err, errNotFound)
+	})
+
+	t.Run("except", func(t *testing.T) {
+		config := fallback.Config{ShouldFallback: fallback.Except(errNotFound)}
+
+		_, err := fallback.Do(context.Background(), config, value("", errNotFound), value("secondary", nil))
+		require.ErrorIs(t, err, errNotFound)
+
+		result, err := fallback.Do(context.Background(), config, value("", errUnavailable), value("secondary", nil))
+		require.NoError(t, err)
+		require.Equal(t, "secondary", result)
+	})
+}
+
+// TestFailover tests trying several alternatives in order
+func TestFailover(t *testing.T) {
+	var failedIndexes []int
+	result, err := fallback.Failover(context.Background(), fallback.Config{
+		OnFallback: func(i int, _ error) { failedIndexes = append(failedIndexes, i) },
+	}, value("", errUnavailable), value("", errUnavailable), value("third", nil))
+
+	require.NoError(t, err)
+	require.Equal(t, "third", result)
+	require.Equal(t, []int{0, 1}, failedIndexes)
+
+	_, err = fallback.Failover[string](context.Background(), fallback.Config{})
+	require.Error(t, err)
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		called := false
+		_, err := fallback.Failover(ctx, fallback.Config{},
+			func(context.Context) (string, error) {
+				cancel()
+				return "", errUnavailable
+			},
+			func(context.Context) (string, error) {
+				called = true
+				return "secondary", nil
+			})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.False(t, called)
+	})
+}
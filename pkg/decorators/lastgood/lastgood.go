@@ -0,0 +1,164 @@
+// Package lastgood provides the runtime used by generated last-known-good decorators.
+//
+// Do remembers the last successful result of a call by key and serves it when a
+// later call with the same key fails, as long as it is not older than the
+// configured staleness bound. Callers learn that a result is stale from a
+// Report attached to the context, or from a *StaleError when the config asks
+// for the failure to be returned alongside the result.
+//
+// Example usage:
+//
+//	flags := cache.NewMemory[string, lastgood.Entry[Flags]](cache.MemoryConfig{MaxEntries: 1000})
+//
+//	ctx, report := lastgood.WithReport(ctx)
+//	f, err := lastgood.Do(ctx, flags, cache.Key("Flags", tenant), lastgood.Config{MaxStaleness: time.Hour},
+//		func(ctx context.Context) (Flags, error) {
+//			return client.Flags(ctx, tenant)
+//		})
+//	if report.Stale {
+//		log.Printf("serving flags from %s ago: %v", report.Age, report.Err)
+//	}
+package lastgood
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/decorators/cache"
+)
+
+// Entry is a remembered result and the time it was returned
+type Entry[V any] struct {
+	Value  V
+	Stored time.Time
+}
+
+// Config holds the configuration of a last-known-good call
+type Config struct {
+	// MaxStaleness bounds the age of the results that are served; zero serves results of any age
+	MaxStaleness time.Duration
+
+	// ShouldServe decides whether a failure is replaced with the last good result
+	// Defaults to every error except context cancellation
+	ShouldServe func(err error) bool
+
+	// ReturnError returns a *StaleError wrapping the failure along with a served result instead of a nil error
+	ReturnError bool
+
+	// OnServe is an optional callback called when a result is served in place of a failure
+	OnServe func(key string, age time.Duration, err error)
+
+	// Now returns the current time
+	// If not provided, time.Now is used
+	Now func() time.Time
+}
+
+// Describe summarizes a config, for decorators.Info
+func Describe(config Config) string {
+	summary := "max_staleness=unlimited"
+	if config.MaxStaleness > 0 {
+		summary = "max_staleness=" + config.MaxStaleness.String()
+	}
+	if config.ReturnError {
+		summary += " return_error"
+	}
+	return summary
+}
+
+// StaleError is returned with a served result when Config.ReturnError is set
+type StaleError struct {
+	// Age is the age of the served result
+	Age time.Duration
+
+	// Err is the failure the result replaces
+	Err error
+}
+
+// Error returns the error message
+func (e *StaleError) Error() string {
+	return fmt.Sprintf("serving result from %s ago: %v", e.Age, e.Err)
+}
+
+// Unwrap returns the failure the result replaces
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+// Report tells whether a call served a stale result
+// It is filled by Do when the context was prepared with WithReport
+type Report struct {
+	// Stale is set when a result was served in place of a failure
+	Stale bool
+
+	// Age is the age of the served result
+	Age time.Duration
+
+	// Err is the failure the result replaces
+	Err error
+}
+
+// reportContextKey is the context key for the report
+type reportContextKey struct{}
+
+// WithReport returns a context whose calls record in the returned report whether they served a stale result
+// The report describes the last such call; calls sharing the context must not run concurrently
+func WithReport(ctx context.Context) (context.Context, *Report) {
+	report := &Report{}
+	return context.WithValue(ctx, reportContextKey{}, report), report
+}
+
+// Do calls op and remembers its result under key, or serves the last good result of key when op fails
+// The error of op is returned when there is no result to serve or it is older than Config.MaxStaleness
+func Do[V any](
+	ctx context.Context,
+	store cache.Cache[string, Entry[V]],
+	key string,
+	config Config,
+	op func(context.Context) (V, error),
+) (V, error) {
+	now := time.Now
+	if config.Now != nil {
+		now = config.Now
+	}
+
+	value, err := op(ctx)
+	if err == nil {
+		store.Set(ctx, key, Entry[V]{Value: value, Stored: now()}, config.MaxStaleness)
+		return value, nil
+	}
+
+	shouldServe := config.ShouldServe
+	if shouldServe == nil {
+		shouldServe = defaultShouldServe
+	}
+	if !shouldServe(err) {
+		return value, err
+	}
+
+	entry, ok := store.Get(ctx, key)
+	if !ok {
+		return value, err
+	}
+	age := now().Sub(entry.Stored)
+	if config.MaxStaleness > 0 && age > config.MaxStaleness {
+		return value, err
+	}
+
+	if report, ok := ctx.Value(reportContextKey{}).(*Report); ok {
+		*report = Report{Stale: true, Age: age, Err: err}
+	}
+	if config.OnServe != nil {
+		config.OnServe(key, age, err)
+	}
+	if config.ReturnError {
+		return entry.Value, &StaleError{Age: age, Err: err}
+	}
+	return entry.Value, nil
+}
+
+// defaultShouldServe serves the last good result for every error except context cancellation
+func defaultShouldServe(err error) bool {
+	return !errors.Is(err, context.Canceled)
+}
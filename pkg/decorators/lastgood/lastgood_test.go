@@ -0,0 +1,84 @@
+package lastgood_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/cache"
+	"github.com/komandakycto/decogen/pkg/decorators/lastgood"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+func TestDo(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := lastgood.Config{
+		MaxStaleness: time.Hour,
+		Now:          func() time.Time { return now },
+	}
+	store := cache.NewMemory[string, lastgood.Entry[string]](cache.MemoryConfig{})
+
+	succeed := func(context.Context) (string, error) { return "fresh", nil }
+	fail := func(context.Context) (string, error) { return "", errUnavailable }
+
+	// Nothing to serve before a first success
+	_, err := lastgood.Do(context.Background(), store, "flags", config, fail)
+	require.ErrorIs(t, err, errUnavailable)
+
+	value, err := lastgood.Do(context.Background(), store, "flags", config, succeed)
+	require.NoError(t, err)
+	require.Equal(t, "fresh", value)
+
+	// A failure serves the last good result and reports it
+	now = now.Add(30 * time.Minute)
+	ctx, report := lastgood.WithReport(context.Background())
+	value, err = lastgood.Do(ctx, store, "flags", config, fail)
+	require.NoError(t, err)
+	require.Equal(t, "fresh", value)
+	require.Equal(t, lastgood.Report{Stale: true, Age: 30 * time.Minute, Err: errUnavailable}, *report)
+
+	// Other keys are not served
+	_, err = lastgood.Do(context.Background(), store, "other", config, fail)
+	require.ErrorIs(t, err, errUnavailable)
+
+	// Results older than the staleness bound are not served
+	now = now.Add(time.Hour)
+	_, err = lastgood.Do(context.Background(), store, "flags", config, fail)
+	require.ErrorIs(t, err, errUnavailable)
+}
+
+func TestDoReturnError(t *testing.T) {
+	store := cache.NewMemory[string, lastgood.Entry[int]](cache.MemoryConfig{})
+	config := lastgood.Config{ReturnError: true}
+	require.Equal(t, "max_staleness=unlimited return_error", lastgood.Describe(config))
+
+	_, err := lastgood.Do(context.Background(), store, "count", config, func(context.Context) (int, error) { return 42, nil })
+	require.NoError(t, err)
+
+	var served []string
+	config.OnServe = func(key string, age time.Duration, err error) { served = append(served, key) }
+	value, err := lastgood.Do(context.Background(), store, "count", config, func(context.Context) (int, error) { return 0, errUnavailable })
+	require.Equal(t, 42, value)
+	var staleErr *lastgood.StaleError
+	require.ErrorAs(t, err, &staleErr)
+	require.ErrorIs(t, err, errUnavailable)
+	require.Equal(t, []string{"count"}, served)
+}
+
+func TestDoShouldServe(t *testing.T) {
+	store := cache.NewMemory[string, lastgood.Entry[int]](cache.MemoryConfig{})
+	_, err := lastgood.Do(context.Background(), store, "count", lastgood.Config{}, func(context.Context) (int, error) { return 42, nil })
+	require.NoError(t, err)
+
+	// Cancellation is not served by default
+	_, err = lastgood.Do(context.Background(), store, "count", lastgood.Config{}, func(context.Context) (int, error) { return 0, context.Canceled })
+	require.ErrorIs(t, err, context.Canceled)
+
+	config := lastgood.Config{ShouldServe: func(err error) bool { return !errors.Is(err, errUnavailable) }}
+	_, err = lastgood.Do(context.Background(), store, "count", config, func(context.Context) (int, error) { return 0, errUnavailable })
+	require.ErrorIs(t, err, errUnavailable)
+}
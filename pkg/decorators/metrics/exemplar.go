@@ -0,0 +1,22 @@
+package metrics
+
+import "context"
+
+// ExemplarRecorder is a Recorder attaching exemplars to histogram samples,
+// such as the trace of the call, so slow samples in dashboards link to their traces
+type ExemplarRecorder interface {
+	Recorder
+
+	// HistogramContext records a value in a distribution with the exemplar carried by ctx, if any
+	HistogramContext(ctx context.Context, name string, labels Labels, value float64)
+}
+
+// HistogramContext records a histogram sample with the exemplar carried by ctx
+// when the recorder is an ExemplarRecorder, and without one otherwise
+func HistogramContext(ctx context.Context, r Recorder, name string, labels Labels, value float64) {
+	if er, ok := r.(ExemplarRecorder); ok {
+		er.HistogramContext(ctx, name, labels, value)
+		return
+	}
+	r.Histogram(name, labels, value)
+}
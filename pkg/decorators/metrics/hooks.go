@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/decorators/bulkhead"
+	"github.com/komandakycto/decogen/pkg/decorators/cache"
+	"github.com/komandakycto/decogen/pkg/decorators/circuitbreaker"
+	"github.com/komandakycto/decogen/pkg/decorators/ratelimit"
+)
+
+// RetryHook returns a retry.Config OnRetry callback reporting retries of the named operation
+func RetryHook(r Recorder, operation string) func(attempt uint, err error, delay time.Duration) {
+	labels := Labels{"operation": operation}
+	return func(_ uint, _ error, delay time.Duration) {
+		r.Counter(RetriesTotal, labels, 1)
+		r.Histogram(RetryDelay, labels, delay.Seconds())
+	}
+}
+
+// RetryTimingHook returns a retry.Config OnDone callback reporting the time retry loops spend executing
+// attempts apart from the time they wait between them, by operation
+func RetryTimingHook(r Recorder) func(op string, execution, sleep time.Duration, err error) {
+	return func(op string, execution, sleep time.Duration, _ error) {
+		labels := Labels{"operation": op}
+		r.Histogram(RetryExecution, labels, execution.Seconds())
+		r.Histogram(RetrySleep, labels, sleep.Seconds())
+	}
+}
+
+// BreakerStateChange returns a circuitbreaker.Config OnStateChange callback reporting transitions
+// The state gauge is set to 1 for the new state and 0 for the previous one
+func BreakerStateChange(r Recorder) func(name string, from, to circuitbreaker.State) {
+	return func(name string, from, to circuitbreaker.State) {
+		r.Counter(BreakerTransitionsTotal, Labels{"breaker": name, "from": from.String(), "to": to.String()}, 1)
+		r.Gauge(BreakerState, Labels{"breaker": name, "state": from.String()}, 0)
+		r.Gauge(BreakerState, Labels{"breaker": name, "state": to.String()}, 1)
+	}
+}
+
+// CacheHooks returns cache hooks reporting hits, misses and evictions of the named cache
+func CacheHooks(r Recorder, name string) cache.Hooks {
+	hit := Labels{"cache": name, "result": "hit"}
+	miss := Labels{"cache": name, "result": "miss"}
+	return cache.Hooks{
+		OnHit:  func() { r.Counter(CacheRequestsTotal, hit, 1) },
+		OnMiss: func() { r.Counter(CacheRequestsTotal, miss, 1) },
+		OnEvict: func(reason cache.EvictionReason) {
+			r.Counter(CacheEvictionsTotal, Labels{"cache": name, "reason": string(reason)}, 1)
+		},
+	}
+}
+
+// RateLimitHooks returns rate limiter hooks reporting decisions of the named limiter
+func RateLimitHooks(r Recorder, name string) ratelimit.Hooks {
+	allowed := Labels{"limiter": name, "result": "allowed"}
+	rejected := Labels{"limiter": name, "result": "rejected"}
+	waited := Labels{"limiter": name}
+	return ratelimit.Hooks{
+		OnAllow:  func() { r.Counter(RateLimitTotal, allowed, 1) },
+		OnReject: func() { r.Counter(RateLimitTotal, rejected, 1) },
+		OnWait:   func(d time.Duration) { r.Histogram(RateLimitWait, waited, d.Seconds()) },
+	}
+}
+
+// BulkheadHooks returns bulkhead hooks reporting saturation, queueing and rejections
+func BulkheadHooks(r Recorder) bulkhead.Hooks {
+	return bulkhead.Hooks{
+		OnAcquire: func(name string, waited time.Duration, inUse int64) {
+			r.Gauge(BulkheadInUse, Labels{"bulkhead": name}, float64(inUse))
+			r.Histogram(BulkheadWait, Labels{"bulkhead": name}, waited.Seconds())
+		},
+		OnReject: func(name string, _ error) {
+			r.Counter(BulkheadRejectedTotal, Labels{"bulkhead": name}, 1)
+		},
+		OnRelease: func(name string, inUse int64) {
+			r.Gauge(BulkheadInUse, Labels{"bulkhead": name}, float64(inUse))
+		},
+	}
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Memory is a Recorder keeping measurements in memory, intended for tests
+type Memory struct {
+	mu         sync.Mutex
+	counters   map[string]float64
+	gauges     map[string]float64
+	histograms map[string][]float64
+}
+
+// Ensure Memory implements Recorder
+var _ Recorder = (*Memory)(nil)
+
+// NewMemory creates an empty in-memory recorder
+func NewMemory() *Memory {
+	return &Memory{
+		counters:   make(map[string]float64),
+		gauges:     make(map[string]float64),
+		histograms: make(map[string][]float64),
+	}
+}
+
+// Counter adds delta to a counter
+func (m *Memory) Counter(name string, labels Labels, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[seriesKey(name, labels)] += delta
+}
+
+// Histogram records a value in a distribution
+func (m *Memory) Histogram(name string, labels Labels, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := seriesKey(name, labels)
+	m.histograms[key] = append(m.histograms[key], value)
+}
+
+// Gauge sets the value of a gauge
+func (m *Memory) Gauge(name string, labels Labels, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[seriesKey(name, labels)] = value
+}
+
+// CounterValue returns the current value of a counter
+func (m *Memory) CounterValue(name string, labels Labels) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[seriesKey(name, labels)]
+}
+
+// GaugeValue returns the current value of a gauge
+func (m *Memory) GaugeValue(name string, labels Labels) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.gauges[seriesKey(name, labels)]
+}
+
+// HistogramValues returns a copy of the values recorded in a histogram
+func (m *Memory) HistogramValues(name string, labels Labels) []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]float64(nil), m.histograms[seriesKey(name, labels)]...)
+}
+
+// seriesKey builds a stable identifier for a metric name and label set
+func seriesKey(name string, labels Labels) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(name)
+	for _, k := range keys {
+		sb.WriteString("|")
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(labels[k])
+	}
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+// Package metrics provides the runtime used by generated metrics decorators.
+//
+// Generated decorators and the other runtime packages report through the small
+// Recorder interface, so a service picks its metrics backend once. Adapters for
+// Prometheus and OpenTelemetry live in the prommetrics and otelmetrics packages,
+// and Memory is an in-process recorder for tests. Both adapters implement
+// ExemplarRecorder, linking the duration samples of ObserveCallContext to the
+// trace active in the context.
+//
+// Example usage:
+//
+//	rec := prommetrics.New(prommetrics.Config{Namespace: "users"})
+//
+//	start := time.Now()
+//	user, err := storage.GetByID(ctx, id)
+//	metrics.ObserveCall(rec, "UserStorage", "GetByID", time.Since(start), err)
+//
+//	breaker := circuitbreaker.New(circuitbreaker.Config{
+//		Name:          "users-db",
+//		OnStateChange: metrics.BreakerStateChange(rec),
+//	})
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// Names of the metrics reported by the helpers in this package
+const (
+	// CallsTotal counts method calls by interface, method and result
+	CallsTotal = "decogen_calls_total"
+	// CallDuration is a histogram of method call durations in seconds
+	CallDuration = "decogen_call_duration_seconds"
+	// RetriesTotal counts retries by operation
+	RetriesTotal = "decogen_retries_total"
+	// RetryDelay is a histogram of delays before retries in seconds
+	RetryDelay = "decogen_retry_delay_seconds"
+	// RetryExecution is a histogram of the time retry loops spent executing attempts in seconds
+	RetryExecution = "decogen_retry_execution_seconds"
+	// RetrySleep is a histogram of the time retry loops spent waiting between attempts in seconds
+	RetrySleep = "decogen_retry_sleep_seconds"
+	// BreakerState is a gauge set to 1 for the current state of each circuit breaker
+	BreakerState = "decogen_circuit_breaker_state"
+	// BreakerTransitionsTotal counts circuit breaker state transitions
+	BreakerTransitionsTotal = "decogen_circuit_breaker_transitions_total"
+	// CacheRequestsTotal counts cache lookups by cache and result
+	CacheRequestsTotal = "decogen_cache_requests_total"
+	// CacheEvictionsTotal counts cache evictions by cache and reason
+	CacheEvictionsTotal = "decogen_cache_evictions_total"
+	// RateLimitTotal counts rate limiter decisions by limiter and result
+	RateLimitTotal = "decogen_rate_limit_total"
+	// RateLimitWait is a histogram of time spent waiting for a rate limiter in seconds
+	RateLimitWait = "decogen_rate_limit_wait_seconds"
+	// BulkheadInUse is a gauge of the weight held by running calls of each bulkhead
+	BulkheadInUse = "decogen_bulkhead_in_use"
+	// BulkheadWait is a histogram of time spent queued for a bulkhead in seconds
+	BulkheadWait = "decogen_bulkhead_wait_seconds"
+	// BulkheadRejectedTotal counts calls rejected by a bulkhead
+	BulkheadRejectedTotal = "decogen_bulkhead_rejected_total"
+)
+
+// Labels are the dimensions of a single measurement
+type Labels map[string]string
+
+// Recorder defines the interface for metrics backends
+// Implementations must be safe for concurrent use
+type Recorder interface {
+	// Counter adds delta to a monotonic counter
+	Counter(name string, labels Labels, delta float64)
+
+	// Histogram records a value in a distribution
+	Histogram(name string, labels Labels, value float64)
+
+	// Gauge sets the current value of a gauge
+	Gauge(name string, labels Labels, value float64)
+}
+
+// Nop is a Recorder that discards all measurements
+type Nop struct{}
+
+// Ensure Nop implements Recorder
+var _ Recorder = Nop{}
+
+// Counter does nothing
+func (Nop) Counter(string, Labels, float64) {}
+
+// Histogram does nothing
+func (Nop) Histogram(string, Labels, float64) {}
+
+// Gauge does nothing
+func (Nop) Gauge(string, Labels, float64) {}
+
+// ObserveCall records a single method call with its duration and outcome
+func ObserveCall(r Recorder, iface, method string, duration time.Duration, err error) {
+	ObserveCallContext(context.Background(), r, iface, method, duration, err)
+}
+
+// ObserveCallContext is ObserveCall recording the duration with the exemplar of ctx, see HistogramContext
+func ObserveCallContext(ctx context.Context, r Recorder, iface, method string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	r.Counter(CallsTotal, Labels{"interface": iface, "method": method, "result": result}, 1)
+	HistogramContext(ctx, r, CallDuration, Labels{"interface": iface, "method": method}, duration.Seconds())
+}
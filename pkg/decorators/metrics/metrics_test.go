@@ -0,0 +1,98 @@
+package metrics_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/bulkhead"
+	"github.com/komandakycto/decogen/pkg/decorators/cache"
+	"github.com/komandakycto/decogen/pkg/decorators/circuitbreaker"
+	"github.com/komandakycto/decogen/pkg/decorators/metrics"
+)
+
+// TestObserveCall tests recording method calls
+func TestObserveCall(t *testing.T) {
+	rec := metrics.NewMemory()
+
+	metrics.ObserveCall(rec, "UserStorage", "GetByID", 10*time.Millisecond, nil)
+	metrics.ObserveCall(rec, "UserStorage", "GetByID", 30*time.Millisecond, errors.New("boom"))
+
+	success := metrics.Labels{"interface": "UserStorage", "method": "GetByID", "result": "success"}
+	failure := metrics.Labels{"interface": "UserStorage", "method": "GetByID", "result": "error"}
+	require.Equal(t, 1.0, rec.CounterValue(metrics.CallsTotal, success))
+	require.Equal(t, 1.0, rec.CounterValue(metrics.CallsTotal, failure))
+	require.Equal(t, []float64{0.01, 0.03},
+		rec.HistogramValues(metrics.CallDuration, metrics.Labels{"interface": "UserStorage", "method": "GetByID"}))
+
+	// Nop must accept the same calls
+	metrics.ObserveCall(metrics.Nop{}, "UserStorage", "GetByID", time.Millisecond, nil)
+}
+
+// TestHooks tests the adapters for the other runtime packages
+func TestHooks(t *testing.T) {
+	rec := metrics.NewMemory()
+
+	t.Run("retry", func(t *testing.T) {
+		hook := metrics.RetryHook(rec, "GetByID")
+		hook(1, errors.New("boom"), 100*time.Millisecond)
+		hook(2, errors.New("boom"), 200*time.Millisecond)
+
+		labels := metrics.Labels{"operation": "GetByID"}
+		require.Equal(t, 2.0, rec.CounterValue(metrics.RetriesTotal, labels))
+		require.Equal(t, []float64{0.1, 0.2}, rec.HistogramValues(metrics.RetryDelay, labels))
+
+		timing := metrics.RetryTimingHook(rec)
+		timing("GetByID", 300*time.Millisecond, 100*time.Millisecond, nil)
+		require.Equal(t, []float64{0.3}, rec.HistogramValues(metrics.RetryExecution, labels))
+		require.Equal(t, []float64{0.1}, rec.HistogramValues(metrics.RetrySleep, labels))
+	})
+
+	t.Run("circuit breaker", func(t *testing.T) {
+		hook := metrics.BreakerStateChange(rec)
+		hook("db", circuitbreaker.StateClosed, circuitbreaker.StateOpen)
+
+		require.Equal(t, 1.0, rec.GaugeValue(metrics.BreakerState, metrics.Labels{"breaker": "db", "state": "open"}))
+		require.Equal(t, 0.0, rec.GaugeValue(metrics.BreakerState, metrics.Labels{"breaker": "db", "state": "closed"}))
+		require.Equal(t, 1.0, rec.CounterValue(metrics.BreakerTransitionsTotal,
+			metrics.Labels{"breaker": "db", "from": "closed", "to": "open"}))
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		hooks := metrics.CacheHooks(rec, "users")
+		hooks.OnHit()
+		hooks.OnMiss()
+		hooks.OnMiss()
+		hooks.OnEvict(cache.EvictionCapacity)
+
+		require.Equal(t, 1.0, rec.CounterValue(metrics.CacheRequestsTotal, metrics.Labels{"cache": "users", "result": "hit"}))
+		require.Equal(t, 2.0, rec.CounterValue(metrics.CacheRequestsTotal, metrics.Labels{"cache": "users", "result": "miss"}))
+		require.Equal(t, 1.0, rec.CounterValue(metrics.CacheEvictionsTotal, metrics.Labels{"cache": "users", "reason": "capacity"}))
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		hooks := metrics.RateLimitHooks(rec, "api")
+		hooks.OnAllow()
+		hooks.OnReject()
+		hooks.OnWait(50 * time.Millisecond)
+
+		require.Equal(t, 1.0, rec.CounterValue(metrics.RateLimitTotal, metrics.Labels{"limiter": "api", "result": "allowed"}))
+		require.Equal(t, 1.0, rec.CounterValue(metrics.RateLimitTotal, metrics.Labels{"limiter": "api", "result": "rejected"}))
+		require.Equal(t, []float64{0.05}, rec.HistogramValues(metrics.RateLimitWait, metrics.Labels{"limiter": "api"}))
+	})
+
+	t.Run("bulkhead", func(t *testing.T) {
+		hooks := metrics.BulkheadHooks(rec)
+		hooks.OnAcquire("db", 10*time.Millisecond, 3)
+		hooks.OnReject("db", bulkhead.ErrFull)
+
+		require.Equal(t, 3.0, rec.GaugeValue(metrics.BulkheadInUse, metrics.Labels{"bulkhead": "db"}))
+		require.Equal(t, []float64{0.01}, rec.HistogramValues(metrics.BulkheadWait, metrics.Labels{"bulkhead": "db"}))
+		require.Equal(t, 1.0, rec.CounterValue(metrics.BulkheadRejectedTotal, metrics.Labels{"bulkhead": "db"}))
+
+		hooks.OnRelease("db", 2)
+		require.Equal(t, 2.0, rec.GaugeValue(metrics.BulkheadInUse, metrics.Labels{"bulkhead": "db"}))
+	})
+}
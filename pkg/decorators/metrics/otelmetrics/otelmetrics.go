@@ -0,0 +1,146 @@
+// Package otelmetrics adapts the metrics Recorder interface to an OpenTelemetry meter.
+//
+// Instruments are created on first use of each metric name and labels are
+// recorded as string attributes. Samples recorded with HistogramContext carry
+// the context to the SDK, which attaches the span of a sampled trace as an
+// exemplar according to its exemplar filter.
+//
+// Example usage:
+//
+//	rec := otelmetrics.New(otelmetrics.Config{
+//		Meter: otel.Meter("github.com/acme/users"),
+//	})
+//
+//	metrics.ObserveCall(rec, "UserStorage", "GetByID", time.Since(start), err)
+package otelmetrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/komandakycto/decogen/pkg/decorators/metrics"
+)
+
+// Config holds the configuration of the OpenTelemetry recorder
+type Config struct {
+	// Meter creates the instruments
+	Meter metric.Meter
+
+	// OnError is an optional callback for instruments that could not be created
+	OnError func(err error)
+}
+
+// Recorder records metrics to OpenTelemetry instruments
+type Recorder struct {
+	config Config
+
+	mu         sync.RWMutex
+	counters   map[string]metric.Float64Counter
+	gauges     map[string]metric.Float64Gauge
+	histograms map[string]metric.Float64Histogram
+}
+
+// Ensure Recorder implements metrics.ExemplarRecorder
+var _ metrics.ExemplarRecorder = (*Recorder)(nil)
+
+// New creates an OpenTelemetry recorder
+func New(config Config) (*Recorder, error) {
+	if config.Meter == nil {
+		return nil, fmt.Errorf("meter is required")
+	}
+
+	return &Recorder{
+		config:     config,
+		counters:   make(map[string]metric.Float64Counter),
+		gauges:     make(map[string]metric.Float64Gauge),
+		histograms: make(map[string]metric.Float64Histogram),
+	}, nil
+}
+
+// Counter adds delta to a counter
+func (r *Recorder) Counter(name string, labels metrics.Labels, delta float64) {
+	counter, err := getOrCreate(r, r.counters, name, func() (metric.Float64Counter, error) {
+		return r.config.Meter.Float64Counter(name)
+	})
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	counter.Add(context.Background(), delta, metric.WithAttributeSet(attributes(labels)))
+}
+
+// Histogram records a value in a histogram
+func (r *Recorder) Histogram(name string, labels metrics.Labels, value float64) {
+	r.HistogramContext(context.Background(), name, labels, value)
+}
+
+// HistogramContext records a value in a histogram with the context the SDK takes exemplars from
+func (r *Recorder) HistogramContext(ctx context.Context, name string, labels metrics.Labels, value float64) {
+	histogram, err := getOrCreate(r, r.histograms, name, func() (metric.Float64Histogram, error) {
+		return r.config.Meter.Float64Histogram(name)
+	})
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	histogram.Record(ctx, value, metric.WithAttributeSet(attributes(labels)))
+}
+
+// Gauge sets the value of a gauge
+func (r *Recorder) Gauge(name string, labels metrics.Labels, value float64) {
+	gauge, err := getOrCreate(r, r.gauges, name, func() (metric.Float64Gauge, error) {
+		return r.config.Meter.Float64Gauge(name)
+	})
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	gauge.Record(context.Background(), value, metric.WithAttributeSet(attributes(labels)))
+}
+
+// fail reports an error to the configured callback
+func (r *Recorder) fail(err error) {
+	if r.config.OnError != nil {
+		r.config.OnError(err)
+	}
+}
+
+// getOrCreate returns the instrument for a metric name, creating it on first use
+func getOrCreate[I any](r *Recorder, instruments map[string]I, name string, create func() (I, error)) (I, error) {
+	r.mu.RLock()
+	instrument, ok := instruments[name]
+	r.mu.RUnlock()
+	if ok {
+		return instrument, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if instrument, ok := instruments[name]; ok {
+		return instrument, nil
+	}
+
+	instrument, err := create()
+	if err != nil {
+		return instrument, fmt.Errorf("failed to create instrument %s: %w", name, err)
+	}
+
+	instruments[name] = instrument
+	return instrument, nil
+}
+
+// attributes converts labels to an OpenTelemetry attribute set
+func attributes(labels metrics.Labels) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(labels))
+	for k, v := range labels {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return attribute.NewSet(kvs...)
+}
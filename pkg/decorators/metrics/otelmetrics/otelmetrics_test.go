@@ -0,0 +1,52 @@
+package otelmetrics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/komandakycto/decogen/pkg/decorators/metrics"
+	"github.com/komandakycto/decogen/pkg/decorators/metrics/otelmetrics"
+)
+
+// TestRecorder tests recording through OpenTelemetry instruments
+func TestRecorder(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	rec, err := otelmetrics.New(otelmetrics.Config{Meter: provider.Meter("test")})
+	require.NoError(t, err)
+
+	metrics.ObserveCall(rec, "UserStorage", "GetByID", 10*time.Millisecond, nil)
+	metrics.ObserveCall(rec, "UserStorage", "GetByID", 20*time.Millisecond, nil)
+	rec.Gauge("queue_size", metrics.Labels{"queue": "jobs"}, 7)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	found := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		found[m.Name] = m.Data
+	}
+
+	sum, ok := found[metrics.CallsTotal].(metricdata.Sum[float64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	require.Equal(t, 2.0, sum.DataPoints[0].Value)
+
+	histogram, ok := found[metrics.CallDuration].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Equal(t, uint64(2), histogram.DataPoints[0].Count)
+
+	gauge, ok := found["queue_size"].(metricdata.Gauge[float64])
+	require.True(t, ok)
+	require.Equal(t, 7.0, gauge.DataPoints[0].Value)
+
+	_, err = otelmetrics.New(otelmetrics.Config{})
+	require.Error(t, err, "A meter should be required")
+}
@@ -0,0 +1,207 @@
+// Package prommetrics adapts the metrics Recorder interface to Prometheus.
+//
+// Collectors are created and registered on first use of each metric name, with
+// label names taken from the first measurement. Later measurements of the same
+// metric must use the same label names.
+//
+// Samples recorded with HistogramContext while a sampled trace is active carry
+// its trace_id and span_id as an exemplar, exposed in the OpenMetrics format.
+//
+// Example usage:
+//
+//	rec := prommetrics.New(prommetrics.Config{
+//		Registerer: prometheus.DefaultRegisterer,
+//		Buckets:    prometheus.DefBuckets,
+//	})
+//
+//	metrics.ObserveCall(rec, "UserStorage", "GetByID", time.Since(start), err)
+package prommetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/komandakycto/decogen/pkg/decorators/metrics"
+)
+
+// Config holds the configuration of the Prometheus recorder
+type Config struct {
+	// Registerer registers the created collectors
+	// Defaults to prometheus.DefaultRegisterer
+	Registerer prometheus.Registerer
+
+	// Namespace is an optional prefix added to every metric name
+	Namespace string
+
+	// Buckets are the histogram buckets
+	// Defaults to prometheus.DefBuckets
+	Buckets []float64
+
+	// OnError is an optional callback for measurements that could not be recorded,
+	// e.g. because of a label mismatch
+	OnError func(err error)
+}
+
+// Recorder records metrics to Prometheus collectors
+type Recorder struct {
+	config Config
+
+	mu         sync.RWMutex
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+// Ensure Recorder implements metrics.ExemplarRecorder
+var _ metrics.ExemplarRecorder = (*Recorder)(nil)
+
+// New creates a Prometheus recorder
+func New(config Config) *Recorder {
+	if config.Registerer == nil {
+		config.Registerer = prometheus.DefaultRegisterer
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = prometheus.DefBuckets
+	}
+
+	return &Recorder{
+		config:     config,
+		counters:   make(map[string]*prometheus.CounterVec),
+		gauges:     make(map[string]*prometheus.GaugeVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+// Counter adds delta to a counter
+func (r *Recorder) Counter(name string, labels metrics.Labels, delta float64) {
+	vec, err := getOrCreate(r, r.counters, name, labels, func(names []string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: r.config.Namespace,
+			Name:      name,
+			Help:      name,
+		}, names)
+	})
+	if err != nil {
+		r.fail(err)
+		return
+	}
+
+	counter, err := vec.GetMetricWith(prometheus.Labels(labels))
+	if err != nil {
+		r.fail(fmt.Errorf("counter %s: %w", name, err))
+		return
+	}
+	counter.Add(delta)
+}
+
+// Histogram records a value in a histogram
+func (r *Recorder) Histogram(name string, labels metrics.Labels, value float64) {
+	r.HistogramContext(context.Background(), name, labels, value)
+}
+
+// HistogramContext records a value in a histogram
+// The span of a sampled trace in ctx is attached as an exemplar
+func (r *Recorder) HistogramContext(ctx context.Context, name string, labels metrics.Labels, value float64) {
+	vec, err := getOrCreate(r, r.histograms, name, labels, func(names []string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: r.config.Namespace,
+			Name:      name,
+			Help:      name,
+			Buckets:   r.config.Buckets,
+		}, names)
+	})
+	if err != nil {
+		r.fail(err)
+		return
+	}
+
+	histogram, err := vec.GetMetricWith(prometheus.Labels(labels))
+	if err != nil {
+		r.fail(fmt.Errorf("histogram %s: %w", name, err))
+		return
+	}
+	span := trace.SpanContextFromContext(ctx)
+	if observer, ok := histogram.(prometheus.ExemplarObserver); ok && span.IsSampled() {
+		observer.ObserveWithExemplar(value, prometheus.Labels{
+			"trace_id": span.TraceID().String(),
+			"span_id":  span.SpanID().String(),
+		})
+		return
+	}
+	histogram.Observe(value)
+}
+
+// Gauge sets the value of a gauge
+func (r *Recorder) Gauge(name string, labels metrics.Labels, value float64) {
+	vec, err := getOrCreate(r, r.gauges, name, labels, func(names []string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: r.config.Namespace,
+			Name:      name,
+			Help:      name,
+		}, names)
+	})
+	if err != nil {
+		r.fail(err)
+		return
+	}
+
+	gauge, err := vec.GetMetricWith(prometheus.Labels(labels))
+	if err != nil {
+		r.fail(fmt.Errorf("gauge %s: %w", name, err))
+		return
+	}
+	gauge.Set(value)
+}
+
+// fail reports an error to the configured callback
+func (r *Recorder) fail(err error) {
+	if r.config.OnError != nil {
+		r.config.OnError(err)
+	}
+}
+
+// getOrCreate returns the collector for a metric name, creating and registering it on first use
+// A collector already registered by another recorder with the same name is reused
+func getOrCreate[C prometheus.Collector](r *Recorder, collectors map[string]C, name string, labels metrics.Labels, create func(names []string) C) (C, error) {
+	r.mu.RLock()
+	c, ok := collectors[name]
+	r.mu.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := collectors[name]; ok {
+		return c, nil
+	}
+
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	c = create(names)
+	if err := r.config.Registerer.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return c, fmt.Errorf("failed to register %s: %w", name, err)
+		}
+		existing, ok := are.ExistingCollector.(C)
+		if !ok {
+			return c, fmt.Errorf("failed to register %s: registered with a different type", name)
+		}
+		c = existing
+	}
+
+	collectors[name] = c
+	return c, nil
+}
@@ -0,0 +1,102 @@
+package prommetrics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/komandakycto/decogen/pkg/decorators/metrics"
+	"github.com/komandakycto/decogen/pkg/decorators/metrics/prommetrics"
+)
+
+// TestRecorder tests recording through Prometheus collectors
+func TestRecorder(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	var errs []error
+	rec := prommetrics.New(prommetrics.Config{
+		Registerer: reg,
+		Namespace:  "test",
+		OnError:    func(err error) { errs = append(errs, err) },
+	})
+
+	metrics.ObserveCall(rec, "UserStorage", "GetByID", 10*time.Millisecond, nil)
+	metrics.ObserveCall(rec, "UserStorage", "GetByID", 20*time.Millisecond, errors.New("boom"))
+	rec.Gauge("queue_size", metrics.Labels{"queue": "jobs"}, 7)
+
+	count, err := testutil.GatherAndCount(reg, "test_"+metrics.CallsTotal)
+	require.NoError(t, err)
+	require.Equal(t, 2, count, "Success and error should be separate series")
+
+	count, err = testutil.GatherAndCount(reg, "test_"+metrics.CallDuration)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "test_queue_size" {
+			require.Equal(t, 7.0, family.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+
+	t.Run("label mismatch is reported", func(t *testing.T) {
+		rec.Gauge("queue_size", metrics.Labels{"other": "x"}, 1)
+		require.Len(t, errs, 1)
+	})
+
+	t.Run("collectors are shared between recorders", func(t *testing.T) {
+		other := prommetrics.New(prommetrics.Config{Registerer: reg, Namespace: "test"})
+		other.Gauge("queue_size", metrics.Labels{"queue": "jobs"}, 9)
+		require.Len(t, errs, 1)
+	})
+}
+
+// TestExemplars tests attaching the active trace to histogram samples
+func TestExemplars(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	rec := prommetrics.New(prommetrics.Config{Registerer: reg})
+
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02},
+		SpanID:     trace.SpanID{0x03},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), span)
+	metrics.ObserveCallContext(ctx, rec, "UserStorage", "GetByID", 2*time.Millisecond, nil)
+	metrics.ObserveCall(rec, "UserStorage", "Save", 2*time.Millisecond, nil)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	exemplars := map[string]map[string]string{}
+	for _, family := range families {
+		if family.GetName() != metrics.CallDuration {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			method := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "method" {
+					method = label.GetValue()
+				}
+			}
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				if exemplar := bucket.GetExemplar(); exemplar != nil {
+					exemplars[method] = map[string]string{}
+					for _, label := range exemplar.GetLabel() {
+						exemplars[method][label.GetName()] = label.GetValue()
+					}
+				}
+			}
+		}
+	}
+
+	require.Equal(t, map[string]map[string]string{
+		"GetByID": {"trace_id": span.TraceID().String(), "span_id": span.SpanID().String()},
+	}, exemplars, "Only samples recorded within a sampled trace should carry an exemplar")
+}
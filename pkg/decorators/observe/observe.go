@@ -0,0 +1,221 @@
+// Package observe provides the runtime used by generated observability decorators.
+//
+// An Observer reports every call to metrics, tracing and logging at once, with a
+// single naming scheme: the span is named Interface.Method like the log message,
+// and metrics, span attributes and log attributes share the interface and method
+// labels. A generated decorator wraps each method with one Start and one End,
+// instead of nesting three decorators that each name the method their own way.
+//
+// Example usage:
+//
+//	observer := observe.New(observe.Config{
+//		Recorder: prommetrics.New(prommetrics.Config{Namespace: "users"}),
+//		Tracer:   otel.Tracer("github.com/acme/users"),
+//		Logger:   slog.Default(),
+//	})
+//
+//	ctx, call := observer.Start(ctx, "UserStorage", "GetByID", req)
+//	user, err := storage.GetByID(ctx, req)
+//	call.End(err)
+package observe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/komandakycto/decogen/pkg/decorators/metrics"
+	"github.com/komandakycto/decogen/pkg/decorators/redact"
+	"github.com/komandakycto/decogen/pkg/decorators/tracing"
+)
+
+// Log attribute keys set on every call logged by this package
+const (
+	// InterfaceKey holds the name of the decorated interface
+	InterfaceKey = "interface"
+	// MethodKey holds the name of the decorated method
+	MethodKey = "method"
+	// DurationKey holds the duration of the call
+	DurationKey = "duration"
+	// ErrorKey holds the error returned by the call
+	ErrorKey = "error"
+	// ArgsKey holds the redacted arguments of the call, see Config.LogArgs
+	ArgsKey = "args"
+)
+
+// Config holds the backends calls are reported to
+// A nil backend is skipped, so any combination of the three can be used
+type Config struct {
+	// Recorder receives the metrics.CallsTotal and metrics.CallDuration measurements
+	// Durations carry the span of the call as an exemplar when it is a metrics.ExemplarRecorder
+	Recorder metrics.Recorder
+
+	// Tracer starts a client span for every call
+	Tracer trace.Tracer
+
+	// Logger logs every call
+	Logger *slog.Logger
+
+	// LogArgs adds the arguments of every call to its log record, redacted with redact.Values
+	LogArgs bool
+
+	// SuccessLevel is the level of the log records of successful calls
+	// Defaults to slog.LevelDebug; failed calls are logged at slog.LevelError,
+	// or slog.LevelWarn when the context was canceled
+	SuccessLevel *slog.Level
+
+	// Sampling thins out the logs and traces of every method
+	Sampling Sampling
+
+	// Methods overrides Sampling for the methods named Interface.Method
+	Methods map[string]Sampling
+
+	// Now returns the current time
+	// If not provided, time.Now is used
+	Now func() time.Time
+}
+
+// Observer reports calls to the configured backends
+type Observer struct {
+	config       Config
+	successLevel slog.Level
+	sampler      *sampler
+}
+
+// New creates an observer with the given configuration
+func New(config Config) *Observer {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	successLevel := slog.LevelDebug
+	if config.SuccessLevel != nil {
+		successLevel = *config.SuccessLevel
+	}
+
+	return &Observer{
+		config:       config,
+		successLevel: successLevel,
+		sampler:      &sampler{sampling: config.Sampling, methods: config.Methods},
+	}
+}
+
+// WithMethodSampling returns an observer sampling the given methods, named Interface.Method, as specified
+// Generated decorators use it for the sampling annotated on methods; Config.Methods takes precedence
+func (o *Observer) WithMethodSampling(methods map[string]Sampling) *Observer {
+	merged := make(map[string]Sampling, len(methods)+len(o.config.Methods))
+	for name, sampling := range methods {
+		merged[name] = sampling
+	}
+	for name, sampling := range o.config.Methods {
+		merged[name] = sampling
+	}
+
+	c := *o
+	c.sampler = &sampler{sampling: o.config.Sampling, methods: merged}
+	return &c
+}
+
+// Describe summarizes the backends of the observer and its sampling, for decorators.Info
+func (o *Observer) Describe() string {
+	var backends []string
+	if o.config.Recorder != nil {
+		backends = append(backends, "metrics")
+	}
+	if o.config.Tracer != nil {
+		backends = append(backends, "tracing")
+	}
+	if o.config.Logger != nil {
+		backends = append(backends, "logging")
+	}
+	if len(backends) == 0 {
+		backends = append(backends, "none")
+	}
+
+	summary := "backends=" + strings.Join(backends, ",")
+	if n := o.config.Sampling.LogSuccesses; n > 1 {
+		summary += fmt.Sprintf(" log_successes=1/%d", n)
+	}
+	if ratio := o.config.Sampling.TraceRatio; ratio != nil {
+		summary += fmt.Sprintf(" trace_ratio=%g", *ratio)
+	}
+	if n := len(o.sampler.methods); n > 0 {
+		summary += fmt.Sprintf(" sampled_methods=%d", n)
+	}
+	return summary
+}
+
+// Call is a call being observed, returned by Start
+type Call struct {
+	observer *Observer
+	ctx      context.Context
+	iface    string
+	method   string
+	args     []any
+	start    time.Time
+	span     trace.Span
+}
+
+// Start starts observing a method call
+// The returned context carries the span of the call and must be passed to the call
+// Arguments implementing tracing.Attributer contribute span attributes
+func (o *Observer) Start(ctx context.Context, iface, method string, args ...any) (context.Context, Call) {
+	call := Call{observer: o, iface: iface, method: method}
+	if o.config.LogArgs {
+		call.args = args
+	}
+	if o.config.Tracer != nil && o.sampler.trace(ctx, tracing.SpanName(iface, method)) {
+		ctx, call.span = tracing.Start(ctx, o.config.Tracer, iface, method, args...)
+	}
+	call.ctx = ctx
+	call.start = o.config.Now()
+	return ctx, call
+}
+
+// End reports the outcome of the call to every configured backend
+func (c Call) End(err error) {
+	config := c.observer.config
+	duration := config.Now().Sub(c.start)
+
+	if config.Recorder != nil {
+		metrics.ObserveCallContext(c.ctx, config.Recorder, c.iface, c.method, duration, err)
+	}
+	if c.span != nil {
+		tracing.End(c.span, err)
+	}
+	if config.Logger != nil && (err != nil || c.observer.sampler.logSuccess(tracing.SpanName(c.iface, c.method))) {
+		c.log(config.Logger, duration, err)
+	}
+}
+
+// log writes the log record of the call
+func (c Call) log(logger *slog.Logger, duration time.Duration, err error) {
+	level := c.observer.successLevel
+	switch {
+	case errors.Is(err, context.Canceled):
+		level = slog.LevelWarn
+	case err != nil:
+		level = slog.LevelError
+	}
+	if !logger.Enabled(c.ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String(InterfaceKey, c.iface),
+		slog.String(MethodKey, c.method),
+		slog.Duration(DurationKey, duration),
+	}
+	if c.observer.config.LogArgs {
+		attrs = append(attrs, slog.Any(ArgsKey, redact.Values(c.args...)))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any(ErrorKey, err))
+	}
+	logger.LogAttrs(c.ctx, level, tracing.SpanName(c.iface, c.method), attrs...)
+}
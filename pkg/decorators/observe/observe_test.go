@@ -0,0 +1,166 @@
+package observe_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/komandakycto/decogen/pkg/decorators/metrics"
+	"github.com/komandakycto/decogen/pkg/decorators/observe"
+	"github.com/komandakycto/decogen/pkg/decorators/redact"
+	"github.com/komandakycto/decogen/pkg/decorators/tracing"
+)
+
+// TestObserver tests reporting calls to every backend with the same names
+func TestObserver(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	recorder := metrics.NewMemory()
+	var logs bytes.Buffer
+
+	now := time.Unix(0, 0)
+	observer := observe.New(observe.Config{
+		Recorder: recorder,
+		Tracer:   provider.Tracer("test"),
+		Logger:   slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Now: func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		},
+	})
+
+	ctx, call := observer.Start(context.Background(), "UserStorage", "Get")
+	require.True(t, ctx != context.Background(), "The context should carry the span")
+	call.End(nil)
+
+	_, call = observer.Start(context.Background(), "UserStorage", "Save")
+	call.End(errors.New("disk full"))
+
+	labels := metrics.Labels{"interface": "UserStorage", "method": "Get", "result": "success"}
+	require.Equal(t, 1.0, recorder.CounterValue(metrics.CallsTotal, labels))
+	require.Equal(t, []float64{1}, recorder.HistogramValues(metrics.CallDuration, metrics.Labels{"interface": "UserStorage", "method": "Get"}))
+	require.Equal(t, 1.0, recorder.CounterValue(metrics.CallsTotal, metrics.Labels{"interface": "UserStorage", "method": "Save", "result": "error"}))
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	require.Equal(t, tracing.SpanName("UserStorage", "Get"), ended[0].Name())
+	require.Equal(t, codes.Error, ended[1].Status().Code)
+
+	require.Contains(t, logs.String(), "level=DEBUG msg=UserStorage.Get interface=UserStorage method=Get duration=1s")
+	require.Contains(t, logs.String(), `level=ERROR msg=UserStorage.Save interface=UserStorage method=Save duration=1s error="disk full"`)
+}
+
+// TestObserverWithoutBackends tests that unset backends are skipped
+func TestObserverWithoutBackends(t *testing.T) {
+	var logs bytes.Buffer
+	level := slog.LevelInfo
+	observer := observe.New(observe.Config{
+		Logger:       slog.New(slog.NewTextHandler(&logs, nil)),
+		SuccessLevel: &level,
+	})
+
+	ctx, call := observer.Start(context.Background(), "UserStorage", "Get")
+	require.Equal(t, context.Background(), ctx)
+	call.End(nil)
+
+	_, call = observer.Start(context.Background(), "UserStorage", "Get")
+	call.End(context.Canceled)
+
+	require.Contains(t, logs.String(), "level=INFO msg=UserStorage.Get")
+	require.Contains(t, logs.String(), "level=WARN msg=UserStorage.Get")
+	require.Equal(t, "backends=logging", observer.Describe())
+}
+
+// TestLogArgs tests logging the redacted arguments of calls
+func TestLogArgs(t *testing.T) {
+	type credentials struct {
+		User     string
+		Password string `decogen:"redact"`
+	}
+
+	var logs bytes.Buffer
+	observer := observe.New(observe.Config{
+		Logger:  slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		LogArgs: true,
+	})
+
+	_, call := observer.Start(context.Background(), "Auth", "Login", credentials{User: "ann", Password: "hunter2"}, redact.Placeholder)
+	call.End(nil)
+
+	require.Contains(t, logs.String(), `args="[{User:ann Password:[REDACTED]} [REDACTED]]"`)
+	require.NotContains(t, logs.String(), "hunter2")
+}
+
+// TestSampling tests thinning out logs and traces while recording metrics for every call
+func TestSampling(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	recorder := metrics.NewMemory()
+	var logs bytes.Buffer
+
+	observer := observe.New(observe.Config{
+		Recorder: recorder,
+		Tracer:   provider.Tracer("test"),
+		Logger:   slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Sampling: observe.Sampling{LogSuccesses: 3, TraceRatio: observe.Ratio(0)},
+		Methods: map[string]observe.Sampling{
+			"UserStorage.Save": {TraceRatio: observe.Ratio(1)},
+		},
+	})
+
+	for range 6 {
+		_, call := observer.Start(context.Background(), "UserStorage", "Get")
+		call.End(nil)
+	}
+	_, call := observer.Start(context.Background(), "UserStorage", "Get")
+	call.End(errors.New("disk full"))
+	_, call = observer.Start(context.Background(), "UserStorage", "Save")
+	call.End(nil)
+
+	require.Equal(t, 6.0, recorder.CounterValue(metrics.CallsTotal, metrics.Labels{"interface": "UserStorage", "method": "Get", "result": "success"}))
+	require.Equal(t, 2, bytes.Count(logs.Bytes(), []byte("level=DEBUG msg=UserStorage.Get")), "One in three successes should be logged")
+	require.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("level=ERROR msg=UserStorage.Get")), "Failures should always be logged")
+	require.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("msg=UserStorage.Save")), "Method sampling should replace the default")
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1, "Only the method with a trace ratio of one should be traced")
+	require.Equal(t, "UserStorage.Save", ended[0].Name())
+
+	t.Run("parent sampling decision is respected", func(t *testing.T) {
+		parent, span := provider.Tracer("test").Start(context.Background(), "parent")
+		_, call := observer.Start(parent, "UserStorage", "Get")
+		call.End(nil)
+		span.End()
+		require.Len(t, spans.Ended(), 3, "A call within a sampled trace should be traced despite its ratio")
+	})
+
+	t.Run("annotated method sampling", func(t *testing.T) {
+		spans := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+		observer := observe.New(observe.Config{
+			Tracer:  provider.Tracer("test"),
+			Methods: map[string]observe.Sampling{"UserStorage.Save": {}},
+		}).WithMethodSampling(map[string]observe.Sampling{
+			"UserStorage.Get":  {TraceRatio: observe.Ratio(0)},
+			"UserStorage.Save": {TraceRatio: observe.Ratio(0)},
+		})
+
+		for _, method := range []string{"Get", "Save", "Search"} {
+			_, call := observer.Start(context.Background(), "UserStorage", method)
+			call.End(nil)
+		}
+		var names []string
+		for _, span := range spans.Ended() {
+			names = append(names, span.Name())
+		}
+		require.Equal(t, []string{"UserStorage.Save", "UserStorage.Search"}, names, "Config.Methods should take precedence over annotations")
+	})
+}
@@ -0,0 +1,64 @@
+package observe
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/komandakycto/decogen/pkg/decorators/tracing"
+)
+
+// Sampling thins out the logs and traces of high-QPS methods
+// Metrics are recorded for every call regardless of sampling
+type Sampling struct {
+	// LogSuccesses logs one in LogSuccesses successful calls; failed calls are always logged
+	// Zero or one logs every call
+	LogSuccesses uint
+
+	// TraceRatio is the fraction of calls without a parent span that start a trace, see tracing.Sampled
+	// Calls with a parent span follow the sampling decision of the parent; nil traces every call
+	TraceRatio *float64
+}
+
+// Ratio returns a pointer to a trace ratio, for use in Sampling literals
+func Ratio(ratio float64) *float64 {
+	return &ratio
+}
+
+// sampler applies the sampling of each method
+type sampler struct {
+	sampling Sampling
+	methods  map[string]Sampling // keyed by tracing.SpanName
+	counters sync.Map            // span name to *atomic.Uint64 counting successful calls
+}
+
+// forMethod returns the sampling of a method
+func (s *sampler) forMethod(name string) Sampling {
+	if sampling, ok := s.methods[name]; ok {
+		return sampling
+	}
+	return s.sampling
+}
+
+// trace reports whether a call starts a span
+func (s *sampler) trace(ctx context.Context, name string) bool {
+	ratio := s.forMethod(name).TraceRatio
+	if ratio == nil {
+		return true
+	}
+	return tracing.Sampled(ctx, *ratio)
+}
+
+// logSuccess reports whether a successful call is logged
+func (s *sampler) logSuccess(name string) bool {
+	n := s.forMethod(name).LogSuccesses
+	if n <= 1 {
+		return true
+	}
+
+	counter, ok := s.counters.Load(name)
+	if !ok {
+		counter, _ = s.counters.LoadOrStore(name, new(atomic.Uint64))
+	}
+	return counter.(*atomic.Uint64).Add(1)%uint64(n) == 1
+}
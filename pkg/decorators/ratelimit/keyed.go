@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// keyContextKey is the context key for the rate limit key
+type keyContextKey struct{}
+
+// WithKey returns a context carrying the key used by Keyed limiters, e.g. a tenant ID
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFromContext returns the key stored with WithKey, or an empty string
+func KeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(keyContextKey{}).(string)
+	return key
+}
+
+// KeyedConfig holds configuration for a keyed limiter
+type KeyedConfig struct {
+	// New creates the limiter for a key seen for the first time
+	New func(key string) Limiter
+
+	// Key extracts the key from the context
+	// If not provided, KeyFromContext is used
+	Key func(ctx context.Context) string
+
+	// MaxKeys bounds the number of limiters
+	// The least recently used limiter is dropped when the set is full; zero means no limit
+	MaxKeys int
+}
+
+// Keyed limits every key separately using one limiter per key
+// It is safe for concurrent use
+type Keyed struct {
+	config   KeyedConfig
+	limiters map[string]*list.Element
+	order    *list.List // front is the most recently used limiter
+	mu       sync.Mutex // protects limiters and order
+}
+
+// keyedEntry is a limiter with its key
+type keyedEntry struct {
+	key     string
+	limiter Limiter
+}
+
+// NewKeyed creates a limiter that keeps one limiter per key
+func NewKeyed(config KeyedConfig) *Keyed {
+	if config.Key == nil {
+		config.Key = KeyFromContext
+	}
+
+	return &Keyed{
+		config:   config,
+		limiters: make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// For returns the limiter for a key, creating it on first use
+func (k *Keyed) For(key string) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if elem, ok := k.limiters[key]; ok {
+		k.order.MoveToFront(elem)
+		return elem.Value.(*keyedEntry).limiter
+	}
+
+	l := k.config.New(key)
+	k.limiters[key] = k.order.PushFront(&keyedEntry{key: key, limiter: l})
+
+	// Drop the least recently used limiters if the set is over capacity
+	for k.config.MaxKeys > 0 && k.order.Len() > k.config.MaxKeys {
+		entry := k.order.Remove(k.order.Back()).(*keyedEntry)
+		delete(k.limiters, entry.key)
+	}
+	return l
+}
+
+// Allow reports whether a call for the key in the context may happen now
+func (k *Keyed) Allow(ctx context.Context) bool {
+	return k.For(k.config.Key(ctx)).Allow()
+}
+
+// Wait blocks until a call for the key in the context may happen or the context is done
+func (k *Keyed) Wait(ctx context.Context) error {
+	return k.For(k.config.Key(ctx)).Wait(ctx)
+}
+
+// Len returns the number of keys with a limiter
+func (k *Keyed) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.order.Len()
+}
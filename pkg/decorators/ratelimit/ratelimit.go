@@ -0,0 +1,139 @@
+// Package ratelimit provides the runtime used by generated rate limit decorators.
+//
+// Two algorithms are available: a token bucket, which allows bursts up to a
+// configured size and refills at a steady rate, and a sliding window, which
+// allows at most a fixed number of calls in any window of time. Both support
+// a non-blocking Allow and a blocking, context-aware Wait.
+//
+// Keyed wraps a limiter factory to limit each key (e.g. tenant) separately,
+// taking the key from the context.
+//
+// Example usage:
+//
+//	limiter := ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{
+//		Rate:  100, // calls per second
+//		Burst: 20,
+//	})
+//
+//	if err := limiter.Wait(ctx); err != nil {
+//		return err
+//	}
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLimitExceeded is returned when a call is rejected by the limiter
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+// Limiter defines the interface for rate limiters
+type Limiter interface {
+	// Allow reports whether a call may happen now, consuming a slot if it may
+	Allow() bool
+
+	// Wait blocks until a call may happen or the context is done
+	Wait(ctx context.Context) error
+}
+
+// Hooks are optional callbacks reporting limiter activity, typically to metrics
+type Hooks struct {
+	// OnAllow is called when a call is allowed
+	OnAllow func()
+
+	// OnReject is called when Allow rejects a call
+	OnReject func()
+
+	// OnWait is called when Wait had to block, with the time spent waiting
+	OnWait func(waited time.Duration)
+}
+
+// allow reports an allowed call
+func (h Hooks) allow() {
+	if h.OnAllow != nil {
+		h.OnAllow()
+	}
+}
+
+// reject reports a rejected call
+func (h Hooks) reject() {
+	if h.OnReject != nil {
+		h.OnReject()
+	}
+}
+
+// wait reports time spent waiting
+func (h Hooks) wait(waited time.Duration) {
+	if h.OnWait != nil && waited > 0 {
+		h.OnWait(waited)
+	}
+}
+
+// reserver is implemented by the limiters to share the Allow and Wait logic
+type reserver interface {
+	// reserve consumes a slot if one is available, otherwise it returns how long to wait for one
+	reserve(now time.Time) (ok bool, wait time.Duration)
+}
+
+// allow implements Limiter.Allow on top of a reserver
+func allow(r reserver, now func() time.Time, hooks Hooks) bool {
+	ok, _ := r.reserve(now())
+	if ok {
+		hooks.allow()
+	} else {
+		hooks.reject()
+	}
+	return ok
+}
+
+// wait implements Limiter.Wait on top of a reserver
+func wait(ctx context.Context, r reserver, now func() time.Time, hooks Hooks) error {
+	start := now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ok, d := r.reserve(now())
+		if ok {
+			hooks.wait(now().Sub(start))
+			hooks.allow()
+			return nil
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Check returns ErrLimitExceeded if the limiter does not allow a call now
+func Check(l Limiter) error {
+	if !l.Allow() {
+		return ErrLimitExceeded
+	}
+	return nil
+}
+
+// Execute waits for the limiter and runs the operation
+func Execute(ctx context.Context, l Limiter, op func(context.Context) error) error {
+	if err := l.Wait(ctx); err != nil {
+		return err
+	}
+	return op(ctx)
+}
+
+// ExecuteWithValue waits for the limiter and runs the operation returning a value
+func ExecuteWithValue[T any](ctx context.Context, l Limiter, op func(context.Context) (T, error)) (T, error) {
+	if err := l.Wait(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+	return op(ctx)
+}
@@ -0,0 +1,198 @@
+package ratelimit_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/ratelimit"
+)
+
+// fakeClock is a manually advanced time source
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// TestTokenBucket tests the token bucket limiter
+func TestTokenBucket(t *testing.T) {
+	t.Run("burst and refill", func(t *testing.T) {
+		clock := newFakeClock()
+		allowed, rejected := 0, 0
+		l := ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{
+			Rate:  2,
+			Burst: 3,
+			Now:   clock.Now,
+			Hooks: ratelimit.Hooks{
+				OnAllow:  func() { allowed++ },
+				OnReject: func() { rejected++ },
+			},
+		})
+
+		require.True(t, l.Allow())
+		require.True(t, l.Allow())
+		require.True(t, l.Allow())
+		require.False(t, l.Allow(), "Bucket should be empty after the burst")
+		require.ErrorIs(t, ratelimit.Check(l), ratelimit.ErrLimitExceeded)
+
+		clock.Advance(500 * time.Millisecond)
+		require.True(t, l.Allow(), "One token should be refilled after 500ms at 2/s")
+		require.False(t, l.Allow())
+
+		clock.Advance(time.Hour)
+		require.InDelta(t, 3.0, l.Tokens(), 0.001, "Refill should be capped at the burst size")
+
+		require.Equal(t, 4, allowed)
+		require.Equal(t, 3, rejected)
+	})
+
+	t.Run("wait blocks until a token is available", func(t *testing.T) {
+		var waited time.Duration
+		l := ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{
+			Rate:  50,
+			Burst: 1,
+			Hooks: ratelimit.Hooks{OnWait: func(d time.Duration) { waited = d }},
+		})
+
+		require.NoError(t, l.Wait(context.Background()))
+		start := time.Now()
+		require.NoError(t, l.Wait(context.Background()))
+		require.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+		require.Greater(t, waited, time.Duration(0))
+	})
+
+	t.Run("wait respects the context", func(t *testing.T) {
+		l := ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{Rate: 0.001, Burst: 1})
+		require.True(t, l.Allow())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+	})
+}
+
+// TestSlidingWindow tests the sliding window limiter
+func TestSlidingWindow(t *testing.T) {
+	t.Run("limits calls in any window", func(t *testing.T) {
+		clock := newFakeClock()
+		l := ratelimit.NewSlidingWindow(ratelimit.SlidingWindowConfig{
+			Limit:  2,
+			Window: time.Second,
+			Now:    clock.Now,
+		})
+
+		require.True(t, l.Allow())
+		clock.Advance(600 * time.Millisecond)
+		require.True(t, l.Allow())
+		require.False(t, l.Allow())
+
+		clock.Advance(400 * time.Millisecond)
+		require.True(t, l.Allow(), "First call should have left the window")
+		require.False(t, l.Allow())
+
+		clock.Advance(600 * time.Millisecond)
+		require.True(t, l.Allow(), "Second call should have left the window")
+	})
+
+	t.Run("wait blocks until the window slides", func(t *testing.T) {
+		l := ratelimit.NewSlidingWindow(ratelimit.SlidingWindowConfig{
+			Limit:  1,
+			Window: 20 * time.Millisecond,
+		})
+
+		require.NoError(t, l.Wait(context.Background()))
+		start := time.Now()
+		require.NoError(t, l.Wait(context.Background()))
+		require.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+	})
+}
+
+// TestKeyed tests per-key limiting
+func TestKeyed(t *testing.T) {
+	k := ratelimit.NewKeyed(ratelimit.KeyedConfig{
+		New: func(key string) ratelimit.Limiter {
+			return ratelimit.NewSlidingWindow(ratelimit.SlidingWindowConfig{Limit: 1, Window: time.Hour})
+		},
+	})
+
+	tenantA := ratelimit.WithKey(context.Background(), "tenant-a")
+	tenantB := ratelimit.WithKey(context.Background(), "tenant-b")
+
+	require.Equal(t, "tenant-a", ratelimit.KeyFromContext(tenantA))
+	require.Equal(t, "", ratelimit.KeyFromContext(context.Background()))
+
+	require.True(t, k.Allow(tenantA))
+	require.False(t, k.Allow(tenantA), "Tenant A should be limited")
+	require.True(t, k.Allow(tenantB), "Tenant B should have its own limit")
+	require.Equal(t, 2, k.Len())
+
+	ctx, cancel := context.WithTimeout(tenantA, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, k.Wait(ctx), context.DeadlineExceeded)
+
+	t.Run("max keys", func(t *testing.T) {
+		k := ratelimit.NewKeyed(ratelimit.KeyedConfig{
+			New: func(key string) ratelimit.Limiter {
+				return ratelimit.NewSlidingWindow(ratelimit.SlidingWindowConfig{Limit: 1, Window: time.Hour})
+			},
+			MaxKeys: 2,
+		})
+
+		a := k.For("a")
+		require.True(t, a.Allow())
+		k.For("b")
+		require.Same(t, a, k.For("a"), "Limiters in use should be kept")
+
+		// The least recently used limiter makes room for a new key
+		k.For("c")
+		require.Equal(t, 2, k.Len())
+		require.Same(t, a, k.For("a"))
+		require.False(t, k.For("a").Allow(), "Tenant A should still be limited")
+
+		for i := range 100 {
+			k.For(fmt.Sprintf("tenant-%d", i))
+		}
+		require.Equal(t, 2, k.Len())
+	})
+}
+
+// TestExecute tests the execution helpers
+func TestExecute(t *testing.T) {
+	l := ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{Rate: 1, Burst: 1})
+
+	value, err := ratelimit.ExecuteWithValue(context.Background(), l, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err = ratelimit.Execute(ctx, l, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, called)
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ensure SlidingWindow implements Limiter
+var _ Limiter = (*SlidingWindow)(nil)
+
+// SlidingWindowConfig holds configuration for a sliding window limiter
+type SlidingWindowConfig struct {
+	// Limit is the maximum number of calls in any window
+	// If not provided, 1 is used
+	Limit int
+
+	// Window is the duration of the window
+	// If not provided, 1 second is used
+	Window time.Duration
+
+	// Hooks optionally report limiter activity
+	Hooks Hooks
+
+	// Now returns the current time
+	// If not provided, time.Now is used
+	Now func() time.Time
+}
+
+// SlidingWindow implements an exact sliding window rate limiter
+// It remembers the time of the last Limit calls, so memory grows with the limit
+// It is safe for concurrent use
+type SlidingWindow struct {
+	config SlidingWindowConfig
+	calls  []time.Time // ring buffer of call times
+	next   int         // index of the oldest call once the buffer is full
+	mu     sync.Mutex  // protects calls and next
+}
+
+// NewSlidingWindow creates a sliding window limiter
+func NewSlidingWindow(config SlidingWindowConfig) *SlidingWindow {
+	if config.Limit <= 0 {
+		config.Limit = 1
+	}
+	if config.Window <= 0 {
+		config.Window = time.Second
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &SlidingWindow{
+		config: config,
+		calls:  make([]time.Time, 0, config.Limit),
+	}
+}
+
+// Allow reports whether a call may happen now
+func (w *SlidingWindow) Allow() bool {
+	return allow(w, w.config.Now, w.config.Hooks)
+}
+
+// Wait blocks until a call may happen or the context is done
+func (w *SlidingWindow) Wait(ctx context.Context) error {
+	return wait(ctx, w, w.config.Now, w.config.Hooks)
+}
+
+// reserve implements reserver
+func (w *SlidingWindow) reserve(now time.Time) (bool, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// The buffer is not full yet, the call is allowed
+	if len(w.calls) < w.config.Limit {
+		w.calls = append(w.calls, now)
+		return true, 0
+	}
+
+	// The oldest of the last Limit calls must have left the window
+	oldest := w.calls[w.next]
+	if freeAt := oldest.Add(w.config.Window); now.Before(freeAt) {
+		return false, freeAt.Sub(now)
+	}
+
+	w.calls[w.next] = now
+	w.next = (w.next + 1) % w.config.Limit
+	return true, 0
+}
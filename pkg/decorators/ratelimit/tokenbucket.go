@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ensure TokenBucket implements Limiter
+var _ Limiter = (*TokenBucket)(nil)
+
+// TokenBucketConfig holds configuration for a token bucket limiter
+type TokenBucketConfig struct {
+	// Rate is the number of tokens added per second
+	Rate float64
+
+	// Burst is the bucket size, i.e. the number of calls allowed at once
+	// If not provided, 1 is used
+	Burst int
+
+	// Hooks optionally report limiter activity
+	Hooks Hooks
+
+	// Now returns the current time
+	// If not provided, time.Now is used
+	Now func() time.Time
+}
+
+// TokenBucket implements a token bucket rate limiter
+// It is safe for concurrent use
+type TokenBucket struct {
+	config TokenBucketConfig
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex // protects tokens and last
+}
+
+// NewTokenBucket creates a token bucket limiter that starts full
+func NewTokenBucket(config TokenBucketConfig) *TokenBucket {
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &TokenBucket{
+		config: config,
+		tokens: float64(config.Burst),
+		last:   config.Now(),
+	}
+}
+
+// Allow reports whether a call may happen now
+func (b *TokenBucket) Allow() bool {
+	return allow(b, b.config.Now, b.config.Hooks)
+}
+
+// Wait blocks until a call may happen or the context is done
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return wait(ctx, b, b.config.Now, b.config.Hooks)
+}
+
+// Tokens returns the number of tokens currently available
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(b.config.Now())
+	return b.tokens
+}
+
+// reserve implements reserver
+func (b *TokenBucket) reserve(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if b.config.Rate <= 0 {
+		// The bucket never refills, wait for a long time
+		return false, time.Hour
+	}
+
+	missing := 1 - b.tokens
+	return false, time.Duration(missing / b.config.Rate * float64(time.Second))
+}
+
+// refill adds the tokens accumulated since the last refill
+func (b *TokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+
+	b.tokens += elapsed.Seconds() * b.config.Rate
+	if burst := float64(b.config.Burst); b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+}
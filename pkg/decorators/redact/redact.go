@@ -0,0 +1,188 @@
+// Package redact hides sensitive arguments from logging and audit output.
+//
+// A value is redacted in one of two ways: its type implements Redactor and
+// returns a copy safe for output, or its struct fields are tagged
+// `decogen:"redact"`. Tagged string fields are replaced by Placeholder and
+// other tagged fields by their zero value. Untagged struct and pointer to
+// struct fields are redacted recursively; slices, maps and interfaces are
+// output as is.
+//
+// Generated observability decorators pass arguments annotated with
+// "//decogen:observability redact=<param>,..." as Placeholder, and log the
+// others through Values when observe.Config.LogArgs is set.
+//
+// Example usage:
+//
+//	type Credentials struct {
+//		User     string
+//		Password string `decogen:"redact"`
+//	}
+//
+//	logger.Info("login", "credentials", redact.Value(credentials))
+package redact
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// Placeholder replaces redacted strings and arguments
+const Placeholder = "[REDACTED]"
+
+// TagName is the struct tag marking fields to redact with the "redact" option, e.g. `decogen:"redact"`
+const TagName = "decogen"
+
+// maxDepth bounds the recursion into nested structs, so cyclic pointers terminate
+// Deeper values needing redaction are cleared
+const maxDepth = 8
+
+// Redactor is implemented by types that hide their sensitive parts themselves
+type Redactor interface {
+	// Redact returns a copy of the value safe for logging and audit output
+	Redact() any
+}
+
+// Value returns v ready for output
+// Redactors return their Redact result, structs with tagged fields a redacted copy, other values themselves
+func Value(v any) any {
+	if r, ok := v.(Redactor); ok {
+		return r.Redact()
+	}
+	if v == nil {
+		return nil
+	}
+
+	if redacted, ok := redactValue(reflect.ValueOf(v), 0); ok {
+		return redacted.Interface()
+	}
+	return v
+}
+
+// Values applies Value to every argument
+func Values(args ...any) []any {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = Value(arg)
+	}
+	return values
+}
+
+// redactValue returns a redacted copy of a struct or pointer to struct, and whether anything was redacted
+func redactValue(v reflect.Value, depth int) (reflect.Value, bool) {
+	if !needsRedaction(v.Type()) {
+		return v, false
+	}
+	if depth > maxDepth {
+		// Too deep to inspect, so cleared rather than output unredacted
+		return reflect.Zero(v.Type()), true
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return v, false
+		}
+		elem, ok := redactValue(v.Elem(), depth+1)
+		if !ok {
+			return v, false
+		}
+		p := reflect.New(elem.Type())
+		p.Elem().Set(elem)
+		return p, true
+	}
+
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	for i := range c.NumField() {
+		field := c.Type().Field(i)
+		value := settable(c.Field(i))
+
+		switch {
+		case tagged(field):
+			if field.Type.Kind() == reflect.String {
+				value.Set(reflect.ValueOf(Placeholder).Convert(field.Type))
+			} else {
+				value.Set(reflect.Zero(field.Type))
+			}
+		case field.Type.Implements(redactorType):
+			if value.Kind() == reflect.Pointer && value.IsNil() {
+				continue
+			}
+			// A Redactor whose result has another type cannot be stored in the field, so the field is cleared
+			redacted := reflect.ValueOf(value.Interface().(Redactor).Redact())
+			if redacted.IsValid() && redacted.Type() == field.Type {
+				value.Set(redacted)
+			} else {
+				value.Set(reflect.Zero(field.Type))
+			}
+		default:
+			if nested, ok := redactValue(value, depth+1); ok {
+				value.Set(nested)
+			}
+		}
+	}
+	return c, true
+}
+
+// redactorType is the type of the Redactor interface
+var redactorType = reflect.TypeFor[Redactor]()
+
+// redactedTypes caches whether values of a type need redaction
+var redactedTypes sync.Map
+
+// needsRedaction reports whether a struct or pointer to struct type has fields to redact,
+// tagged or implementing Redactor, directly or in nested structs
+func needsRedaction(t reflect.Type) bool {
+	if cached, ok := redactedTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	needs := scan(t, make(map[reflect.Type]bool))
+	redactedTypes.Store(t, needs)
+	return needs
+}
+
+// scan inspects the fields of a type, skipping the types being inspected so cyclic types terminate
+func scan(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return t.Elem().Kind() == reflect.Struct && scan(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if tagged(field) || field.Type.Implements(redactorType) || scan(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tagged reports whether a struct field is tagged for redaction
+func tagged(field reflect.StructField) bool {
+	tag, ok := field.Tag.Lookup(TagName)
+	if !ok {
+		return false
+	}
+	for _, option := range strings.Split(tag, ",") {
+		if strings.TrimSpace(option) == "redact" {
+			return true
+		}
+	}
+	return false
+}
+
+// settable returns a settable view of a field of an addressable struct, including unexported fields,
+// since loggers formatting values with fmt print unexported fields too
+func settable(v reflect.Value) reflect.Value {
+	if v.CanSet() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
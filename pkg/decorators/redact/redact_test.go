@@ -0,0 +1,68 @@
+package redact_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/redact"
+)
+
+type credentials struct {
+	User     string
+	Password string `decogen:"redact"`
+	PIN      int    `json:"pin" decogen:"redact"`
+	token    string `decogen:"redact"`
+}
+
+type login struct {
+	Credentials credentials
+	Previous    *credentials
+	Card        card
+	Attempts    int
+}
+
+// card hides its number itself
+type card struct {
+	Number string
+}
+
+func (c card) Redact() any {
+	return card{Number: "****" + c.Number[len(c.Number)-4:]}
+}
+
+type node struct {
+	Secret string `decogen:"redact"`
+	Next   *node
+}
+
+func TestValue(t *testing.T) {
+	creds := credentials{User: "ann", Password: "hunter2", PIN: 1234, token: "t0k3n"}
+
+	redacted := redact.Value(creds).(credentials)
+	require.Equal(t, credentials{User: "ann", Password: redact.Placeholder, token: redact.Placeholder}, redacted)
+	require.NotContains(t, fmt.Sprintf("%+v", redacted), "t0k3n", "Unexported fields should be redacted too, as fmt prints them")
+	require.Equal(t, "hunter2", creds.Password, "The argument should not be modified")
+
+	l := &login{Credentials: creds, Previous: &creds, Card: card{Number: "4111111111111111"}, Attempts: 2}
+	out := redact.Value(l).(*login)
+	require.Equal(t, redact.Placeholder, out.Credentials.Password)
+	require.Equal(t, redact.Placeholder, out.Previous.Password)
+	require.Equal(t, "****1111", out.Card.Number)
+	require.Equal(t, 2, out.Attempts)
+	require.Equal(t, "hunter2", l.Previous.Password)
+
+	require.Equal(t, "****1111", redact.Value(card{Number: "4111111111111111"}).(card).Number)
+	require.Equal(t, "plain", redact.Value("plain"))
+	require.Nil(t, redact.Value(nil))
+	require.Equal(t, []any{"id", 3}, redact.Values("id", 3))
+
+	list := &node{Secret: "s"}
+	for range 20 {
+		list = &node{Secret: "s", Next: list}
+	}
+	for n := redact.Value(list).(*node); n != nil; n = n.Next {
+		require.NotEqual(t, "s", n.Secret, "Deep values should be cleared rather than output unredacted")
+	}
+}
@@ -1,12 +1,7 @@
 package retry
 
-import "time"
+import "github.com/komandakycto/decogen/pkg/backoff"
 
 // Backoff defines the interface for backoff strategies
-type Backoff interface {
-	// MinDelay returns the minimum delay duration
-	MinDelay() time.Duration
-
-	// Delay calculates the next delay based on the previous delay
-	Delay(previous time.Duration) time.Duration
-}
+// It is an alias of backoff.Strategy, so any pkg/backoff implementation can be used directly
+type Backoff = backoff.Strategy
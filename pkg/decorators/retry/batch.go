@@ -0,0 +1,87 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// DoAll executes a batch of operations concurrently, retrying each one according to the provided config.
+// The returned slice has one entry per operation, nil for operations that succeeded.
+//
+// All operations share a single retry budget of (MaxAttempts-1) retries per operation in the batch,
+// so a few flaky operations may retry more often while a failing dependency cannot multiply the load.
+// A concurrency value of zero or less runs every operation at once.
+// Operations that have not started when the context is done are not executed and report the context error.
+func DoAll(ctx context.Context, config Config, ops []func() error, concurrency int) []error {
+	errs := make([]error, len(ops))
+	if len(ops) == 0 {
+		return errs
+	}
+
+	// Validate and prepare configuration
+	if err := validateConfig(&config); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	if concurrency <= 0 || concurrency > len(ops) {
+		concurrency = len(ops)
+	}
+
+	// The shared budget replaces the per-operation attempt limit
+	config.budget = newRetryBudget(uint64(config.MaxAttempts-1) * uint64(len(ops)))
+	config.MaxAttempts = math.MaxUint
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, op := range ops {
+		// Wait for a free slot or stop scheduling when the context is done
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, op func() error) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			errs[i] = Do(ctx, config, op)
+		}(i, op)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+// retryBudget is a pool of retries shared between concurrent operations
+type retryBudget struct {
+	remaining atomic.Int64
+}
+
+// newRetryBudget creates a budget allowing the given number of retries
+func newRetryBudget(retries uint64) *retryBudget {
+	b := &retryBudget{}
+	if retries > math.MaxInt64 {
+		retries = math.MaxInt64
+	}
+	b.remaining.Store(int64(retries))
+	return b
+}
+
+// take consumes one retry from the budget, reporting whether one was available
+// A nil budget always has one
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	return b.remaining.Add(-1) >= 0
+}
@@ -0,0 +1,17 @@
+package retry
+
+import "time"
+
+// Clock abstracts waiting in the retry loop so tests can control time
+type Clock interface {
+	// After waits for the duration to elapse and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock implements Clock using the time package
+type realClock struct{}
+
+// After implements Clock
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
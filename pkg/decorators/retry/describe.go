@@ -0,0 +1,45 @@
+package retry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Describe summarizes the retry config of each method, for decorators.Info
+// methods maps method names to policy names, as generated in <Interface>RetryPolicies, e.g.
+// "UserStorage.Get: reads(max_attempts=3 backoff=exponential(min=100ms,max=10s,factor=2,jitter=0.1))"
+func Describe(policies PolicySource, methods map[string]string, idempotent Idempotent) string {
+	names := make([]string, 0, len(methods))
+	for method := range methods {
+		names = append(names, method)
+	}
+	slices.Sort(names)
+
+	summaries := make([]string, 0, len(names))
+	for _, method := range names {
+		config := idempotent.Config(method, policies.Policy(methods[method]))
+		summaries = append(summaries, fmt.Sprintf("%s: %s(%s)", method, methods[method], describeConfig(config)))
+	}
+	return strings.Join(summaries, "; ")
+}
+
+// describeConfig summarizes the attempts, backoff and time budget of a config
+func describeConfig(config Config) string {
+	parts := []string{fmt.Sprintf("max_attempts=%d", config.MaxAttempts)}
+	if config.MaxAttempts > 1 {
+		// Backoffs without a String method, such as custom strategies, are named by type
+		if s, ok := config.Backoff.(fmt.Stringer); ok {
+			parts = append(parts, "backoff="+s.String())
+		} else if config.Backoff != nil {
+			parts = append(parts, fmt.Sprintf("backoff=%T", config.Backoff))
+		}
+		if len(config.ErrorBackoffs) > 0 {
+			parts = append(parts, fmt.Sprintf("error_backoffs=%d", len(config.ErrorBackoffs)))
+		}
+	}
+	if config.MaxElapsedTime > 0 {
+		parts = append(parts, "max_elapsed="+config.MaxElapsedTime.String())
+	}
+	return strings.Join(parts, " ")
+}
@@ -42,3 +42,15 @@ func IsUnrecoverableError(err error) bool {
 	var unrecoverableErr *UnrecoverableError
 	return errors.As(err, &unrecoverableErr)
 }
+
+// PanicError reports a panic recovered by DoRecover
+// IsRecoverable and OnRetry see it in place of an error
+type PanicError struct {
+	// Value is the value the operation panicked with
+	Value interface{}
+}
+
+// Error implements the error interface
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
@@ -27,16 +27,21 @@ func WithTimeout[T any](ctx context.Context, config Config, timeout time.Duratio
 }
 
 // WithLogging wraps a Config to add logging on each retry
+// Messages name the operation when Config.Op is set
 func WithLogging(config Config, logger *log.Logger) Config {
-	originalOnRetry := config.OnRetry
+	originalOnRetryOp := config.OnRetryOp
 
-	config.OnRetry = func(attempt uint, err error, delay time.Duration) {
+	config.OnRetryOp = func(op string, attempt uint, err error, delay time.Duration) {
 		if logger != nil {
-			logger.Printf("Retry attempt %d after error: %v (waiting %v)", attempt, err, delay)
+			if op != "" {
+				logger.Printf("Retry attempt %d of %s after error: %v (waiting %v)", attempt, op, err, delay)
+			} else {
+				logger.Printf("Retry attempt %d after error: %v (waiting %v)", attempt, err, delay)
+			}
 		}
 
-		if originalOnRetry != nil {
-			originalOnRetry(attempt, err, delay)
+		if originalOnRetryOp != nil {
+			originalOnRetryOp(op, attempt, err, delay)
 		}
 	}
 
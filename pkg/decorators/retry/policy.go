@@ -0,0 +1,52 @@
+package retry
+
+// DefaultPolicy is the name of the policy used by methods without an explicit policy
+const DefaultPolicy = "default"
+
+// Policies maps policy names (e.g. "reads", "writes") to retry configs
+// Generated decorators resolve the policy of each method by name
+type Policies map[string]Config
+
+// Single returns policies using the same config for every method
+func Single(config Config) Policies {
+	return Policies{DefaultPolicy: config}
+}
+
+// Policy returns the config of the named policy
+// Unknown names fall back to DefaultPolicy; if that is missing too, the zero Config is returned
+// and retry.Do reports it as invalid
+func (p Policies) Policy(name string) Config {
+	if config, ok := p[name]; ok {
+		return config
+	}
+	return p[DefaultPolicy]
+}
+
+// Idempotent is the set of method names that are safe to retry
+// Generated decorators attempt the other methods once, so operators can stop retrying a method
+// by removing it from the set at construction, without regenerating code
+type Idempotent map[string]bool
+
+// Config returns the config of a method: unchanged if the method is idempotent, limited to one attempt otherwise
+// The config is named after the method unless it already has an Op
+func (i Idempotent) Config(method string, config Config) Config {
+	if config.Op == "" {
+		config.Op = method
+	}
+	if !i[method] {
+		config.MaxAttempts = 1
+	}
+	return config
+}
+
+// Without returns a copy of the set without the named methods
+func (i Idempotent) Without(methods ...string) Idempotent {
+	result := make(Idempotent, len(i))
+	for method, idempotent := range i {
+		result[method] = idempotent
+	}
+	for _, method := range methods {
+		delete(result, method)
+	}
+	return result
+}
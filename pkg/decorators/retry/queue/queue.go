@@ -0,0 +1,245 @@
+// Package queue provides retry decisions for message-queue consumers.
+//
+// A consumer cannot sleep between attempts the way retry.Do does: a failed
+// message is redelivered later, possibly to another process. A Policy reads the
+// delivery attempt from a message header, classifies the error with the same
+// IsRecoverable functions as retry.Config, and decides whether to acknowledge,
+// redeliver after the backoff delay of that attempt, or dead-letter the message.
+// It fits Kafka, SQS and RabbitMQ handlers alike; adapt the message headers to
+// Headers and act on the returned Decision.
+//
+// Example usage:
+//
+//	policy := queue.New(queue.Config{
+//		MaxAttempts:   5,
+//		Backoff:       backoff.New(time.Second, time.Hour, 2, 0.2),
+//		IsRecoverable: func(err error) bool {
+//			return !errors.Is(err, ErrMalformedMessage)
+//		},
+//	})
+//
+//	headers := queue.MapHeaders(msg.Headers)
+//	decision := policy.Handle(ctx, headers, func(ctx context.Context, attempt uint) error {
+//		return handler.Handle(ctx, msg.Body)
+//	})
+//	switch decision.Action {
+//	case queue.Ack:
+//		msg.Ack()
+//	case queue.Retry:
+//		producer.PublishDelayed(ctx, msg.Body, headers, decision.Delay)
+//		msg.Ack()
+//	case queue.DeadLetter:
+//		deadLetters.Publish(ctx, msg.Body, headers)
+//		msg.Ack()
+//	}
+package queue
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+)
+
+// DefaultAttemptHeader is the header carrying the delivery attempt when Config.AttemptHeader is not set
+const DefaultAttemptHeader = "x-retry-attempt"
+
+// Headers are the headers of a message
+// Applications adapt the header type of their queue client to this interface
+type Headers interface {
+	// Get returns the value of a header, or an empty string
+	Get(key string) string
+
+	// Set sets the value of a header
+	Set(key, value string)
+}
+
+// MapHeaders adapts a map to Headers
+type MapHeaders map[string]string
+
+// Ensure MapHeaders implements Headers
+var _ Headers = MapHeaders(nil)
+
+// Get implements Headers
+func (h MapHeaders) Get(key string) string {
+	return h[key]
+}
+
+// Set implements Headers
+func (h MapHeaders) Set(key, value string) {
+	h[key] = value
+}
+
+// Action is what a consumer does with a message
+type Action int
+
+const (
+	// Ack acknowledges the message, which was handled
+	Ack Action = iota
+	// Retry redelivers the message after Decision.Delay
+	Retry
+	// DeadLetter moves the message to the dead-letter queue
+	DeadLetter
+)
+
+// String returns the name of the action
+func (a Action) String() string {
+	switch a {
+	case Ack:
+		return "ack"
+	case Retry:
+		return "retry"
+	case DeadLetter:
+		return "dead-letter"
+	default:
+		return "Action(" + strconv.Itoa(int(a)) + ")"
+	}
+}
+
+// Decision tells a consumer what to do with a handled message
+type Decision struct {
+	// Action is what to do with the message
+	Action Action
+
+	// Delay is how long to wait before redelivering the message, set for Retry
+	Delay time.Duration
+
+	// Attempt is the delivery attempt that was handled, counting from 1
+	Attempt uint
+
+	// NextAttempt is the attempt recorded for the redelivery, set for Retry
+	// A canceled attempt is redelivered without counting it
+	NextAttempt uint
+
+	// Err is the error returned by the handler
+	Err error
+}
+
+// Config holds the configuration of a policy
+type Config struct {
+	// MaxAttempts is the number of deliveries before a message is dead-lettered
+	// Defaults to 5
+	MaxAttempts uint
+
+	// Backoff computes the delay before each redelivery
+	// Strategies with a DelayForAttempt method, like *backoff.BackOff, compute it directly;
+	// the delays of other strategies are chained from MinDelay
+	// If not provided, backoff.Default is used
+	Backoff backoff.Strategy
+
+	// IsRecoverable decides whether a failed message is redelivered or dead-lettered at once
+	// If not provided, all errors except retry.UnrecoverableError are recoverable
+	IsRecoverable func(error) bool
+
+	// AttemptHeader is the header carrying the delivery attempt
+	// Defaults to DefaultAttemptHeader
+	AttemptHeader string
+
+	// OnDecision is an optional callback called with every decision of Handle, e.g. to log dead letters
+	OnDecision func(Decision)
+}
+
+// attemptDelayer is implemented by strategies computing the delay of an attempt directly
+type attemptDelayer interface {
+	DelayForAttempt(n uint) time.Duration
+}
+
+// Policy decides what consumers do with failed messages
+type Policy struct {
+	config Config
+}
+
+// New creates a policy with the given configuration
+func New(config Config) *Policy {
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = 5
+	}
+	if config.Backoff == nil {
+		config.Backoff = backoff.Default()
+	}
+	if config.IsRecoverable == nil {
+		config.IsRecoverable = func(err error) bool {
+			return !retry.IsUnrecoverableError(err)
+		}
+	}
+	if config.AttemptHeader == "" {
+		config.AttemptHeader = DefaultAttemptHeader
+	}
+
+	return &Policy{config: config}
+}
+
+// Attempt returns the delivery attempt recorded in the headers, 1 when it is missing or invalid
+func (p *Policy) Attempt(h Headers) uint {
+	attempt, err := strconv.ParseUint(h.Get(p.config.AttemptHeader), 10, 0)
+	if err != nil || attempt == 0 {
+		return 1
+	}
+	return uint(attempt)
+}
+
+// SetAttempt records the delivery attempt in the headers of a message to redeliver
+func (p *Policy) SetAttempt(h Headers, attempt uint) {
+	h.Set(p.config.AttemptHeader, strconv.FormatUint(uint64(attempt), 10))
+}
+
+// Decide returns what to do with a message whose delivery attempt, counting from 1, returned err
+func (p *Policy) Decide(attempt uint, err error) Decision {
+	if attempt == 0 {
+		attempt = 1
+	}
+	decision := Decision{Attempt: attempt, Err: err}
+
+	switch {
+	case err == nil:
+		decision.Action = Ack
+	case errors.Is(err, context.Canceled):
+		// The consumer is shutting down, the message was not really handled
+		decision.Action = Retry
+		decision.NextAttempt = attempt
+	case !p.config.IsRecoverable(err), attempt >= p.config.MaxAttempts:
+		decision.Action = DeadLetter
+	default:
+		delay := p.delay(attempt - 1)
+		if delay == backoff.Stop {
+			decision.Action = DeadLetter
+			break
+		}
+		decision.Action = Retry
+		decision.Delay = delay
+		decision.NextAttempt = attempt + 1
+	}
+	return decision
+}
+
+// Handle calls handler with the attempt recorded in the headers and decides what to do with the message
+// For Retry, the next attempt is recorded in the headers, ready for republishing the message
+func (p *Policy) Handle(ctx context.Context, h Headers, handler func(ctx context.Context, attempt uint) error) Decision {
+	attempt := p.Attempt(h)
+	decision := p.Decide(attempt, handler(ctx, attempt))
+	if decision.Action == Retry {
+		p.SetAttempt(h, decision.NextAttempt)
+	}
+	if p.config.OnDecision != nil {
+		p.config.OnDecision(decision)
+	}
+	return decision
+}
+
+// delay returns the delay before retry n, counting from zero
+func (p *Policy) delay(n uint) time.Duration {
+	if b, ok := p.config.Backoff.(attemptDelayer); ok {
+		return b.DelayForAttempt(n)
+	}
+
+	// Stateful strategies such as backoff.MaxRetries must not count the retries of other messages
+	strategy := backoff.NewSequence(p.config.Backoff)
+	delay := strategy.MinDelay()
+	for i := uint(0); i < n && delay != backoff.Stop; i++ {
+		delay = strategy.Delay(delay)
+	}
+	return delay
+}
@@ -0,0 +1,76 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+	"github.com/komandakycto/decogen/pkg/decorators/retry/queue"
+)
+
+func TestPolicy(t *testing.T) {
+	failure := errors.New("downstream unavailable")
+	policy := queue.New(queue.Config{
+		MaxAttempts: 3,
+		Backoff:     backoff.New(time.Second, time.Minute, 2, 0),
+	})
+
+	tests := []struct {
+		name    string
+		attempt uint
+		err     error
+		want    queue.Decision
+	}{
+		{name: "success", attempt: 1, want: queue.Decision{Action: queue.Ack, Attempt: 1}},
+		{name: "first failure", attempt: 1, err: failure,
+			want: queue.Decision{Action: queue.Retry, Delay: time.Second, Attempt: 1, NextAttempt: 2, Err: failure}},
+		{name: "second failure", attempt: 2, err: failure,
+			want: queue.Decision{Action: queue.Retry, Delay: 2 * time.Second, Attempt: 2, NextAttempt: 3, Err: failure}},
+		{name: "attempts exhausted", attempt: 3, err: failure,
+			want: queue.Decision{Action: queue.DeadLetter, Attempt: 3, Err: failure}},
+		{name: "unrecoverable", attempt: 1, err: retry.NewUnrecoverableError(failure),
+			want: queue.Decision{Action: queue.DeadLetter, Attempt: 1, Err: retry.NewUnrecoverableError(failure)}},
+		{name: "canceled is not counted", attempt: 2, err: context.Canceled,
+			want: queue.Decision{Action: queue.Retry, Attempt: 2, NextAttempt: 2, Err: context.Canceled}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, policy.Decide(tt.attempt, tt.err))
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	var decisions []queue.Action
+	policy := queue.New(queue.Config{
+		MaxAttempts: 2,
+		Backoff:     backoff.WithCap(backoff.Linear(time.Second, time.Second), time.Minute),
+		OnDecision:  func(d queue.Decision) { decisions = append(decisions, d.Action) },
+	})
+
+	headers := queue.MapHeaders{}
+	var attempts []uint
+	handler := func(_ context.Context, attempt uint) error {
+		attempts = append(attempts, attempt)
+		return errors.New("boom")
+	}
+
+	decision := policy.Handle(context.Background(), headers, handler)
+	require.Equal(t, queue.Retry, decision.Action)
+	require.Equal(t, time.Second, decision.Delay)
+	require.Equal(t, "2", headers[queue.DefaultAttemptHeader])
+
+	// The redelivered message carries the attempt in its headers
+	decision = policy.Handle(context.Background(), headers, handler)
+	require.Equal(t, queue.DeadLetter, decision.Action)
+	require.Equal(t, []uint{1, 2}, attempts)
+	require.Equal(t, []queue.Action{queue.Retry, queue.DeadLetter}, decisions)
+
+	require.Equal(t, uint(1), policy.Attempt(queue.MapHeaders{queue.DefaultAttemptHeader: "garbage"}))
+}
@@ -0,0 +1,32 @@
+package retry
+
+import (
+	"context"
+)
+
+// DoRecover executes a function that reports failures by panicking, retrying its panics based on the provided config
+// When the last attempt panics, its panic is raised again; when no attempt can be made, as with an invalid config
+// or a context that is already done, op is called once without retries
+func DoRecover(ctx context.Context, config Config, op func()) {
+	var attempted bool
+	var last *PanicError
+
+	err := Do(ctx, config, func() (err error) {
+		attempted = true
+		defer func() {
+			if v := recover(); v != nil {
+				last = &PanicError{Value: v}
+				err = last
+			}
+		}()
+		op()
+		return nil
+	})
+
+	switch {
+	case !attempted:
+		op()
+	case err != nil && last != nil:
+		panic(last.Value)
+	}
+}
@@ -0,0 +1,307 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+// PolicySource hands out retry configs by policy name
+// Generated decorators accept any source, such as Policies or a *PolicyRegistry
+type PolicySource interface {
+	// Policy returns the config of the named policy
+	Policy(name string) Config
+}
+
+// Ensure Policies and PolicyRegistry implement PolicySource
+var (
+	_ PolicySource = Policies(nil)
+	_ PolicySource = (*PolicyRegistry)(nil)
+)
+
+// PolicySpec is the serialized form of a retry policy
+type PolicySpec struct {
+	// MaxAttempts is the maximum number of attempts
+	MaxAttempts uint `json:"max_attempts" yaml:"max_attempts"`
+
+	// Backoff is a backoff specification accepted by backoff.Parse, e.g. "exponential(min=100ms,max=5s)"
+	Backoff string `json:"backoff" yaml:"backoff"`
+
+	// MaxElapsed bounds the total time spent retrying, e.g. "30s"
+	MaxElapsed string `json:"max_elapsed,omitempty" yaml:"max_elapsed,omitempty"`
+
+	// JitterFirstDelay randomizes the initial delay
+	JitterFirstDelay bool `json:"jitter_first_delay,omitempty" yaml:"jitter_first_delay,omitempty"`
+
+	// Recoverable names the error classes that are retried, as registered in RegistryConfig.Classes
+	// An empty list retries every error except context errors and unrecoverable errors
+	Recoverable []string `json:"recoverable,omitempty" yaml:"recoverable,omitempty"`
+
+	// ErrorBackoffs select other backoff strategies for error classes, see Config.ErrorBackoffs
+	ErrorBackoffs []ErrorBackoffSpec `json:"error_backoffs,omitempty" yaml:"error_backoffs,omitempty"`
+}
+
+// ErrorBackoffSpec is the serialized form of an ErrorBackoff
+type ErrorBackoffSpec struct {
+	// Class names the error class, as registered in RegistryConfig.Classes
+	Class string `json:"class" yaml:"class"`
+
+	// Backoff is a backoff specification accepted by backoff.Parse
+	Backoff string `json:"backoff" yaml:"backoff"`
+}
+
+// PolicyDocument is the serialized form of a set of policies, e.g.
+//
+//	{"policies": {"default": {"max_attempts": 3, "backoff": "exponential(min=100ms,max=5s)"}}}
+type PolicyDocument struct {
+	Policies map[string]PolicySpec `json:"policies" yaml:"policies"`
+}
+
+// Watcher delivers the versions of a policy document
+type Watcher interface {
+	// Watch calls update with the current document and with every new version until ctx is done
+	Watch(ctx context.Context, update func(data []byte)) error
+}
+
+// RegistryConfig holds the configuration of a policy registry
+type RegistryConfig struct {
+	// Fallback are the policies used until a document is loaded
+	Fallback Policies
+
+	// Base is the config loaded policies start from, for the fields that are not serialized
+	// such as Clock, OnRetry and Stats
+	Base Config
+
+	// Classes maps the error class names used by PolicySpec.Recoverable to classifiers
+	// "temporary" is always available and matches errors implementing IsTemporaryError
+	Classes map[string]func(error) bool
+
+	// Unmarshal decodes documents, e.g. yaml.Unmarshal
+	// If not provided, documents are decoded as JSON
+	Unmarshal func(data []byte, v any) error
+
+	// OnReload is an optional callback called after each document delivered by a Watcher,
+	// with the error that kept the previous policies in place, or nil
+	OnReload func(err error)
+}
+
+// PolicyRegistry hands out retry configs loaded from a policy document
+// Documents can be reloaded at any time; calls in progress keep the config they started with
+type PolicyRegistry struct {
+	config   RegistryConfig
+	policies atomic.Pointer[Policies]
+}
+
+// NewPolicyRegistry creates a registry serving the fallback policies until a document is loaded
+func NewPolicyRegistry(config RegistryConfig) *PolicyRegistry {
+	if config.Unmarshal == nil {
+		config.Unmarshal = json.Unmarshal
+	}
+
+	r := &PolicyRegistry{config: config}
+	fallback := config.Fallback
+	r.policies.Store(&fallback)
+	return r
+}
+
+// Policy returns the config of the named policy, see Policies.Policy
+func (r *PolicyRegistry) Policy(name string) Config {
+	return r.policies.Load().Policy(name)
+}
+
+// Policies returns the current policies
+func (r *PolicyRegistry) Policies() Policies {
+	return *r.policies.Load()
+}
+
+// Load replaces the policies with the ones of a document
+// An invalid document is rejected as a whole and the current policies stay in place
+func (r *PolicyRegistry) Load(data []byte) error {
+	var doc PolicyDocument
+	if err := r.config.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to decode retry policies: %w", err)
+	}
+
+	policies, err := r.build(doc)
+	if err != nil {
+		return err
+	}
+	r.policies.Store(&policies)
+	return nil
+}
+
+// LoadFile replaces the policies with the ones of a document file
+func (r *PolicyRegistry) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read retry policies: %w", err)
+	}
+	return r.Load(data)
+}
+
+// Watch loads every document delivered by the watcher until ctx is done
+// Invalid documents are reported to RegistryConfig.OnReload and leave the current policies in place
+func (r *PolicyRegistry) Watch(ctx context.Context, watcher Watcher) error {
+	return watcher.Watch(ctx, func(data []byte) {
+		err := r.Load(data)
+		if r.config.OnReload != nil {
+			r.config.OnReload(err)
+		}
+	})
+}
+
+// build converts a document to policies
+func (r *PolicyRegistry) build(doc PolicyDocument) (Policies, error) {
+	names := make([]string, 0, len(doc.Policies))
+	for name := range doc.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	policies := make(Policies, len(doc.Policies))
+	var errs []error
+	for _, name := range names {
+		config, err := r.buildPolicy(doc.Policies[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("policy %s: %w", name, err))
+			continue
+		}
+		policies[name] = config
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid retry policies: %w", err)
+	}
+	return policies, nil
+}
+
+// buildPolicy converts a policy spec to a config
+func (r *PolicyRegistry) buildPolicy(spec PolicySpec) (Config, error) {
+	config := r.config.Base
+	if spec.MaxAttempts == 0 {
+		return Config{}, errors.New("max_attempts must be positive")
+	}
+	config.MaxAttempts = spec.MaxAttempts
+	config.JitterFirstDelay = spec.JitterFirstDelay
+
+	if spec.Backoff != "" {
+		b, err := backoff.Parse(spec.Backoff)
+		if err != nil {
+			return Config{}, err
+		}
+		config.Backoff = b
+	}
+	if config.Backoff == nil {
+		return Config{}, errors.New("backoff is required")
+	}
+
+	if spec.MaxElapsed != "" {
+		d, err := time.ParseDuration(spec.MaxElapsed)
+		if err != nil || d < 0 {
+			return Config{}, fmt.Errorf("invalid max_elapsed %q", spec.MaxElapsed)
+		}
+		config.MaxElapsedTime = d
+	}
+
+	config.ErrorBackoffs = nil
+	for _, eb := range spec.ErrorBackoffs {
+		class, err := r.class(eb.Class)
+		if err != nil {
+			return Config{}, err
+		}
+		b, err := backoff.Parse(eb.Backoff)
+		if err != nil {
+			return Config{}, fmt.Errorf("backoff of error class %s: %w", eb.Class, err)
+		}
+		config.ErrorBackoffs = append(config.ErrorBackoffs, ErrorBackoff{Match: class, Backoff: b})
+	}
+
+	if len(spec.Recoverable) == 0 {
+		if config.IsRecoverable == nil {
+			config.IsRecoverable = defaultRecoverable()
+		}
+		return config, nil
+	}
+
+	classes := make([]func(error) bool, 0, len(spec.Recoverable))
+	for _, name := range spec.Recoverable {
+		class, err := r.class(name)
+		if err != nil {
+			return Config{}, err
+		}
+		classes = append(classes, class)
+	}
+	config.IsRecoverable = func(err error) bool {
+		if err == nil || IsUnrecoverableError(err) {
+			return false
+		}
+		for _, class := range classes {
+			if class(err) {
+				return true
+			}
+		}
+		return false
+	}
+	return config, nil
+}
+
+// class returns the classifier of an error class
+func (r *PolicyRegistry) class(name string) (func(error) bool, error) {
+	if class, ok := r.config.Classes[name]; ok {
+		return class, nil
+	}
+	if name == "temporary" {
+		return IsTemporary, nil
+	}
+	return nil, fmt.Errorf("unknown error class %q", name)
+}
+
+// FileWatcher is a Watcher polling a file for changes
+type FileWatcher struct {
+	// Path is the file holding the document
+	Path string
+
+	// Interval is the time between two checks of the file
+	// Defaults to 10 seconds
+	Interval time.Duration
+}
+
+// Watch implements Watcher
+// update is called with the file contents at start and whenever they change
+func (w FileWatcher) Watch(ctx context.Context, update func(data []byte)) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", w.Path, err)
+	}
+	update(data)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		// A file being replaced may be missing for a moment, the next tick sees the new version
+		current, err := os.ReadFile(w.Path)
+		if err != nil || bytes.Equal(current, data) {
+			continue
+		}
+		data = current
+		update(data)
+	}
+}
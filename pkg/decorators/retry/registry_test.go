@@ -0,0 +1,99 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+)
+
+var errThrottled = errors.New("throttled")
+
+func TestPolicyRegistry(t *testing.T) {
+	fallback := retry.Single(retry.Config{MaxAttempts: 2, Backoff: backoff.NewConstant(time.Millisecond)})
+	registry := retry.NewPolicyRegistry(retry.RegistryConfig{
+		Fallback: fallback,
+		Classes: map[string]func(error) bool{
+			"throttled": func(err error) bool { return errors.Is(err, errThrottled) },
+		},
+	})
+	require.Equal(t, uint(2), registry.Policy("reads").MaxAttempts, "Fallback policies should be served before a load")
+
+	err := registry.Load([]byte(`{"policies": {
+		"default": {"max_attempts": 1, "backoff": "constant(delay=1ms)"},
+		"reads": {"max_attempts": 4, "backoff": "exponential(min=1ms,max=10ms)", "max_elapsed": "1s", "recoverable": ["throttled"],
+			"error_backoffs": [{"class": "throttled", "backoff": "constant(delay=2ms)"}]}
+	}}`))
+	require.NoError(t, err)
+
+	reads := registry.Policy("reads")
+	require.Equal(t, uint(4), reads.MaxAttempts)
+	require.Equal(t, time.Second, reads.MaxElapsedTime)
+	require.True(t, reads.IsRecoverable(errThrottled))
+	require.False(t, reads.IsRecoverable(errors.New("other")))
+	require.False(t, reads.IsRecoverable(retry.NewUnrecoverableError(errThrottled)))
+	require.Len(t, reads.ErrorBackoffs, 1)
+	require.True(t, reads.ErrorBackoffs[0].Match(errThrottled))
+	require.Equal(t, 2*time.Millisecond, reads.ErrorBackoffs[0].Backoff.MinDelay())
+	require.Equal(t, uint(1), registry.Policy("writes").MaxAttempts, "Unknown policies should fall back to the default")
+
+	var calls int
+	err = retry.Do(context.Background(), reads, func() error {
+		calls++
+		return errThrottled
+	})
+	require.ErrorIs(t, err, errThrottled)
+	require.Equal(t, 4, calls)
+
+	t.Run("invalid documents keep the current policies", func(t *testing.T) {
+		for _, doc := range []string{
+			`{"policies": `,
+			`{"policies": {"reads": {"max_attempts": 0, "backoff": "constant(delay=1ms)"}}}`,
+			`{"policies": {"reads": {"max_attempts": 3, "backoff": "sometimes"}}}`,
+			`{"policies": {"reads": {"max_attempts": 3, "backoff": "constant(delay=1ms)", "recoverable": ["flaky"]}}}`,
+			`{"policies": {"reads": {"max_attempts": 3, "backoff": "constant(delay=1ms)", "error_backoffs": [{"class": "flaky", "backoff": "constant(delay=1ms)"}]}}}`,
+			`{"policies": {"reads": {"max_attempts": 3, "backoff": "constant(delay=1ms)", "error_backoffs": [{"class": "temporary", "backoff": "never"}]}}}`,
+			`{"policies": {"reads": {"max_attempts": 3, "backoff": "constant(delay=1ms)", "max_elapsed": "soon"}}}`,
+		} {
+			require.Error(t, registry.Load([]byte(doc)), doc)
+			require.Equal(t, uint(4), registry.Policy("reads").MaxAttempts, doc)
+		}
+	})
+}
+
+func TestPolicyRegistryWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"policies": {"default": {"max_attempts": 3, "backoff": "constant(delay=1ms)"}}}`), 0o600))
+
+	reloads := make(chan error, 10)
+	registry := retry.NewPolicyRegistry(retry.RegistryConfig{
+		OnReload: func(err error) { reloads <- err },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- registry.Watch(ctx, retry.FileWatcher{Path: path, Interval: time.Millisecond})
+	}()
+
+	require.NoError(t, <-reloads)
+	require.Equal(t, uint(3), registry.Policy("reads").MaxAttempts)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"policies": {"default": {"max_attempts": 0}}}`), 0o600))
+	require.Error(t, <-reloads)
+	require.Equal(t, uint(3), registry.Policy("reads").MaxAttempts, "An invalid document should keep the current policies")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"policies": {"default": {"max_attempts": 5, "backoff": "constant(delay=1ms)"}}}`), 0o600))
+	require.NoError(t, <-reloads)
+	require.Equal(t, uint(5), registry.Policy("reads").MaxAttempts)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
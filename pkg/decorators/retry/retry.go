@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"time"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
 )
 
 // Config holds configuration for retry operations
@@ -15,6 +18,11 @@ type Config struct {
 	// Backoff is the backoff strategy to use
 	Backoff Backoff
 
+	// ErrorBackoffs select other backoff strategies for classes of errors, such as a long delay for throttling
+	// The first class matching the error of an attempt sets the delay before the next one; other errors use Backoff
+	// Each strategy keeps its own sequence of delays across the attempts of a call
+	ErrorBackoffs []ErrorBackoff
+
 	// IsRecoverable is a function that determines if an error should be retried
 	// If not provided, all errors except context.Canceled and unrecoverable errors will be retried
 	IsRecoverable func(error) bool
@@ -23,6 +31,61 @@ type Config struct {
 	// The callback receives the current attempt number (starting from 1), error from the previous attempt,
 	// and the delay before the next attempt
 	OnRetry func(attempt uint, err error, delay time.Duration)
+
+	// OnBeforeSleep is an optional callback called before each wait, once OnRetry was called, with the context of the call
+	// The directive it returns can cut the wait short, e.g. when a health watcher reports that the dependency recovered,
+	// so that the next attempt starts at once instead of after a long capped delay; see Waker
+	// Before the first attempt, as with DelayFirstAttempt, attempt is 0 and err nil
+	OnBeforeSleep func(ctx context.Context, attempt uint, err error, delay time.Duration) SleepDirective
+
+	// MaxElapsedTime bounds the total time spent retrying
+	// Retries stop once the next delay would end after the budget; zero means no limit
+	MaxElapsedTime time.Duration
+
+	// DelayFirstAttempt makes the retry loop wait the initial delay before the first attempt
+	DelayFirstAttempt bool
+
+	// JitterFirstDelay randomizes the initial delay uniformly within [0, MinDelay]
+	// This spreads out workers that start retrying the same dependency at the same instant
+	JitterFirstDelay bool
+
+	// JitterSeed seeds the random source of JitterFirstDelay, so that tests reproduce the jittered delays
+	// Zero draws a new seed for each call
+	JitterSeed uint64
+
+	// Clock is used to wait between attempts
+	// If not provided, the real clock is used
+	Clock Clock
+
+	// Stats optionally collects retry activity (in-flight loops, exhaustion counts, last error)
+	Stats *Stats
+
+	// Op names the retried operation, e.g. "UserStorage.Get"
+	// It prefixes the error of a loop that ran out of attempts and is reported to OnRetryOp and Stats
+	// Generated decorators set it to the method name
+	Op string
+
+	// OnRetryOp is like OnRetry and also receives Op, so that a callback shared by many operations
+	// can tell them apart
+	OnRetryOp func(op string, attempt uint, err error, delay time.Duration)
+
+	// OnDone is an optional callback called when a retry loop ends, with Op, the time spent executing attempts,
+	// the time spent waiting between them and the error ending the loop, ErrAllAttemptsFailed when it ran out of attempts
+	// Comparing both tells a slow dependency apart from a budget spent in backoff
+	OnDone func(op string, execution, sleep time.Duration, err error)
+
+	// budget is the pool of retries DoAll shares between the operations of a batch
+	// A retry it cannot pay for ends the loop as if it ran out of attempts; nil means no limit
+	budget *retryBudget
+}
+
+// ErrorBackoff is the backoff strategy of a class of errors
+type ErrorBackoff struct {
+	// Match reports whether an error belongs to the class
+	Match func(error) bool
+
+	// Backoff is the backoff strategy of the class
+	Backoff Backoff
 }
 
 // Default returns a RetryConfig with sensible defaults
@@ -34,6 +97,11 @@ func Default(backoff Backoff) Config {
 	}
 }
 
+// DefaultExponential returns a RetryConfig with sensible defaults and exponential backoff with jitter
+func DefaultExponential() Config {
+	return Default(backoff.Default())
+}
+
 // Do executes a function with retries based on the provided config
 // This is for functions that return only an error
 func Do(ctx context.Context, config Config, op func() error) error {
@@ -58,7 +126,7 @@ func Do(ctx context.Context, config Config, op func() error) error {
 	// check if all attempts failed
 	if err != nil {
 		if errors.Is(err, ErrAllAttemptsFailed) {
-			return fmt.Errorf("%w: %w", ErrAllAttemptsFailed, lastErr)
+			return config.named(fmt.Errorf("%w: %w", ErrAllAttemptsFailed, lastErr))
 		}
 
 		return err
@@ -94,7 +162,7 @@ func DoWithValue[T any](ctx context.Context, config Config, op func() (T, error)
 	// If we have an actual error from the retry mechanism, return it
 	if err != nil {
 		if errors.Is(err, ErrAllAttemptsFailed) {
-			return zero, fmt.Errorf("%w: %v", ErrAllAttemptsFailed, lastErr)
+			return zero, config.named(fmt.Errorf("%w: %v", ErrAllAttemptsFailed, lastErr))
 		}
 
 		return zero, err
@@ -104,12 +172,51 @@ func DoWithValue[T any](ctx context.Context, config Config, op func() (T, error)
 	return result, nil
 }
 
+// values2 holds the values of an operation returning two values and an error
+type values2[T1, T2 any] struct {
+	v1 T1
+	v2 T2
+}
+
+// values3 holds the values of an operation returning three values and an error
+type values3[T1, T2, T3 any] struct {
+	v1 T1
+	v2 T2
+	v3 T3
+}
+
+// DoWithValues2 executes a function with retries based on the provided config
+// This is for functions that return two values and an error, which are zero when all attempts fail, as with DoWithValue
+func DoWithValues2[T1, T2 any](ctx context.Context, config Config, op func() (T1, T2, error)) (T1, T2, error) {
+	v, err := DoWithValue(ctx, config, func() (values2[T1, T2], error) {
+		v1, v2, err := op()
+		return values2[T1, T2]{v1, v2}, err
+	})
+	return v.v1, v.v2, err
+}
+
+// DoWithValues3 executes a function with retries based on the provided config
+// This is for functions that return three values and an error, which are zero when all attempts fail, as with DoWithValue
+func DoWithValues3[T1, T2, T3 any](ctx context.Context, config Config, op func() (T1, T2, T3, error)) (T1, T2, T3, error) {
+	v, err := DoWithValue(ctx, config, func() (values3[T1, T2, T3], error) {
+		v1, v2, v3, err := op()
+		return values3[T1, T2, T3]{v1, v2, v3}, err
+	})
+	return v.v1, v.v2, v.v3, err
+}
+
 // validateConfig checks and initializes the retry configuration
 func validateConfig(config *Config) error {
 	if config.Backoff == nil {
 		return fmt.Errorf("backoff strategy is required")
 	}
 
+	for i, eb := range config.ErrorBackoffs {
+		if eb.Match == nil || eb.Backoff == nil {
+			return fmt.Errorf("error backoff %d: match and backoff strategy are required", i)
+		}
+	}
+
 	if config.MaxAttempts == 0 {
 		config.MaxAttempts = 1 // At least one attempt
 	}
@@ -118,14 +225,63 @@ func validateConfig(config *Config) error {
 		config.IsRecoverable = defaultRecoverable()
 	}
 
+	if config.Clock == nil {
+		config.Clock = realClock{}
+	}
+
 	return nil
 }
 
 // doRetry implements the core retry logic
 // The operation function returns a boolean indicating success and an error
-func doRetry(ctx context.Context, config Config, operation func(attempt uint) (bool, error)) error {
+func doRetry(ctx context.Context, config Config, operation func(attempt uint) (bool, error)) (result error) {
+	// Record retry activity if stats collection is enabled
+	var execution, sleep time.Duration
+	config.Stats.start()
+	defer func() {
+		config.Stats.finish(config.Op, result, execution, sleep)
+		if config.OnDone != nil {
+			config.OnDone(config.Op, execution, sleep, result)
+		}
+	}()
+
+	// Stateful strategies such as backoff.MaxRetries count the retries of this loop only
+	config.Backoff = backoff.NewSequence(config.Backoff)
+
+	// Classes of errors with their own strategy keep their own delays
+	seed := config.jitterSeed()
+	strategies := make([]Backoff, len(config.ErrorBackoffs))
+	delays := make([]time.Duration, len(config.ErrorBackoffs))
+	for i, eb := range config.ErrorBackoffs {
+		strategies[i] = backoff.NewSequence(eb.Backoff)
+		delays[i] = strategies[i].MinDelay()
+		if config.JitterFirstDelay {
+			delays[i] = fullJitter(delays[i], seed, uint64(i)+1)
+		}
+	}
+
+	// Bound the retry loop by wall-clock time if requested
+	if config.MaxElapsedTime > 0 {
+		config.Backoff = backoff.WithMaxElapsed(config.Backoff, config.MaxElapsedTime)
+		for i := range strategies {
+			strategies[i] = backoff.WithMaxElapsed(strategies[i], config.MaxElapsedTime)
+		}
+	}
+
 	attempt := uint(0)
 	delay := config.Backoff.MinDelay()
+	if config.JitterFirstDelay {
+		delay = fullJitter(delay, seed, 0)
+	}
+
+	// Wait before the first attempt if requested
+	if config.DelayFirstAttempt {
+		slept, err := config.wait(ctx, 0, nil, delay)
+		sleep += slept
+		if err != nil {
+			return err
+		}
+	}
 
 	for attempt < config.MaxAttempts {
 		// Check context before the attempt
@@ -134,10 +290,14 @@ func doRetry(ctx context.Context, config Config, operation func(attempt uint) (b
 		}
 
 		// Execute the operation
+		config.Stats.attempt(attempt)
+		started := time.Now()
 		success, err := operation(attempt)
+		execution += time.Since(started)
 		if success {
 			return nil // Operation succeeded
 		}
+		config.Stats.attemptFailed(config.Op, err)
 
 		// Check if context is canceled or deadline exceeded
 		if errors.Is(err, context.Canceled) ||
@@ -154,29 +314,97 @@ func doRetry(ctx context.Context, config Config, operation func(attempt uint) (b
 		// Increment attempt counter
 		attempt++
 
-		// Last attempt, don't delay
-		if attempt >= config.MaxAttempts {
+		// Last attempt or no retry left in the budget, don't delay
+		if attempt >= config.MaxAttempts || !config.budget.take() {
+			break
+		}
+
+		// Use the strategy of the error class, if any
+		strategy, next := config.Backoff, &delay
+		for i, eb := range config.ErrorBackoffs {
+			if eb.Match(err) {
+				strategy, next = strategies[i], &delays[i]
+				break
+			}
+		}
+		wait := *next
+
+		// Stop retrying when the backoff strategy signals it
+		if wait == backoff.Stop {
 			break
 		}
 
 		// Call the OnRetry callback if provided
 		if config.OnRetry != nil {
-			config.OnRetry(attempt, err, delay)
+			config.OnRetry(attempt, err, wait)
+		}
+		if config.OnRetryOp != nil {
+			config.OnRetryOp(config.Op, attempt, err, wait)
 		}
 
-		// Calculate next delay and wait
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(delay):
-			delay = config.Backoff.Delay(delay)
+		// Wait, then calculate the next delay
+		slept, waitErr := config.wait(ctx, attempt, err, wait)
+		sleep += slept
+		if waitErr != nil {
+			return waitErr
 		}
+		*next = strategy.Delay(wait)
 	}
 
 	// We've exhausted all attempts
 	return ErrAllAttemptsFailed
 }
 
+// wait sleeps for the delay before an attempt, returning early when the context is done or OnBeforeSleep wakes it
+// It returns the time slept and the error of the context, if done
+func (c Config) wait(ctx context.Context, attempt uint, err error, delay time.Duration) (time.Duration, error) {
+	var directive SleepDirective
+	if c.OnBeforeSleep != nil {
+		directive = c.OnBeforeSleep(ctx, attempt, err, delay)
+	}
+	if directive.Skip {
+		return 0, nil
+	}
+
+	started := time.Now()
+	select {
+	case <-ctx.Done():
+		return time.Since(started), ctx.Err()
+	case <-directive.Wake:
+	case <-c.Clock.After(delay):
+	}
+	return time.Since(started), nil
+}
+
+// named prefixes an error with the operation name, if any
+func (c Config) named(err error) error {
+	if c.Op == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", c.Op, err)
+}
+
+// jitterSeed returns the seed of the first delay jitter of a call
+func (c Config) jitterSeed() uint64 {
+	if c.JitterSeed != 0 {
+		return c.JitterSeed
+	}
+	return rand.Uint64()
+}
+
+// fullJitter returns a random duration in the range [0, d], drawn from the stream of seed
+// As in pkg/backoff, the PCG generator lives on the stack so that jitter does not allocate
+func fullJitter(d time.Duration, seed, stream uint64) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	var pcg rand.PCG
+	pcg.Seed(seed, stream)
+	// Use the top 53 bits for a uniformly distributed float
+	jittered := time.Duration(float64(pcg.Uint64()>>11) / (1 << 53) * float64(d+1))
+	return min(jittered, d)
+}
+
 func defaultRecoverable() func(err error) bool {
 	return func(err error) bool {
 		return err != nil &&
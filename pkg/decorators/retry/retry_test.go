@@ -3,17 +3,24 @@ package retry_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"go/build"
 	"log"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/komandakycto/decogen/pkg/backoff"
 	"github.com/komandakycto/decogen/pkg/decorators/retry"
+	"github.com/komandakycto/decogen/pkg/decorators/retry/retrytest"
 )
 
 // MockBackoff implements the retry.Backoff interface for testing
@@ -162,6 +169,63 @@ func TestDoWithValue(t *testing.T) {
 	})
 }
 
+// TestDoWithValues tests retrying functions returning several values
+func TestDoWithValues(t *testing.T) {
+	config := retry.Config{MaxAttempts: 3, Backoff: backoff.NewConstant(time.Millisecond)}
+
+	t.Run("two values after retries", func(t *testing.T) {
+		attempts := 0
+		users, total, err := retry.DoWithValues2(context.Background(), config, func() ([]string, int, error) {
+			attempts++
+			if attempts < 2 {
+				return []string{"partial"}, 1, errors.New("temporary error")
+			}
+			return []string{"alice", "bob"}, 2, nil
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"alice", "bob"}, users)
+		require.Equal(t, 2, total)
+		require.Equal(t, 2, attempts)
+	})
+
+	t.Run("three values after retries", func(t *testing.T) {
+		attempts := 0
+		name, age, active, err := retry.DoWithValues3(context.Background(), config, func() (string, int, bool, error) {
+			attempts++
+			if attempts < 3 {
+				return "", 0, false, errors.New("temporary error")
+			}
+			return "alice", 30, true, nil
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, "alice", name)
+		require.Equal(t, 30, age)
+		require.True(t, active)
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("zero values after all attempts fail", func(t *testing.T) {
+		name, age, active, err := retry.DoWithValues3(context.Background(), config, func() (string, int, bool, error) {
+			return "partial", 1, true, errors.New("persistent error")
+		})
+
+		require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+		require.Contains(t, err.Error(), "persistent error")
+		require.Zero(t, name)
+		require.Zero(t, age)
+		require.False(t, active)
+	})
+
+	t.Run("unrecoverable error", func(t *testing.T) {
+		_, _, err := retry.DoWithValues2(context.Background(), config, func() (int, int, error) {
+			return 0, 0, retry.NewUnrecoverableError(errors.New("bad request"))
+		})
+		require.True(t, retry.IsUnrecoverableError(err))
+	})
+}
+
 // TestErrorHandling tests error handling functionality
 func TestErrorHandling(t *testing.T) {
 	t.Run("unrecoverable error stops retries", func(t *testing.T) {
@@ -490,3 +554,747 @@ func (e *temporaryTestError) Error() string {
 func (e *temporaryTestError) Temporary() bool {
 	return e.isTemp
 }
+
+// TestDoAll tests batch retry execution
+func TestDoAll(t *testing.T) {
+	t.Run("all operations succeed", func(t *testing.T) {
+		mockB := new(MockBackoff)
+		mockB.On("MinDelay").Return(time.Millisecond)
+		mockB.On("Delay", mock.Anything).Return(time.Millisecond).Maybe()
+
+		var calls atomic.Int32
+		ops := make([]func() error, 5)
+		for i := range ops {
+			ops[i] = func() error {
+				calls.Add(1)
+				return nil
+			}
+		}
+
+		errs := retry.DoAll(context.Background(), retry.Config{
+			MaxAttempts: 3,
+			Backoff:     mockB,
+		}, ops, 2)
+
+		require.Len(t, errs, 5)
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+		require.Equal(t, int32(5), calls.Load(), "Each operation should be called exactly once")
+	})
+
+	t.Run("per-operation errors are reported", func(t *testing.T) {
+		mockB := new(MockBackoff)
+		mockB.On("MinDelay").Return(time.Millisecond)
+		mockB.On("Delay", mock.Anything).Return(time.Millisecond).Maybe()
+
+		flakyAttempts := 0
+		ops := []func() error{
+			func() error { return nil },
+			func() error {
+				flakyAttempts++
+				if flakyAttempts < 2 {
+					return errors.New("temporary error")
+				}
+				return nil
+			},
+			func() error { return retry.NewUnrecoverableError(errors.New("critical error")) },
+		}
+
+		errs := retry.DoAll(context.Background(), retry.Config{
+			MaxAttempts: 3,
+			Backoff:     mockB,
+		}, ops, 0)
+
+		require.NoError(t, errs[0])
+		require.NoError(t, errs[1])
+		require.Equal(t, 2, flakyAttempts, "Flaky operation should succeed on its second attempt")
+		require.True(t, retry.IsUnrecoverableError(errs[2]))
+	})
+
+	t.Run("shared budget limits total retries", func(t *testing.T) {
+		mockB := new(MockBackoff)
+		mockB.On("MinDelay").Return(time.Millisecond)
+		mockB.On("Delay", mock.Anything).Return(time.Millisecond).Maybe()
+
+		var calls atomic.Int32
+		ops := make([]func() error, 4)
+		for i := range ops {
+			ops[i] = func() error {
+				calls.Add(1)
+				return errors.New("persistent error")
+			}
+		}
+
+		errs := retry.DoAll(context.Background(), retry.Config{
+			MaxAttempts: 3,
+			Backoff:     mockB,
+		}, ops, 2)
+
+		for _, err := range errs {
+			require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+			require.Contains(t, err.Error(), "persistent error")
+		}
+		// 4 first attempts plus a shared budget of 2 retries per operation
+		require.Equal(t, int32(12), calls.Load())
+	})
+
+	t.Run("an exhausted budget is reported as exhausted", func(t *testing.T) {
+		mockB := new(MockBackoff)
+		mockB.On("MinDelay").Return(time.Millisecond)
+		mockB.On("Delay", mock.Anything).Return(time.Millisecond).Maybe()
+
+		ops := make([]func() error, 3)
+		for i := range ops {
+			ops[i] = func() error {
+				return errors.New("persistent error")
+			}
+		}
+
+		stats := &retry.Stats{}
+		var exhausted atomic.Int32
+		errs := retry.DoAll(context.Background(), retry.Config{
+			MaxAttempts: 2,
+			Backoff:     mockB,
+			Stats:       stats,
+			Op:          "batch",
+			OnDone: func(op string, execution, sleep time.Duration, err error) {
+				if errors.Is(err, retry.ErrAllAttemptsFailed) {
+					exhausted.Add(1)
+				}
+			},
+		}, ops, 1)
+
+		for _, err := range errs {
+			require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+		}
+		snapshot := stats.Snapshot()
+		require.Equal(t, uint64(3), snapshot.Exhausted, "Every operation stopped by the budget should count as exhausted")
+		require.Equal(t, uint64(3), snapshot.ExhaustedOps["batch"])
+		require.Equal(t, int32(3), exhausted.Load(), "OnDone should report the exhaustion of every operation")
+	})
+
+	t.Run("canceled context skips pending operations", func(t *testing.T) {
+		mockB := new(MockBackoff)
+		mockB.On("MinDelay").Return(time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		called := false
+		errs := retry.DoAll(ctx, retry.Config{
+			MaxAttempts: 3,
+			Backoff:     mockB,
+		}, []func() error{func() error {
+			called = true
+			return nil
+		}}, 1)
+
+		require.ErrorIs(t, errs[0], context.Canceled)
+		require.False(t, called, "Operation should not run after cancellation")
+	})
+
+	t.Run("missing backoff", func(t *testing.T) {
+		errs := retry.DoAll(context.Background(), retry.Config{}, []func() error{func() error { return nil }}, 1)
+		require.Error(t, errs[0])
+		require.Contains(t, errs[0].Error(), "backoff strategy is required")
+	})
+}
+
+// TestFirstDelayOptions tests the first-attempt delay and initial jitter options
+func TestFirstDelayOptions(t *testing.T) {
+	t.Run("delay before first attempt", func(t *testing.T) {
+		mockB := new(MockBackoff)
+		mockB.On("MinDelay").Return(30 * time.Millisecond)
+
+		start := time.Now()
+		err := retry.Do(context.Background(), retry.Config{
+			MaxAttempts:       3,
+			Backoff:           mockB,
+			DelayFirstAttempt: true,
+		}, func() error {
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond, "First attempt should wait MinDelay")
+		mockB.AssertExpectations(t)
+	})
+
+	t.Run("context canceled during first delay", func(t *testing.T) {
+		mockB := new(MockBackoff)
+		mockB.On("MinDelay").Return(time.Second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		attempts := 0
+		err := retry.Do(ctx, retry.Config{
+			MaxAttempts:       3,
+			Backoff:           mockB,
+			DelayFirstAttempt: true,
+		}, func() error {
+			attempts++
+			return nil
+		})
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, 0, attempts, "Operation should not run when the first delay is interrupted")
+	})
+
+	t.Run("jittered first delay stays within MinDelay", func(t *testing.T) {
+		mockB := new(MockBackoff)
+		mockB.On("MinDelay").Return(20 * time.Millisecond)
+		mockB.On("Delay", mock.Anything).Return(20 * time.Millisecond).Maybe()
+
+		var delays []time.Duration
+		for i := 0; i < 20; i++ {
+			_ = retry.Do(context.Background(), retry.Config{
+				MaxAttempts:      2,
+				Backoff:          mockB,
+				JitterFirstDelay: true,
+				OnRetry: func(attempt uint, err error, delay time.Duration) {
+					delays = append(delays, delay)
+				},
+			}, func() error {
+				return errors.New("temporary error")
+			})
+		}
+
+		require.Len(t, delays, 20)
+		unique := make(map[time.Duration]bool)
+		for _, d := range delays {
+			require.GreaterOrEqual(t, d, time.Duration(0))
+			require.LessOrEqual(t, d, 20*time.Millisecond)
+			unique[d] = true
+		}
+		require.Greater(t, len(unique), 1, "First delays should be jittered")
+	})
+
+	t.Run("a jitter seed reproduces the first delay", func(t *testing.T) {
+		mockB := new(MockBackoff)
+		mockB.On("MinDelay").Return(time.Second)
+		mockB.On("Delay", mock.Anything).Return(time.Second).Maybe()
+
+		firstDelay := func(seed uint64) time.Duration {
+			clock := retrytest.NewClock()
+			_ = retry.Do(context.Background(), retry.Config{
+				MaxAttempts:       1,
+				Backoff:           mockB,
+				DelayFirstAttempt: true,
+				JitterFirstDelay:  true,
+				JitterSeed:        seed,
+				Clock:             clock,
+			}, func() error {
+				return nil
+			})
+			require.Len(t, clock.Delays(), 1)
+			return clock.Delays()[0]
+		}
+
+		delay := firstDelay(42)
+		require.Less(t, delay, time.Second)
+		for i := 0; i < 10; i++ {
+			require.Equal(t, delay, firstDelay(42), "The same seed should produce the same first delay")
+		}
+		require.NotEqual(t, delay, firstDelay(43))
+	})
+}
+
+// TestBackoffCompatibility tests that pkg/backoff strategies plug into retry
+func TestBackoffCompatibility(t *testing.T) {
+	t.Run("default exponential config", func(t *testing.T) {
+		config := retry.DefaultExponential()
+		require.Equal(t, uint(3), config.MaxAttempts)
+		require.NotNil(t, config.Backoff)
+		require.NotNil(t, config.IsRecoverable)
+		require.Equal(t, backoff.Default().MinDelay(), config.Backoff.MinDelay())
+	})
+
+	t.Run("backoff strategy used directly", func(t *testing.T) {
+		var b retry.Backoff = backoff.New(time.Millisecond, 5*time.Millisecond, 2.0, 0.0)
+
+		attempts := 0
+		err := retry.Do(context.Background(), retry.Default(b), func() error {
+			attempts++
+			if attempts < 2 {
+				return errors.New("temporary error")
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 2, attempts)
+	})
+}
+
+// TestStats tests retry activity collection
+func TestStats(t *testing.T) {
+	mockB := new(MockBackoff)
+	mockB.On("MinDelay").Return(time.Millisecond)
+	mockB.On("Delay", mock.Anything).Return(time.Millisecond).Maybe()
+
+	stats := retry.NewStats()
+	config := retry.Config{
+		MaxAttempts: 3,
+		Backoff:     mockB,
+		Stats:       stats,
+	}
+
+	// Snapshots without errors leave the error fields out of their JSON
+	encoded, err := json.Marshal(stats.Snapshot())
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "last_error")
+
+	// One loop that succeeds after a retry
+	attempts := 0
+	err = retry.Do(context.Background(), config, func() error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("temporary error")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// One loop that exhausts all attempts
+	err = retry.Do(context.Background(), config, func() error {
+		return errors.New("persistent error")
+	})
+	require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+
+	// One loop that stops on an unrecoverable error
+	err = retry.Do(context.Background(), config, func() error {
+		return retry.NewUnrecoverableError(errors.New("critical error"))
+	})
+	require.Error(t, err)
+
+	snapshot := stats.Snapshot()
+	require.Equal(t, int64(0), snapshot.InFlight)
+	require.Equal(t, uint64(6), snapshot.Attempts)
+	require.Equal(t, uint64(3), snapshot.Retries)
+	require.Equal(t, uint64(1), snapshot.Successes)
+	require.Equal(t, uint64(2), snapshot.Failures)
+	require.Equal(t, uint64(1), snapshot.Exhausted)
+	require.Contains(t, snapshot.LastError, "critical error")
+	require.False(t, snapshot.LastErrorTime.IsZero())
+
+	encoded, err = json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), `"last_error_time":`)
+}
+
+// TestOp tests naming the retried operation in errors, callbacks, logs and stats
+func TestOp(t *testing.T) {
+	var buf bytes.Buffer
+	var ops []string
+	stats := retry.NewStats()
+	config := retry.WithLogging(retry.Config{
+		MaxAttempts: 2,
+		Backoff:     backoff.NewConstant(time.Millisecond),
+		Stats:       stats,
+		OnRetryOp: func(op string, attempt uint, err error, delay time.Duration) {
+			ops = append(ops, op)
+		},
+	}, log.New(&buf, "", 0))
+
+	named := retry.Idempotent{"UserStorage.Get": true}.Config("UserStorage.Get", config)
+	require.Equal(t, "UserStorage.Get", named.Op)
+
+	err := retry.Do(context.Background(), named, func() error {
+		return errors.New("connection reset")
+	})
+	require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+	require.Equal(t, "UserStorage.Get: all retry attempts failed: connection reset", err.Error())
+	require.Equal(t, []string{"UserStorage.Get"}, ops)
+	require.Contains(t, buf.String(), "Retry attempt 1 of UserStorage.Get")
+
+	_, err = retry.DoWithValue(context.Background(), named, func() (int, error) {
+		return 0, errors.New("connection reset")
+	})
+	require.True(t, strings.HasPrefix(err.Error(), "UserStorage.Get: "))
+
+	snapshot := stats.Snapshot()
+	require.Equal(t, "UserStorage.Get", snapshot.LastErrorOp)
+	require.Equal(t, map[string]uint64{"UserStorage.Get": 2}, snapshot.ExhaustedOps)
+
+	kept := retry.Idempotent{}.Config("Get", retry.Config{Op: "users.get"})
+	require.Equal(t, "users.get", kept.Op, "An explicit Op should not be replaced by the method name")
+}
+
+// TestTiming tests splitting the time of retry loops between executing attempts and sleeping between them
+func TestTiming(t *testing.T) {
+	var execution, sleep time.Duration
+	var done error
+	stats := retry.NewStats()
+	config := retry.Config{
+		MaxAttempts: 3,
+		Backoff:     backoff.NewConstant(20 * time.Millisecond),
+		Stats:       stats,
+		Op:          "UserStorage.Get",
+		OnDone: func(op string, e, s time.Duration, err error) {
+			require.Equal(t, "UserStorage.Get", op)
+			execution, sleep, done = e, s, err
+		},
+	}
+
+	err := retry.Do(context.Background(), config, func() error {
+		time.Sleep(5 * time.Millisecond)
+		return errors.New("temporary error")
+	})
+	require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+	require.Equal(t, retry.ErrAllAttemptsFailed, done)
+	require.GreaterOrEqual(t, execution, 15*time.Millisecond)
+	require.GreaterOrEqual(t, sleep, 40*time.Millisecond)
+
+	snapshot := stats.Snapshot()
+	require.Equal(t, execution, snapshot.Execution)
+	require.Equal(t, sleep, snapshot.Sleep)
+	require.Equal(t, map[string]retry.OpTimes{"UserStorage.Get": {Execution: execution, Sleep: sleep}}, snapshot.OpTimes)
+}
+
+// TestOnBeforeSleep tests that the directive of OnBeforeSleep cuts waits short
+func TestOnBeforeSleep(t *testing.T) {
+	t.Run("skip", func(t *testing.T) {
+		var calls []uint
+		start := time.Now()
+		err := retry.Do(context.Background(), retry.Config{
+			MaxAttempts:       3,
+			Backoff:           backoff.NewConstant(time.Hour),
+			DelayFirstAttempt: true,
+			OnBeforeSleep: func(ctx context.Context, attempt uint, err error, delay time.Duration) retry.SleepDirective {
+				require.NotNil(t, ctx)
+				require.Equal(t, time.Hour, delay)
+				require.Equal(t, attempt == 0, err == nil)
+				calls = append(calls, attempt)
+				return retry.SleepDirective{Skip: true}
+			},
+		}, func() error {
+			return errors.New("temporary error")
+		})
+
+		require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+		require.Equal(t, []uint{0, 1, 2}, calls)
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("waker", func(t *testing.T) {
+		var recovered retry.Waker
+		stats := retry.NewStats()
+		attempts := 0
+		done := make(chan error, 1)
+		go func() {
+			done <- retry.Do(context.Background(), retry.Config{
+				MaxAttempts:   2,
+				Backoff:       backoff.NewConstant(time.Hour),
+				Stats:         stats,
+				OnBeforeSleep: recovered.OnBeforeSleep,
+			}, func() error {
+				if attempts++; attempts == 1 {
+					return errors.New("temporary error")
+				}
+				return nil
+			})
+		}()
+
+		// Wake until the loop is sleeping
+		for {
+			select {
+			case err := <-done:
+				require.NoError(t, err)
+				require.Equal(t, 2, attempts)
+				require.Less(t, stats.Snapshot().Sleep, time.Second)
+				return
+			case <-time.After(time.Millisecond):
+				recovered.Wake()
+			}
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var recovered retry.Waker
+		err := retry.Do(ctx, retry.Config{
+			MaxAttempts: 2,
+			Backoff:     backoff.NewConstant(time.Hour),
+			OnBeforeSleep: func(ctx context.Context, attempt uint, err error, delay time.Duration) retry.SleepDirective {
+				cancel()
+				return recovered.OnBeforeSleep(ctx, attempt, err, delay)
+			},
+		}, func() error {
+			return errors.New("temporary error")
+		})
+
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// TestBackoffStop tests that retries stop when the backoff returns backoff.Stop
+func TestBackoffStop(t *testing.T) {
+	attempts := 0
+	err := retry.Do(context.Background(), retry.Config{
+		MaxAttempts: 10,
+		Backoff:     backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Millisecond), 2),
+	}, func() error {
+		attempts++
+		return errors.New("persistent error")
+	})
+
+	require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+	require.Equal(t, 3, attempts, "Operation should be called once plus two retries")
+
+	t.Run("each call counts its own retries", func(t *testing.T) {
+		// Generated decorators reuse their config for every call
+		config := retry.Config{
+			MaxAttempts: 10,
+			Backoff:     backoff.WithCap(backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Millisecond), 2), time.Second),
+		}
+		for call := 0; call < 3; call++ {
+			attempts := 0
+			err := retry.Do(context.Background(), config, func() error {
+				attempts++
+				return errors.New("persistent error")
+			})
+			require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+			require.Equal(t, 3, attempts, "Call %d should be called once plus two retries", call+1)
+		}
+	})
+}
+
+// TestMaxElapsedTime tests the elapsed time budget of the retry loop
+func TestMaxElapsedTime(t *testing.T) {
+	attempts := 0
+	start := time.Now()
+	err := retry.Do(context.Background(), retry.Config{
+		MaxAttempts:    100,
+		Backoff:        backoff.NewConstant(20 * time.Millisecond),
+		MaxElapsedTime: 50 * time.Millisecond,
+	}, func() error {
+		attempts++
+		return errors.New("persistent error")
+	})
+
+	require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+	require.Less(t, time.Since(start), 100*time.Millisecond, "Retrying should stop once the budget is used up")
+	require.GreaterOrEqual(t, attempts, 2)
+	require.LessOrEqual(t, attempts, 3)
+}
+
+// TestErrorBackoffs tests that each error class waits according to its own backoff sequence
+func TestErrorBackoffs(t *testing.T) {
+	config, clock := retrytest.Deterministic(retry.Config{
+		MaxAttempts: 6,
+		Backoff:     backoff.NewConstant(time.Millisecond),
+		ErrorBackoffs: []retry.ErrorBackoff{{
+			Match:   func(err error) bool { return errors.Is(err, errThrottled) },
+			Backoff: retrytest.NewBackoff(100*time.Millisecond, time.Second, 2),
+		}},
+	})
+
+	failures := []error{errThrottled, errors.New("flaky"), errThrottled, errors.New("flaky"), errThrottled}
+	attempt := 0
+	err := retry.Do(context.Background(), config, func() error {
+		if attempt == len(failures) {
+			return nil
+		}
+		attempt++
+		return failures[attempt-1]
+	})
+
+	require.NoError(t, err)
+	retrytest.AssertDelays(t, clock,
+		100*time.Millisecond, time.Millisecond, 200*time.Millisecond, time.Millisecond, 400*time.Millisecond)
+
+	_, err = retry.DoWithValue(context.Background(), retry.Config{
+		MaxAttempts:   2,
+		Backoff:       backoff.NewConstant(time.Millisecond),
+		ErrorBackoffs: []retry.ErrorBackoff{{Backoff: backoff.NewConstant(time.Millisecond)}},
+	}, func() (int, error) { return 0, nil })
+	require.Error(t, err, "An error backoff without a classifier should be reported as an invalid config")
+}
+
+// TestPolicies tests resolving named retry policies
+func TestPolicies(t *testing.T) {
+	reads := retry.Config{MaxAttempts: 5, Backoff: backoff.NewConstant(time.Millisecond)}
+	writes := retry.Config{MaxAttempts: 1, Backoff: backoff.NewConstant(time.Millisecond)}
+
+	policies := retry.Policies{
+		"reads":             reads,
+		retry.DefaultPolicy: writes,
+	}
+	require.Equal(t, uint(5), policies.Policy("reads").MaxAttempts)
+	require.Equal(t, uint(1), policies.Policy("writes").MaxAttempts, "Unknown policy should fall back to the default")
+
+	require.Equal(t, uint(5), retry.Single(reads).Policy("anything").MaxAttempts)
+
+	err := retry.Do(context.Background(), retry.Policies{}.Policy("reads"), func() error { return nil })
+	require.Error(t, err, "Missing policies should be reported as an invalid config")
+}
+
+func TestIdempotent(t *testing.T) {
+	config := retry.Config{MaxAttempts: 5, Backoff: backoff.NewConstant(time.Millisecond)}
+	idempotent := retry.Idempotent{"Get": true, "Search": true}
+
+	require.Equal(t, uint(5), idempotent.Config("Get", config).MaxAttempts)
+	require.Equal(t, uint(1), idempotent.Config("Save", config).MaxAttempts, "Methods missing from the set should be attempted once")
+
+	flipped := idempotent.Without("Get")
+	require.Equal(t, uint(1), flipped.Config("Get", config).MaxAttempts)
+	require.Equal(t, uint(5), flipped.Config("Search", config).MaxAttempts)
+	require.True(t, idempotent["Get"], "Without should not modify the original set")
+}
+
+func TestDescribe(t *testing.T) {
+	policies := retry.Policies{
+		retry.DefaultPolicy: {MaxAttempts: 3, Backoff: backoff.NewConstant(time.Second), MaxElapsedTime: time.Minute},
+		"writes":            {MaxAttempts: 2, Backoff: struct{ retry.Backoff }{backoff.NewConstant(time.Second)}},
+	}
+	methods := map[string]string{"Get": retry.DefaultPolicy, "Save": "writes", "Delete": "writes"}
+
+	require.Equal(t,
+		"Delete: writes(max_attempts=1); Get: default(max_attempts=3 backoff=constant(delay=1s) max_elapsed=1m0s); "+
+			"Save: writes(max_attempts=2 backoff=struct { backoff.Strategy })",
+		retry.Describe(policies, methods, retry.Idempotent{"Get": true, "Save": true}))
+}
+
+func TestDoRecover(t *testing.T) {
+	config := retry.Config{MaxAttempts: 3, Backoff: backoff.NewConstant(time.Millisecond)}
+
+	t.Run("panics are retried", func(t *testing.T) {
+		var calls int
+		var retried []error
+		config := config
+		config.OnRetry = func(attempt uint, err error, delay time.Duration) { retried = append(retried, err) }
+
+		retry.DoRecover(context.Background(), config, func() {
+			calls++
+			if calls < 3 {
+				panic("flaky")
+			}
+		})
+		require.Equal(t, 3, calls)
+		require.Len(t, retried, 2)
+		var panicErr *retry.PanicError
+		require.ErrorAs(t, retried[0], &panicErr)
+		require.Equal(t, "flaky", panicErr.Value)
+	})
+
+	t.Run("last panic is raised again", func(t *testing.T) {
+		var calls int
+		require.PanicsWithValue(t, "broken 3", func() {
+			retry.DoRecover(context.Background(), config, func() {
+				calls++
+				panic(fmt.Sprintf("broken %d", calls))
+			})
+		})
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("called once without attempts", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var calls int
+		retry.DoRecover(ctx, config, func() { calls++ })
+		require.Equal(t, 1, calls)
+
+		retry.DoRecover(context.Background(), retry.Config{}, func() { calls++ })
+		require.Equal(t, 2, calls, "An invalid config should not skip the call")
+	})
+}
+
+// TestSimulate tests the delay schedules of retry configs
+func TestSimulate(t *testing.T) {
+	exponential := retrytest.NewBackoff(100*time.Millisecond, time.Second, 2)
+
+	tests := []struct {
+		name     string
+		config   retry.Config
+		failures int
+		expected []time.Duration
+	}{
+		{
+			name:     "every attempt fails",
+			config:   retry.Config{MaxAttempts: 4, Backoff: exponential},
+			failures: 10,
+			expected: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond},
+		},
+		{
+			name:     "second attempt succeeds",
+			config:   retry.Config{MaxAttempts: 4, Backoff: exponential},
+			failures: 1,
+			expected: []time.Duration{100 * time.Millisecond},
+		},
+		{
+			name:     "delay before the first attempt",
+			config:   retry.Config{MaxAttempts: 2, Backoff: exponential, DelayFirstAttempt: true},
+			failures: 2,
+			expected: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond},
+		},
+		{
+			name:     "elapsed time budget",
+			config:   retry.Config{MaxAttempts: 10, Backoff: exponential, MaxElapsedTime: 800 * time.Millisecond},
+			failures: 10,
+			expected: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond},
+		},
+		{
+			name:     "backoff stop",
+			config:   retry.Config{MaxAttempts: 10, Backoff: backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Second), 2)},
+			failures: 10,
+			expected: []time.Duration{time.Second, time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, retry.Simulate(tt.config, tt.failures))
+		})
+	}
+
+	require.Empty(t, retry.Simulate(retry.Config{MaxAttempts: 3, Backoff: exponential}, 0))
+	require.Nil(t, retry.Simulate(retry.Config{MaxAttempts: 3}, 3), "A config without a backoff strategy has no schedule")
+
+	t.Run("stateful strategies are left as they were", func(t *testing.T) {
+		limited := backoff.WithMaxRetriesAsStop(backoff.NewConstant(time.Second), 2)
+		config := retry.Config{MaxAttempts: 10, Backoff: limited}
+		require.Equal(t, []time.Duration{time.Second, time.Second}, retry.Simulate(config, 10))
+		require.Equal(t, []time.Duration{time.Second, time.Second}, retry.Simulate(config, 10))
+
+		// A dry run does not use up the retries of the strategy
+		require.Equal(t, time.Second, limited.Delay(time.Second))
+	})
+}
+
+// TestTinyGoImports tests that the retry and backoff runtimes avoid what TinyGo cannot build for wasm plugins:
+// no reflection or syscalls of their own, and no dependency on net/http through expvar
+func TestTinyGoImports(t *testing.T) {
+	ctx := build.Default
+	ctx.GOOS, ctx.GOARCH = "wasip1", "wasm"
+	ctx.BuildTags = []string{"tinygo"}
+
+	forbidden := []string{"expvar", "net/http", "os/exec", "plugin"}
+	for _, dir := range []string{".", "queue", filepath.Join("..", "..", "backoff")} {
+		pkg, err := ctx.ImportDir(dir, 0)
+		require.NoError(t, err)
+		for _, imp := range pkg.Imports {
+			require.NotContains(t, []string{"reflect", "syscall", "unsafe"}, imp, "%s imports %s", dir, imp)
+		}
+
+		// Follow the standard library imports, which TinyGo builds from its own sources
+		seen := make(map[string]bool)
+		queue := slices.Clone(pkg.Imports)
+		for len(queue) > 0 {
+			imp := queue[0]
+			queue = queue[1:]
+			if seen[imp] || strings.Contains(strings.Split(imp, "/")[0], ".") {
+				continue
+			}
+			seen[imp] = true
+			require.NotContains(t, forbidden, imp, "%s depends on %s", dir, imp)
+
+			std, err := ctx.Import(imp, "", 0)
+			require.NoError(t, err)
+			queue = append(queue, std.Imports...)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package retrytest provides helpers for testing code built on the retry package.
+//
+// It makes the retry and backoff pipeline fully deterministic: waiting is replaced
+// by a fake clock that returns immediately and records every requested delay, and
+// backoff strategies are created without jitter from a fixed random source.
+//
+// Example usage:
+//
+//	config, clock := retrytest.Deterministic(retry.Config{
+//		MaxAttempts: 3,
+//		Backoff:     retrytest.NewBackoff(100*time.Millisecond, time.Second, 2.0),
+//	})
+//
+//	err := retry.Do(ctx, config, operation)
+//
+//	retrytest.AssertDelays(t, clock, 100*time.Millisecond, 200*time.Millisecond)
+package retrytest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+)
+
+// Ensure Clock implements retry.Clock
+var _ retry.Clock = (*Clock)(nil)
+
+// Clock is a fake retry.Clock that returns immediately and records requested delays
+type Clock struct {
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+// NewClock creates a new fake clock
+func NewClock() *Clock {
+	return &Clock{}
+}
+
+// After records the delay and returns a channel that is already fired
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.delays = append(c.delays, d)
+	c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+// Delays returns a copy of all delays requested so far
+func (c *Clock) Delays() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delays := make([]time.Duration, len(c.delays))
+	copy(delays, c.delays)
+	return delays
+}
+
+// Reset forgets all recorded delays
+func (c *Clock) Reset() {
+	c.mu.Lock()
+	c.delays = nil
+	c.mu.Unlock()
+}
+
+// NewBackoff creates an exponential backoff without jitter that always produces the same delays
+func NewBackoff(minDelay, maxDelay time.Duration, factor float64) *backoff.BackOff {
+	return backoff.NewWithSeed(minDelay, maxDelay, factor, 0, 1)
+}
+
+// Deterministic returns a copy of the config wired to a fake clock
+// Random first-delay jitter is disabled so that the recorded delays are reproducible
+func Deterministic(config retry.Config) (retry.Config, *Clock) {
+	clock := NewClock()
+	config.Clock = clock
+	config.JitterFirstDelay = false
+	return config, clock
+}
+
+// AssertRetries checks that exactly n waits between attempts were recorded
+func AssertRetries(t testing.TB, clock *Clock, n int) bool {
+	t.Helper()
+
+	delays := clock.Delays()
+	if len(delays) != n {
+		t.Errorf("expected %d retries, got %d (delays: %v)", n, len(delays), delays)
+		return false
+	}
+	return true
+}
+
+// AssertDelays checks that the recorded waits match the expected delays in order
+func AssertDelays(t testing.TB, clock *Clock, expected ...time.Duration) bool {
+	t.Helper()
+
+	delays := clock.Delays()
+	if len(delays) != len(expected) {
+		t.Errorf("expected %d retries with delays %v, got %d with delays %v", len(expected), expected, len(delays), delays)
+		return false
+	}
+
+	for i := range expected {
+		if delays[i] != expected[i] {
+			t.Errorf("expected delays %v, got %v (first mismatch at retry %d)", expected, delays, i+1)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package retrytest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+	"github.com/komandakycto/decogen/pkg/decorators/retry/retrytest"
+)
+
+func TestDeterministic(t *testing.T) {
+	config, clock := retrytest.Deterministic(retry.Config{
+		MaxAttempts:      4,
+		Backoff:          retrytest.NewBackoff(100*time.Millisecond, 300*time.Millisecond, 2.0),
+		JitterFirstDelay: true,
+	})
+
+	start := time.Now()
+	err := retry.Do(context.Background(), config, func() error {
+		return errors.New("persistent error")
+	})
+
+	require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+	require.Less(t, time.Since(start), 100*time.Millisecond, "Fake clock should not actually wait")
+	require.False(t, config.JitterFirstDelay, "First delay jitter should be disabled")
+
+	retrytest.AssertRetries(t, clock, 3)
+	retrytest.AssertDelays(t, clock,
+		100*time.Millisecond,
+		200*time.Millisecond,
+		300*time.Millisecond,
+	)
+}
+
+func TestAssertions(t *testing.T) {
+	clock := retrytest.NewClock()
+	<-clock.After(time.Second)
+
+	fake := &recordingT{TB: t}
+	require.False(t, retrytest.AssertRetries(fake, clock, 2))
+	require.False(t, retrytest.AssertDelays(fake, clock, 2*time.Second))
+	require.True(t, retrytest.AssertDelays(t, clock, time.Second))
+	require.Equal(t, 2, fake.failures)
+
+	clock.Reset()
+	require.Empty(t, clock.Delays())
+}
+
+// recordingT captures assertion failures instead of failing the test
+type recordingT struct {
+	testing.TB
+	failures int
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.failures++
+}
@@ -0,0 +1,53 @@
+package retry
+
+import (
+	"time"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+)
+
+// Simulate returns the delays Do would wait when the first failures attempts fail, without waiting
+// The first delay is the wait before the first attempt when DelayFirstAttempt is set.
+// Attempts are assumed to take no time, so MaxElapsedTime stops the schedule once the delays
+// alone use up the budget, and every failure is recoverable and uses Backoff, not ErrorBackoffs.
+// The schedule of a strategy with jitter is one sample; simulate again for others.
+// Stateful strategies such as backoff.MaxRetries run on a new sequence, as in a call,
+// so simulating leaves the strategy of config as it was.
+func Simulate(config Config, failures int) []time.Duration {
+	if config.Backoff == nil {
+		return nil
+	}
+	config.Backoff = backoff.NewSequence(config.Backoff)
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = 1
+	}
+
+	// Mirror backoff.WithMaxElapsed, with the budget spent by the delays only
+	var elapsed time.Duration
+	limit := func(delay time.Duration) time.Duration {
+		if config.MaxElapsedTime > 0 && delay != backoff.Stop && elapsed+delay > config.MaxElapsedTime {
+			return backoff.Stop
+		}
+		return delay
+	}
+
+	var schedule []time.Duration
+	delay := limit(config.Backoff.MinDelay())
+	if config.JitterFirstDelay {
+		delay = fullJitter(delay, config.jitterSeed(), 0)
+	}
+	if config.DelayFirstAttempt && delay != backoff.Stop {
+		schedule = append(schedule, delay)
+		elapsed += delay
+	}
+
+	for attempt := 1; attempt <= failures && uint(attempt) < config.MaxAttempts; attempt++ {
+		if delay == backoff.Stop {
+			break
+		}
+		schedule = append(schedule, delay)
+		elapsed += delay
+		delay = limit(config.Backoff.Delay(delay))
+	}
+	return schedule
+}
@@ -0,0 +1,234 @@
+// Package sqlclass classifies database errors for retry decisions.
+//
+// Classify recognizes the errors of database/sql and of the common drivers
+// without importing them: PostgreSQL errors of pgx and lib/pq by their SQLSTATE,
+// MySQL errors of go-sql-driver/mysql by their error number, and the network
+// errors of a lost connection. Serialization failures, deadlocks and connection
+// failures are retryable; constraint violations, syntax errors and the other
+// server errors are not.
+//
+// A retryable error only tells that trying again may succeed. A connection lost
+// while a write was in flight may have committed it, so retry only idempotent
+// statements or whole transactions.
+//
+// Example usage with a generated retry decorator:
+//
+//	reads := sqlclass.WithClassification(retry.DefaultExponential())
+//	writes := sqlclass.WithClassification(retry.Config{MaxAttempts: 2, Backoff: backoff.Default()})
+//
+//	storage := NewUserStorageWithRetryPolicies(db, retry.Policies{
+//		"reads":  reads,
+//		"writes": writes,
+//	})
+package sqlclass
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"syscall"
+
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+)
+
+// Class is the retry classification of an error
+type Class int
+
+const (
+	// Unknown is used for errors that are not recognized as database or connection errors
+	Unknown Class = iota
+	// Retryable is used for transient errors that may not happen again, such as deadlocks and lost connections
+	Retryable
+	// Permanent is used for errors that happen again on every attempt, such as constraint violations
+	Permanent
+)
+
+// String returns the name of the class
+func (c Class) String() string {
+	switch c {
+	case Retryable:
+		return "retryable"
+	case Permanent:
+		return "permanent"
+	default:
+		return "unknown"
+	}
+}
+
+// retryableStates are the PostgreSQL SQLSTATE codes of transient errors
+// Class 08 (connection exception) is retryable as a whole
+var retryableStates = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"55P03": true, // lock_not_available
+	"53300": true, // too_many_connections
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// retryableNumbers are the MySQL error numbers of transient errors
+var retryableNumbers = map[uint16]bool{
+	1040: true, // ER_CON_COUNT_ERROR, too many connections
+	1053: true, // ER_SERVER_SHUTDOWN
+	1205: true, // ER_LOCK_WAIT_TIMEOUT
+	1213: true, // ER_LOCK_DEADLOCK
+	1927: true, // ER_CONNECTION_KILLED
+	2002: true, // CR_CONNECTION_ERROR
+	2003: true, // CR_CONN_HOST_ERROR
+	2006: true, // CR_SERVER_GONE_ERROR
+	2013: true, // CR_SERVER_LOST
+}
+
+// sqlStater is implemented by the errors of pgx (*pgconn.PgError) and lib/pq (*pq.Error)
+type sqlStater interface {
+	SQLState() string
+}
+
+// safeToRetrier is implemented by pgx errors raised before anything was sent to the server
+type safeToRetrier interface {
+	SafeToRetry() bool
+}
+
+// Classify returns the retry classification of err and of the errors it wraps
+// The first error of the chain that is recognized decides
+func Classify(err error) Class {
+	if err == nil {
+		return Unknown
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return Permanent
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, sql.ErrTxDone), errors.Is(err, sql.ErrConnDone):
+		return Permanent
+	case errors.Is(err, driver.ErrBadConn):
+		return Retryable
+	}
+
+	class := Unknown
+	walk(err, func(e error) bool {
+		class = classifyOne(e)
+		return class != Unknown
+	})
+	return class
+}
+
+// IsRetryable reports whether err is classified as Retryable
+func IsRetryable(err error) bool {
+	return Classify(err) == Retryable
+}
+
+// WithClassification returns config retrying the errors classified as Retryable and stopping on Permanent ones
+// The errors that are not recognized are left to the IsRecoverable function of config, or are not retried
+// when it is not set
+func WithClassification(config retry.Config) retry.Config {
+	fallback := config.IsRecoverable
+
+	config.IsRecoverable = func(err error) bool {
+		if retry.IsUnrecoverableError(err) {
+			return false
+		}
+		switch Classify(err) {
+		case Retryable:
+			return true
+		case Permanent:
+			return false
+		}
+		if fallback != nil {
+			return fallback(err)
+		}
+		return false
+	}
+
+	return config
+}
+
+// classifyOne classifies a single error of a chain without looking at the errors it wraps
+func classifyOne(err error) Class {
+	if e, ok := err.(sqlStater); ok {
+		return classifyState(e.SQLState())
+	}
+	if number, ok := mysqlNumber(err); ok {
+		if retryableNumbers[number] {
+			return Retryable
+		}
+		return Permanent
+	}
+	if e, ok := err.(safeToRetrier); ok && e.SafeToRetry() {
+		return Retryable
+	}
+
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		return Retryable
+	}
+	if errno, ok := err.(syscall.Errno); ok {
+		switch errno {
+		case syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.ECONNABORTED, syscall.EPIPE:
+			return Retryable
+		}
+	}
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return Retryable
+	}
+	if _, ok := err.(*net.OpError); ok {
+		return Retryable
+	}
+	return Unknown
+}
+
+// classifyState classifies a SQLSTATE code
+func classifyState(state string) Class {
+	if state == "" {
+		return Unknown
+	}
+	if retryableStates[state] || strings.HasPrefix(state, "08") {
+		return Retryable
+	}
+	return Permanent
+}
+
+// mysqlNumber returns the error number of a go-sql-driver/mysql *MySQLError
+func mysqlNumber(err error) (uint16, bool) {
+	v := reflect.ValueOf(err)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return 0, false
+	}
+	v = v.Elem()
+	if v.Type().Name() != "MySQLError" {
+		return 0, false
+	}
+	number := v.FieldByName("Number")
+	if !number.IsValid() || number.Kind() != reflect.Uint16 {
+		return 0, false
+	}
+	return uint16(number.Uint()), true
+}
+
+// walk calls visit for err and the errors it wraps, depth first, until visit returns true
+func walk(err error, visit func(error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if visit(err) {
+		return true
+	}
+
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return walk(e.Unwrap(), visit)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if walk(inner, visit) {
+				return true
+			}
+		}
+	}
+	return false
+}
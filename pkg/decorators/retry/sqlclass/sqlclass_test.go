@@ -0,0 +1,98 @@
+package sqlclass_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+	"github.com/komandakycto/decogen/pkg/decorators/retry/retrytest"
+	"github.com/komandakycto/decogen/pkg/decorators/retry/sqlclass"
+)
+
+// PgError mimics *pgconn.PgError and *pq.Error
+type PgError struct {
+	Code string
+}
+
+func (e *PgError) Error() string    { return "pg: " + e.Code }
+func (e *PgError) SQLState() string { return e.Code }
+
+// MySQLError mimics *mysql.MySQLError
+type MySQLError struct {
+	Number   uint16
+	SQLState [5]byte
+	Message  string
+}
+
+func (e *MySQLError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+// connectError mimics the pgx errors raised before anything was sent
+type connectError struct{}
+
+func (connectError) Error() string     { return "failed to connect" }
+func (connectError) SafeToRetry() bool { return true }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want sqlclass.Class
+	}{
+		{name: "nil", err: nil, want: sqlclass.Unknown},
+		{name: "other", err: errors.New("boom"), want: sqlclass.Unknown},
+		{name: "serialization failure", err: &PgError{Code: "40001"}, want: sqlclass.Retryable},
+		{name: "deadlock", err: fmt.Errorf("save: %w", &PgError{Code: "40P01"}), want: sqlclass.Retryable},
+		{name: "connection exception", err: &PgError{Code: "08006"}, want: sqlclass.Retryable},
+		{name: "unique violation", err: &PgError{Code: "23505"}, want: sqlclass.Permanent},
+		{name: "mysql deadlock", err: &MySQLError{Number: 1213}, want: sqlclass.Retryable},
+		{name: "mysql server gone", err: fmt.Errorf("query: %w", &MySQLError{Number: 2006}), want: sqlclass.Retryable},
+		{name: "mysql duplicate entry", err: &MySQLError{Number: 1062}, want: sqlclass.Permanent},
+		{name: "safe to retry", err: connectError{}, want: sqlclass.Retryable},
+		{name: "bad connection", err: driver.ErrBadConn, want: sqlclass.Retryable},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: sqlclass.Retryable},
+		{name: "joined", err: errors.Join(errors.New("rollback failed"), syscall.EPIPE), want: sqlclass.Retryable},
+		{name: "no rows", err: sql.ErrNoRows, want: sqlclass.Permanent},
+		{name: "canceled", err: fmt.Errorf("query: %w", context.Canceled), want: sqlclass.Permanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, sqlclass.Classify(tt.err))
+			require.Equal(t, tt.want == sqlclass.Retryable, sqlclass.IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestWithClassification(t *testing.T) {
+	config := sqlclass.WithClassification(retry.Config{MaxAttempts: 3})
+	require.True(t, config.IsRecoverable(&PgError{Code: "40001"}))
+	require.False(t, config.IsRecoverable(&PgError{Code: "23505"}))
+	require.False(t, config.IsRecoverable(errors.New("boom")))
+	require.False(t, config.IsRecoverable(retry.NewUnrecoverableError(driver.ErrBadConn)))
+
+	fallback := sqlclass.WithClassification(retry.DefaultExponential())
+	require.True(t, fallback.IsRecoverable(errors.New("boom")))
+	require.False(t, fallback.IsRecoverable(&MySQLError{Number: 1062}))
+
+	attempts := 0
+	config, _ = retrytest.Deterministic(retry.Config{MaxAttempts: 3, Backoff: backoff.NewConstant(time.Second)})
+	err := retry.Do(context.Background(), sqlclass.WithClassification(config), func() error {
+		attempts++
+		if attempts < 3 {
+			return &PgError{Code: "40001"}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+}
@@ -0,0 +1,190 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats collects retry activity for debugging in production
+// A single Stats value can be shared by any number of configs and is safe for concurrent use
+type Stats struct {
+	inFlight  atomic.Int64
+	attempts  atomic.Uint64
+	retries   atomic.Uint64
+	successes atomic.Uint64
+	failures  atomic.Uint64
+	exhausted atomic.Uint64
+	execution atomic.Int64
+	sleep     atomic.Int64
+
+	mu           sync.Mutex // protects lastErr, lastErrOp, lastErrTime, exhaustedOps and opTimes
+	lastErr      error
+	lastErrOp    string
+	lastErrTime  time.Time
+	exhaustedOps map[string]uint64
+	opTimes      map[string]OpTimes
+}
+
+// OpTimes is the time retry loops of an operation spent executing attempts and waiting between them
+type OpTimes struct {
+	// Execution is the total time spent in attempts
+	Execution time.Duration `json:"execution"`
+
+	// Sleep is the total time spent waiting for the backoff between attempts
+	Sleep time.Duration `json:"sleep"`
+}
+
+// StatsSnapshot is a point-in-time copy of the collected retry activity
+type StatsSnapshot struct {
+	// InFlight is the number of retry loops currently running
+	InFlight int64 `json:"in_flight"`
+
+	// Attempts is the total number of operation attempts
+	Attempts uint64 `json:"attempts"`
+
+	// Retries is the total number of attempts made after a failure
+	Retries uint64 `json:"retries"`
+
+	// Successes is the number of retry loops that ended with a successful attempt
+	Successes uint64 `json:"successes"`
+
+	// Failures is the number of retry loops that ended with an error
+	Failures uint64 `json:"failures"`
+
+	// Exhausted is the number of retry loops that ran out of attempts
+	Exhausted uint64 `json:"exhausted"`
+
+	// LastError is the message of the most recent attempt error
+	LastError string `json:"last_error,omitempty"`
+
+	// LastErrorTime is when the most recent attempt error happened
+	LastErrorTime time.Time `json:"last_error_time,omitzero"`
+
+	// LastErrorOp is the Config.Op of the most recent attempt error
+	LastErrorOp string `json:"last_error_op,omitempty"`
+
+	// ExhaustedOps counts the loops that ran out of attempts by Config.Op, for the named operations
+	ExhaustedOps map[string]uint64 `json:"exhausted_ops,omitempty"`
+
+	// Execution is the total time finished retry loops spent executing attempts
+	Execution time.Duration `json:"execution"`
+
+	// Sleep is the total time finished retry loops spent waiting between attempts
+	Sleep time.Duration `json:"sleep"`
+
+	// OpTimes splits Execution and Sleep by Config.Op, for the named operations
+	OpTimes map[string]OpTimes `json:"op_times,omitempty"`
+}
+
+// NewStats creates an empty stats collector
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+// Snapshot returns a copy of the current counters
+func (s *Stats) Snapshot() StatsSnapshot {
+	snapshot := StatsSnapshot{
+		InFlight:  s.inFlight.Load(),
+		Attempts:  s.attempts.Load(),
+		Retries:   s.retries.Load(),
+		Successes: s.successes.Load(),
+		Failures:  s.failures.Load(),
+		Exhausted: s.exhausted.Load(),
+		Execution: time.Duration(s.execution.Load()),
+		Sleep:     time.Duration(s.sleep.Load()),
+	}
+
+	s.mu.Lock()
+	if s.lastErr != nil {
+		snapshot.LastError = s.lastErr.Error()
+		snapshot.LastErrorTime = s.lastErrTime
+		snapshot.LastErrorOp = s.lastErrOp
+	}
+	if len(s.exhaustedOps) > 0 {
+		snapshot.ExhaustedOps = make(map[string]uint64, len(s.exhaustedOps))
+		for op, n := range s.exhaustedOps {
+			snapshot.ExhaustedOps[op] = n
+		}
+	}
+	if len(s.opTimes) > 0 {
+		snapshot.OpTimes = make(map[string]OpTimes, len(s.opTimes))
+		for op, times := range s.opTimes {
+			snapshot.OpTimes[op] = times
+		}
+	}
+	s.mu.Unlock()
+
+	return snapshot
+}
+
+// start records the beginning of a retry loop
+func (s *Stats) start() {
+	if s == nil {
+		return
+	}
+	s.inFlight.Add(1)
+}
+
+// attempt records an attempt, counting it as a retry when it is not the first one
+func (s *Stats) attempt(n uint) {
+	if s == nil {
+		return
+	}
+	s.attempts.Add(1)
+	if n > 0 {
+		s.retries.Add(1)
+	}
+}
+
+// attemptFailed records the error of a failed attempt of an operation
+func (s *Stats) attemptFailed(op string, err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.lastErr = err
+	s.lastErrOp = op
+	s.lastErrTime = time.Now()
+	s.mu.Unlock()
+}
+
+// finish records the end of a retry loop of an operation with its final error and the time it spent
+// executing attempts and sleeping between them
+func (s *Stats) finish(op string, err error, execution, sleep time.Duration) {
+	if s == nil {
+		return
+	}
+	s.inFlight.Add(-1)
+	s.execution.Add(int64(execution))
+	s.sleep.Add(int64(sleep))
+	if op != "" {
+		s.mu.Lock()
+		if s.opTimes == nil {
+			s.opTimes = make(map[string]OpTimes)
+		}
+		times := s.opTimes[op]
+		times.Execution += execution
+		times.Sleep += sleep
+		s.opTimes[op] = times
+		s.mu.Unlock()
+	}
+	switch {
+	case err == nil:
+		s.successes.Add(1)
+	case errors.Is(err, ErrAllAttemptsFailed):
+		s.exhausted.Add(1)
+		s.failures.Add(1)
+		if op != "" {
+			s.mu.Lock()
+			if s.exhaustedOps == nil {
+				s.exhaustedOps = make(map[string]uint64)
+			}
+			s.exhaustedOps[op]++
+			s.mu.Unlock()
+		}
+	default:
+		s.failures.Add(1)
+	}
+}
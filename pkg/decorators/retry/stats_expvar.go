@@ -0,0 +1,15 @@
+//go:build !tinygo && !decogen_tiny
+
+package retry
+
+import "expvar"
+
+// Publish exposes the stats snapshot through expvar under the given name
+// Like expvar.Publish, it panics if the name is already registered
+// It is left out of TinyGo builds and builds with the decogen_tiny tag, as expvar depends on net/http;
+// such builds can serve Snapshot themselves
+func (s *Stats) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return s.Snapshot()
+	}))
+}
@@ -0,0 +1,36 @@
+//go:build !tinygo && !decogen_tiny
+
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+)
+
+// TestPublish tests exposing the stats snapshot through expvar
+func TestPublish(t *testing.T) {
+	stats := retry.NewStats()
+	err := retry.Do(context.Background(), retry.Config{
+		MaxAttempts: 2,
+		Backoff:     backoff.NewConstant(time.Millisecond),
+		Stats:       stats,
+	}, func() error {
+		return errors.New("persistent error")
+	})
+	require.ErrorIs(t, err, retry.ErrAllAttemptsFailed)
+
+	name := fmt.Sprintf("retry_test_stats_%d", time.Now().UnixNano())
+	stats.Publish(name)
+	published := expvar.Get(name)
+	require.NotNil(t, published)
+	require.Contains(t, published.String(), `"exhausted":1`)
+}
@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// Seq returns the items of a stream that is reopened after recoverable failures
+// open is called with the last item received so far, nil at first, and returns the items from the
+// position after it, such as a cursor resumed from the last seen ID
+// Failures before any item are retried according to config; a failure after items were received reopens
+// the stream at once with a fresh count of attempts
+// The error that ends the stream is yielded with the zero item, and no items follow it
+func Seq[T any](ctx context.Context, config Config, open func(ctx context.Context, last *T) iter.Seq2[T, error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if err := validateConfig(&config); err != nil {
+			yield(zero, err)
+			return
+		}
+
+		var last *T
+		for {
+			// A stream failing after items were received is reopened with a fresh loop,
+			// so that MaxAttempts bounds consecutive failures only
+			var lastErr error
+			stopped, reopen := false, false
+			err := doRetry(ctx, config, func(attempt uint) (bool, error) {
+				progressed := false
+				for item, err := range open(ctx, last) {
+					if err != nil {
+						lastErr = err
+						if progressed && ctx.Err() == nil && config.IsRecoverable(err) {
+							reopen = true
+							return true, nil
+						}
+						return false, err
+					}
+
+					progressed = true
+					last = &item
+					if !yield(item, nil) {
+						stopped = true
+						return true, nil
+					}
+				}
+				return true, nil
+			})
+
+			switch {
+			case stopped:
+				return
+			case err == nil && reopen:
+				continue
+			case err == nil:
+				return
+			case errors.Is(err, ErrAllAttemptsFailed):
+				yield(zero, config.named(fmt.Errorf("%w: %w", ErrAllAttemptsFailed, lastErr)))
+				return
+			default:
+				yield(zero, err)
+				return
+			}
+		}
+	}
+}
+
+// Channel is Seq for streams delivered on channels
+// open returns the items from the position after last and a function reporting why the channel was closed,
+// nil when the stream ended, like bufio.Scanner.Err
+// The returned channel is closed when the stream ends or ctx is done; the returned function then reports the error
+// that ended it
+func Channel[T any](ctx context.Context, config Config, open func(ctx context.Context, last *T) (<-chan T, func() error)) (<-chan T, func() error) {
+	items := make(chan T)
+	var streamErr error
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer close(items)
+
+		seq := Seq(ctx, config, func(ctx context.Context, last *T) iter.Seq2[T, error] {
+			return func(yield func(T, error) bool) {
+				ch, errFn := open(ctx, last)
+				for item := range ch {
+					if !yield(item, nil) {
+						return
+					}
+				}
+				if err := errFn(); err != nil {
+					var zero T
+					yield(zero, err)
+				}
+			}
+		})
+		for item, err := range seq {
+			if err != nil {
+				streamErr = err
+				return
+			}
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				streamErr = ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return items, func() error {
+		<-done
+		return streamErr
+	}
+}
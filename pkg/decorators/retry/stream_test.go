@@ -0,0 +1,142 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/backoff"
+	"github.com/komandakycto/decogen/pkg/decorators/retry"
+)
+
+var errConnReset = errors.New("connection reset")
+
+// flakyCursor returns items up to max from the position after last, failing after every two items
+func flakyCursor(max int, opens *int) func(ctx context.Context, last *int) iter.Seq2[int, error] {
+	return func(ctx context.Context, last *int) iter.Seq2[int, error] {
+		*opens++
+		return func(yield func(int, error) bool) {
+			next := 1
+			if last != nil {
+				next = *last + 1
+			}
+			for sent := 0; next <= max; sent, next = sent+1, next+1 {
+				if sent == 2 {
+					yield(0, errConnReset)
+					return
+				}
+				if !yield(next, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestSeq(t *testing.T) {
+	config := retry.Config{MaxAttempts: 2, Backoff: backoff.NewConstant(time.Millisecond)}
+
+	t.Run("resumes after the last item", func(t *testing.T) {
+		var opens int
+		var items []int
+		for item, err := range retry.Seq(context.Background(), config, flakyCursor(5, &opens)) {
+			require.NoError(t, err)
+			items = append(items, item)
+		}
+		require.Equal(t, []int{1, 2, 3, 4, 5}, items)
+		require.Equal(t, 3, opens, "Failures after progress should not use up attempts")
+	})
+
+	t.Run("stops when the consumer stops", func(t *testing.T) {
+		var opens int
+		var items []int
+		for item := range retry.Seq(context.Background(), config, flakyCursor(5, &opens)) {
+			items = append(items, item)
+			if item == 3 {
+				break
+			}
+		}
+		require.Equal(t, []int{1, 2, 3}, items)
+	})
+
+	t.Run("failures before any item are retried", func(t *testing.T) {
+		var opens int
+		named := config
+		named.Op = "Users.Stream"
+		var errs []error
+		for _, err := range retry.Seq(context.Background(), named, func(ctx context.Context, last *int) iter.Seq2[int, error] {
+			opens++
+			return func(yield func(int, error) bool) { yield(0, errConnReset) }
+		}) {
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+		require.ErrorIs(t, errs[0], retry.ErrAllAttemptsFailed)
+		require.ErrorIs(t, errs[0], errConnReset)
+		require.Contains(t, errs[0].Error(), "Users.Stream: ")
+		require.Equal(t, 2, opens)
+	})
+
+	t.Run("unrecoverable failures end the stream", func(t *testing.T) {
+		var opens int
+		var items []int
+		var errs []error
+		for item, err := range retry.Seq(context.Background(), config, func(ctx context.Context, last *int) iter.Seq2[int, error] {
+			opens++
+			return func(yield func(int, error) bool) {
+				if yield(1, nil) {
+					yield(0, retry.NewUnrecoverableError(errConnReset))
+				}
+			}
+		}) {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			items = append(items, item)
+		}
+		require.Equal(t, []int{1}, items)
+		require.Len(t, errs, 1)
+		require.True(t, retry.IsUnrecoverableError(errs[0]))
+		require.Equal(t, 1, opens)
+	})
+}
+
+func TestChannel(t *testing.T) {
+	config := retry.Config{MaxAttempts: 2, Backoff: backoff.NewConstant(time.Millisecond)}
+
+	var opens int
+	items, errFn := retry.Channel(context.Background(), config, func(ctx context.Context, last *int) (<-chan int, func() error) {
+		opens++
+		next := 1
+		if last != nil {
+			next = *last + 1
+		}
+
+		ch := make(chan int)
+		var err error
+		go func() {
+			defer close(ch)
+			for sent := 0; next <= 5; sent, next = sent+1, next+1 {
+				if sent == 2 {
+					err = errConnReset
+					return
+				}
+				ch <- next
+			}
+		}()
+		return ch, func() error { return err }
+	})
+
+	var got []int
+	for item := range items {
+		got = append(got, item)
+	}
+	require.NoError(t, errFn())
+	require.Equal(t, []int{1, 2, 3, 4, 5}, got)
+	require.Equal(t, 3, opens)
+}
@@ -0,0 +1,53 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SleepDirective tells the retry loop how to wait before the next attempt, as returned by Config.OnBeforeSleep
+// The zero value waits the whole delay
+type SleepDirective struct {
+	// Skip starts the next attempt at once, without waiting
+	Skip bool
+
+	// Wake ends the wait early when it receives or is closed; a nil channel never does
+	Wake <-chan struct{}
+}
+
+// Waker wakes the retry loops waiting between attempts when its Wake method is called,
+// e.g. by a health watcher seeing a dependency recover
+// Loops only wait for it when its OnBeforeSleep is set in their Config; a Waker is safe for concurrent use
+// and its zero value is ready to use
+//
+// Example usage:
+//
+//	var recovered retry.Waker
+//	config := retry.DefaultExponential()
+//	config.OnBeforeSleep = recovered.OnBeforeSleep
+//	watcher.OnHealthy(recovered.Wake)
+type Waker struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// Wake ends the waits of the retry loops currently sleeping; loops sleeping later wait for the next call
+func (w *Waker) Wake() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ch != nil {
+		close(w.ch)
+		w.ch = nil
+	}
+}
+
+// OnBeforeSleep implements Config.OnBeforeSleep, returning a directive woken by the next call to Wake
+func (w *Waker) OnBeforeSleep(ctx context.Context, attempt uint, err error, delay time.Duration) SleepDirective {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ch == nil {
+		w.ch = make(chan struct{})
+	}
+	return SleepDirective{Wake: w.ch}
+}
@@ -0,0 +1,165 @@
+// Package timeout provides the runtime used by generated timeout decorators.
+//
+// Do bounds how long a caller waits for an operation. Unlike a bare
+// context.WithTimeout, it returns at the deadline even if the operation ignores
+// its context, and it keeps track of the abandoned operation: OnAbandoned is
+// called when it eventually finishes, so results can be cleaned up and late
+// completions counted. With Detach, the operation is not canceled at all and
+// is allowed to finish in the background, e.g. to complete a write the caller
+// no longer waits for.
+//
+// Example usage:
+//
+//	user, err := timeout.Do(ctx, timeout.Config{
+//		Timeout: 200 * time.Millisecond,
+//		OnAbandoned: func(result any, err error, elapsed time.Duration) {
+//			log.Printf("GetByID finished %s after it was abandoned: %v", elapsed, err)
+//		},
+//	}, func(ctx context.Context) (*User, error) {
+//		return storage.GetByID(ctx, id)
+//	})
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ErrTimeout is returned when the operation did not finish in time
+// It also matches context.DeadlineExceeded with errors.Is
+var ErrTimeout = errors.New("operation timed out")
+
+// Config holds the configuration of a timeout
+type Config struct {
+	// Timeout is how long the caller waits for the operation
+	// Zero or less runs the operation without a timeout
+	Timeout time.Duration
+
+	// Detach lets the operation finish in the background after the timeout
+	// instead of canceling its context; context values are preserved
+	Detach bool
+
+	// DetachedTimeout optionally bounds how long a detached operation may run in total
+	// Zero means the detached operation is never canceled
+	DetachedTimeout time.Duration
+
+	// OnAbandoned is an optional callback called when an operation finishes after the caller stopped waiting,
+	// with its result, its error and its total running time
+	OnAbandoned func(result any, err error, elapsed time.Duration)
+}
+
+// PanicError is reported to OnAbandoned when an abandoned operation panicked
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error returns the error message
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("operation panicked: %v", e.Value)
+}
+
+// outcome is the result of an operation run in its own goroutine
+type outcome[T any] struct {
+	value    T
+	err      error
+	panicked *PanicError
+}
+
+// Do runs the operation and waits for it at most config.Timeout
+func Do[T any](ctx context.Context, config Config, op func(context.Context) (T, error)) (T, error) {
+	if config.Timeout <= 0 {
+		return op(ctx)
+	}
+
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	opCtx, cancel := operationContext(ctx, config)
+
+	// A canceled operation shares the caller's deadline; a detached one outlives it
+	waitCtx := opCtx
+	if config.Detach {
+		var cancelWait context.CancelFunc
+		waitCtx, cancelWait = context.WithTimeout(ctx, config.Timeout)
+		defer cancelWait()
+	}
+
+	// Buffered so the operation never blocks once the caller has gone
+	done := make(chan outcome[T], 1)
+	start := time.Now()
+
+	go func() {
+		defer cancel()
+		done <- run(opCtx, op)
+	}()
+
+	select {
+	case out := <-done:
+		return out.result()
+	case <-waitCtx.Done():
+	}
+
+	// The operation context is also canceled once the operation returns, after its outcome was sent:
+	// an outcome ready now finished in time rather than timing out
+	select {
+	case out := <-done:
+		return out.result()
+	default:
+	}
+
+	if config.OnAbandoned != nil {
+		go func() {
+			out := <-done
+			err := out.err
+			if out.panicked != nil {
+				err = out.panicked
+			}
+			config.OnAbandoned(out.value, err, time.Since(start))
+		}()
+	}
+
+	if err := ctx.Err(); err != nil {
+		// The caller's context ended first, report it rather than our timeout
+		return zero, err
+	}
+	return zero, fmt.Errorf("%w after %s: %w", ErrTimeout, config.Timeout, context.DeadlineExceeded)
+}
+
+// result returns the value and error of a finished operation, panicking again if it panicked
+func (o outcome[T]) result() (T, error) {
+	if o.panicked != nil {
+		panic(o.panicked.Value)
+	}
+	return o.value, o.err
+}
+
+// operationContext returns the context the operation runs with
+func operationContext(ctx context.Context, config Config) (context.Context, context.CancelFunc) {
+	if !config.Detach {
+		return context.WithTimeout(ctx, config.Timeout)
+	}
+
+	detached := context.WithoutCancel(ctx)
+	if config.DetachedTimeout > 0 {
+		return context.WithTimeout(detached, config.DetachedTimeout)
+	}
+	return context.WithCancel(detached)
+}
+
+// run executes the operation, capturing a panic instead of crashing the process
+func run[T any](ctx context.Context, op func(context.Context) (T, error)) (out outcome[T]) {
+	defer func() {
+		if r := recover(); r != nil {
+			out.panicked = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	out.value, out.err = op(ctx)
+	return out
+}
@@ -0,0 +1,166 @@
+package timeout_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/decorators/timeout"
+)
+
+// abandoned collects the report of an abandoned operation
+type abandoned struct {
+	result any
+	err    error
+}
+
+// TestDo tests waiting for operations with a timeout
+func TestDo(t *testing.T) {
+	t.Run("finishes in time", func(t *testing.T) {
+		value, err := timeout.Do(context.Background(), timeout.Config{Timeout: time.Second},
+			func(ctx context.Context) (int, error) {
+				_, ok := ctx.Deadline()
+				require.True(t, ok, "Operation should see the deadline")
+				return 42, nil
+			})
+		require.NoError(t, err)
+		require.Equal(t, 42, value)
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		value, err := timeout.Do(context.Background(), timeout.Config{},
+			func(ctx context.Context) (int, error) { return 1, nil })
+		require.NoError(t, err)
+		require.Equal(t, 1, value)
+	})
+
+	t.Run("instant operations are never reported as timeouts", func(t *testing.T) {
+		var abandonedCalls atomic.Int64
+		config := timeout.Config{
+			Timeout: time.Second,
+			OnAbandoned: func(result any, err error, elapsed time.Duration) {
+				abandonedCalls.Add(1)
+			},
+		}
+		for i := 0; i < 50000; i++ {
+			value, err := timeout.Do(context.Background(), config, func(ctx context.Context) (int, error) {
+				return i, nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, i, value)
+		}
+		require.Zero(t, abandonedCalls.Load())
+	})
+
+	t.Run("returns at the deadline even if the operation ignores its context", func(t *testing.T) {
+		reports := make(chan abandoned, 1)
+		release := make(chan struct{})
+
+		start := time.Now()
+		_, err := timeout.Do(context.Background(), timeout.Config{
+			Timeout: 10 * time.Millisecond,
+			OnAbandoned: func(result any, err error, elapsed time.Duration) {
+				reports <- abandoned{result: result, err: err}
+			},
+		}, func(ctx context.Context) (string, error) {
+			<-release
+			return "late", ctx.Err()
+		})
+
+		require.ErrorIs(t, err, timeout.ErrTimeout)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), time.Second)
+
+		close(release)
+		report := <-reports
+		require.Equal(t, "late", report.result)
+		require.ErrorIs(t, report.err, context.DeadlineExceeded, "Operation context should be canceled")
+	})
+
+	t.Run("detached operation finishes in the background", func(t *testing.T) {
+		type key struct{}
+		reports := make(chan abandoned, 1)
+
+		ctx := context.WithValue(context.Background(), key{}, "value")
+		_, err := timeout.Do(ctx, timeout.Config{
+			Timeout: 10 * time.Millisecond,
+			Detach:  true,
+			OnAbandoned: func(result any, err error, elapsed time.Duration) {
+				reports <- abandoned{result: result, err: err}
+			},
+		}, func(ctx context.Context) (string, error) {
+			time.Sleep(30 * time.Millisecond)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return ctx.Value(key{}).(string), nil
+		})
+		require.ErrorIs(t, err, timeout.ErrTimeout)
+
+		report := <-reports
+		require.NoError(t, report.err, "Detached operation should not be canceled")
+		require.Equal(t, "value", report.result)
+	})
+
+	t.Run("detached timeout bounds the background work", func(t *testing.T) {
+		reports := make(chan abandoned, 1)
+
+		_, err := timeout.Do(context.Background(), timeout.Config{
+			Timeout:         5 * time.Millisecond,
+			Detach:          true,
+			DetachedTimeout: 20 * time.Millisecond,
+			OnAbandoned: func(result any, err error, elapsed time.Duration) {
+				reports <- abandoned{result: result, err: err}
+			},
+		}, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		require.ErrorIs(t, err, timeout.ErrTimeout)
+		require.ErrorIs(t, (<-reports).err, context.DeadlineExceeded)
+	})
+
+	t.Run("caller cancellation is reported as is", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			cancel()
+		}()
+
+		_, err := timeout.Do(ctx, timeout.Config{Timeout: time.Second}, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.False(t, errors.Is(err, timeout.ErrTimeout))
+	})
+
+	t.Run("panics", func(t *testing.T) {
+		require.PanicsWithValue(t, "boom", func() {
+			_, _ = timeout.Do(context.Background(), timeout.Config{Timeout: time.Second},
+				func(ctx context.Context) (int, error) { panic("boom") })
+		})
+
+		reports := make(chan abandoned, 1)
+		release := make(chan struct{})
+		_, err := timeout.Do(context.Background(), timeout.Config{
+			Timeout: 5 * time.Millisecond,
+			OnAbandoned: func(result any, err error, elapsed time.Duration) {
+				reports <- abandoned{result: result, err: err}
+			},
+		}, func(ctx context.Context) (int, error) {
+			<-release
+			panic("late boom")
+		})
+		require.ErrorIs(t, err, timeout.ErrTimeout)
+
+		close(release)
+		var pe *timeout.PanicError
+		require.ErrorAs(t, (<-reports).err, &pe)
+		require.Equal(t, "late boom", pe.Value)
+	})
+}
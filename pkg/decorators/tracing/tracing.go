@@ -0,0 +1,108 @@
+// Package tracing provides the runtime used by generated tracing decorators.
+//
+// Generated code calls Start with the interface and method names and the method
+// arguments, and End with the returned error. Span naming, error recording and
+// attribute extraction are implemented here so every service traces the same way.
+//
+// Arguments contribute span attributes by implementing Attributer:
+//
+//	func (r GetUserRequest) TraceAttributes() []attribute.KeyValue {
+//		return []attribute.KeyValue{attribute.String("user.id", r.ID)}
+//	}
+//
+// Example usage:
+//
+//	tracer := otel.Tracer("github.com/acme/users")
+//
+//	ctx, span := tracing.Start(ctx, tracer, "UserStorage", "GetByID", req)
+//	user, err := storage.GetByID(ctx, req)
+//	tracing.End(span, err)
+package tracing
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys set on every span started by this package
+const (
+	// InterfaceKey holds the name of the decorated interface
+	InterfaceKey = attribute.Key("decogen.interface")
+	// MethodKey holds the name of the decorated method
+	MethodKey = attribute.Key("decogen.method")
+)
+
+// Attributer is implemented by method arguments that contribute span attributes
+type Attributer interface {
+	TraceAttributes() []attribute.KeyValue
+}
+
+// SpanName returns the span name used for a method of an interface
+func SpanName(iface, method string) string {
+	if iface == "" {
+		return method
+	}
+	return iface + "." + method
+}
+
+// Attributes collects span attributes from the arguments implementing Attributer
+// Other arguments are ignored
+func Attributes(args ...any) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, arg := range args {
+		if a, ok := arg.(Attributer); ok {
+			attrs = append(attrs, a.TraceAttributes()...)
+		}
+	}
+	return attrs
+}
+
+// Start starts a client span for a method call with attributes taken from its arguments
+func Start(ctx context.Context, tracer trace.Tracer, iface, method string, args ...any) (context.Context, trace.Span) {
+	attrs := append([]attribute.KeyValue{
+		InterfaceKey.String(iface),
+		MethodKey.String(method),
+	}, Attributes(args...)...)
+
+	return tracer.Start(ctx, SpanName(iface, method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// Sampled reports whether a call starts a span, respecting the sampling decision of the parent span in ctx
+// Calls without a parent span are sampled with probability ratio
+func Sampled(ctx context.Context, ratio float64) bool {
+	if parent := trace.SpanContextFromContext(ctx); parent.IsValid() {
+		return parent.IsSampled()
+	}
+	return ratio >= 1 || (ratio > 0 && rand.Float64() < ratio)
+}
+
+// End records the outcome of a method call and ends the span
+// Context cancellation is recorded as an event rather than an error status
+func End(span trace.Span, err error) {
+	RecordError(span, err)
+	span.End()
+}
+
+// RecordError records err on the span and marks the span as failed
+// A nil error leaves the span untouched
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	if errors.Is(err, context.Canceled) {
+		span.AddEvent("canceled", trace.WithAttributes(attribute.String("error.message", err.Error())))
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
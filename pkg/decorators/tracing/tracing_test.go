@@ -0,0 +1,85 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/komandakycto/decogen/pkg/decorators/tracing"
+)
+
+type request struct {
+	ID string
+}
+
+func (r request) TraceAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{attribute.String("user.id", r.ID)}
+}
+
+// newTracer creates a tracer recording finished spans
+func newTracer() (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	return recorder, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+}
+
+// TestSpanName tests the span naming convention
+func TestSpanName(t *testing.T) {
+	require.Equal(t, "UserStorage.GetByID", tracing.SpanName("UserStorage", "GetByID"))
+	require.Equal(t, "GetByID", tracing.SpanName("", "GetByID"))
+}
+
+// TestAttributes tests attribute extraction from arguments
+func TestAttributes(t *testing.T) {
+	attrs := tracing.Attributes(context.Background(), request{ID: "42"}, 7, nil)
+	require.Equal(t, []attribute.KeyValue{attribute.String("user.id", "42")}, attrs)
+	require.Empty(t, tracing.Attributes("plain", 1))
+}
+
+// TestStartEnd tests span creation and error recording
+func TestStartEnd(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		recorder, provider := newTracer()
+
+		_, span := tracing.Start(context.Background(), provider.Tracer("test"), "UserStorage", "GetByID", request{ID: "42"})
+		tracing.End(span, nil)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		require.Equal(t, "UserStorage.GetByID", spans[0].Name())
+		require.Equal(t, codes.Unset, spans[0].Status().Code)
+		require.Contains(t, spans[0].Attributes(), tracing.MethodKey.String("GetByID"))
+		require.Contains(t, spans[0].Attributes(), attribute.String("user.id", "42"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		recorder, provider := newTracer()
+
+		_, span := tracing.Start(context.Background(), provider.Tracer("test"), "UserStorage", "GetByID")
+		tracing.End(span, fmt.Errorf("query failed: %w", errors.New("boom")))
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		require.Equal(t, codes.Error, spans[0].Status().Code)
+		require.Equal(t, "query failed: boom", spans[0].Status().Description)
+		require.Len(t, spans[0].Events(), 1, "The error should be recorded as an event")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		recorder, provider := newTracer()
+
+		_, span := tracing.Start(context.Background(), provider.Tracer("test"), "UserStorage", "GetByID")
+		tracing.End(span, context.Canceled)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		require.Equal(t, codes.Unset, spans[0].Status().Code, "Cancellation should not fail the span")
+		require.Equal(t, "canceled", spans[0].Events()[0].Name)
+	})
+}
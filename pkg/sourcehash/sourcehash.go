@@ -0,0 +1,100 @@
+// Package sourcehash fingerprints interface declarations so generated decorators can tell they are stale.
+//
+// decogen writes the hash of the interface declaration into the header of every
+// generated file. "decogen check" compares it with the current source, and
+// decorators generated with the "assertSource" option call Assert from an init
+// function so a binary built from a stale tree fails at startup.
+//
+// The hash covers the text of the type specification, methods and their
+// annotations included; formatting changes gofmt would undo change it too.
+package sourcehash
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// HeaderPrefix starts the header line of generated files holding the hash
+const HeaderPrefix = "// decogen source hash: "
+
+// Sum returns the hash of the text of an interface declaration
+// Line endings are normalized, so a checkout converting them to CRLF, as on Windows, keeps the hash
+func Sum(decl []byte) string {
+	sum := sha256.Sum256(bytes.ReplaceAll(decl, []byte("\r\n"), []byte("\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Interface returns the hash of the declaration of the named interface in Go source
+func Interface(src []byte, name string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse source: %w", err)
+	}
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != name {
+				continue
+			}
+			if _, ok := typeSpec.Type.(*ast.InterfaceType); !ok {
+				continue
+			}
+			return Sum(src[fset.Position(typeSpec.Pos()).Offset:fset.Position(typeSpec.End()).Offset]), nil
+		}
+	}
+
+	return "", fmt.Errorf("interface %s not found", name)
+}
+
+// FromHeader returns the hash recorded in the header of a generated file
+func FromHeader(code []byte) (string, bool) {
+	for _, line := range strings.SplitN(string(code), "\n", 4) {
+		if hash, ok := strings.CutPrefix(strings.TrimSpace(line), HeaderPrefix); ok {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+// Assert panics if the interface declared in source no longer has the hash the caller was generated from
+// source is looked up next to the file of the caller, which is only known where the binary was built;
+// when it cannot be read, as in deployed binaries, Assert does nothing
+func Assert(source, iface, hash string) {
+	_, file, _, ok := runtime.Caller(1)
+	if !ok {
+		return
+	}
+
+	src, err := os.ReadFile(filepath.Join(filepath.Dir(file), source))
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		return
+	}
+	if err != nil {
+		panic(fmt.Sprintf("sourcehash: %v", err))
+	}
+
+	current, err := Interface(src, iface)
+	if err != nil {
+		panic(fmt.Sprintf("sourcehash: %s: %v", source, err))
+	}
+	if current != hash {
+		panic(fmt.Sprintf("sourcehash: %s changed in %s since %s was generated, run decogen again", iface, source, filepath.Base(file)))
+	}
+}
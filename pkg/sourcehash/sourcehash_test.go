@@ -0,0 +1,71 @@
+package sourcehash_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/komandakycto/decogen/pkg/sourcehash"
+)
+
+const source = `package storage
+
+// Storage is documented
+type Storage interface {
+	Get(id string) (string, error)
+}
+
+type Other interface{}
+`
+
+func TestInterface(t *testing.T) {
+	hash, err := sourcehash.Interface([]byte(source), "Storage")
+	require.NoError(t, err)
+	assert.Len(t, hash, 16)
+
+	// Documentation is not part of the declaration
+	same, err := sourcehash.Interface([]byte("package storage\n\ntype Storage interface {\n\tGet(id string) (string, error)\n}\n"), "Storage")
+	require.NoError(t, err)
+	assert.Equal(t, hash, same)
+
+	changed, err := sourcehash.Interface([]byte("package storage\n\ntype Storage interface {\n\tGet(id int) (string, error)\n}\n"), "Storage")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+
+	// Checkouts converting line endings to CRLF keep the hash
+	crlf, err := sourcehash.Interface([]byte(strings.ReplaceAll(source, "\n", "\r\n")), "Storage")
+	require.NoError(t, err)
+	assert.Equal(t, hash, crlf)
+
+	_, err = sourcehash.Interface([]byte(source), "Missing")
+	assert.ErrorContains(t, err, "interface Missing not found")
+}
+
+func TestFromHeader(t *testing.T) {
+	hash, ok := sourcehash.FromHeader([]byte("// Code generated by decogen. DO NOT EDIT.\n" + sourcehash.HeaderPrefix + "0123456789abcdef\n\npackage storage\n"))
+	require.True(t, ok)
+	assert.Equal(t, "0123456789abcdef", hash)
+
+	_, ok = sourcehash.FromHeader([]byte("// Code generated by decogen. DO NOT EDIT.\n\npackage storage\n"))
+	assert.False(t, ok)
+}
+
+// asserted is the interface TestAssert checks
+type asserted interface {
+	Get(id string) (string, error)
+}
+
+func TestAssert(t *testing.T) {
+	src, err := os.ReadFile("sourcehash_test.go")
+	require.NoError(t, err)
+	hash, err := sourcehash.Interface(src, "asserted")
+	require.NoError(t, err)
+
+	// The source is read next to the calling file
+	assert.NotPanics(t, func() { sourcehash.Assert("sourcehash_test.go", "asserted", hash) })
+	assert.Panics(t, func() { sourcehash.Assert("sourcehash_test.go", "asserted", "0123456789abcdef") })
+	assert.NotPanics(t, func() { sourcehash.Assert("missing.go", "asserted", "0123456789abcdef") })
+}